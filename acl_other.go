@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+import (
+	"os"
+)
+
+// withSecurity returns info as is outside Windows, where chown copies the
+// owner and group that info holds already.
+func withSecurity(info os.FileInfo, _ string) os.FileInfo {
+	return info
+}
+
+// restrictMode does nothing outside Windows, where the mode files are created
+// with is all there is to it.
+func restrictMode(_ string, _ os.FileMode, _ PermissionProfile) error {
+	return nil
+}
@@ -0,0 +1,231 @@
+package lumberjack
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	seFileObject = 1 // SE_FILE_OBJECT
+
+	daclSecurityInformation            = 0x00000004
+	protectedDaclSecurityInformation   = 0x80000000
+	unprotectedDaclSecurityInformation = 0x20000000
+
+	seDaclProtected = 0x1000 // SE_DACL_PROTECTED
+
+	sddlRevision1 = 1
+
+	// ownerOnlySDDL grants full access to the file's owner, the system and
+	// administrators, and nobody else, without inheriting from the directory.
+	ownerOnlySDDL = "D:P(A;;FA;;;OW)(A;;FA;;;SY)(A;;FA;;;BA)"
+
+	// eventLogReadersSDDL and usersReadSDDL add read access for the Event
+	// Log Readers group and for all users to ownerOnlySDDL.
+	eventLogReadersSDDL = "(A;;FR;;;ER)"
+	usersReadSDDL       = "(A;;FR;;;BU)"
+)
+
+var (
+	modadvapi32                                              = syscall.NewLazyDLL("advapi32.dll")
+	procGetNamedSecurityInfoW                                = modadvapi32.NewProc("GetNamedSecurityInfoW")
+	procSetNamedSecurityInfoW                                = modadvapi32.NewProc("SetNamedSecurityInfoW")
+	procGetSecurityDescriptorLength                          = modadvapi32.NewProc("GetSecurityDescriptorLength")
+	procGetSecurityDescriptorDacl                            = modadvapi32.NewProc("GetSecurityDescriptorDacl")
+	procGetSecurityDescriptorControl                         = modadvapi32.NewProc("GetSecurityDescriptorControl")
+	procConvertStringSecurityDescriptorToSecurityDescriptorW = modadvapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+	procLocalFree                                            = modkernel32.NewProc("LocalFree")
+)
+
+// secureInfo is an os.FileInfo along with the security descriptor of its file,
+// for chown to copy the file's access control list to another file.
+type secureInfo struct {
+	os.FileInfo
+	sd []byte // self-relative SECURITY_DESCRIPTOR holding the DACL
+}
+
+// withSecurity returns info, the info of the file at path, along with the
+// access control list of the file, so that chown can give it to other files
+// even once the file has been moved or removed.  If the access control list
+// can't be read, info is returned as is, and chown leaves files alone.
+func withSecurity(info os.FileInfo, path string) os.FileInfo {
+	sd, err := fileSecurity(path)
+	if err != nil {
+		return info
+	}
+	return secureInfo{FileInfo: info, sd: sd}
+}
+
+// chown creates the named file if it doesn't exist, and gives it the access
+// control list of the file info was taken with by withSecurity, keeping it
+// from inheriting the directory's if the original didn't either.
+func chown(name string, info os.FileInfo) error {
+	si, ok := info.(secureInfo)
+	if !ok {
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return setFileSecurity(name, si.sd)
+}
+
+// fchown is a no-op, as files are only given owners once open on Linux.
+func fchown(_ *os.File, _ os.FileInfo) error {
+	return nil
+}
+
+// chownDir gives the directory name the access control list of the directory
+// info was taken with by withSecurity.
+func chownDir(name string, info os.FileInfo) error {
+	si, ok := info.(secureInfo)
+	if !ok {
+		return nil
+	}
+	return setFileSecurity(name, si.sd)
+}
+
+// chownToDir is a no-op on Windows, where new files inherit the access
+// control entries that their directory passes on.
+func chownToDir(_, _ string) error {
+	return nil
+}
+
+// restrictMode makes the mode of the named, newly created file take effect as
+// far as Windows allows: a mode that gives the group and others no access
+// leaves the file to its owner, the system and administrators, instead of
+// whoever the directory lets in.  With a PermissionProfile, the file gets the
+// access control list of the profile instead.
+func restrictMode(name string, mode os.FileMode, profile PermissionProfile) error {
+	sddl := ownerOnlySDDL
+	switch profile {
+	case PermissionsGroupReadable:
+		sddl += eventLogReadersSDDL
+	case PermissionsWorldReadable:
+		sddl += usersReadSDDL
+	case PermissionsPrivate:
+	default:
+		if mode.Perm()&0077 != 0 {
+			return nil
+		}
+	}
+	sd, err := sddlSecurity(sddl)
+	if err != nil {
+		return err
+	}
+	return setFileSecurity(name, sd)
+}
+
+// sddlSecurity returns the security descriptor written in SDDL as sddl.
+func sddlSecurity(sddl string) ([]byte, error) {
+	p, err := syscall.UTF16PtrFromString(sddl)
+	if err != nil {
+		return nil, err
+	}
+	var sd unsafe.Pointer
+	r, _, err := procConvertStringSecurityDescriptorToSecurityDescriptorW.Call(
+		uintptr(unsafe.Pointer(p)),
+		sddlRevision1,
+		uintptr(unsafe.Pointer(&sd)),
+		0,
+	)
+	if r == 0 {
+		return nil, err
+	}
+	defer procLocalFree.Call(uintptr(sd))
+	return copySecurity(sd), nil
+}
+
+// fileSecurity returns a copy of the security descriptor of the named file,
+// holding its DACL.
+func fileSecurity(name string) ([]byte, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	var sd unsafe.Pointer
+	r, _, _ := procGetNamedSecurityInfoW.Call(
+		uintptr(unsafe.Pointer(p)),
+		seFileObject,
+		daclSecurityInformation,
+		0,
+		0,
+		0,
+		0,
+		uintptr(unsafe.Pointer(&sd)),
+	)
+	if r != 0 {
+		return nil, syscall.Errno(r)
+	}
+	defer procLocalFree.Call(uintptr(sd))
+	return copySecurity(sd), nil
+}
+
+// copySecurity copies the self-relative security descriptor at sd into Go
+// memory.
+func copySecurity(sd unsafe.Pointer) []byte {
+	n, _, _ := procGetSecurityDescriptorLength.Call(uintptr(sd))
+	b := make([]byte, n)
+	copy(b, (*[1 << 30]byte)(sd)[:n:n])
+	return b
+}
+
+// setFileSecurity gives the named file the DACL of the security descriptor sd,
+// protected from inheritance if it is in sd.
+func setFileSecurity(name string, sd []byte) error {
+	if len(sd) == 0 {
+		return nil
+	}
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	defer runtime.KeepAlive(sd)
+	psd := uintptr(unsafe.Pointer(&sd[0]))
+
+	var present, defaulted int32
+	var dacl uintptr
+	r, _, err := procGetSecurityDescriptorDacl.Call(
+		psd,
+		uintptr(unsafe.Pointer(&present)),
+		uintptr(unsafe.Pointer(&dacl)),
+		uintptr(unsafe.Pointer(&defaulted)),
+	)
+	if r == 0 {
+		return err
+	}
+	if present == 0 {
+		return nil
+	}
+	var control uint16
+	var revision uint32
+	r, _, err = procGetSecurityDescriptorControl.Call(
+		psd,
+		uintptr(unsafe.Pointer(&control)),
+		uintptr(unsafe.Pointer(&revision)),
+	)
+	if r == 0 {
+		return err
+	}
+	info := uintptr(daclSecurityInformation | unprotectedDaclSecurityInformation)
+	if control&seDaclProtected != 0 {
+		info = daclSecurityInformation | protectedDaclSecurityInformation
+	}
+	r, _, _ = procSetNamedSecurityInfoW.Call(
+		uintptr(unsafe.Pointer(p)),
+		seFileObject,
+		info,
+		0,
+		0,
+		dacl,
+		0,
+	)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
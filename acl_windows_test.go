@@ -0,0 +1,53 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"unsafe"
+)
+
+func TestFileModeACL(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFileModeACL", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+		FileMode: 0600,
+		Compress: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// a mode for the owner alone keeps the directory's entries out.
+	equals(true, daclProtected(filename, t), t)
+
+	// and the next log file and the compressed backup get the same list.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	equals(true, daclProtected(filename, t), t)
+	backup := backupFile(dir) + compressSuffix
+	waitFor(func() bool { return fileExists(backup) }, t)
+	equals(true, daclProtected(backup, t), t)
+}
+
+// daclProtected reports whether the access control list of the named file
+// doesn't inherit from its directory.
+func daclProtected(name string, t testing.TB) bool {
+	sd, err := fileSecurity(name)
+	isNil(err, t)
+	var control uint16
+	var revision uint32
+	r, _, err := procGetSecurityDescriptorControl.Call(
+		uintptr(unsafe.Pointer(&sd[0])),
+		uintptr(unsafe.Pointer(&control)),
+		uintptr(unsafe.Pointer(&revision)),
+	)
+	assert(r != 0, t, "can't get security descriptor control: %v", err)
+	return control&seDaclProtected != 0
+}
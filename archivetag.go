@@ -0,0 +1,30 @@
+package lumberjack
+
+import (
+	"os"
+	"strings"
+)
+
+// archiveTag returns ArchiveTag with its placeholders and environment
+// variables expanded, and with path separators and spaces it ended up with
+// replaced by underscores, or "" if it is unset or expands to nothing.
+func (l *Logger) archiveTag() string {
+	if l.ArchiveTag == "" {
+		return ""
+	}
+	l.tagMu.Lock()
+	defer l.tagMu.Unlock()
+	if l.tagOf != l.ArchiveTag {
+		hostname, _ := os.Hostname()
+		tag := strings.Replace(l.ArchiveTag, hostnamePlaceholder, hostname, -1)
+		tag = os.ExpandEnv(tag)
+		tag = strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r == ' ' || r == os.PathSeparator {
+				return '_'
+			}
+			return r
+		}, tag)
+		l.tagOf, l.tag = l.ArchiveTag, strings.Trim(tag, ".")
+	}
+	return l.tag
+}
@@ -0,0 +1,93 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveTag(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestArchiveTag", t)
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("LUMBERJACK_TEST_POD")
+	os.Setenv("LUMBERJACK_TEST_POD", "pod/7")
+	hostname, _ := os.Hostname()
+
+	// one compressed before the tag was set.
+	old := backupFile(dir) + compressSuffix
+	isNil(ioutil.WriteFile(old, gzipped([]byte("old"), t), 0644), t)
+	newFakeTime()
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    100,
+		MaxBackups: 2,
+		Compress:   true,
+		ArchiveTag: "{hostname}-$LUMBERJACK_TEST_POD",
+	}
+	defer l.Close()
+	equals("."+hostname+"-pod_7"+compressSuffix, l.compressSuffix(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	tagged := backupFile(dir) + "." + hostname + "-pod_7" + compressSuffix
+	waitFor(func() bool {
+		_, err := os.Stat(tagged)
+		return err == nil
+	}, t)
+	existsWithContent(tagged, gzipped([]byte("boo!"), t), t)
+	notExist(backupFile(dir), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+	equals(tagged, backups[0].Path, t)
+	equals(old, backups[1].Path, t)
+
+	// and both count towards MaxBackups.
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool {
+		_, err := os.Stat(old)
+		return os.IsNotExist(err)
+	}, t)
+	exists(tagged, t)
+	matches, err := filepath.Glob(filepath.Join(dir, "*."+hostname+"-pod_7"+compressSuffix))
+	isNil(err, t)
+	equals(2, len(matches), t)
+}
+
+func TestArchiveTagCompressOnWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestArchiveTagCompressOnWrite", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		MaxSize:         100,
+		Compress:        true,
+		CompressOnWrite: true,
+		ArchiveTag:      "web1",
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	existsWithContent(backupFile(dir)+".web1"+compressSuffix, gzipped([]byte("boo!"), t), t)
+	notExist(backupFile(dir), t)
+}
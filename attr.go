@@ -0,0 +1,58 @@
+package lumberjack
+
+import "fmt"
+
+// FileAttr is a filesystem attribute that guards backup files against
+// tampering.  See Logger.BackupAttr.
+type FileAttr int
+
+const (
+	// AttrNone leaves the attributes of backup files alone.
+	AttrNone FileAttr = iota
+
+	// AttrAppendOnly marks backup files append-only, so their existing
+	// contents can no longer be modified or truncated.
+	AttrAppendOnly
+
+	// AttrImmutable marks backup files immutable, so they can no longer be
+	// modified, renamed or linked to.
+	AttrImmutable
+)
+
+// String returns the name of a: "none", "appendonly" or "immutable".
+func (a FileAttr) String() string {
+	switch a {
+	case AttrNone:
+		return "none"
+	case AttrAppendOnly:
+		return "appendonly"
+	case AttrImmutable:
+		return "immutable"
+	}
+	return fmt.Sprintf("FileAttr(%d)", int(a))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a FileAttr) MarshalText() ([]byte, error) {
+	if a < AttrNone || a > AttrImmutable {
+		return nil, fmt.Errorf("invalid file attribute %d", int(a))
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "none",
+// "appendonly" and "immutable", so that the attribute can be set from config
+// files.
+func (a *FileAttr) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "none", "":
+		*a = AttrNone
+	case "appendonly":
+		*a = AttrAppendOnly
+	case "immutable":
+		*a = AttrImmutable
+	default:
+		return fmt.Errorf("invalid file attribute %q, expected none, appendonly or immutable", text)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le && !ppc && !ppc64 && !ppc64le && !sparc && !sparc64
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc,!ppc64,!ppc64le,!sparc,!sparc64
+
+package lumberjack
+
+// The direction bits of ioctl requests, from asm-generic/ioctl.h.
+const (
+	iocWrite    = 1
+	iocRead     = 2
+	iocDirShift = 30
+)
@@ -0,0 +1,13 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le || ppc || ppc64 || ppc64le || sparc || sparc64)
+// +build linux
+// +build mips mipsle mips64 mips64le ppc ppc64 ppc64le sparc sparc64
+
+package lumberjack
+
+// The direction bits of ioctl requests on MIPS, PowerPC and SPARC, which use
+// their own layout rather than that of asm-generic/ioctl.h.
+const (
+	iocRead     = 2
+	iocWrite    = 4
+	iocDirShift = 29
+)
@@ -0,0 +1,74 @@
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Inode flags from linux/fs.h.
+const (
+	fsImmutableFl = 0x00000010
+	fsAppendFl    = 0x00000020
+)
+
+// The ioctl requests for reading and writing inode flags, _IOR('f', 1, long)
+// and _IOW('f', 2, long), with the direction bits of the architecture.
+var (
+	fsIocGetFlags = uintptr(iocRead<<iocDirShift | unsafe.Sizeof(int(0))<<16 | 'f'<<8 | 1)
+	fsIocSetFlags = uintptr(iocWrite<<iocDirShift | unsafe.Sizeof(int(0))<<16 | 'f'<<8 | 2)
+)
+
+// setFileAttr sets the given attribute on the named file.  Doing so requires
+// CAP_LINUX_IMMUTABLE and a filesystem that supports inode flags.
+func setFileAttr(name string, attr FileAttr) error {
+	var flag int32
+	switch attr {
+	case AttrAppendOnly:
+		flag = fsAppendFl
+	case AttrImmutable:
+		flag = fsImmutableFl
+	default:
+		return nil
+	}
+	return updateInodeFlags(name, func(flags int32) int32 { return flags | flag })
+}
+
+// unprotect clears the append-only and immutable attributes from the named
+// file, so that it can be removed.
+func unprotect(name string) error {
+	return updateInodeFlags(name, func(flags int32) int32 {
+		return flags &^ (fsAppendFl | fsImmutableFl)
+	})
+}
+
+// updateInodeFlags applies fn to the inode flags of the named file, writing
+// them back only if they changed.
+func updateInodeFlags(name string, fn func(int32) int32) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var flags int32
+	if err := ioctl(f, fsIocGetFlags, &flags); err != nil {
+		return &os.PathError{Op: "getflags", Path: name, Err: err}
+	}
+	newFlags := fn(flags)
+	if newFlags == flags {
+		return nil
+	}
+	if err := ioctl(f, fsIocSetFlags, &newFlags); err != nil {
+		return &os.PathError{Op: "setflags", Path: name, Err: err}
+	}
+	return nil
+}
+
+func ioctl(f *os.File, req uintptr, arg *int32) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(arg)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
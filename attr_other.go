@@ -0,0 +1,19 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package lumberjack
+
+import (
+	"errors"
+)
+
+// setFileAttr is a no-op anywhere but linux.
+func setFileAttr(_ string, _ FileAttr) error {
+	return nil
+}
+
+// unprotect reports that there is nothing that would keep a file from being
+// removed anywhere but linux and windows.
+func unprotect(_ string) error {
+	return errors.New("file is not protected")
+}
@@ -0,0 +1,17 @@
+package lumberjack
+
+import (
+	"os"
+)
+
+// setFileAttr is a no-op on Windows, which has no equivalent of the
+// append-only and immutable attributes.
+func setFileAttr(_ string, _ FileAttr) error {
+	return nil
+}
+
+// unprotect makes the named file writable, since Windows refuses to remove
+// read-only files.
+func unprotect(name string) error {
+	return os.Chmod(name, 0600)
+}
@@ -0,0 +1,116 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+const (
+	// defaultMillRetryInterval is how long the mill waits after a failed run,
+	// if MillRetryInterval isn't set.
+	defaultMillRetryInterval = 10 * time.Second
+
+	// maxMillRetryInterval caps the wait between failed mill runs.
+	maxMillRetryInterval = time.Hour
+
+	// millDegradedAfter is the number of failed mill runs in a row that make
+	// the Logger degraded.
+	millDegradedAfter = 3
+)
+
+// millRunBackoff runs millRunOnce, unless the mill is backing off after
+// failed runs, and keeps count of the failures.
+func (l *Logger) millRunBackoff() {
+	now := currentTime()
+	l.statsMu.Lock()
+	wait := l.millRetryAt.Sub(now)
+	l.statsMu.Unlock()
+	if wait > 0 {
+		// try again once the wait is over, even if nothing rotates.
+		l.millAfter(wait)
+		return
+	}
+
+	err := l.millRunOnce()
+
+	l.statsMu.Lock()
+	if err == nil {
+		degraded := l.stats.Degraded
+		l.stats.MillFailures = 0
+		l.stats.Degraded = false
+		l.millRetryAt = time.Time{}
+		l.statsMu.Unlock()
+		if degraded {
+			l.emit(Event{Type: EventMillRecovered, Path: l.filename()})
+		}
+		return
+	}
+
+	l.stats.MillFailures++
+	interval := l.MillRetryInterval
+	if interval <= 0 {
+		interval = defaultMillRetryInterval
+	}
+	for i := int64(1); i < l.stats.MillFailures && interval < maxMillRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxMillRetryInterval {
+		interval = maxMillRetryInterval
+	}
+	l.millRetryAt = now.Add(interval)
+	degraded := !l.stats.Degraded && l.stats.MillFailures >= millDegradedAfter
+	if degraded {
+		l.stats.Degraded = true
+	}
+	l.statsMu.Unlock()
+
+	if degraded {
+		l.emit(Event{Type: EventMillDegraded, Path: l.filename(), Err: err})
+	}
+	if l.hasDetached() {
+		// a detached backup is lost if it waits for a rotation that never
+		// comes.
+		l.millAfter(interval)
+	}
+}
+
+// millAfter arranges for the mill to run once d has passed, unless a run is
+// arranged to happen sooner already.
+func (l *Logger) millAfter(d time.Duration) {
+	if inlineMill {
+		return
+	}
+	l.millTimerMu.Lock()
+	defer l.millTimerMu.Unlock()
+	if l.isClosed() {
+		return
+	}
+	at := time.Now().Add(d)
+	if l.millTimer != nil {
+		if !at.Before(l.millTimerAt) {
+			return
+		}
+		l.millTimer.Stop()
+	}
+	l.millTimerAt = at
+	l.millTimer = time.AfterFunc(d, func() {
+		l.millTimerMu.Lock()
+		l.millTimer = nil
+		l.millTimerMu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.isClosed() {
+			l.mill()
+		}
+	})
+}
+
+// stopMillTimer cancels the mill run arranged by millAfter, if any.
+func (l *Logger) stopMillTimer() {
+	l.millTimerMu.Lock()
+	defer l.millTimerMu.Unlock()
+	if l.millTimer != nil {
+		l.millTimer.Stop()
+		l.millTimer = nil
+	}
+}
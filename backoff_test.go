@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMillBackoff(t *testing.T) {
+	if inlineMill {
+		t.Skip("failed runs are retried on the next rotation in TinyGo builds")
+	}
+	now := fakeTime()
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+	dir := makeTempDir("TestMillBackoff", t)
+	defer os.RemoveAll(dir)
+
+	// compression fails until osStat is restored.
+	osStat = func(string) (os.FileInfo, error) { return nil, errors.New("broken") }
+	defer func() { osStat = os.Stat }()
+
+	var events []Event
+	l := &Logger{
+		Filename:          logFile(dir),
+		Compress:          true,
+		MillRetryInterval: time.Minute,
+		OnEvent:           func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+
+	l.millRunBackoff()
+	equals(int64(1), l.Stats().MillFailures, t)
+	equals(int64(1), l.Stats().CompressErrors, t)
+
+	// nothing is tried again until the wait is over.
+	l.millRunBackoff()
+	equals(int64(1), l.Stats().CompressErrors, t)
+	notNil(l.millTimer, t)
+
+	// the wait doubles, and the third failure in a row makes it degraded.
+	now = now.Add(time.Minute)
+	l.millRunBackoff()
+	equals(int64(2), l.Stats().MillFailures, t)
+	now = now.Add(time.Minute)
+	l.millRunBackoff()
+	equals(int64(2), l.Stats().MillFailures, t)
+	now = now.Add(time.Minute)
+	l.millRunBackoff()
+	equals(int64(3), l.Stats().MillFailures, t)
+	assert(l.Stats().Degraded, t, "expected the Logger to be degraded")
+	equals(1, len(events), t)
+	equals(EventMillDegraded, events[0].Type, t)
+	notNil(events[0].Err, t)
+
+	// a successful run clears it.
+	osStat = os.Stat
+	now = now.Add(4 * time.Hour)
+	l.millRunBackoff()
+	exists(backup+compressSuffix, t)
+	equals(int64(0), l.Stats().MillFailures, t)
+	assert(!l.Stats().Degraded, t, "expected the Logger not to be degraded")
+	equals(3, len(events), t)
+	equals(EventCompressed, events[1].Type, t)
+	equals(EventMillRecovered, events[2].Type, t)
+}
@@ -0,0 +1,171 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// backupDir returns the directory that backups are kept in: BackupDir,
+// resolved against the directory of the log file, or that directory itself.
+func (l *Logger) backupDir() string {
+	if l.BackupDir == "" {
+		return l.dir()
+	}
+	dir := longPath(expandPath(l.BackupDir))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(l.dir(), dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// staged reports whether backups are rotated into the directory of the log
+// file first, to be moved to BackupDir later.
+func (l *Logger) staged() bool {
+	return l.backupDir() != l.dir()
+}
+
+// archivePath returns where the named backup goes in the backup directory.
+func (l *Logger) archivePath(name string) string {
+	return filepath.Join(l.backupDir(), filepath.Base(name))
+}
+
+// makeBackupDir creates the backup directory, if it doesn't exist.
+func (l *Logger) makeBackupDir() error {
+	if err := os.MkdirAll(l.backupDir(), l.dirMode()); err != nil {
+		return fmt.Errorf("can't make backup directory: %s", err)
+	}
+	return nil
+}
+
+// archive moves a backup file that is still next to the log file to the backup
+// directory, returning its new path.
+func (l *Logger) archive(f logInfo) (string, error) {
+	if err := l.makeBackupDir(); err != nil {
+		return "", err
+	}
+	dst := l.archivePath(f.Name())
+	if err := l.fault(FaultRename, f.path()); err != nil {
+		return "", err
+	}
+	if err := moveFile(f.path(), dst, l.reflinks(), l.chown); err != nil {
+		return "", err
+	}
+	l.listRemove(f.path())
+	l.listAdd(dst)
+	if err := l.moveKeep(f); err != nil {
+		return dst, err
+	}
+	if l.OwnerFromDir {
+		if err := l.chownToDir(dst, l.backupDir()); err != nil {
+			return dst, fmt.Errorf("can't set owner of backup file: %s", err)
+		}
+	}
+	return dst, nil
+}
+
+// archiveStaged moves the backup files among files that are still next to the
+// log file to the backup directory, except for those that have been placed
+// there already, noting where they went in placed, and those it couldn't move
+// in skip.  It returns the first error.
+func (l *Logger) archiveStaged(files []logInfo, placed map[string][]string, skip map[string]bool) error {
+	var err error
+	markers := make(map[string][]string)
+	for _, f := range files {
+		if f.dir == l.backupDir() || placed[f.Name()] != nil {
+			continue
+		}
+		if l.isFinishing(f.path()) {
+			// being compressed by CompressOnWrite; moved next time.
+			skip[f.Name()] = true
+			continue
+		}
+		dst, errMove := l.archive(f)
+		if os.IsNotExist(errMove) {
+			// compressed by CompressOnWrite since it was listed.
+			skip[f.Name()] = true
+			continue
+		}
+		if errMove != nil {
+			skip[f.Name()] = true
+			l.recordError(otherError, errMove)
+			if err == nil {
+				err = errMove
+			}
+			continue
+		}
+		placed[f.Name()] = []string{dst}
+		if !l.Compress || isCompressed(dst, l.compressSuffixes()) {
+			backup := uncompressedName(dst, l.compressSuffixes())
+			markers[backup] = append(markers[backup], dst)
+		}
+	}
+	for backup, names := range markers {
+		sort.Strings(names)
+		l.writeMarker(backup, names)
+	}
+	return err
+}
+
+// moveFile moves the file src to dst.  Files can't be renamed across
+// filesystems, so if renaming fails, the file is copied under a temporary
+// name, synced, renamed into place and only then removed, keeping its mode,
+// owner and modification time.  With reflinks, the copy shares the blocks of
+// the file where the filesystem can, and is only written out where it can't.
+func moveFile(src, dst string, reflinks bool, chown func(name string, info os.FileInfo) error) (err error) {
+	errRename := os.Rename(src, dst)
+	if errRename == nil || os.IsNotExist(errRename) {
+		return errRename
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	info = withSecurity(info, src)
+
+	tmp := dst + tmpSuffix
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+	if !reflinks || reflink(out, in) != nil {
+		if _, err = io.Copy(out, in); err != nil {
+			return fmt.Errorf("can't move backup file: %s", err)
+		}
+	}
+	if err = out.Sync(); err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	if err = chown(tmp, info); err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	if err = os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("can't move backup file: %s", err)
+	}
+	in.Close()
+	if errRemove := removeFile(src); errRemove != nil {
+		// both copies are there now, which is better than none.
+		return fmt.Errorf("can't remove moved backup file: %s", errRemove)
+	}
+	return nil
+}
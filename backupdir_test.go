@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupDir", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	archive := filepath.Join(dir, "archive")
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		BackupDir:      "archive",
+		RotationMarker: true,
+	}
+	defer l.Close()
+
+	// backups end up in BackupDir, once the mill has run.
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	first := filepath.Join(archive, filepath.Base(backupFile(dir)))
+	existsWithContent(first, []byte("boo!"), t)
+	notExist(backupFile(dir), t)
+	files, err := ReadMarker(first + markerSuffix)
+	isNil(err, t)
+	equals([]string{first}, files, t)
+
+	// with Compress, they are compressed straight into it.
+	l = &Logger{
+		Filename:  filename,
+		MaxSize:   100,
+		BackupDir: archive,
+		Compress:  true,
+	}
+	defer l.Close()
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	second := filepath.Join(archive, filepath.Base(backupFile(dir)))
+	existsWithContent(second+compressSuffix, gzipped([]byte("foo!"), t), t)
+	notExist(backupFile(dir), t)
+
+	// a backup that hasn't been moved yet counts along with the archived
+	// ones.
+	newFakeTime()
+	staged := backupFile(dir)
+	isNil(ioutil.WriteFile(staged, []byte("bar!"), 0644), t)
+	logs, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(3, len(logs), t)
+	equals(staged, logs[0].path(), t)
+	equals(second+compressSuffix, logs[1].path(), t)
+
+	l.MaxBackups = 2
+	isNil(l.millRunOnce(), t)
+	notExist(staged, t)
+	notExist(first, t)
+	exists(second+compressSuffix, t)
+	existsWithContent(filepath.Join(archive, filepath.Base(staged))+compressSuffix, gzipped([]byte("bar!"), t), t)
+}
+
+func TestBackupDirExistingBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupDirExistingBackups", t)
+	defer os.RemoveAll(dir)
+
+	// backups from before BackupDir was set are moved on the first write.
+	old := backupFile(dir)
+	isNil(ioutil.WriteFile(old, []byte("boo!"), 0644), t)
+	newFakeTime()
+	l := &Logger{Filename: logFile(dir), MaxSize: 100, BackupDir: "archive"}
+	defer l.Close()
+	_, err := l.Write([]byte("foo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	notExist(old, t)
+	existsWithContent(filepath.Join(dir, "archive", filepath.Base(old)), []byte("boo!"), t)
+}
+
+func TestMoveFileAcrossDevices(t *testing.T) {
+	src := makeTempDir("TestMoveFileAcrossDevices", t)
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("/dev/shm", "TestMoveFileAcrossDevices")
+	if err != nil {
+		t.Skip("no /dev/shm to move files to")
+	}
+	defer os.RemoveAll(dst)
+
+	name := filepath.Join(src, "foo.log")
+	isNil(ioutil.WriteFile(name, []byte("boo!"), 0600), t)
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	isNil(os.Chtimes(name, mtime, mtime), t)
+
+	moved := filepath.Join(dst, "foo.log")
+	noChown := func(string, os.FileInfo) error { return nil }
+	isNil(moveFile(name, moved, false, noChown), t)
+	notExist(name, t)
+	existsWithContent(moved, []byte("boo!"), t)
+	info, err := os.Stat(moved)
+	isNil(err, t)
+	equals(os.FileMode(0600), info.Mode(), t)
+	assert(info.ModTime().Equal(mtime), t, "expected mtime %v, got %v", mtime, info.ModTime())
+	notExist(moved+tmpSuffix, t)
+}
@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// BackupInfo describes a backup of the log file.  See Logger.Backups.
+type BackupInfo struct {
+	// Path is the name of the backup: the uncompressed file while there is
+	// one, and otherwise the compressed one, or with CompressPartSize, its
+	// first part.
+	Path string
+
+	// Timestamp is the rotation time encoded in the name.
+	Timestamp time.Time
+
+	// Size is the total size in bytes of the files of the backup, as they
+	// are on disk, which is what counts towards MaxTotalSize.
+	Size int64
+
+	// Compressed reports whether Path is compressed.
+	Compressed bool
+
+	// Pinned reports whether the backup is pinned with a .keep file, which
+	// exempts it from cleanup.
+	Pinned bool
+
+	// Files are all the files of the backup, such as both the uncompressed
+	// and the compressed file while it's being compressed, or the parts of a
+	// split one.
+	Files []string
+}
+
+// CurrentFile returns the name of the current log file.
+func (l *Logger) CurrentFile() string {
+	return l.filename()
+}
+
+// CurrentSize returns the size in bytes of the current log file, as far as the
+// Logger has written it, or 0 if it isn't open.
+func (l *Logger) CurrentSize() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return 0
+	}
+	return l.size
+}
+
+// Backups returns the backups of the log file, in the directory of the log
+// file and in BackupDir, newest first, as cleanup sees them, for reporting how
+// much log is kept on disk and when the log file was last rotated.
+func (l *Logger) Backups() ([]BackupInfo, error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []BackupInfo
+	var groups [][]logInfo
+	index := make(map[string]int)
+	for _, f := range files {
+		base := uncompressedName(f.Name(), l.compressSuffixes())
+		i, ok := index[base]
+		if !ok {
+			i = len(backups)
+			index[base] = i
+			backups = append(backups, BackupInfo{Timestamp: f.timestamp})
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+		b := &backups[i]
+		b.Files = append(b.Files, f.path())
+		b.Size += f.Size()
+		if b.Path == "" || l.pathRank(f.Name()) < l.pathRank(filepath.Base(b.Path)) {
+			b.Path = f.path()
+			b.Compressed = isCompressed(f.Name(), l.compressSuffixes())
+		}
+	}
+	for i := range backups {
+		backups[i].Pinned = l.pinned(groups[i])
+	}
+	return backups, nil
+}
+
+// pathRank orders the files of a backup by how well they stand for it as its
+// BackupInfo.Path: the uncompressed file first, then the compressed file or
+// its first part, then the other parts.
+func (l *Logger) pathRank(name string) int {
+	switch _, part, ok := splitPart(name, l.compressSuffixes()); {
+	case !isCompressed(name, l.compressSuffixes()):
+		return 0
+	case !ok || part == 0:
+		return 1
+	}
+	return 2
+}
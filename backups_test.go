@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackups", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 100}
+	defer l.Close()
+	equals(filename, l.CurrentFile(), t)
+	equals(int64(0), l.CurrentSize(), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(int64(4), l.CurrentSize(), t)
+
+	// one compressed backup, split in parts, and one being compressed.
+	older := backupFile(dir)
+	isNil(ioutil.WriteFile(older+compressSuffix+".001", []byte("xx"), 0644), t)
+	isNil(ioutil.WriteFile(older+compressSuffix+".000", []byte("xxx"), 0644), t)
+	newFakeTime()
+	newer := backupFile(dir)
+	isNil(ioutil.WriteFile(newer, []byte("12345"), 0644), t)
+	isNil(ioutil.WriteFile(newer+compressSuffix, []byte("1"), 0644), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+
+	equals(newer, backups[0].Path, t)
+	equals(fakeTime().UTC().Truncate(time.Millisecond), backups[0].Timestamp.UTC(), t)
+	equals(int64(6), backups[0].Size, t)
+	equals(false, backups[0].Compressed, t)
+	equals(2, len(backups[0].Files), t)
+
+	equals(older+compressSuffix+".000", backups[1].Path, t)
+	equals(int64(5), backups[1].Size, t)
+	equals(true, backups[1].Compressed, t)
+	equals(filepath.Dir(older), filepath.Dir(backups[1].Files[0]), t)
+}
@@ -0,0 +1,508 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// spillHeaderLen is the size of the length prefix of each record in the spill
+// file.
+const spillHeaderLen = 4
+
+// asyncBuffer queues writes for a Logger in memory, up to BufferSize bytes,
+// while a background goroutine drains them into the log file.  Writes that
+// don't fit either wait for room, or, with BufferSpill, go to a spill file.
+// Nothing enters the memory queue while the spill file holds data, and the
+// memory queue is always drained first, so writes are kept in order.  A write
+// larger than the whole buffer is spilled too, or else waits for the buffer to
+// drain and is written to the log file directly, so that memory use is bounded
+// by BufferSize either way.
+type asyncBuffer struct {
+	l    *Logger
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	queue    [][]byte
+	keys     []string // the key of each write in queue, with KeyQuota
+	queued   int      // bytes in memory, including the batch being written
+	busy     bool     // the drain goroutine is writing
+	waiting  int      // writes waiting for room
+	flushing int      // calls to flush waiting for the queue to drain
+
+	// With KeyQuota, the bytes in memory, including the batch being
+	// written, of each key with any.
+	keyQueued map[string]int
+
+	// With StrictOrder, writes take tickets in the order they call Write,
+	// and are queued in the order of their tickets.
+	tickets   uint64
+	served    uint64          // the last ticket queued or given up
+	abandoned map[uint64]bool // tickets given up before their turn
+
+	spill  *os.File
+	spillR int64
+	spillW int64
+
+	err    error
+	closed bool
+	done   chan struct{}
+}
+
+// buffer returns the Logger's write buffer, starting it if necessary, or nil if
+// the Logger has been closed.
+func (l *Logger) buffer() *asyncBuffer {
+	l.bufMu.Lock()
+	defer l.bufMu.Unlock()
+	if l.isClosed() {
+		return nil
+	}
+	if l.buf == nil {
+		b := &asyncBuffer{l: l, done: make(chan struct{})}
+		b.cond = sync.NewCond(&b.mu)
+		go l.labeled("buffer", b.run)
+		l.buf = b
+	}
+	return l.buf
+}
+
+// flushBuffer waits for everything queued in the write buffer, if any, to be
+// written to the log file.
+func (l *Logger) flushBuffer() {
+	l.bufMu.Lock()
+	b := l.buf
+	l.bufMu.Unlock()
+	if b != nil {
+		b.flush()
+	}
+}
+
+// Flush waits for everything queued in the write buffer, if writes are
+// buffered, to be written to the log file, without waiting for FlushInterval.
+// It returns the error from writing in the background that hasn't been
+// returned by Write yet, if any.  Unlike Sync, it leaves it to the operating
+// system to get the data to disk.
+func (l *Logger) Flush() error {
+	l.bufMu.Lock()
+	b := l.buf
+	l.bufMu.Unlock()
+	if b == nil {
+		return nil
+	}
+	b.flush()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// closeBuffer writes out everything queued in the write buffer, if any, and
+// stops it.
+func (l *Logger) closeBuffer() error {
+	l.bufMu.Lock()
+	b := l.buf
+	l.buf = nil
+	l.bufMu.Unlock()
+	if b == nil {
+		return nil
+	}
+	return b.close()
+}
+
+// write queues a copy of p, giving up if ctx is done while it waits for room,
+// or with StrictOrder, for its turn.
+func (b *asyncBuffer) write(ctx context.Context, p []byte) (int, error) {
+	writeLen := int64(len(p))
+	if b.l.tooLong(writeLen) {
+		err := fmt.Errorf(
+			"write length %d exceeds maximum file size %d", writeLen, b.l.max(),
+		)
+		b.l.recordError(writeError, err)
+		return 0, err
+	}
+
+	var stop chan struct{}
+	var ticket uint64
+	key := writeKey(ctx)
+	var waitStart time.Time
+	var quotaWait bool
+	defer func() {
+		if !waitStart.IsZero() {
+			b.l.recordBufferWait(key, quotaWait, time.Since(waitStart))
+		}
+	}()
+	b.mu.Lock()
+	if b.l.StrictOrder {
+		b.tickets++
+		ticket = b.tickets
+		defer b.passTurn(ticket)
+	}
+	for {
+		if b.closed {
+			// Close got here first.
+			b.mu.Unlock()
+			return 0, ErrClosed
+		}
+		if b.err != nil {
+			err := b.err
+			b.err = nil
+			b.mu.Unlock()
+			return 0, err
+		}
+		turn := !b.l.StrictOrder || ticket == b.served+1
+		over := b.overQuota(key, len(p))
+		full := over || b.spilling() || (b.queued > 0 && b.queued+len(p) > b.l.BufferSize) ||
+			(b.l.BufferSpill && len(p) > b.l.BufferSize)
+		if !turn || full {
+			if turn && b.l.BufferSpill {
+				n, err := b.spillRecord(p, key)
+				b.mu.Unlock()
+				if err != nil {
+					b.l.recordError(writeError, err)
+				}
+				return n, err
+			}
+			if err := ctx.Err(); err != nil {
+				b.mu.Unlock()
+				return 0, err
+			}
+			if stop == nil && ctx.Done() != nil {
+				// Wake up the wait below when ctx is done.
+				stop = make(chan struct{})
+				defer close(stop)
+				go func() {
+					select {
+					case <-ctx.Done():
+						b.mu.Lock()
+						b.cond.Broadcast()
+						b.mu.Unlock()
+					case <-stop:
+					}
+				}()
+			}
+			if !turn {
+				// Wait for the writes that came first.
+				b.cond.Wait()
+				continue
+			}
+			if waitStart.IsZero() {
+				waitStart = time.Now()
+			}
+			quotaWait = quotaWait || over
+			// Let the drain goroutine know not to wait for FlushInterval.
+			b.waiting++
+			b.cond.Broadcast()
+			b.cond.Wait()
+			b.waiting--
+			continue
+		}
+		break
+	}
+
+	// Wrap it only now, so that its sequence number follows those queued
+	// before it.
+	rec, changed, err := b.l.record(p, b.source(key))
+	if err != nil {
+		b.mu.Unlock()
+		b.l.recordError(writeError, err)
+		return 0, err
+	}
+	if len(p) > b.l.BufferSize {
+		// It can't fit however empty the buffer is, and the buffer has
+		// drained.
+		return b.writeDirect(p, rec)
+	}
+	if !changed {
+		rec = make([]byte, len(p))
+		copy(rec, p)
+	}
+	b.queue = append(b.queue, rec)
+	b.queued += len(rec)
+	if b.quotas() {
+		b.keys = append(b.keys, key)
+		b.addQueued(key, len(rec))
+	}
+	b.cond.Broadcast()
+	b.mu.Unlock()
+	return len(p), nil
+}
+
+// writeDirect writes rec, the record of p, to the log file while the buffer
+// is empty, counting it as queued meanwhile so that later writes wait behind
+// it.  It assumes that b.mu is held, and releases it.
+func (b *asyncBuffer) writeDirect(p, rec []byte) (int, error) {
+	b.queued += len(rec)
+	b.busy = true
+	b.mu.Unlock()
+
+	b.l.mu.Lock()
+	_, err := b.l.write(rec)
+	b.l.mu.Unlock()
+
+	b.mu.Lock()
+	b.busy = false
+	b.queued -= len(rec)
+	b.cond.Broadcast()
+	b.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// passTurn lets the write after the one with the given ticket go ahead with
+// StrictOrder, once that write has been queued or has given up, or marks the
+// ticket as given up if it wasn't its turn yet.
+func (b *asyncBuffer) passTurn(ticket uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ticket != b.served+1 {
+		if b.abandoned == nil {
+			b.abandoned = make(map[uint64]bool)
+		}
+		b.abandoned[ticket] = true
+		return
+	}
+	b.served++
+	for b.abandoned[b.served+1] {
+		delete(b.abandoned, b.served+1)
+		b.served++
+	}
+	b.cond.Broadcast()
+}
+
+// spillRecord checks and wraps p, of the given key, as configured and appends
+// it to the spill file.  It assumes that b.mu is held.
+func (b *asyncBuffer) spillRecord(p []byte, key string) (int, error) {
+	rec, _, err := b.l.record(p, b.source(key))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := b.spillWrite(rec); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// spilling reports whether the spill file holds data that hasn't been written
+// to the log file yet.  It assumes that b.mu is held.
+func (b *asyncBuffer) spilling() bool {
+	return b.spillW > b.spillR
+}
+
+// spillWrite appends p to the spill file as a length-prefixed record, creating
+// the file if necessary.  It assumes that b.mu is held.
+func (b *asyncBuffer) spillWrite(p []byte) (int, error) {
+	if b.spill == nil {
+		if err := os.MkdirAll(b.l.dir(), b.l.dirMode()); err != nil {
+			return 0, fmt.Errorf("can't make directories for spill file: %s", err)
+		}
+		f, err := ioutil.TempFile(b.l.dir(), filepath.Base(b.l.filename())+".spill")
+		if err != nil {
+			return 0, fmt.Errorf("can't create spill file: %s", err)
+		}
+		b.spill = f
+	}
+
+	rec := make([]byte, spillHeaderLen+len(p))
+	binary.BigEndian.PutUint32(rec, uint32(len(p)))
+	copy(rec[spillHeaderLen:], p)
+	if _, err := b.spill.WriteAt(rec, b.spillW); err != nil {
+		return 0, fmt.Errorf("can't write to spill file: %s", err)
+	}
+	b.spillW += int64(len(rec))
+	b.cond.Broadcast()
+	return len(p), nil
+}
+
+// run drains the buffer into the log file until the buffer is closed and
+// empty.
+func (b *asyncBuffer) run() {
+	defer close(b.done)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		for len(b.queue) == 0 && !b.spilling() && !b.closed {
+			b.cond.Wait()
+		}
+		b.linger()
+
+		switch {
+		case len(b.queue) > 0:
+			batch, keys := b.queue, b.keys
+			b.queue, b.keys = nil, nil
+			b.busy = true
+			b.mu.Unlock()
+			n, err := b.writeBatch(batch)
+			b.mu.Lock()
+			b.busy = false
+			b.queued -= n
+			for i, key := range keys {
+				b.addQueued(key, -len(batch[i]))
+			}
+			b.setErr(err)
+
+		case b.spilling():
+			b.busy = true
+			from, to := b.spillR, b.spillW
+			b.mu.Unlock()
+			err := b.drainSpill(from, to)
+			b.mu.Lock()
+			b.busy = false
+			b.setErr(err)
+			// Either everything up to the snapshot was written, or the spilled
+			// data can't be read back, in which case it is dropped rather
+			// than retried forever.
+			b.spillR = to
+			if err != nil {
+				b.spillR = b.spillW
+			}
+			if b.spillR == b.spillW {
+				b.spillR, b.spillW = 0, 0
+				_ = b.spill.Truncate(0)
+			}
+
+		default:
+			return
+		}
+		b.cond.Broadcast()
+	}
+}
+
+// linger waits for up to FlushInterval for more writes to queue up, so that
+// they are written together, unless the buffer is full, spilling, flushed or
+// closed.  It assumes that b.mu is held.
+func (b *asyncBuffer) linger() {
+	interval := b.l.FlushInterval
+	if interval <= 0 || !b.lingering() {
+		return
+	}
+	deadline := time.Now().Add(interval)
+	timer := time.AfterFunc(interval, func() {
+		b.mu.Lock()
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	})
+	defer timer.Stop()
+	for b.lingering() && time.Now().Before(deadline) {
+		b.cond.Wait()
+	}
+}
+
+// lingering reports whether the drain goroutine may keep waiting for more
+// writes.  It assumes that b.mu is held.
+func (b *asyncBuffer) lingering() bool {
+	return !b.closed && !b.spilling() && b.waiting == 0 && b.flushing == 0 &&
+		b.queued < b.l.BufferSize
+}
+
+// setErr records err to be returned by the next call to Write or Close, unless
+// an earlier error is still waiting to be returned.  It assumes that b.mu is
+// held.
+func (b *asyncBuffer) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// writeBatch writes the queued writes in batch to the log file, and returns the
+// number of bytes the batch held in memory.  Consecutive writes are joined into
+// a single write to the file, as long as they fit in the file together, so
+// that no write is split across files.
+func (b *asyncBuffer) writeBatch(batch [][]byte) (n int, err error) {
+	b.l.mu.Lock()
+	defer b.l.mu.Unlock()
+	write := func(p []byte) {
+		if _, errWrite := b.l.write(p); err == nil {
+			err = errWrite
+		}
+	}
+	if len(batch) == 1 {
+		write(batch[0])
+		return len(batch[0]), err
+	}
+	var chunk []byte
+	for _, p := range batch {
+		n += len(p)
+		if len(chunk) > 0 && b.l.size+int64(len(chunk)+len(p)) > b.l.max() {
+			write(chunk)
+			chunk = chunk[:0]
+		}
+		chunk = append(chunk, p...)
+	}
+	write(chunk)
+	return n, err
+}
+
+// drainSpill writes the records in the spill file between the offsets from and
+// to to the log file.
+func (b *asyncBuffer) drainSpill(from, to int64) error {
+	r := io.NewSectionReader(b.spill, from, to-from)
+	var header [spillHeaderLen]byte
+	var err error
+	for {
+		if _, errRead := io.ReadFull(r, header[:]); errRead == io.EOF {
+			return err
+		} else if errRead != nil {
+			return fmt.Errorf("can't read spill file: %s", errRead)
+		}
+		p := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, errRead := io.ReadFull(r, p); errRead != nil {
+			return fmt.Errorf("can't read spill file: %s", errRead)
+		}
+
+		b.l.mu.Lock()
+		_, errWrite := b.l.write(p)
+		b.l.mu.Unlock()
+		if err == nil {
+			err = errWrite
+		}
+	}
+}
+
+// flush waits until everything queued so far has been written.
+func (b *asyncBuffer) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushing++
+	b.cond.Broadcast()
+	for len(b.queue) > 0 || b.spilling() || b.busy {
+		b.cond.Wait()
+	}
+	b.flushing--
+}
+
+// close writes out everything still queued, stops the drain goroutine and
+// removes the spill file.  It returns the last error from writing in the
+// background that hasn't been returned by Write yet.
+func (b *asyncBuffer) close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.cond.Broadcast()
+	b.mu.Unlock()
+
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.busy {
+		// A write too large for the buffer is still under way.
+		b.cond.Wait()
+	}
+	if b.spill != nil {
+		b.spill.Close()
+		_ = os.Remove(b.spill.Name())
+		b.spill = nil
+	}
+	err := b.err
+	b.err = nil
+	return err
+}
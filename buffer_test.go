@@ -0,0 +1,404 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferedWrite", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1000,
+		BufferSize: 16,
+	}
+	defer l.Close()
+
+	var want bytes.Buffer
+	for i := 0; i < 100; i++ {
+		b := []byte(fmt.Sprintf("line %d\n", i))
+		want.Write(b)
+		n, err := l.Write(b)
+		isNil(err, t)
+		equals(len(b), n, t)
+	}
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, want.Bytes(), t)
+}
+
+func TestBufferedWriteBlocksWhenFull(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferedWriteBlocksWhenFull", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1000,
+		BufferSize: 8,
+	}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+
+	_, err := l.Write([]byte("12345678"))
+	isNil(err, t)
+
+	written := make(chan struct{})
+	go func() {
+		l.Write([]byte("abc"))
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("expected write to a full buffer to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	equals(8, l.buf.queued, t)
+
+	l.mu.Unlock()
+	<-written
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("12345678abc"), t)
+}
+
+func TestBufferSpill(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferSpill", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     1000,
+		BufferSize:  32,
+		BufferSpill: true,
+	}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+
+	var want bytes.Buffer
+	for i := 0; i < 50; i++ {
+		b := []byte(fmt.Sprintf("line %d\n", i))
+		want.Write(b)
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+
+	// memory use stays bounded, and the rest went to the spill file.
+	assert(l.buf.queued <= 32, t, "expected at most 32 bytes queued, got %d", l.buf.queued)
+	spills, err := filepath.Glob(filepath.Join(dir, "*.spill*"))
+	isNil(err, t)
+	equals(1, len(spills), t)
+
+	l.mu.Unlock()
+
+	// writes that arrive while the spill file is drained still come after
+	// everything spilled before them.
+	for i := 50; i < 60; i++ {
+		b := []byte(fmt.Sprintf("line %d\n", i))
+		want.Write(b)
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, want.Bytes(), t)
+
+	// the spill file gets removed on close.
+	notExist(spills[0], t)
+	fileCount(dir, 1, t)
+}
+
+func TestBufferOversizeWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferOversizeWrite", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1000,
+		BufferSize: 8,
+	}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+
+	_, err := l.Write([]byte("1234"))
+	isNil(err, t)
+
+	// a write larger than the whole buffer waits for it to drain, without
+	// being held in memory.
+	big := []byte("0123456789abcdef")
+	written := make(chan error)
+	go func() {
+		n, err := l.Write(big)
+		if err == nil && n != len(big) {
+			err = fmt.Errorf("expected %d bytes written, got %d", len(big), n)
+		}
+		written <- err
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("expected a write larger than the buffer to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	equals(4, l.buf.queued, t)
+
+	l.mu.Unlock()
+	isNil(<-written, t)
+	equals(0, l.buf.queued, t)
+
+	_, err = l.Write([]byte("end"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("12340123456789abcdefend"), t)
+
+	// with BufferSpill, it is spilled instead.
+	l = &Logger{
+		Filename:    filename,
+		MaxSize:     1000,
+		BufferSize:  8,
+		BufferSpill: true,
+	}
+	defer l.Close()
+	l.mu.Lock()
+	_, err = l.Write(big)
+	isNil(err, t)
+	equals(0, l.buf.queued, t)
+	spills, err := filepath.Glob(filepath.Join(dir, "*.spill*"))
+	isNil(err, t)
+	equals(1, len(spills), t)
+	l.mu.Unlock()
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("12340123456789abcdefend0123456789abcdef"), t)
+}
+
+func TestBufferedRotate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferedRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		BufferSize: 100,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// Rotate waits for queued writes, so they end up in the backup.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(dir), b, t)
+
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+
+	// a write that is too long is still refused right away.
+	_, err = l.Write([]byte(strings.Repeat("x", 11)))
+	notNil(err, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, b2, t)
+}
+
+func TestBufferedWriteError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestBufferedWriteError", t)
+	defer os.RemoveAll(dir)
+
+	// A file where the log directory should be makes every write fail.
+	notdir := filepath.Join(dir, "notdir")
+	isNil(ioutil.WriteFile(notdir, nil, 0644), t)
+	filename := logFile(notdir)
+	l := &Logger{
+		Filename:   filename,
+		BufferSize: 100,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// the background failure is reported by Close.
+	notNil(l.Close(), t)
+
+	files, err := ioutil.ReadDir(dir)
+	isNil(err, t)
+	equals(1, len(files), t)
+}
+
+func TestBufferedWriteContext(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferedWriteContext", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1000,
+		BufferSize: 8,
+	}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+
+	_, err := l.Write([]byte("12345678"))
+	isNil(err, t)
+
+	// a write waiting for room gives up when its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	n, err := l.WriteContext(ctx, []byte("abc"))
+	equals(context.DeadlineExceeded, err, t)
+	equals(0, n, t)
+
+	// and one with a done context isn't even queued.
+	_, err = l.WriteContext(ctx, []byte("def"))
+	equals(context.DeadlineExceeded, err, t)
+
+	l.mu.Unlock()
+	n, err = l.WriteContext(context.Background(), []byte("ghi"))
+	isNil(err, t)
+	equals(3, n, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("12345678ghi"), t)
+}
+
+func TestBufferFlushInterval(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferFlushInterval", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		MaxSize:       10,
+		BufferSize:    100,
+		FlushInterval: time.Hour,
+	}
+	defer l.Close()
+
+	// writes wait in the buffer until flushed.
+	for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+	}
+	time.Sleep(50 * time.Millisecond)
+	notExist(filename, t)
+
+	// and are then written together, as long as they fit in the file.
+	isNil(l.Flush(), t)
+	existsWithContent(backupFile(dir), []byte("aaaabbbb"), t)
+	existsWithContent(filename, []byte("cccc"), t)
+
+	// a full buffer doesn't wait.
+	l.MaxSize = 1000
+	for i := 0; i < 30; i++ {
+		_, err := l.Write([]byte("dddd"))
+		isNil(err, t)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for fileSize(filename) < 104 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert(fileSize(filename) >= 104, t, "expected the full buffer to be written, got %d bytes", fileSize(filename))
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("cccc"+strings.Repeat("dddd", 30)), t)
+}
+
+func TestBufferStrictOrder(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferStrictOrder", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     1000,
+		BufferSize:  8,
+		StrictOrder: true,
+	}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+	_, err := l.Write([]byte("1234"))
+	isNil(err, t)
+
+	// a write that doesn't fit waits for room...
+	go l.Write([]byte("abcdefgh"))
+	waitFor(func() bool {
+		l.buf.mu.Lock()
+		defer l.buf.mu.Unlock()
+		return l.buf.waiting == 1
+	}, t)
+
+	// ...and one that would fit waits for it.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.WriteContext(ctx, []byte("xy"))
+	equals(context.DeadlineExceeded, err, t)
+	done := make(chan struct{})
+	go func() {
+		l.Write([]byte("z"))
+		close(done)
+	}()
+	waitFor(func() bool {
+		l.buf.mu.Lock()
+		defer l.buf.mu.Unlock()
+		return l.buf.tickets == 4
+	}, t)
+
+	l.mu.Unlock()
+	<-done
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("1234abcdefghz"), t)
+}
+
+// fileSize returns the size of the named file, or 0 if it can't be found out.
+func fileSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
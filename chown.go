@@ -1,4 +1,4 @@
-// +build !linux
+// +build !linux,!windows
 
 package lumberjack
 
@@ -9,3 +9,15 @@ import (
 func chown(_ string, _ os.FileInfo) error {
 	return nil
 }
+
+func fchown(_ *os.File, _ os.FileInfo) error {
+	return nil
+}
+
+func chownDir(_ string, _ os.FileInfo) error {
+	return nil
+}
+
+func chownToDir(_, _ string) error {
+	return nil
+}
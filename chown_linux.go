@@ -2,6 +2,7 @@ package lumberjack
 
 import (
 	"os"
+	"path/filepath"
 	"syscall"
 )
 
@@ -15,5 +16,39 @@ func chown(name string, info os.FileInfo) error {
 	}
 	f.Close()
 	stat := info.Sys().(*syscall.Stat_t)
+	gid := int(stat.Gid)
+	if setgidDir(filepath.Dir(name)) {
+		// Keep the directory's group, which the file got when it was created.
+		gid = -1
+	}
+	return osChown(name, int(stat.Uid), gid)
+}
+
+// fchown changes the owner and group of the open file f to those of the file
+// info describes, as chown does by name.
+func fchown(f *os.File, info os.FileInfo) error {
+	stat := info.Sys().(*syscall.Stat_t)
+	gid := int(stat.Gid)
+	if setgidDir(filepath.Dir(f.Name())) {
+		// Keep the directory's group, which the file got when it was created.
+		gid = -1
+	}
+	return f.Chown(int(stat.Uid), gid)
+}
+
+// chownDir changes the owner and group of the directory name to those of the
+// directory info describes.
+func chownDir(name string, info os.FileInfo) error {
+	stat := info.Sys().(*syscall.Stat_t)
+	return osChown(name, int(stat.Uid), int(stat.Gid))
+}
+
+// chownToDir changes the owner and group of name to those of the directory dir.
+func chownToDir(name, dir string) error {
+	info, err := osStat(dir)
+	if err != nil {
+		return err
+	}
+	stat := info.Sys().(*syscall.Stat_t)
 	return osChown(name, int(stat.Uid), int(stat.Gid))
 }
@@ -0,0 +1,66 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// monotonicStart is the reference point for monotonicNow.
+var monotonicStart = time.Now()
+
+// monotonicNow returns the time elapsed on the monotonic clock, which isn't
+// affected by steps of the wall clock.  It's a variable so tests can mock it.
+var monotonicNow = func() time.Duration {
+	return time.Since(monotonicStart)
+}
+
+// clockRef pairs a reading of the wall clock with one of the monotonic clock,
+// so that later steps of the wall clock can be detected.
+type clockRef struct {
+	wall time.Time
+	mono time.Duration
+	set  bool
+
+	// stepped is true while the wall clock is off by more than MaxClockSkew,
+	// so that the step is only reported once.
+	stepped bool
+}
+
+// retentionNow returns the current time to measure MaxAge against.  Without
+// MaxClockSkew that is simply the wall clock.  Otherwise, when the wall clock
+// has moved more than MaxClockSkew away from where the monotonic clock says it
+// should be, the earlier of the two is used: a step forward can't then remove
+// backups that aren't due yet, and a step back only delays their removal.
+//
+// It's only called from the mill goroutine.
+func (l *Logger) retentionNow() time.Time {
+	now := currentTime()
+	if l.MaxClockSkew <= 0 {
+		return now
+	}
+	mono := monotonicNow()
+	ref := &l.clock
+	if !ref.set {
+		ref.wall, ref.mono, ref.set = now, mono, true
+		return now
+	}
+
+	expected := ref.wall.Add(mono - ref.mono)
+	skew := now.Sub(expected)
+	if skew <= l.MaxClockSkew && skew >= -l.MaxClockSkew {
+		ref.stepped = false
+		return now
+	}
+	if !ref.stepped {
+		ref.stepped = true
+		l.emit(Event{
+			Type: EventClockStep,
+			Time: now,
+			Err:  fmt.Errorf("wall clock is %s off the monotonic clock", skew),
+		})
+	}
+	if skew > 0 {
+		return expected
+	}
+	return now
+}
@@ -0,0 +1,84 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaxClockSkew(t *testing.T) {
+	currentTime = fakeTime
+	var mono time.Duration
+	monotonicNow = func() time.Duration { return mono }
+	defer func() {
+		monotonicNow = func() time.Duration { return time.Since(monotonicStart) }
+	}()
+	dir := makeTempDir("TestMaxClockSkew", t)
+	defer os.RemoveAll(dir)
+
+	var events []Event
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxAge:       1,
+		MaxClockSkew: time.Minute,
+		OnEvent:      func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+	isNil(l.millRunOnce(), t)
+	existsWithContent(backup, []byte("boo!"), t)
+
+	// the wall clock jumps ten days ahead while the monotonic clock only says
+	// an hour passed, so the backup is kept.
+	fakeCurrentTime = fakeCurrentTime.Add(10 * 24 * time.Hour)
+	mono += time.Hour
+	isNil(l.millRunOnce(), t)
+	existsWithContent(backup, []byte("boo!"), t)
+	isNil(l.millRunOnce(), t)
+	equals(1, len(events), t)
+	equals(EventClockStep, events[0].Type, t)
+	notNil(events[0].Err, t)
+
+	// once the monotonic clock says the day is over, the backup goes.
+	mono += 24 * time.Hour
+	isNil(l.millRunOnce(), t)
+	notExist(backup, t)
+}
+
+func TestMaxClockSkewStepBack(t *testing.T) {
+	currentTime = fakeTime
+	var mono time.Duration
+	monotonicNow = func() time.Duration { return mono }
+	defer func() {
+		monotonicNow = func() time.Duration { return time.Since(monotonicStart) }
+	}()
+	dir := makeTempDir("TestMaxClockSkewStepBack", t)
+	defer os.RemoveAll(dir)
+
+	var events []Event
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxAge:       1,
+		MaxClockSkew: time.Minute,
+		OnEvent:      func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	isNil(l.millRunOnce(), t)
+
+	// a step back is reported, and the wall clock is still used, so nothing
+	// extra gets removed.
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+	fakeCurrentTime = fakeCurrentTime.Add(-time.Hour)
+	mono += 2 * 24 * time.Hour
+	isNil(l.millRunOnce(), t)
+	existsWithContent(backup, []byte("boo!"), t)
+	equals(1, len(events), t)
+	equals(EventClockStep, events[0].Type, t)
+}
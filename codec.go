@@ -0,0 +1,164 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Compressor compresses backups for a Logger, for using a codec other than
+// gzip, such as zstd or lz4 from a third-party package, or gzip at another
+// level.  See Logger.Compressor.
+type Compressor interface {
+	// Compress writes the compressed form of everything read from src to
+	// dst.
+	Compress(dst io.Writer, src io.Reader) error
+
+	// Suffix is the extension given to compressed backups, such as ".zst".
+	Suffix() string
+}
+
+// Decompressor is implemented by Compressors that can read back what they
+// compressed, which Grep and OpenBackupAt need for backups compressed with
+// them.
+type Decompressor interface {
+	// Decompress returns a reader of the decompressed form of src.
+	Decompress(src io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip is a Compressor for gzip at the given Level, one of the levels of
+// compress/gzip.  The zero value compresses at gzip.DefaultCompression, like a
+// Logger does without a Compressor.
+type Gzip struct {
+	Level int
+}
+
+// Compress implements Compressor.
+func (g Gzip) Compress(dst io.Writer, src io.Reader) error {
+	gz, err := getGzipWriter(dst, g.level())
+	if err != nil {
+		return err
+	}
+	defer putGzipWriter(gz, g.level())
+	if _, err := copyBuffered(gz, src); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Suffix implements Compressor.
+func (Gzip) Suffix() string {
+	return compressSuffix
+}
+
+// Decompress implements Decompressor.
+func (Gzip) Decompress(src io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(src)
+}
+
+// newWriter returns a gzip writer to dst at g's level.
+func (g Gzip) newWriter(dst io.Writer) (*gzip.Writer, error) {
+	return gzip.NewWriterLevel(dst, g.level())
+}
+
+// level returns g's level, with 0 meaning gzip.DefaultCompression.
+func (g Gzip) level() int {
+	if g.Level == 0 {
+		return gzip.DefaultCompression
+	}
+	return g.Level
+}
+
+// gzipWriters holds gzip writers for reuse, with a pool for each level from
+// gzip.HuffmanOnly up, since each one allocates hundreds of kilobytes of
+// compression state, which would otherwise be thrown away with every backup.
+var gzipWriters [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
+// getGzipWriter returns a gzip writer to dst at the given level, reusing one
+// from gzipWriters if there is one.
+func getGzipWriter(dst io.Writer, level int) (*gzip.Writer, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return gzip.NewWriterLevel(dst, level)
+	}
+	if gz, ok := gzipWriters[level-gzip.HuffmanOnly].Get().(*gzip.Writer); ok {
+		gz.Reset(dst)
+		return gz, nil
+	}
+	return gzip.NewWriterLevel(dst, level)
+}
+
+// putGzipWriter returns gz, at the given level, to gzipWriters, once it is no
+// longer used.
+func putGzipWriter(gz *gzip.Writer, level int) {
+	// don't keep dst from being collected.
+	gz.Reset(ioutil.Discard)
+	gzipWriters[level-gzip.HuffmanOnly].Put(gz)
+}
+
+// gzipCompressor returns the Gzip compressor to use, and whether the Logger
+// compresses with gzip at all.
+func (l *Logger) gzipCompressor() (Gzip, bool) {
+	c := l.codec()
+	if c == nil {
+		return Gzip{}, true
+	}
+	g, ok := c.(Gzip)
+	return g, ok
+}
+
+// compressSuffix returns the extension the Logger gives compressed backups.
+func (l *Logger) compressSuffix() string {
+	suffix := compressSuffix
+	if c := l.codec(); c != nil {
+		suffix = c.Suffix()
+	}
+	if tag := l.archiveTag(); tag != "" {
+		return "." + tag + suffix
+	}
+	return suffix
+}
+
+// compressSuffixes returns the extensions of the compressed backups the Logger
+// recognizes: its own, that of its Compressor without encryption, and that of
+// gzip, so that backups compressed before a change of Compressor or Encrypter
+// are still cleaned up.
+func (l *Logger) compressSuffixes() []string {
+	suffixes := []string{l.compressSuffix()}
+	if l.archiveTag() != "" {
+		// from before the tag was set.
+		suffixes = append(suffixes, strings.TrimPrefix(suffixes[0], "."+l.archiveTag()))
+	}
+	if l.Encrypter != nil && l.Compressor != nil {
+		suffixes = append(suffixes, l.Compressor.Suffix())
+	}
+	if suffixes[len(suffixes)-1] != compressSuffix {
+		suffixes = append(suffixes, compressSuffix)
+	}
+	return suffixes
+}
+
+// openLogReader opens the named log file for reading, transparently
+// decompressing it if it is a compressed backup, compressed with gzip or c,
+// which may be nil.  Given a part of a compressed backup that was split by
+// CompressPartSize, it reads the whole backup, from that part on.
+func openLogReader(name string, c Compressor) (io.ReadCloser, error) {
+	var d Decompressor = Gzip{}
+	suffixes := []string{compressSuffix}
+	if c != nil && c.Suffix() != compressSuffix {
+		suffixes = append(suffixes, c.Suffix())
+	}
+	archive := name
+	if a, _, ok := splitPart(name, suffixes); ok {
+		archive = a
+	}
+	if c != nil && c.Suffix() != compressSuffix && strings.HasSuffix(archive, c.Suffix()) {
+		var ok bool
+		if d, ok = c.(Decompressor); !ok {
+			return nil, fmt.Errorf("can't decompress %s: the Compressor has no Decompress method", name)
+		}
+	}
+	return openCompressed(name, suffixes, d)
+}
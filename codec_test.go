@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// zlibCompressor is a Compressor for a codec other than gzip.
+type zlibCompressor struct{}
+
+func (zlibCompressor) Compress(dst io.Writer, src io.Reader) error {
+	zw := zlib.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (zlibCompressor) Suffix() string { return ".zz" }
+
+func (zlibCompressor) Decompress(src io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(src)
+}
+
+func TestCompressor(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressor", t)
+	defer os.RemoveAll(dir)
+
+	// a backup compressed with gzip before switching codecs.
+	old := backupFile(dir) + compressSuffix
+	isNil(ioutil.WriteFile(old, gzipped([]byte("old\n"), t), 0644), t)
+	newFakeTime()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    100,
+		Compress:   true,
+		Compressor: zlibCompressor{},
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	backup := backupFile(dir) + ".zz"
+	f, err := os.Open(backup)
+	isNil(err, t)
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	isNil(err, t)
+	got, err := ioutil.ReadAll(zr)
+	isNil(err, t)
+	equals(b, got, t)
+	notExist(backupFile(dir), t)
+
+	// both backups are recognized, and read back.
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(2, len(files), t)
+	equals(filepath.Base(backup), files[0].Name(), t)
+	equals(filepath.Base(old), files[1].Name(), t)
+
+	matches, err := l.Grep(context.Background(), regexp.MustCompile("o"), fakeTime().AddDate(0, 0, -7), fakeTime())
+	isNil(err, t)
+	equals(2, len(matches), t)
+	equals("old", matches[0].Text, t)
+	equals("boo!", matches[1].Text, t)
+}
+
+func TestGzipLevel(t *testing.T) {
+	var buf bytes.Buffer
+	in := bytes.Repeat([]byte("gzip level "), 1000)
+	isNil(Gzip{Level: gzip.BestSpeed}.Compress(&buf, bytes.NewReader(in)), t)
+
+	r, err := Gzip{}.Decompress(&buf)
+	isNil(err, t)
+	out, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	equals(in, out, t)
+	equals(compressSuffix, Gzip{}.Suffix(), t)
+
+	// an invalid level is reported.
+	notNil(Gzip{Level: 42}.Compress(&buf, bytes.NewReader(in)), t)
+}
@@ -0,0 +1,152 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrBackupExists is returned when rotating with CollisionError, if the
+// backup file that the log file was to be moved to already exists.
+var ErrBackupExists = errors.New("lumberjack: backup file already exists")
+
+// Collision is what to do when a backup name is already taken by an existing
+// backup, which happens when two rotations fall on the same timestamp, such as
+// when a restarted process rotates within the same millisecond as the one
+// before it, or when BackupTimeFormat is coarse.  See Logger.BackupCollision.
+type Collision int
+
+const (
+	// CollisionOverwrite replaces the existing backup.
+	CollisionOverwrite Collision = iota
+
+	// CollisionSuffix gives the new backup a name of its own, by adding a
+	// number after the timestamp, as in foo-<timestamp>.1.log.  Such backups
+	// are taken to be newer than the one whose name they share.
+	CollisionSuffix
+
+	// CollisionError fails the rotation with ErrBackupExists, leaving the
+	// existing backup alone.
+	CollisionError
+)
+
+// collisionNames holds the text form of each Collision.
+var collisionNames = []string{
+	CollisionOverwrite: "overwrite",
+	CollisionSuffix:    "suffix",
+	CollisionError:     "error",
+}
+
+// String returns the name of c: "overwrite", "suffix" or "error".
+func (c Collision) String() string {
+	if c < 0 || int(c) >= len(collisionNames) {
+		return fmt.Sprintf("Collision(%d)", int(c))
+	}
+	return collisionNames[c]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Collision) MarshalText() ([]byte, error) {
+	if c < 0 || int(c) >= len(collisionNames) {
+		return nil, fmt.Errorf("invalid collision strategy %d", int(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "overwrite",
+// "suffix" and "error", so that the strategy can be set from config files.
+func (c *Collision) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = CollisionOverwrite
+		return nil
+	}
+	for i, name := range collisionNames {
+		if string(text) == name {
+			*c = Collision(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid collision strategy %q, expected overwrite, suffix or error", text)
+}
+
+// resolveCollision returns the name to move the log file to instead of backup,
+// according to BackupCollision, if a backup of that name exists already.
+func (l *Logger) resolveCollision(backup string) (string, error) {
+	if l.BackupCollision == CollisionOverwrite || !l.backupTaken(backup) {
+		return backup, nil
+	}
+	if l.BackupCollision == CollisionError {
+		return "", fmt.Errorf("%w: %s", ErrBackupExists, backup)
+	}
+	return l.freeName(backup), nil
+}
+
+// freeName returns backup, or if a backup of that name exists already, the
+// first name CollisionSuffix would give it that is free.
+func (l *Logger) freeName(backup string) string {
+	if !l.backupTaken(backup) {
+		return backup
+	}
+	return l.numberedName(backup)
+}
+
+// numberedName returns the first name CollisionSuffix would give backup that
+// is free.
+func (l *Logger) numberedName(backup string) string {
+	ext := filepath.Ext(backup)
+	stem := backup[:len(backup)-len(ext)]
+	for i := 1; ; i++ {
+		name := stem + "." + strconv.Itoa(i) + ext
+		if !l.backupTaken(name) {
+			return name
+		}
+	}
+}
+
+// backupTaken reports whether there is a backup of the given name, as is or
+// compressed, next to the log file or in the backup directory.
+func (l *Logger) backupTaken(name string) bool {
+	bases := []string{name}
+	if l.staged() {
+		bases = append(bases, l.archivePath(name))
+	}
+	var names []string
+	for _, base := range bases {
+		names = append(names, base)
+		for _, suffix := range l.compressSuffixes() {
+			names = append(names, base+suffix, base+suffix+"."+formatPart(0))
+		}
+	}
+	for _, n := range names {
+		if _, err := osStat(n); err == nil {
+			return true
+		}
+	}
+	for _, base := range bases {
+		if l.hashedBackup(base) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDup splits the number CollisionSuffix added off a backup stamp, if there
+// is one.
+func splitDup(stamp string) (string, int, bool) {
+	i := strings.LastIndexByte(stamp, '.')
+	if i < 0 || i == len(stamp)-1 || stamp[i+1] == '0' {
+		return "", 0, false
+	}
+	for _, c := range stamp[i+1:] {
+		if c < '0' || c > '9' {
+			return "", 0, false
+		}
+	}
+	n, err := strconv.Atoi(stamp[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return stamp[:i], n, true
+}
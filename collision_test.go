@@ -0,0 +1,84 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupCollision(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupCollision", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         100,
+		BackupCollision: CollisionSuffix,
+	}
+	defer l.Close()
+
+	// the time doesn't move, so every rotation gets the same name.
+	for _, s := range []string{"one", "two", "three"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	backup := backupFile(dir)
+	stem := backup[:len(backup)-len(".log")]
+	existsWithContent(backup, []byte("one"), t)
+	existsWithContent(stem+".1.log", []byte("two"), t)
+	existsWithContent(stem+".2.log", []byte("three"), t)
+
+	// the suffixed backups are the newer ones.
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(3, len(files), t)
+	equals(filepath.Base(stem+".2.log"), files[0].Name(), t)
+	equals(filepath.Base(stem+".1.log"), files[1].Name(), t)
+	equals(filepath.Base(backup), files[2].Name(), t)
+
+	// a compressed backup takes the name too.
+	isNil(os.Rename(stem+".1.log", stem+".1.log"+compressSuffix), t)
+	name, err := l.resolveCollision(backup)
+	isNil(err, t)
+	equals(stem+".3.log", name, t)
+	isNil(os.Remove(stem+".2.log"), t)
+	name, err = l.resolveCollision(backup)
+	isNil(err, t)
+	equals(stem+".2.log", name, t)
+
+	// CollisionError leaves the existing backup alone.
+	l.BackupCollision = CollisionError
+	_, err = l.Write([]byte("four"))
+	isNil(err, t)
+	err = l.Rotate()
+	assert(errors.Is(err, ErrBackupExists), t, "expected ErrBackupExists, got %v", err)
+	existsWithContent(backup, []byte("one"), t)
+	existsWithContent(filename, []byte("four"), t)
+
+	// and by default it's replaced.
+	l.BackupCollision = CollisionOverwrite
+	isNil(l.Rotate(), t)
+	existsWithContent(backup, []byte("four"), t)
+}
+
+func TestCollisionText(t *testing.T) {
+	var c struct {
+		BackupCollision Collision `json:"backupcollision"`
+	}
+	isNil(json.Unmarshal([]byte(`{"backupcollision": "suffix"}`), &c), t)
+	equals(CollisionSuffix, c.BackupCollision, t)
+
+	b, err := json.Marshal(c)
+	isNil(err, t)
+	equals(`{"backupcollision":"suffix"}`, string(b), t)
+
+	notNil(json.Unmarshal([]byte(`{"backupcollision": "clobber"}`), &c), t)
+	_, err = Collision(7).MarshalText()
+	notNil(err, t)
+}
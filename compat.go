@@ -0,0 +1,195 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Location is the time zone of the timestamps in backup names and elsewhere.
+// See Config.Location.
+type Location int
+
+const (
+	// LocationUTC gives timestamps in UTC.  It is the default.
+	LocationUTC Location = iota
+
+	// LocationLocal gives timestamps in the computer's local time, like
+	// Logger.LocalTime.
+	LocationLocal
+)
+
+// locationNames holds the text form of each Location.
+var locationNames = []string{
+	LocationUTC:   "utc",
+	LocationLocal: "local",
+}
+
+// String returns the name of loc: "utc" or "local".
+func (loc Location) String() string {
+	if loc < 0 || int(loc) >= len(locationNames) {
+		return fmt.Sprintf("Location(%d)", int(loc))
+	}
+	return locationNames[loc]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (loc Location) MarshalText() ([]byte, error) {
+	if loc < 0 || int(loc) >= len(locationNames) {
+		return nil, fmt.Errorf("invalid location %d", int(loc))
+	}
+	return []byte(loc.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "utc" and
+// "local", in any case, so that the location can be set from config files.
+func (loc *Location) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*loc = LocationUTC
+		return nil
+	}
+	for i, name := range locationNames {
+		if strings.EqualFold(string(text), name) {
+			*loc = Location(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid location %q, expected utc or local", text)
+}
+
+// Deprecation describes an option of a Config given in a form that is still
+// accepted but has been replaced, and what to give instead.  See
+// Config.Deprecations.
+type Deprecation struct {
+	// Field is the config file name of the option, such as "maxsize".
+	Field string
+
+	// Old is the option as it was given, such as "maxsize: 100", and New the
+	// same option in its current form, such as "maxsize: 100MB".
+	Old string
+	New string
+}
+
+// String describes d, such as "maxsize: 100 is deprecated, use maxsize:
+// 100MB".
+func (d Deprecation) String() string {
+	return d.Old + " is deprecated, use " + d.New
+}
+
+// Deprecations returns the options of cfg that are given in a deprecated
+// form: LocalTime, and the MaxSize and MaxAge of config files written for the
+// original lumberjack v2 Logger, as plain numbers of megabytes and days.
+// They keep working, and a Logger made from cfg reports each of them with
+// EventDeprecated when it first opens its log file.
+func (cfg Config) Deprecations() []Deprecation {
+	deprecations := append([]Deprecation(nil), cfg.legacy...)
+	if cfg.LocalTime {
+		deprecations = append(deprecations, Deprecation{
+			Field: "localtime",
+			Old:   "localtime: true",
+			New:   "location: " + LocationLocal.String(),
+		})
+	}
+	return deprecations
+}
+
+// localTime reports whether cfg gives timestamps in local time, with Location
+// or the deprecated LocalTime.
+func (cfg Config) localTime() bool {
+	return cfg.Location == LocationLocal || cfg.LocalTime
+}
+
+// plainConfig is Config without its methods, for decoding it the usual way
+// from inside its own UnmarshalJSON and UnmarshalYAML.
+type plainConfig Config
+
+// UnmarshalJSON implements json.Unmarshaler, decoding cfg as usual, except
+// that a plain number for maxsize or maxage is taken in the units of the
+// original lumberjack v2 Logger, megabytes and days, and recorded in
+// Deprecations.  Like encoding/json, it matches the names in any case, as in
+// the MaxSize and MaxAge that a v2 Logger without tags marshals to.
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*plainConfig)(cfg)); err != nil {
+		return err
+	}
+	cfg.legacy = nil
+	for _, field := range []string{"maxsize", "maxage"} {
+		var raw json.RawMessage
+		for name, value := range fields {
+			if strings.EqualFold(name, field) {
+				raw = bytes.TrimSpace(value)
+			}
+		}
+		if len(raw) == 0 || raw[0] == '"' {
+			continue
+		}
+		if n, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			cfg.useLegacy(field, n)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, of gopkg.in/yaml.v2, like
+// UnmarshalJSON does json.Unmarshaler.
+func (cfg *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var fields map[string]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	if err := unmarshal((*plainConfig)(cfg)); err != nil {
+		return err
+	}
+	cfg.legacy = nil
+	for _, field := range []string{"maxsize", "maxage"} {
+		switch n := fields[field].(type) {
+		case int:
+			cfg.useLegacy(field, int64(n))
+		case int64:
+			cfg.useLegacy(field, n)
+		case uint64:
+			cfg.useLegacy(field, int64(n))
+		}
+	}
+	return nil
+}
+
+// useLegacy sets the named field, given as the plain number n, in the units
+// of the original lumberjack v2 Logger, and records it in Deprecations.  A
+// maxsize of 0 or less means the same either way, so it isn't recorded.
+func (cfg *Config) useLegacy(field string, n int64) {
+	var d Deprecation
+	switch field {
+	case "maxsize":
+		if n <= 0 {
+			return
+		}
+		cfg.MaxSize = ByteSize(n * int64(megabyte))
+		d = Deprecation{Old: fmt.Sprintf("maxsize: %d", n), New: "maxsize: " + cfg.MaxSize.String()}
+	case "maxage":
+		if n == 0 {
+			return
+		}
+		cfg.MaxAge = Duration(n * int64(day))
+		d = Deprecation{Old: fmt.Sprintf("maxage: %d", n), New: "maxage: " + cfg.MaxAge.String()}
+	}
+	d.Field = field
+	cfg.legacy = append(cfg.legacy, d)
+}
+
+// reportDeprecations sends EventDeprecated for each of the deprecations of
+// the Config the Logger was last configured with, once.  This method assumes
+// l.mu is held, if the Logger is in use.
+func (l *Logger) reportDeprecations() {
+	deprecations := l.deprecations
+	l.deprecations = nil
+	for _, d := range deprecations {
+		l.emit(Event{Type: EventDeprecated, Deprecation: d})
+	}
+}
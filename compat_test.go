@@ -0,0 +1,163 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigLegacyJSON(t *testing.T) {
+	megabyte = 1024 * 1024
+	defer func() { megabyte = 1 }()
+
+	data := []byte(`{"filename": "foo.log", "maxsize": 100, "maxage": 28, "maxbackups": 3, ` +
+		`"localtime": true, "compress": true}`)
+	var cfg Config
+	isNil(json.Unmarshal(data, &cfg), t)
+	equals(ByteSize(100<<20), cfg.MaxSize, t)
+	equals(Duration(28*24*time.Hour), cfg.MaxAge, t)
+	equals([]Deprecation{
+		{Field: "maxsize", Old: "maxsize: 100", New: "maxsize: 100MB"},
+		{Field: "maxage", Old: "maxage: 28", New: "maxage: 672h0m0s"},
+		{Field: "localtime", Old: "localtime: true", New: "location: local"},
+	}, cfg.Deprecations(), t)
+
+	l, err := New(cfg)
+	isNil(err, t)
+	equals(int64(100<<20), l.MaxBytes, t)
+	equals(28, l.MaxAge, t)
+	equals(3, l.MaxBackups, t)
+	equals(true, l.LocalTime, t)
+	equals(true, l.Compress, t)
+
+	// the current forms mean the same, without deprecations.
+	data = []byte(`{"filename": "foo.log", "maxsize": "100MB", "maxage": "672h", "location": "local"}`)
+	var current Config
+	isNil(json.Unmarshal(data, &current), t)
+	equals(0, len(current.Deprecations()), t)
+	l, err = New(current)
+	isNil(err, t)
+	equals(int64(100<<20), l.MaxBytes, t)
+	equals(28, l.MaxAge, t)
+	equals(true, l.LocalTime, t)
+}
+
+func TestConfigLegacyJSONExportedNames(t *testing.T) {
+	megabyte = 1024 * 1024
+	defer func() { megabyte = 1 }()
+
+	// as a lumberjack v2 Logger marshals without its tags.
+	data := []byte(`{"Filename": "foo.log", "MaxSize": 100, "MaxAge": 7, "MaxBackups": 3, "LocalTime": true}`)
+	var cfg Config
+	isNil(json.Unmarshal(data, &cfg), t)
+	equals(ByteSize(100<<20), cfg.MaxSize, t)
+	equals(Duration(7*24*time.Hour), cfg.MaxAge, t)
+	equals([]string{"maxsize", "maxage", "localtime"}, deprecatedFields(cfg), t)
+	l, err := New(cfg)
+	isNil(err, t)
+	equals(int64(100<<20), l.MaxBytes, t)
+	equals(7, l.MaxAge, t)
+	equals(3, l.MaxBackups, t)
+
+	// in any case at all, and only as plain numbers.
+	data = []byte(`{"filename": "foo.log", "MAXSIZE": 5, "maxAge": "48h"}`)
+	cfg = Config{}
+	isNil(json.Unmarshal(data, &cfg), t)
+	equals(ByteSize(5<<20), cfg.MaxSize, t)
+	equals(Duration(48*time.Hour), cfg.MaxAge, t)
+	equals([]string{"maxsize"}, deprecatedFields(cfg), t)
+}
+
+// deprecatedFields returns the fields of the deprecations of cfg.
+func deprecatedFields(cfg Config) []string {
+	var fields []string
+	for _, d := range cfg.Deprecations() {
+		fields = append(fields, d.Field)
+	}
+	return fields
+}
+
+func TestConfigLegacyYaml(t *testing.T) {
+	megabyte = 1024 * 1024
+	defer func() { megabyte = 1 }()
+
+	data := []byte(`
+filename: foo.log
+maxsize: 5
+maxage: 10
+maxbackups: 3
+localtime: true`[1:])
+	var cfg Config
+	isNil(yaml.Unmarshal(data, &cfg), t)
+	equals(ByteSize(5<<20), cfg.MaxSize, t)
+	equals(Duration(10*24*time.Hour), cfg.MaxAge, t)
+	equals(3, len(cfg.Deprecations()), t)
+	equals("maxsize: 5 is deprecated, use maxsize: 5MB", cfg.Deprecations()[0].String(), t)
+
+	// decoding again only keeps the deprecations of what is left as it was.
+	isNil(yaml.Unmarshal([]byte("filename: foo.log\nmaxsize: 5MB\nlocation: UTC"), &cfg), t)
+	equals(ByteSize(5<<20), cfg.MaxSize, t)
+	equals(LocationUTC, cfg.Location, t)
+	equals(1, len(cfg.Deprecations()), t)
+	equals("localtime", cfg.Deprecations()[0].Field, t)
+}
+
+func TestDeprecatedEvents(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestDeprecatedEvents", t)
+	defer os.RemoveAll(dir)
+
+	var cfg Config
+	isNil(json.Unmarshal([]byte(`{"maxsize": 10, "localtime": true}`), &cfg), t)
+	cfg.Filename = logFile(dir)
+	l, err := New(cfg)
+	isNil(err, t)
+	defer l.Close()
+	var events []Event
+	l.OnEvent = func(e Event) {
+		if e.Type == EventDeprecated {
+			events = append(events, e)
+		}
+	}
+
+	// they are sent when the log file is first opened, after OnEvent is set.
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(2, len(events), t)
+	equals("maxsize", events[0].Deprecation.Field, t)
+	equals("localtime", events[1].Deprecation.Field, t)
+	equals("deprecated", events[1].Type.String(), t)
+
+	// and only once.
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(2, len(events), t)
+
+	// UpdateConfig sends them straight away.
+	isNil(l.UpdateConfig(Config{Filename: l.Filename, LocalTime: true}), t)
+	equals(3, len(events), t)
+	equals("localtime", events[2].Deprecation.Field, t)
+}
+
+func TestLocationText(t *testing.T) {
+	for _, loc := range []Location{LocationUTC, LocationLocal} {
+		b, err := loc.MarshalText()
+		isNil(err, t)
+		var got Location
+		isNil(got.UnmarshalText(b), t)
+		equals(loc, got, t)
+	}
+	var loc Location
+	isNil(loc.UnmarshalText([]byte("Local")), t)
+	equals(LocationLocal, loc, t)
+	notNil(loc.UnmarshalText([]byte("America/New_York")), t)
+	_, err := Location(5).MarshalText()
+	notNil(err, t)
+	equals("Location(5)", Location(5).String(), t)
+}
@@ -0,0 +1,339 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CompressFile gzips the file src into dst, removing src if successful.  This is
+// the same procedure lumberjack uses to compress backups, so tools working on
+// the same directory see the same behavior: the output is first written to a
+// temporary file next to dst, which is synced and then renamed into place, so
+// that anything looking for dst never sees a partially written file.  The
+// output gets the given mode, or the mode of src if mode is 0, and on Linux the
+// owner of src as well, though it keeps the group of a setgid directory.
+func CompressFile(src, dst string, mode fs.FileMode) error {
+	_, err := compressFile(src, dst, 0, "", mode, gzipCopy, nil, chown)
+	return err
+}
+
+// compressFile compresses src into dst using the given compress function,
+// and gives it the owner of src using the given chown function, removing src
+// if successful.  If partSize is more than 0, the output is split into parts
+// of at most partSize bytes, named dst.000, dst.001 and so on, which together
+// make up the compressed file.  If hashBefore is not empty, dst ends with it,
+// and the output is named with a hash of the content of src in front of it
+// (see Logger.ContentHash).  If verify is not nil, the output is read back
+// with it, and src is only removed if it decompresses to what was compressed
+// (see Logger.VerifyCompression).  It returns the names of the files written.
+// See CompressFile.
+func compressFile(src, dst string, partSize int64, hashBefore string, mode fs.FileMode, compress func(dst io.Writer, src io.Reader) error, verify Decompressor, chown func(name string, info os.FileInfo) error) (names []string, err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	fi, err := osStat(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %v", err)
+	}
+	fi = withSecurity(fi, src)
+
+	if names, err = compressFrom(f, fi, dst, partSize, hashBefore, mode, compress, verify, chown); err != nil {
+		return nil, err
+	}
+
+	// close the source file we copied from
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress log file: %v", err)
+	}
+
+	if err := removeFile(src); err != nil {
+		return nil, fmt.Errorf("failed to compress log file: %v", err)
+	}
+
+	return names, nil
+}
+
+// compressFrom compresses what it reads from src, a log file with the given
+// info, into dst, as compressFile does, without removing anything.
+func compressFrom(src io.Reader, fi os.FileInfo, dst string, partSize int64, hashBefore string, mode fs.FileMode, compress func(dst io.Writer, src io.Reader) error, verify Decompressor, chown func(name string, info os.FileInfo) error) (names []string, err error) {
+	w := &partWriter{dst: dst, size: partSize, mode: mode, info: fi, chown: chown}
+	if hashBefore != "" {
+		w.hash, w.hashBefore = sha256.New(), hashBefore
+		src = io.TeeReader(src, w.hash)
+	}
+	var sum hash.Hash
+	if verify != nil {
+		sum = sha256.New()
+		src = io.TeeReader(src, sum)
+	}
+	defer func() {
+		if err != nil {
+			w.abort()
+		}
+	}()
+
+	if err := compress(w, src); err != nil {
+		if w.err != nil {
+			return nil, w.err
+		}
+		return nil, fmt.Errorf("failed to compress log file: %v", err)
+	}
+
+	if verify != nil {
+		if err := w.verify(verify, sum.Sum(nil)); err != nil {
+			return nil, fmt.Errorf("failed to verify compressed log file: %v", err)
+		}
+	}
+	if names, err = w.commit(); err != nil {
+		return nil, fmt.Errorf("failed to compress log file: %v", err)
+	}
+	return names, nil
+}
+
+// partWriter writes compressed output to temporary files, starting a new one
+// every size bytes if size is more than 0, until it is committed.
+type partWriter struct {
+	dst   string
+	tmp   string // the base of the temporary names, if not dst
+	size  int64
+	mode  fs.FileMode
+	info  os.FileInfo
+	chown func(name string, info os.FileInfo) error
+
+	hash       hash.Hash // of the uncompressed content, to name the files by
+	hashBefore string    // what the hash goes in front of in dst
+
+	files []*os.File
+	n     int64 // bytes written to the last file
+	err   error // the error from setting up a file
+}
+
+// name returns the final name of the i'th file.
+func (w *partWriter) name(i int) string {
+	if w.size <= 0 {
+		return w.dst
+	}
+	return w.dst + "." + formatPart(i)
+}
+
+// tmpName returns the temporary name of the i'th file.
+func (w *partWriter) tmpName(i int) string {
+	base := w.tmp
+	if base == "" {
+		base = w.dst
+	}
+	if w.size > 0 {
+		base += "." + formatPart(i)
+	}
+	return base + tmpSuffix
+}
+
+// Write implements io.Writer.
+func (w *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 || len(w.files) == 0 {
+		if len(w.files) == 0 || (w.size > 0 && w.n >= w.size) {
+			if err := w.next(); err != nil {
+				return written, err
+			}
+		}
+		chunk := p
+		if w.size > 0 && int64(len(chunk)) > w.size-w.n {
+			chunk = chunk[:w.size-w.n]
+		}
+		n, err := w.files[len(w.files)-1].Write(chunk)
+		written += n
+		w.n += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// written returns the number of bytes written to the files so far, every one
+// of which but the last is full.
+func (w *partWriter) written() int64 {
+	if len(w.files) == 0 {
+		return 0
+	}
+	return int64(len(w.files)-1)*w.size + w.n
+}
+
+// next starts the next file.
+func (w *partWriter) next() error {
+	// Use a different filename to write the file, so that anything looking for
+	// "*.gz" only sees the compressed file after it's been finished writing to.
+	tmpDst := w.tmpName(len(w.files))
+
+	// A leftover from an earlier attempt may be read-only (see BackupMode), in
+	// which case it could not be truncated, so clear it out of the way first.
+	_ = removeFile(tmpDst)
+
+	if err := w.chown(tmpDst, w.info); err != nil {
+		w.err = fmt.Errorf("failed to chown compressed log file: %v", err)
+		return w.err
+	}
+
+	// If this file already exists, we presume it was created by
+	// a previous attempt to compress the log file.
+	gzf, err := os.OpenFile(tmpDst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, w.info.Mode())
+	if err != nil {
+		w.err = fmt.Errorf("failed to open compressed log file: %v", err)
+		return w.err
+	}
+	w.files = append(w.files, gzf)
+	w.n = 0
+
+	if w.mode != 0 && w.mode != w.info.Mode() {
+		if err := gzf.Chmod(w.mode); err != nil {
+			w.err = fmt.Errorf("failed to set mode of compressed log file: %v", err)
+			return w.err
+		}
+	}
+	return nil
+}
+
+// commit syncs and closes the files and renames them into place, and returns
+// their names.
+func (w *partWriter) commit() ([]string, error) {
+	if len(w.files) == 0 {
+		// nothing was written, not even a header.
+		if err := w.next(); err != nil {
+			return nil, err
+		}
+	}
+	if w.hash != nil {
+		if w.tmp == "" {
+			w.tmp = w.dst
+		}
+		w.dst = hashedName(w.dst, w.hashBefore, w.hash.Sum(nil))
+	}
+	for _, f := range w.files {
+		// fsync is important, otherwise os.Rename could rename a zero-length file
+		if err := f.Sync(); err != nil {
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parts beyond the last one are left over from an earlier attempt with a
+	// smaller part size, and would otherwise be taken as part of the file.
+	if w.size > 0 {
+		for i := len(w.files); ; i++ {
+			if removeFile(w.name(i)) != nil {
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(w.files))
+	for i := range w.files {
+		names[i] = w.name(i)
+		// Atomically replace the destination file
+		if err := os.Rename(w.tmpName(i), names[i]); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// abort closes and removes the temporary files.
+func (w *partWriter) abort() {
+	for i, f := range w.files {
+		f.Close()
+		os.Remove(w.tmpName(i))
+	}
+}
+
+// formatPart formats the number of a part of a compressed backup.
+func formatPart(i int) string {
+	return fmt.Sprintf("%03d", i)
+}
+
+// splitPart returns the name of the compressed backup that the named file is a
+// part of, and the number of the part, if it is one.  Compressed backups are
+// recognized by any of the given suffixes.
+func splitPart(name string, suffixes []string) (archive string, part int, ok bool) {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 || len(name)-i-1 < 3 || !hasAnySuffix(name[:i], suffixes) {
+		return "", 0, false
+	}
+	for _, c := range name[i+1:] {
+		if c < '0' || c > '9' {
+			return "", 0, false
+		}
+	}
+	n, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], n, true
+}
+
+// tooSmallToCompress reports whether a backup of the given size is left
+// uncompressed for CompressMinSize.
+func (l *Logger) tooSmallToCompress(size int64) bool {
+	return size < l.CompressMinSize
+}
+
+// isCompressed reports whether the named file is a compressed backup, or a
+// part of one, with any of the given suffixes.
+func isCompressed(name string, suffixes []string) bool {
+	_, _, ok := splitPart(name, suffixes)
+	return ok || hasAnySuffix(name, suffixes)
+}
+
+// uncompressedName returns the name of the backup that the named file is a
+// compressed version of, or a part of one, with any of the given suffixes, or
+// name itself.
+func uncompressedName(name string, suffixes []string) string {
+	if archive, _, ok := splitPart(name, suffixes); ok {
+		name = archive
+	}
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return unhashedName(strings.TrimSuffix(name, suffix))
+		}
+	}
+	return name
+}
+
+// hasAnySuffix reports whether name ends with any of the given suffixes.
+func hasAnySuffix(name string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipCopy gzips everything from src into dst.
+func gzipCopy(dst io.Writer, src io.Reader) error {
+	gz, _ := getGzipWriter(dst, gzip.DefaultCompression)
+	defer putGzipWriter(gz, gzip.DefaultCompression)
+	if _, err := copyBuffered(gz, src); err != nil {
+		return err
+	}
+
+	// Close the gzip writer.
+	// Closing also triggers a Flush to the underlying
+	// io.Writer, and doesnot close the underlying io.Writer.
+	// We must Close() or Flush() the gz writer before Sync()ing otherwise we may
+	// see partially written data and a corrupt gzip archive.
+	return gz.Close()
+}
@@ -0,0 +1,230 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCompressFile(t *testing.T) {
+	dir := makeTempDir("TestCompressFile", t)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.log")
+	dst := src + compressSuffix
+	b := []byte("boo!")
+	isNil(ioutil.WriteFile(src, b, 0644), t)
+
+	isNil(CompressFile(src, dst, 0), t)
+
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err := gz.Write(b)
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	existsWithContent(dst, bc.Bytes(), t)
+	notExist(src, t)
+	notExist(dst+tmpSuffix, t)
+	fileCount(dir, 1, t)
+}
+
+func TestCompressFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not fully supported on windows")
+	}
+	dir := makeTempDir("TestCompressFileMode", t)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.log")
+	dst := src + compressSuffix
+	isNil(ioutil.WriteFile(src, []byte("boo!"), 0644), t)
+
+	isNil(CompressFile(src, dst, 0400), t)
+
+	info, err := os.Stat(dst)
+	isNil(err, t)
+	equals(os.FileMode(0400), info.Mode(), t)
+}
+
+func TestCompressFileMissing(t *testing.T) {
+	dir := makeTempDir("TestCompressFileMissing", t)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.log")
+	notNil(CompressFile(src, src+compressSuffix, 0), t)
+	fileCount(dir, 0, t)
+}
+
+func TestCompressPartSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressPartSize", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		Compress:         true,
+		CompressPartSize: 100,
+	}
+	defer l.Close()
+
+	// data that doesn't compress, so that it takes several parts.
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7919 >> 3)
+	}
+	first := backupFile(dir)
+	isNil(ioutil.WriteFile(first, data, 0644), t)
+	isNil(l.millRunOnce(), t)
+
+	notExist(first, t)
+	parts, err := filepath.Glob(first + compressSuffix + ".*")
+	isNil(err, t)
+	assert(len(parts) > 1, t, "expected several parts, got %v", parts)
+	var whole []byte
+	for i, part := range parts {
+		equals(first+compressSuffix+"."+formatPart(i), part, t)
+		b, err := ioutil.ReadFile(part)
+		isNil(err, t)
+		assert(len(b) <= 100, t, "expected at most 100 bytes in %s, got %d", part, len(b))
+		whole = append(whole, b...)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(whole))
+	isNil(err, t)
+	b, err := ioutil.ReadAll(gz)
+	isNil(err, t)
+	equals(data, b, t)
+
+	// the parts are read back as one backup.
+	r, err := l.OpenBackupAt(fakeTime().Add(-time.Hour))
+	isNil(err, t)
+	b, err = ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals(data, b, t)
+
+	// and counted and removed as one.
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(ioutil.WriteFile(second, data, 0644), t)
+	l.MaxBackups = 1
+	isNil(l.millRunOnce(), t)
+	for _, part := range parts {
+		notExist(part, t)
+	}
+	exists(second+compressSuffix+"."+formatPart(0), t)
+	fileCount(dir, len(parts), t)
+}
+
+func TestSplitPart(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		archive string
+		part    int
+		ok      bool
+	}{
+		{"foo.log.gz.000", "foo.log.gz", 0, true},
+		{"foo.log.gz.012", "foo.log.gz", 12, true},
+		{"foo.log.gz.1000", "foo.log.gz", 1000, true},
+		{"foo.log.gz.01", "", 0, false},
+		{"foo.log.gz.+01", "", 0, false},
+		{"foo.log.gz", "", 0, false},
+		{"foo.log.000", "", 0, false},
+	} {
+		archive, part, ok := splitPart(tt.name, []string{compressSuffix})
+		equals(tt.archive, archive, t)
+		equals(tt.part, part, t)
+		equals(tt.ok, ok, t)
+	}
+}
+
+func TestCompressAfter(t *testing.T) {
+	if inlineMill {
+		t.Skip("young backups are compressed on the next rotation in TinyGo builds")
+	}
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+	dir := makeTempDir("TestCompressAfter", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		CompressAfter: 100 * time.Millisecond,
+	}
+	defer l.Close()
+
+	older := filepath.Join(dir, "foobar-"+time.Now().Add(-time.Second).UTC().Format(backupTimeFormat)+".log")
+	newer := filepath.Join(dir, "foobar-"+time.Now().UTC().Format(backupTimeFormat)+".log")
+	isNil(ioutil.WriteFile(older, []byte("boo!"), 0644), t)
+	isNil(ioutil.WriteFile(newer, []byte("foo!"), 0644), t)
+
+	// only the backup that is old enough gets compressed.
+	isNil(l.millRunOnce(), t)
+	notExist(older, t)
+	exists(older+compressSuffix, t)
+	exists(newer, t)
+	notExist(newer+compressSuffix, t)
+
+	// the newer one is compressed once it is too, without a rotation.
+	deadline := time.Now().Add(5 * time.Second)
+	for fileExists(newer) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	isNil(l.Close(), t)
+	notExist(newer, t)
+	existsWithContent(newer+compressSuffix, gzipped([]byte("foo!"), t), t)
+}
+
+func TestCompressMinSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, mode := range []string{"mill", "onwrite", "direct"} {
+		dir := makeTempDir("TestCompressMinSize"+mode, t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename:        logFile(dir),
+			MaxSize:         1000,
+			Compress:        true,
+			CompressOnWrite: mode == "onwrite",
+			CompressDirect:  mode == "direct",
+			CompressMinSize: 10,
+		}
+		defer l.Close()
+
+		// too small to compress.
+		small := []byte("boo!")
+		_, err := l.Write(small)
+		isNil(err, t)
+		waitMill(l)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		smallBackup := backupFile(dir)
+
+		big := []byte("0123456789abcdef")
+		_, err = l.Write(big)
+		isNil(err, t)
+		waitMill(l)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+
+		waitFor(func() bool {
+			_, err := os.Stat(backupFile(dir) + compressSuffix)
+			return err == nil
+		}, t)
+		<-time.After(10 * time.Millisecond)
+		existsWithContent(backupFile(dir)+compressSuffix, gzipped(big, t), t)
+		notExist(backupFile(dir), t)
+		existsWithContent(smallBackup, small, t)
+		notExist(smallBackup+compressSuffix, t)
+		isNil(l.Close(), t)
+	}
+}
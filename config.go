@@ -0,0 +1,634 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Config holds the options of a Logger that can be set from a config file, as
+// read with encoding/json, gopkg.in/yaml.v2 or github.com/BurntSushi/toml, for
+// New to check and turn into a Logger.  Each field is the Logger field of the
+// same name, except that sizes are ByteSizes, such as "100MB", durations are
+// Durations, such as "168h", and modes are Modes, such as "0640", rather than
+// numbers in the units of the Logger field:
+//
+//	filename: /var/log/app/app.log
+//	maxsize: 100MB
+//	maxage: 168h
+//	maxbackups: 10
+//	compress: true
+//
+// The zero value of a field leaves the Logger with its default, just like the
+// Logger field.  Options that take code, such as OnEvent or Compressor, can be
+// set on the Logger New returns before it is first used.
+//
+// Config files written for the original lumberjack v2 Logger, with maxsize in
+// megabytes, maxage in days and localtime, keep working with encoding/json and
+// gopkg.in/yaml.v2, and the Logger reports what they should say instead with
+// EventDeprecated.  See Config.Deprecations.
+type Config struct {
+	Filename  string `json:"filename" yaml:"filename"`
+	Dir       string `json:"dir" yaml:"dir"`
+	BaseName  string `json:"basename" yaml:"basename"`
+	BackupDir string `json:"backupdir" yaml:"backupdir"`
+
+	// MaxSize is set as MaxBytes.  -1 never rotates the log file for its
+	// size, like a negative Logger.MaxSize.  In JSON and YAML, a plain number
+	// is a number of megabytes, as for the original lumberjack v2 Logger, and
+	// deprecated.
+	MaxSize          ByteSize `json:"maxsize" yaml:"maxsize"`
+	OversizeWrites   bool     `json:"oversizewrites" yaml:"oversizewrites"`
+	TrustedWrites    bool     `json:"trustedwrites" yaml:"trustedwrites"`
+	RotationInterval Duration `json:"rotationinterval" yaml:"rotationinterval"`
+	TriggerFile      string   `json:"triggerfile" yaml:"triggerfile"`
+	ResolveOnRotate  bool     `json:"resolveonrotate" yaml:"resolveonrotate"`
+	Symlink          string   `json:"symlink" yaml:"symlink"`
+	TriggerInterval  Duration `json:"triggerinterval" yaml:"triggerinterval"`
+	StateFile        string   `json:"statefile" yaml:"statefile"`
+	Manifest         bool     `json:"manifest" yaml:"manifest"`
+	PromFile         string   `json:"promfile" yaml:"promfile"`
+
+	// MaxAge has to be a whole number of days, such as "168h".  A plain
+	// number is a number of days, and deprecated, like for MaxSize.
+	MaxAge       Duration `json:"maxage" yaml:"maxage"`
+	MaxHistory   Duration `json:"maxhistory" yaml:"maxhistory"`
+	MaxClockSkew Duration `json:"maxclockskew" yaml:"maxclockskew"`
+	MaxBackups   int      `json:"maxbackups" yaml:"maxbackups"`
+
+	// MaxTotalSize has to be a whole number of megabytes.
+	MaxTotalSize ByteSize `json:"maxtotalsize" yaml:"maxtotalsize"`
+	ProjectQuota bool     `json:"projectquota" yaml:"projectquota"`
+
+	// MinDiskFree is set as MinFreeBytes.
+	MinDiskFree     ByteSize `json:"mindiskfree" yaml:"mindiskfree"`
+	MinFreePercent  float64  `json:"minfreepercent" yaml:"minfreepercent"`
+	DenyWhenDiskLow bool     `json:"denywhendisklow" yaml:"denywhendisklow"`
+
+	// LocalTime is the original form of Location: local, still accepted, and
+	// reported by Deprecations.
+	//
+	// Deprecated: Use Location.
+	LocalTime           bool          `json:"localtime" yaml:"localtime"`
+	Location            Location      `json:"location" yaml:"location"`
+	ZoneOffset          bool          `json:"zoneoffset" yaml:"zoneoffset"`
+	BackupTimePrecision Precision     `json:"backuptimeprecision" yaml:"backuptimeprecision"`
+	BackupTimeFormat    string        `json:"backuptimeformat" yaml:"backuptimeformat"`
+	BackupCollision     Collision     `json:"backupcollision" yaml:"backupcollision"`
+	RotationCounter     bool          `json:"rotationcounter" yaml:"rotationcounter"`
+	LegacyBackupFormats []string      `json:"legacybackupformats" yaml:"legacybackupformats"`
+	MigrateBackups      bool          `json:"migratebackups" yaml:"migratebackups"`
+	StrictBackupNames   bool          `json:"strictbackupnames" yaml:"strictbackupnames"`
+	RotationOrder       RotationOrder `json:"rotationorder" yaml:"rotationorder"`
+	Filesystem          Filesystem    `json:"filesystem" yaml:"filesystem"`
+	NamingScheme        NamingScheme  `json:"namingscheme" yaml:"namingscheme"`
+
+	Compress            bool   `json:"compress" yaml:"compress"`
+	RotationMarker      bool   `json:"rotationmarker" yaml:"rotationmarker"`
+	CompressConcurrency int    `json:"compressconcurrency" yaml:"compressconcurrency"`
+	CompressRsyncable   bool   `json:"compressrsyncable" yaml:"compressrsyncable"`
+	VerifyCompression   bool   `json:"verifycompression" yaml:"verifycompression"`
+	ArchiveTag          string `json:"archivetag" yaml:"archivetag"`
+
+	// CompressPartSize and CompressPadSize have to be whole numbers of
+	// megabytes.
+	CompressPartSize ByteSize `json:"compresspartsize" yaml:"compresspartsize"`
+	CompressPadSize  ByteSize `json:"compresspadsize" yaml:"compresspadsize"`
+	ContentHash      bool     `json:"contenthash" yaml:"contenthash"`
+	CompressOnWrite  bool     `json:"compressonwrite" yaml:"compressonwrite"`
+	CompressDirect   bool     `json:"compressdirect" yaml:"compressdirect"`
+	CompressWindow   Window   `json:"compresswindow" yaml:"compresswindow"`
+	CompressAfter    Duration `json:"compressafter" yaml:"compressafter"`
+	CompressMinSize  ByteSize `json:"compressminsize" yaml:"compressminsize"`
+
+	FileMode      Mode              `json:"filemode" yaml:"filemode"`
+	BackupMode    Mode              `json:"backupmode" yaml:"backupmode"`
+	DirMode       Mode              `json:"dirmode" yaml:"dirmode"`
+	Permissions   PermissionProfile `json:"permissions" yaml:"permissions"`
+	BackupAttr    FileAttr          `json:"backupattr" yaml:"backupattr"`
+	BackupXattrs  map[string]string `json:"backupxattrs" yaml:"backupxattrs"`
+	BackupXattrID string            `json:"backupxattrid" yaml:"backupxattrid"`
+
+	Adopt                 bool     `json:"adopt" yaml:"adopt"`
+	ReopenOnMove          bool     `json:"reopenonmove" yaml:"reopenonmove"`
+	RecreateDir           bool     `json:"recreatedir" yaml:"recreatedir"`
+	Preopen               bool     `json:"preopen" yaml:"preopen"`
+	PinDir                bool     `json:"pindir" yaml:"pindir"`
+	FollowSymlinks        bool     `json:"followsymlinks" yaml:"followsymlinks"`
+	RecentSize            ByteSize `json:"recentsize" yaml:"recentsize"`
+	MillRetryInterval     Duration `json:"millretryinterval" yaml:"millretryinterval"`
+	FallbackRetryInterval Duration `json:"fallbackretryinterval" yaml:"fallbackretryinterval"`
+	TeeErrors             bool     `json:"teeerrors" yaml:"teeerrors"`
+	LockFile              bool     `json:"lockfile" yaml:"lockfile"`
+	RotationIntent        bool     `json:"rotationintent" yaml:"rotationintent"`
+	MillLock              bool     `json:"milllock" yaml:"milllock"`
+	RescanInterval        Duration `json:"rescaninterval" yaml:"rescaninterval"`
+
+	OwnerFromDir         bool        `json:"ownerfromdir" yaml:"ownerfromdir"`
+	NoChown              bool        `json:"nochown" yaml:"nochown"`
+	ChownPolicy          ChownPolicy `json:"chownpolicy" yaml:"chownpolicy"`
+	SkipChownInSetgidDir bool        `json:"skipchowninsetgiddir" yaml:"skipchowninsetgiddir"`
+	LowPriority          bool        `json:"lowpriority" yaml:"lowpriority"`
+
+	BufferSize    ByteSize `json:"buffersize" yaml:"buffersize"`
+	BufferSpill   bool     `json:"bufferspill" yaml:"bufferspill"`
+	FlushInterval Duration `json:"flushinterval" yaml:"flushinterval"`
+	StrictOrder   bool     `json:"strictorder" yaml:"strictorder"`
+	KeyQuota      ByteSize `json:"keyquota" yaml:"keyquota"`
+	SingleWriter  bool     `json:"singlewriter" yaml:"singlewriter"`
+
+	Envelope              bool              `json:"envelope" yaml:"envelope"`
+	EnvelopeSource        bool              `json:"envelopesource" yaml:"envelopesource"`
+	InvalidUTF8           UTF8Policy        `json:"invalidutf8" yaml:"invalidutf8"`
+	PartialLines          PartialLinePolicy `json:"partiallines" yaml:"partiallines"`
+	ShortWrites           ShortWritePolicy  `json:"shortwrites" yaml:"shortwrites"`
+	Sequence              SequencePosition  `json:"sequence" yaml:"sequence"`
+	MaxRotations          int               `json:"maxrotations" yaml:"maxrotations"`
+	RotationLimitInterval Duration          `json:"rotationlimitinterval" yaml:"rotationlimitinterval"`
+	SyncInterval          Duration          `json:"syncinterval" yaml:"syncinterval"`
+	SyncWrites            bool              `json:"syncwrites" yaml:"syncwrites"`
+
+	Header       string            `json:"header" yaml:"header"`
+	HeaderFields map[string]string `json:"headerfields" yaml:"headerfields"`
+	Summary      SummaryPlacement  `json:"summary" yaml:"summary"`
+	Labels       map[string]string `json:"labels" yaml:"labels"`
+
+	TerminationFile  string `json:"terminationfile" yaml:"terminationfile"`
+	TerminationLines int    `json:"terminationlines" yaml:"terminationlines"`
+
+	legacy []Deprecation // the options decoded in their lumberjack v2 form
+}
+
+// New returns a Logger configured by cfg, once Validate finds nothing wrong
+// with it, and with Preopen, starts opening its log file.
+func New(cfg Config) (*Logger, error) {
+	l, err := newLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+	l.startPreopen()
+	return l, nil
+}
+
+// newLogger returns a Logger configured by cfg, like New, without opening
+// anything.
+func newLogger(cfg Config) (*Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	l := &Logger{}
+	cfg.applyFixed(l)
+	cfg.apply(l)
+	return l, nil
+}
+
+// ConfigError lists what is wrong with a Config.  See Config.Validate.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "lumberjack: invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that cfg makes sense, returning a *ConfigError listing
+// everything wrong with it, if anything is: an empty Filename, negative sizes,
+// counts and durations, sizes and ages that the Logger can't represent, and
+// options that conflict with or depend on others.
+func (cfg Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(cfg.Filename != "" || cfg.Dir != "", "filename is empty")
+	check(cfg.Filename == "" || cfg.Dir == "", "filename and dir are both set")
+	check(cfg.BackupDir == "" || cfg.Dir == "", "backupdir and dir are both set")
+	check(cfg.BaseName == "" || cfg.Dir != "", "basename is set without dir")
+	check(!strings.ContainsAny(cfg.BaseName, `/\`), "basename %q has a path separator in it", cfg.BaseName)
+
+	check(cfg.MaxSize >= -1, "maxsize is negative")
+	for _, s := range []struct {
+		name string
+		size ByteSize
+	}{
+		{"maxtotalsize", cfg.MaxTotalSize},
+		{"mindiskfree", cfg.MinDiskFree},
+		{"compresspartsize", cfg.CompressPartSize},
+		{"compresspadsize", cfg.CompressPadSize},
+		{"recentsize", cfg.RecentSize},
+		{"buffersize", cfg.BufferSize},
+		{"keyquota", cfg.KeyQuota},
+		{"compressminsize", cfg.CompressMinSize},
+	} {
+		check(s.size >= 0, "%s is negative", s.name)
+	}
+	for _, s := range []struct {
+		name string
+		size ByteSize
+	}{
+		{"maxtotalsize", cfg.MaxTotalSize},
+		{"compresspartsize", cfg.CompressPartSize},
+		{"compresspadsize", cfg.CompressPadSize},
+	} {
+		check(s.size <= 0 || int64(s.size)%int64(megabyte) == 0, "%s %s isn't a whole number of megabytes", s.name, s.size)
+	}
+	for _, d := range []struct {
+		name string
+		d    Duration
+	}{
+		{"rotationinterval", cfg.RotationInterval},
+		{"triggerinterval", cfg.TriggerInterval},
+		{"maxage", cfg.MaxAge},
+		{"maxhistory", cfg.MaxHistory},
+		{"maxclockskew", cfg.MaxClockSkew},
+		{"compressafter", cfg.CompressAfter},
+		{"millretryinterval", cfg.MillRetryInterval},
+		{"fallbackretryinterval", cfg.FallbackRetryInterval},
+		{"flushinterval", cfg.FlushInterval},
+		{"rotationlimitinterval", cfg.RotationLimitInterval},
+		{"syncinterval", cfg.SyncInterval},
+		{"rescaninterval", cfg.RescanInterval},
+	} {
+		check(d.d >= 0, "%s is negative", d.name)
+	}
+	check(cfg.MaxAge%Duration(day) == 0, "maxage %s isn't a whole number of days", cfg.MaxAge)
+	for _, n := range []struct {
+		name string
+		n    int
+	}{
+		{"maxbackups", cfg.MaxBackups},
+		{"compressconcurrency", cfg.CompressConcurrency},
+		{"maxrotations", cfg.MaxRotations},
+		{"terminationlines", cfg.TerminationLines},
+	} {
+		check(n.n >= 0, "%s is negative", n.name)
+	}
+	check(cfg.MinFreePercent >= 0 && cfg.MinFreePercent < 100, "minfreepercent %v isn't between 0 and 100", cfg.MinFreePercent)
+
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"compressonwrite", cfg.CompressOnWrite},
+		{"compressdirect", cfg.CompressDirect},
+		{"contenthash", cfg.ContentHash},
+		{"compresspartsize", cfg.CompressPartSize != 0},
+		{"compresspadsize", cfg.CompressPadSize != 0},
+		{"compresswindow", cfg.CompressWindow != Window{}},
+		{"compressafter", cfg.CompressAfter != 0},
+		{"compressminsize", cfg.CompressMinSize != 0},
+		{"compressconcurrency", cfg.CompressConcurrency > 1},
+		{"compressrsyncable", cfg.CompressRsyncable},
+		{"verifycompression", cfg.VerifyCompression},
+		{"archivetag", cfg.ArchiveTag != ""},
+	} {
+		check(!o.set || cfg.Compress, "%s is set without compress", o.name)
+	}
+	check(!cfg.CompressOnWrite || !cfg.CompressDirect, "compressonwrite and compressdirect are both set")
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"bufferspill", cfg.BufferSpill},
+		{"flushinterval", cfg.FlushInterval != 0},
+		{"strictorder", cfg.StrictOrder},
+		{"keyquota", cfg.KeyQuota != 0},
+	} {
+		check(!o.set || cfg.BufferSize != 0, "%s is set without buffersize", o.name)
+	}
+	check(cfg.KeyQuota == 0 || !cfg.StrictOrder, "keyquota and strictorder are both set")
+	check(!cfg.SingleWriter || cfg.BufferSize == 0, "singlewriter and buffersize are both set")
+	check(!cfg.Preopen || !cfg.SingleWriter, "preopen and singlewriter are both set")
+	check(cfg.SyncInterval == 0 || cfg.BufferSize == 0, "syncinterval and buffersize are both set")
+	check(!cfg.SyncWrites || cfg.BufferSize == 0, "syncwrites and buffersize are both set")
+	check(!cfg.ZoneOffset || cfg.localTime(), "zoneoffset is set without localtime")
+	check(cfg.Location >= 0 && int(cfg.Location) < len(locationNames), "location %d is invalid", int(cfg.Location))
+	check(cfg.BackupTimeFormat == "" || !cfg.ZoneOffset && cfg.BackupTimePrecision == Milliseconds,
+		"backuptimeformat is set along with zoneoffset or backuptimeprecision")
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"backupdir", cfg.BackupDir != ""},
+		{"rotationcounter", cfg.RotationCounter},
+		{"backuptimeformat", cfg.BackupTimeFormat != ""},
+		{"legacybackupformats", len(cfg.LegacyBackupFormats) > 0},
+		{"contenthash", cfg.ContentHash},
+		{"compresspartsize", cfg.CompressPartSize != 0},
+		{"compressonwrite", cfg.CompressOnWrite},
+		{"compressdirect", cfg.CompressDirect},
+	} {
+		check(!o.set || cfg.NamingScheme != Sequential, "%s is set with namingscheme sequential", o.name)
+	}
+	check(!cfg.MigrateBackups || len(cfg.LegacyBackupFormats) > 0, "migratebackups is set without legacybackupformats")
+	check(cfg.TriggerInterval == 0 || cfg.TriggerFile != "", "triggerinterval is set without triggerfile")
+	check(cfg.RotationLimitInterval == 0 || cfg.MaxRotations != 0, "rotationlimitinterval is set without maxrotations")
+	check(cfg.TerminationLines == 0 || cfg.TerminationFile != "", "terminationlines is set without terminationfile")
+	check(!cfg.EnvelopeSource || cfg.Envelope, "envelopesource is set without envelope")
+	check(!cfg.OwnerFromDir || !cfg.NoChown, "ownerfromdir and nochown are both set")
+	check(cfg.FileMode&^Mode(fs.ModePerm) == 0, "filemode %s has more than permission bits", cfg.FileMode)
+	check(cfg.BackupMode&^Mode(fs.ModePerm) == 0, "backupmode %s has more than permission bits", cfg.BackupMode)
+	check(cfg.DirMode&^Mode(fs.ModePerm) == 0, "dirmode %s has more than permission bits", cfg.DirMode)
+	if cfg.Header != "" {
+		_, err := template.New("header").Funcs(headerFuncs).Parse(cfg.Header)
+		check(err == nil, "header: %v", err)
+	}
+
+	if problems != nil {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// applyFixed sets the fields of l that cfg covers and that UpdateConfig can't
+// change.
+func (cfg Config) applyFixed(l *Logger) {
+	l.Filename = cfg.Filename
+	l.BufferSize = int(cfg.BufferSize)
+	l.BufferSpill = cfg.BufferSpill
+	l.FlushInterval = time.Duration(cfg.FlushInterval)
+	l.StrictOrder = cfg.StrictOrder
+	l.KeyQuota = int(cfg.KeyQuota)
+	l.SingleWriter = cfg.SingleWriter
+	l.SyncInterval = time.Duration(cfg.SyncInterval)
+	l.SyncWrites = cfg.SyncWrites
+	l.LockFile = cfg.LockFile
+	l.TriggerFile = cfg.TriggerFile
+	l.TriggerInterval = time.Duration(cfg.TriggerInterval)
+	l.RecentSize = int(cfg.RecentSize)
+}
+
+// apply sets the other fields of l that cfg covers, leaving the rest alone.
+func (cfg Config) apply(l *Logger) {
+	l.Dir = cfg.Dir
+	l.BaseName = cfg.BaseName
+	l.BackupDir = cfg.BackupDir
+	l.MaxSize, l.MaxBytes = 0, 0
+	if cfg.MaxSize < 0 {
+		l.MaxSize = -1
+	} else {
+		l.MaxBytes = int64(cfg.MaxSize)
+	}
+	l.OversizeWrites = cfg.OversizeWrites
+	l.TrustedWrites = cfg.TrustedWrites
+	l.RotationInterval = time.Duration(cfg.RotationInterval)
+	l.ResolveOnRotate = cfg.ResolveOnRotate
+	l.Symlink = cfg.Symlink
+	l.MaxAge = int(cfg.MaxAge / Duration(day))
+	l.MaxHistory = time.Duration(cfg.MaxHistory)
+	l.MaxClockSkew = time.Duration(cfg.MaxClockSkew)
+	l.MaxBackups = cfg.MaxBackups
+	l.MaxTotalSize = int(int64(cfg.MaxTotalSize) / int64(megabyte))
+	l.ProjectQuota = cfg.ProjectQuota
+	l.MinDiskFree = 0
+	l.MinFreeBytes = int64(cfg.MinDiskFree)
+	l.MinFreePercent = cfg.MinFreePercent
+	l.DenyWhenDiskLow = cfg.DenyWhenDiskLow
+	l.LocalTime = cfg.localTime()
+	l.deprecations = cfg.Deprecations()
+	l.ZoneOffset = cfg.ZoneOffset
+	l.BackupTimePrecision = cfg.BackupTimePrecision
+	l.BackupTimeFormat = cfg.BackupTimeFormat
+	l.BackupCollision = cfg.BackupCollision
+	l.RotationCounter = cfg.RotationCounter
+	l.LegacyBackupFormats = cfg.LegacyBackupFormats
+	l.MigrateBackups = cfg.MigrateBackups
+	l.StrictBackupNames = cfg.StrictBackupNames
+	l.RotationOrder = cfg.RotationOrder
+	l.Filesystem = cfg.Filesystem
+	l.NamingScheme = cfg.NamingScheme
+	l.Compress = cfg.Compress
+	l.RotationMarker = cfg.RotationMarker
+	l.StateFile = cfg.StateFile
+	l.Manifest = cfg.Manifest
+	l.PromFile = cfg.PromFile
+	l.CompressConcurrency = cfg.CompressConcurrency
+	l.CompressRsyncable = cfg.CompressRsyncable
+	l.VerifyCompression = cfg.VerifyCompression
+	l.ArchiveTag = cfg.ArchiveTag
+	l.CompressPartSize = int(int64(cfg.CompressPartSize) / int64(megabyte))
+	l.CompressPadSize = int(int64(cfg.CompressPadSize) / int64(megabyte))
+	l.ContentHash = cfg.ContentHash
+	l.CompressOnWrite = cfg.CompressOnWrite
+	l.CompressDirect = cfg.CompressDirect
+	l.CompressWindow = cfg.CompressWindow
+	l.CompressAfter = time.Duration(cfg.CompressAfter)
+	l.CompressMinSize = int64(cfg.CompressMinSize)
+	l.FileMode = fs.FileMode(cfg.FileMode)
+	l.BackupMode = fs.FileMode(cfg.BackupMode)
+	l.DirMode = fs.FileMode(cfg.DirMode)
+	l.Permissions = cfg.Permissions
+	l.BackupAttr = cfg.BackupAttr
+	l.BackupXattrs = cfg.BackupXattrs
+	l.BackupXattrID = cfg.BackupXattrID
+	l.Adopt = cfg.Adopt
+	l.ReopenOnMove = cfg.ReopenOnMove
+	l.RecreateDir = cfg.RecreateDir
+	l.Preopen = cfg.Preopen
+	l.PinDir = cfg.PinDir
+	l.FollowSymlinks = cfg.FollowSymlinks
+	l.MillRetryInterval = time.Duration(cfg.MillRetryInterval)
+	l.FallbackRetryInterval = time.Duration(cfg.FallbackRetryInterval)
+	l.TeeErrors = cfg.TeeErrors
+	l.RotationIntent = cfg.RotationIntent
+	l.MillLock = cfg.MillLock
+	l.RescanInterval = time.Duration(cfg.RescanInterval)
+	l.TerminationFile = cfg.TerminationFile
+	l.TerminationLines = cfg.TerminationLines
+	l.OwnerFromDir = cfg.OwnerFromDir
+	l.NoChown = cfg.NoChown
+	l.ChownPolicy = cfg.ChownPolicy
+	l.SkipChownInSetgidDir = cfg.SkipChownInSetgidDir
+	l.LowPriority = cfg.LowPriority
+	l.Envelope = cfg.Envelope
+	l.EnvelopeSource = cfg.EnvelopeSource
+	l.InvalidUTF8 = cfg.InvalidUTF8
+	l.PartialLines = cfg.PartialLines
+	l.ShortWrites = cfg.ShortWrites
+	l.Sequence = cfg.Sequence
+	l.MaxRotations = cfg.MaxRotations
+	l.RotationLimitInterval = time.Duration(cfg.RotationLimitInterval)
+	l.Header = cfg.Header
+	l.HeaderFields = cfg.HeaderFields
+	l.Labels = cfg.Labels
+	l.Summary = cfg.Summary
+}
+
+// ByteSize is a size in bytes that config files can give with a unit, such as
+// "100MB".  See Config.
+type ByteSize int64
+
+// byteUnits are the units ByteSize accepts, longest first so that they are
+// matched before their suffixes.  Like MaxSize, they count in powers of 1024.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30}, {"tb", 1 << 40},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+	{"b", 1},
+}
+
+// String returns s in the largest unit that gives a whole number, such as
+// "100MB", or in bytes, such as "1500".
+func (s ByteSize) String() string {
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if s != 0 && int64(s)%u.size == 0 {
+			return strconv.FormatInt(int64(s)/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(s), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ByteSize) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting a number of
+// bytes, optionally with a fraction and a unit of B, KB, MB, GB or TB, or KiB
+// and so on, which mean the same, in any case, such as "100MB" or "1.5 GiB".
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	str := strings.ToLower(strings.TrimSpace(string(text)))
+	mult := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str, mult = strings.TrimSpace(str[:len(str)-len(u.suffix)]), u.size
+			break
+		}
+	}
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if n > math.MaxInt64/mult || n < math.MinInt64/mult {
+			return fmt.Errorf("size %q is too large", text)
+		}
+		*s = ByteSize(n * mult)
+		return nil
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size %q, expected a form like 100MB", text)
+	}
+	if f*float64(mult) >= math.MaxInt64 || f*float64(mult) <= math.MinInt64 {
+		return fmt.Errorf("size %q is too large", text)
+	}
+	*s = ByteSize(f * float64(mult))
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a plain number of bytes
+// as well as a string for UnmarshalText.
+func (s *ByteSize) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, s, (*int64)(s))
+}
+
+// Duration is a time.Duration that config files give like "168h", in the
+// format of time.ParseDuration.  See Config.
+type Duration time.Duration
+
+// String returns d in the format of time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting durations in
+// the format of time.ParseDuration, such as "168h" or "1h30m".
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expected a form like 168h", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a plain number of
+// nanoseconds as well as a string for UnmarshalText.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, d, (*int64)(d))
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, of gopkg.in/yaml.v2, accepting a
+// plain number of nanoseconds, like UnmarshalJSON, as well as a string for
+// UnmarshalText.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var n int64
+	if err := unmarshal(&n); err == nil {
+		*d = Duration(n)
+		return nil
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Mode is a file mode that config files give in octal, such as "0640".  See
+// Config.
+type Mode fs.FileMode
+
+// String returns m in octal, such as "0640".
+func (m Mode) String() string {
+	return fmt.Sprintf("%#o", uint32(m))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting modes in
+// octal, with or without a leading 0.
+func (m *Mode) UnmarshalText(text []byte) error {
+	n, err := strconv.ParseUint(strings.TrimSpace(string(text)), 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid mode %q, expected an octal mode like 0640", text)
+	}
+	*m = Mode(n)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a plain number as well
+// as a string for UnmarshalText.  JSON has no octal numbers, so a number is
+// taken as it is.
+func (m *Mode) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := unmarshalJSONText(data, m, &n); err != nil {
+		return err
+	}
+	if len(data) > 0 && data[0] != '"' {
+		*m = Mode(n)
+	}
+	return nil
+}
+
+// unmarshalJSONText decodes data, a JSON string for t or a JSON number for n.
+func unmarshalJSONText(data []byte, t interface{ UnmarshalText([]byte) error }, n *int64) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return t.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal(data, n)
+}
@@ -0,0 +1,183 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigYaml(t *testing.T) {
+	megabyte = 1024 * 1024
+	defer func() { megabyte = 1 }()
+
+	data := []byte(`
+filename: foo.log
+maxsize: 100MB
+maxage: 168h
+maxbackups: 3
+maxtotalsize: 1.5GiB
+mindiskfree: 512k
+compress: true
+compresswindow: 02:00-05:00
+filemode: 0640
+backupattr: appendonly
+buffersize: 4096
+flushinterval: 100ms
+chownpolicy: ignore`[1:])
+
+	var cfg Config
+	isNil(yaml.Unmarshal(data, &cfg), t)
+	l, err := New(cfg)
+	isNil(err, t)
+	equals("foo.log", l.Filename, t)
+	equals(int64(100<<20), l.MaxBytes, t)
+	equals(int64(100<<20), l.max(), t)
+	equals(7, l.MaxAge, t)
+	equals(3, l.MaxBackups, t)
+	equals(1536, l.MaxTotalSize, t)
+	equals(int64(512<<10), l.MinFreeBytes, t)
+	equals(true, l.Compress, t)
+	equals(Window{Start: 2 * time.Hour, End: 5 * time.Hour}, l.CompressWindow, t)
+	equals(fs.FileMode(0640), l.FileMode, t)
+	equals(AttrAppendOnly, l.BackupAttr, t)
+	equals(4096, l.BufferSize, t)
+	equals(100*time.Millisecond, l.FlushInterval, t)
+	equals(ChownIgnore, l.ChownPolicy, t)
+}
+
+func TestConfigJSON(t *testing.T) {
+	data := []byte(`{"filename": "foo.log", "maxsize": "10 KB", "recentsize": 2048, ` +
+		`"maxhistory": "36h", "syncinterval": 1000000, "backupmode": "0440", "filemode": 384}`)
+
+	var cfg Config
+	isNil(json.Unmarshal(data, &cfg), t)
+	equals(ByteSize(10<<10), cfg.MaxSize, t)
+	equals(ByteSize(2048), cfg.RecentSize, t)
+	equals(Duration(36*time.Hour), cfg.MaxHistory, t)
+	equals(Duration(time.Millisecond), cfg.SyncInterval, t)
+	equals(Mode(0440), cfg.BackupMode, t)
+	equals(Mode(0600), cfg.FileMode, t)
+
+	// and it goes back the same way.
+	b, err := json.Marshal(cfg)
+	isNil(err, t)
+	var again Config
+	isNil(json.Unmarshal(b, &again), t)
+	equals(cfg, again, t)
+}
+
+func TestConfigToml(t *testing.T) {
+	data := `
+filename = "foo.log"
+maxsize = "1MB"
+maxage = "48h"
+compress = true`[1:]
+
+	var cfg Config
+	md, err := toml.Decode(data, &cfg)
+	isNil(err, t)
+	equals(0, len(md.Undecoded()), t)
+	equals(ByteSize(1<<20), cfg.MaxSize, t)
+	equals(Duration(48*time.Hour), cfg.MaxAge, t)
+	equals(true, cfg.Compress, t)
+}
+
+func TestConfigValidate(t *testing.T) {
+	megabyte = 1024 * 1024
+	defer func() { megabyte = 1 }()
+
+	for _, tt := range []struct {
+		cfg  Config
+		want []string
+	}{
+		{Config{}, []string{"filename is empty"}},
+		{Config{Filename: "foo.log", MaxSize: -1}, nil},
+		{Config{Filename: "foo.log", MaxSize: -2, MaxBackups: -1, FlushInterval: -1, BufferSize: 10}, []string{
+			"maxsize is negative", "flushinterval is negative", "maxbackups is negative",
+		}},
+		{Config{Filename: "foo.log", MaxAge: Duration(36 * time.Hour), MaxTotalSize: 1000}, []string{
+			"maxtotalsize 1000 isn't a whole number of megabytes", "maxage 36h0m0s isn't a whole number of days",
+		}},
+		{Config{Filename: "foo.log", CompressOnWrite: true, CompressDirect: true}, []string{
+			"compressonwrite is set without compress", "compressdirect is set without compress",
+			"compressonwrite and compressdirect are both set",
+		}},
+		{Config{Filename: "foo.log", SingleWriter: true, BufferSize: 10, ZoneOffset: true}, []string{
+			"singlewriter and buffersize are both set", "zoneoffset is set without localtime",
+		}},
+		{Config{Filename: "foo.log", NamingScheme: Sequential, BackupDir: "old", RotationCounter: true}, []string{
+			"backupdir is set with namingscheme sequential", "rotationcounter is set with namingscheme sequential",
+		}},
+		{Config{Filename: "foo.log", MigrateBackups: true}, []string{
+			"migratebackups is set without legacybackupformats",
+		}},
+		{Config{Dir: "logs", BaseName: "app"}, nil},
+		{Config{Filename: "foo.log", DirMode: 01755}, []string{"dirmode 01755 has more than permission bits"}},
+		{Config{Filename: "foo.log", EnvelopeSource: true}, []string{"envelopesource is set without envelope"}},
+		{Config{Filename: "foo.log", Preopen: true, SingleWriter: true}, []string{"preopen and singlewriter are both set"}},
+		{Config{Filename: "foo.log", BufferSize: 100, StrictOrder: true, KeyQuota: 10}, []string{
+			"keyquota and strictorder are both set",
+		}},
+		{Config{Filename: "foo.log", TerminationLines: -1}, []string{
+			"terminationlines is negative", "terminationlines is set without terminationfile",
+		}},
+		{Config{Filename: "foo.log", Dir: "logs", BaseName: "a/b", BackupDir: "old"}, []string{
+			"filename and dir are both set", "backupdir and dir are both set", `basename "a/b" has a path separator in it`,
+		}},
+		{Config{Filename: "foo.log", StrictOrder: true, MinFreePercent: 100, Header: "{{.Seq"}, []string{
+			"minfreepercent 100 isn't between 0 and 100", "strictorder is set without buffersize",
+		}},
+	} {
+		err := tt.cfg.Validate()
+		if tt.want == nil {
+			isNil(err, t)
+			continue
+		}
+		cerr, ok := err.(*ConfigError)
+		assert(ok, t, "expected a *ConfigError, got %v", err)
+		for _, want := range tt.want {
+			assert(strings.Contains(cerr.Error(), want), t, "expected %q in %v", want, cerr)
+		}
+		n := len(tt.want)
+		if tt.cfg.Header != "" {
+			n++
+		}
+		equals(n, len(cerr.Problems), t)
+	}
+
+	_, err := New(Config{})
+	notNil(err, t)
+}
+
+func TestByteSize(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"10k", 10 << 10},
+		{"100MB", 100 << 20},
+		{"100mib", 100 << 20},
+		{"1.5 GB", 3 << 29},
+		{"2TiB", 2 << 40},
+	} {
+		var s ByteSize
+		isNil(s.UnmarshalText([]byte(tt.text)), t)
+		equals(tt.want, s, t)
+	}
+	for _, text := range []string{"", "MB", "10XB", "1e30TB"} {
+		var s ByteSize
+		notNil(s.UnmarshalText([]byte(text)), t)
+	}
+	equals("100MB", ByteSize(100<<20).String(), t)
+	equals("1536MB", ByteSize(3<<29).String(), t)
+	equals("1500", ByteSize(1500).String(), t)
+	equals("0", ByteSize(0).String(), t)
+}
@@ -0,0 +1,85 @@
+package lumberjack
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// contentHashLen is the number of hex digits of the SHA-256 of a backup that
+// ContentHash puts in its name.
+const contentHashLen = 16
+
+// hashBefore returns what the names of compressed backups end with, in front
+// of which ContentHash puts the hash of their content, or "" if it is unset.
+func (l *Logger) hashBefore() string {
+	if !l.ContentHash {
+		return ""
+	}
+	_, ext := l.prefixAndExt()
+	return ext + l.compressSuffix()
+}
+
+// hashedName returns name, which ends with before, with the start of sum put
+// in front of before, as in foo-<timestamp>-<hash>.log.gz.
+func hashedName(name, before string, sum []byte) string {
+	if !strings.HasSuffix(name, before) {
+		return name
+	}
+	stem := name[:len(name)-len(before)]
+	return stem + "-" + hex.EncodeToString(sum)[:contentHashLen] + before
+}
+
+// splitHash returns stem without the content hash at its end, if it has one.
+func splitHash(stem string) (string, bool) {
+	i := strings.LastIndexByte(stem, '-')
+	if i < 0 || len(stem)-i-1 != contentHashLen {
+		return "", false
+	}
+	for _, c := range stem[i+1:] {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", false
+		}
+	}
+	return stem[:i], true
+}
+
+// unhashedName returns name, an uncompressed name of a compressed backup,
+// without the content hash that ContentHash put in front of its extension, if
+// any.
+func unhashedName(name string) string {
+	ext := filepath.Ext(name)
+	if stem, ok := splitHash(name[:len(name)-len(ext)]); ok {
+		return stem + ext
+	}
+	if stem, ok := splitHash(name); ok {
+		// a log file without an extension.
+		return stem
+	}
+	return name
+}
+
+// hashedBackup returns the compressed, content hashed version of the named
+// backup, or its first part, or "" if there is none.
+func (l *Logger) hashedBackup(name string) string {
+	if !l.ContentHash {
+		return ""
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(name))
+	if err != nil {
+		return ""
+	}
+	base := filepath.Base(name)
+	for _, f := range files {
+		archive := strings.TrimSuffix(f.Name(), "."+formatPart(0))
+		if !strings.HasSuffix(archive, l.compressSuffix()) {
+			continue
+		}
+		stem := strings.TrimSuffix(archive, l.compressSuffix())
+		if stem != base && unhashedName(stem) == base {
+			return filepath.Join(filepath.Dir(name), f.Name())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestContentHash", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     100,
+		MaxBackups:  1,
+		Compress:    true,
+		ContentHash: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+
+	// the compressed backup is named with the hash of what was rotated.
+	hashed := contentHashed(first, b)
+	waitFor(func() bool { return fileExists(hashed) }, t)
+	existsWithContent(hashed, gzipped(b, t), t)
+	notExist(first, t)
+	notExist(first+compressSuffix, t)
+
+	// and is still known for a backup.
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(1, len(backups), t)
+	equals(hashed, backups[0].Path, t)
+	equals(true, backups[0].Compressed, t)
+
+	r, err := l.OpenBackupAt(fakeTime().Add(-time.Hour))
+	isNil(err, t)
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	isNil(err, t)
+	equals(string(b), string(got), t)
+
+	// so it is counted by MaxBackups.
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(err, t)
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	waitFor(func() bool { return !fileExists(hashed) }, t)
+	waitFor(func() bool { return fileExists(contentHashed(second, []byte("foo!\n"))) }, t)
+	isNil(l.Close(), t)
+	fileCount(dir, 2, t)
+}
+
+func TestContentHashOnWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestContentHashOnWrite", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         100,
+		Compress:        true,
+		CompressOnWrite: true,
+		ContentHash:     true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	backup := backupFile(dir)
+	isNil(l.Rotate(), t)
+
+	// the stream is hashed as it is written, too.
+	existsWithContent(contentHashed(backup, b), gzipped(b, t), t)
+	notExist(backup, t)
+}
+
+func TestUnhashedName(t *testing.T) {
+	for name, want := range map[string]string{
+		"foo-2000-01-01T00-00-00.000-0123456789abcdef.log":   "foo-2000-01-01T00-00-00.000.log",
+		"foo-2000-01-01T00-00-00.000.1-0123456789abcdef.log": "foo-2000-01-01T00-00-00.000.1.log",
+		"foo-2000-01-01T00-00-00.000-0123456789abcdef":       "foo-2000-01-01T00-00-00.000",
+		"foo-2000-01-01T00-00-00.000.log":                    "foo-2000-01-01T00-00-00.000.log",
+		"foo-2000-01-01T00-00-00.000-0123456789ABCDEF.log":   "foo-2000-01-01T00-00-00.000-0123456789ABCDEF.log",
+	} {
+		equals(want, unhashedName(name), t)
+	}
+}
+
+// contentHashed returns the name ContentHash gives the gzipped version of the
+// given backup, holding b.
+func contentHashed(backup string, b []byte) string {
+	sum := sha256.Sum256(b)
+	stem := strings.TrimSuffix(backup, ".log")
+	return stem + "-" + hex.EncodeToString(sum[:])[:contentHashLen] + ".log" + compressSuffix
+}
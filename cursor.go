@@ -0,0 +1,271 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cursorHeadSize is how much of the start of a file a Cursor recognizes it by.
+const cursorHeadSize = 256
+
+// Cursor is a position in the log, for a consumer such as a shipper or an
+// indexer to save as it goes, and carry on from with ResumeFrom, even after
+// rotations and restarts.  It is meant to be stored as is, such as in JSON.
+type Cursor struct {
+	// File is the base name of the log file or the backup that the position
+	// is in, uncompressed.
+	File string `json:"file" yaml:"file"`
+
+	// Offset is how many bytes of File come before the position.
+	Offset int64 `json:"offset" yaml:"offset"`
+
+	// Head is a fingerprint of the start of File, up to Offset, by which it
+	// is recognized once it has been rotated, or renumbered with Sequential
+	// naming, and told apart from a newer file of the same name.  It is
+	// empty at the start of a file.
+	Head string `json:"head,omitempty" yaml:"head,omitempty"`
+}
+
+// CursorReader reads the log from a Cursor on, through the backups in order
+// and then the current log file, decompressing them as needed.  Once it has
+// caught up with the current log file, Read returns io.EOF, and later calls
+// return what has been written since, following the log file into the backup
+// it becomes when it is rotated.  It behaves like a file being tailed, and
+// holds no file open between calls that reach the end, so that it doesn't get
+// in the way of rotations on Windows.
+type CursorReader struct {
+	l *Logger
+	c Cursor
+
+	rc      io.ReadCloser // the file being read, if open
+	head    []byte        // the start of the file, up to cursorHeadSize bytes
+	next    string        // the base name of the file after it, if any
+	current bool          // whether it is the current log file
+}
+
+// ResumeFrom returns a CursorReader of the log from c on, or from the start
+// of the oldest backup if c is the zero Cursor.  If the file of c can no
+// longer be found, having been removed by cleanup, it starts from the oldest
+// backup still around.  Use Cursor to find out where the reader got to.  It's
+// the caller's job to close the reader.
+func (l *Logger) ResumeFrom(c Cursor) (*CursorReader, error) {
+	r := &CursorReader{l: l, c: c}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Cursor returns the position after what has been read so far.
+func (r *CursorReader) Cursor() Cursor {
+	return r.c
+}
+
+// Read implements io.Reader.
+func (r *CursorReader) Read(p []byte) (int, error) {
+	reopened := false
+	for {
+		if r.rc == nil {
+			if err := r.open(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.rc.Read(p)
+		if n > 0 {
+			r.advance(p[:n])
+			return n, nil
+		}
+		if err != io.EOF {
+			return 0, err
+		}
+		r.closeFile()
+		if !r.current {
+			r.c = Cursor{File: r.next}
+			continue
+		}
+		if reopened {
+			return 0, io.EOF
+		}
+		// The log file may have been rotated since it was opened, with more
+		// written to it before then.
+		reopened = true
+	}
+}
+
+// Close implements io.Closer.
+func (r *CursorReader) Close() error {
+	if r.rc == nil {
+		return nil
+	}
+	err := r.rc.Close()
+	r.rc = nil
+	return err
+}
+
+// closeFile closes the file being read, which has been read to the end.
+func (r *CursorReader) closeFile() {
+	r.rc.Close()
+	r.rc = nil
+}
+
+// advance moves the cursor past p, just read.
+func (r *CursorReader) advance(p []byte) {
+	if room := cursorHeadSize - len(r.head); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		r.head = append(r.head, p[:room]...)
+	}
+	r.c.Offset += int64(len(p))
+	r.c.Head = fingerprint(r.head, r.c.Offset)
+}
+
+// open finds the file of the cursor and opens it at the cursor, retrying once
+// for a backup compressed or moved since it was found.
+func (r *CursorReader) open() error {
+	var err error
+	for try := 0; try < 2; try++ {
+		var spans []logSpan
+		var i int
+		if spans, i, err = r.l.locateCursor(r.c); err != nil {
+			return err
+		}
+		if i < 0 || r.c.File == "" {
+			// the start of the log, or removed since, so carry on from the
+			// oldest file left.
+			i = 0
+			r.c = Cursor{File: spanBase(r.l, spans[0])}
+		}
+		r.current = i == len(spans)-1
+		r.next = ""
+		if !r.current {
+			r.next = spanBase(r.l, spans[i+1])
+		}
+		if err = r.openAt(spans[i].name); !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// openAt opens the named file, reading its head, and skips to the offset of
+// the cursor.
+func (r *CursorReader) openAt(name string) error {
+	rc, err := openLogReader(name, r.l.codec())
+	if err != nil {
+		if os.IsNotExist(err) && r.current {
+			// not written to yet.
+			return r.emptyFile()
+		}
+		return err
+	}
+	n := headSize(r.c.Offset)
+	head := make([]byte, n)
+	m, err := io.ReadFull(rc, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		rc.Close()
+		return err
+	}
+	r.head = head[:m]
+	skip := r.c.Offset - int64(m)
+	if s, ok := rc.(io.Seeker); ok {
+		_, err = s.Seek(skip, io.SeekCurrent)
+	} else {
+		_, err = io.CopyN(ioutil.Discard, rc, skip)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		rc.Close()
+		return err
+	}
+	r.rc = rc
+	return nil
+}
+
+// emptyFile stands in for the current log file while there is none.
+func (r *CursorReader) emptyFile() error {
+	r.head = nil
+	r.rc = ioutil.NopCloser(eofReader{})
+	return nil
+}
+
+// eofReader is an io.Reader that is always at its end.
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// locateCursor returns the backups and the current log file, oldest first, as
+// logSpans does, and the index of the one c is in, or -1 if none is.  That is
+// the one named by c with the same start, or else any with the same start,
+// the newest first.
+func (l *Logger) locateCursor(c Cursor) ([]logSpan, int, error) {
+	spans, err := l.logSpans()
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.File == "" {
+		return spans, 0, nil
+	}
+	n := headSize(c.Offset)
+	matches := func(s logSpan) bool {
+		return c.Head == "" || fingerprint(readHead(s.name, n, l.codec()), c.Offset) == c.Head
+	}
+	for i, s := range spans {
+		if spanBase(l, s) == c.File && matches(s) {
+			return spans, i, nil
+		}
+	}
+	if c.Head != "" {
+		for i := len(spans) - 1; i >= 0; i-- {
+			if matches(spans[i]) {
+				return spans, i, nil
+			}
+		}
+	}
+	return spans, -1, nil
+}
+
+// spanBase returns the base name of the file of s, uncompressed.
+func spanBase(l *Logger, s logSpan) string {
+	return uncompressedName(filepath.Base(s.name), l.compressSuffixes())
+}
+
+// headSize returns how much of the start of a file a Cursor at offset
+// recognizes it by.
+func headSize(offset int64) int {
+	if offset < cursorHeadSize {
+		return int(offset)
+	}
+	return cursorHeadSize
+}
+
+// fingerprint returns the Cursor.Head of a file starting with head, for a
+// Cursor at offset.
+func fingerprint(head []byte, offset int64) string {
+	n := headSize(offset)
+	if n == 0 || len(head) < n {
+		return ""
+	}
+	sum := sha256.Sum256(head[:n])
+	return hex.EncodeToString(sum[:8])
+}
+
+// readHead returns up to the first n bytes of the named log file, decompressed.
+func readHead(name string, n int, c Compressor) []byte {
+	rc, err := openLogReader(name, c)
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	head := make([]byte, n)
+	m, _ := io.ReadFull(rc, head)
+	return head[:m]
+}
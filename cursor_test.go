@@ -0,0 +1,130 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestResumeFrom(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestResumeFrom", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+		Compress: true,
+	}
+	defer l.Close()
+
+	write := func(s string) {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+	}
+	rotate := func() {
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+	write("one\n")
+	rotate()
+	write("two\n")
+
+	r, err := l.ResumeFrom(Cursor{})
+	isNil(err, t)
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	equals("one\ntwo\n", string(b), t)
+	isNil(r.Close(), t)
+
+	// the cursor survives being saved, and the log file being rotated and
+	// written to in the meantime.
+	saved, err := json.Marshal(r.Cursor())
+	isNil(err, t)
+	write("three\n")
+	rotate()
+	write("four\n")
+	waitFor(func() bool {
+		backups, err := l.Backups()
+		return err == nil && len(backups) == 2 && backups[0].Compressed && backups[1].Compressed
+	}, t)
+
+	var c Cursor
+	isNil(json.Unmarshal(saved, &c), t)
+	equals("foobar.log", c.File, t)
+	r, err = l.ResumeFrom(c)
+	isNil(err, t)
+	defer r.Close()
+	b, err = ioutil.ReadAll(r)
+	isNil(err, t)
+	equals("three\nfour\n", string(b), t)
+
+	// once caught up, the reader follows what is written next.
+	write("five\n")
+	b = make([]byte, 100)
+	n, err := r.Read(b)
+	isNil(err, t)
+	equals("five\n", string(b[:n]), t)
+	_, err = r.Read(b)
+	equals(io.EOF, err, t)
+}
+
+func TestResumeFromSequential(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestResumeFromSequential", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		NamingScheme: Sequential,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+
+	r, err := l.ResumeFrom(Cursor{})
+	isNil(err, t)
+	b := make([]byte, 2)
+	_, err = io.ReadFull(r, b)
+	isNil(err, t)
+	c := r.Cursor()
+	isNil(r.Close(), t)
+	equals("foobar.log.1", c.File, t)
+
+	// the backup is found by its start once renumbered.
+	isNil(l.Rotate(), t)
+	r, err = l.ResumeFrom(c)
+	isNil(err, t)
+	defer r.Close()
+	rest, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	equals("e\ntwo\n", string(rest), t)
+}
+
+func TestResumeFromRemoved(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestResumeFromRemoved", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	_, err := l.Write([]byte("two\n"))
+	isNil(err, t)
+
+	// a cursor in a file that is gone starts over from the oldest one.
+	r, err := l.ResumeFrom(Cursor{File: "foobar-2000-01-01T00-00-00.000.log", Offset: 3, Head: "0011223344556677"})
+	isNil(err, t)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	equals("two\n", string(b), t)
+}
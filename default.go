@@ -0,0 +1,42 @@
+package lumberjack
+
+import (
+	"sync"
+)
+
+// defaultLogger holds the Logger used by the package-level Write.
+var defaultLogger struct {
+	mu sync.Mutex
+	l  *Logger
+}
+
+// Default returns the default Logger, which the package-level Write writes
+// to.  Unless SetDefault was called, it is a Logger with the default
+// configuration, made on first use, which writes to
+// <processname>-lumberjack.log in os.TempDir().
+func Default() *Logger {
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	if defaultLogger.l == nil {
+		defaultLogger.l = &Logger{}
+	}
+	return defaultLogger.l
+}
+
+// SetDefault makes l the default Logger, for small programs and code that runs
+// before the rest of the program is wired up to log through the package-level
+// Write.  A nil l goes back to a Logger with the default configuration.  The
+// previous default Logger is returned, and left open, so that it can be
+// closed once nothing writes to it anymore.
+func SetDefault(l *Logger) (previous *Logger) {
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	previous = defaultLogger.l
+	defaultLogger.l = l
+	return previous
+}
+
+// Write writes p to the default Logger.  See Logger.Write.
+func Write(p []byte) (n int, err error) {
+	return Default().Write(p)
+}
@@ -0,0 +1,36 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestDefault", t)
+	defer os.RemoveAll(dir)
+
+	orig := Default()
+	notNil(orig, t)
+	equals(orig, Default(), t)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+	equals(orig, SetDefault(l), t)
+	defer SetDefault(orig)
+	equals(l, Default(), t)
+
+	b := []byte("boo!")
+	n, err := Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, b, t)
+
+	// nil goes back to the default configuration.
+	equals(l, SetDefault(nil), t)
+	d := Default()
+	assert(d != l && d != nil, t, "expected a new default Logger")
+	equals("", d.Filename, t)
+}
@@ -0,0 +1,157 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// detachedBackup is a rotated log file that has been removed, waiting to be
+// compressed from the descriptor that keeps it alive.  See
+// Logger.CompressDirect.
+type detachedBackup struct {
+	f    *os.File
+	info os.FileInfo
+	name string // the name of the backup, without the compression suffix
+}
+
+// detachBackup removes the log file and hands it to the mill, to be compressed
+// into backup straight from an open descriptor, if CompressDirect is set.  It
+// reports whether it did.  This method assumes l.mu is held.
+func (l *Logger) detachBackup(name, backup string) bool {
+	if !l.Compress || !l.CompressDirect || l.stream != nil {
+		return false
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err == nil && l.tooSmallToCompress(info.Size()) {
+		// renamed, and left uncompressed, as usual.
+		f.Close()
+		return false
+	}
+	if err == nil {
+		// This fails on Windows, where open files can't be removed.
+		err = os.Remove(name)
+	}
+	if err != nil {
+		f.Close()
+		return false
+	}
+
+	l.detachedMu.Lock()
+	defer l.detachedMu.Unlock()
+	l.detached = append(l.detached, detachedBackup{f: f, info: info, name: backup})
+	return true
+}
+
+// hasDetached reports whether there are backups waiting in detachBackup's
+// hands to be compressed.
+func (l *Logger) hasDetached() bool {
+	l.detachedMu.Lock()
+	defer l.detachedMu.Unlock()
+	return len(l.detached) > 0
+}
+
+// compressDetached compresses the backups handed over by detachBackup,
+// returning the first error.  The descriptor of a backup that fails to be
+// compressed is all that is left of it, so it is kept for the next mill run
+// to try again.
+func (l *Logger) compressDetached() error {
+	l.detachedMu.Lock()
+	detached := l.detached
+	l.detached = nil
+	l.detachedMu.Unlock()
+
+	var err error
+	var failed []detachedBackup
+	for _, d := range detached {
+		start := time.Now()
+		partSize := int64(l.CompressPartSize) * int64(megabyte)
+		errCompress := l.makeBackupDir()
+		if errCompress == nil {
+			_, errCompress = d.f.Seek(0, io.SeekStart)
+		}
+		var names []string
+		if errCompress == nil {
+			names, errCompress = compressFrom(d.f, d.info, l.archivePath(d.name)+l.compressSuffix(), partSize, l.hashBefore(), l.BackupMode, l.compressor(), l.verifier(), l.chown)
+		}
+		if errCompress != nil {
+			l.recordError(compressError, errCompress)
+			if err == nil {
+				err = errCompress
+			}
+			failed = append(failed, d)
+			continue
+		}
+		d.f.Close()
+		l.compressionDone(d.info.Size(), time.Since(start), names)
+		if l.BackupAttr == AttrNone {
+			continue
+		}
+		for _, name := range names {
+			if errAttr := setFileAttr(name, l.BackupAttr); errAttr != nil {
+				l.recordError(otherError, errAttr)
+				if err == nil {
+					err = errAttr
+				}
+			}
+		}
+	}
+	if len(failed) > 0 {
+		l.detachedMu.Lock()
+		l.detached = append(failed, l.detached...)
+		l.detachedMu.Unlock()
+	}
+	return err
+}
+
+// restoreDetached writes the backups that are still detached, having failed
+// to be compressed, back to uncompressed backups of their own names, as a
+// Logger that is closing won't try to compress them again.  It returns the
+// first error, for a backup that is then lost.
+func (l *Logger) restoreDetached() error {
+	l.detachedMu.Lock()
+	detached := l.detached
+	l.detached = nil
+	l.detachedMu.Unlock()
+
+	var err error
+	for _, d := range detached {
+		errRestore := l.restoreBackup(d)
+		d.f.Close()
+		if errRestore != nil {
+			l.recordError(otherError, errRestore)
+			if err == nil {
+				err = errRestore
+			}
+		}
+	}
+	return err
+}
+
+// restoreBackup copies the detached backup d to a file of its name.
+func (l *Logger) restoreBackup(d detachedBackup) error {
+	if _, err := d.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(d.name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, d.info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, d.f)
+	if err == nil {
+		err = l.chownOpen(f, d.info)
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(d.name)
+		return fmt.Errorf("can't restore detached backup %s: %v", d.name, err)
+	}
+	return nil
+}
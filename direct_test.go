@@ -0,0 +1,130 @@
+package lumberjack
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompressDirect(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressDirect", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		Compress:       true,
+		CompressDirect: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	waitMill(l)
+
+	// the log file is gone as soon as it is rotated, without a backup taking
+	// its place before it is compressed.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	notExist(backupFile(dir), t)
+	existsWithContent(filename, []byte{}, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir)+compressSuffix, gzipped(b, t), t)
+	notExist(backupFile(dir), t)
+	fileCount(dir, 2, t)
+	equals(int64(1), l.Stats().Compressions, t)
+}
+
+// errNoSpace stands in for ENOSPC, which not every platform has.
+var errNoSpace = errors.New("no space left on device")
+
+// failingCompressor reads everything it is given and then fails, as with a
+// full disk, for as long as fail is set.
+type failingCompressor struct {
+	fail *int32
+}
+
+func (c failingCompressor) Compress(dst io.Writer, src io.Reader) error {
+	if atomic.LoadInt32(c.fail) != 0 {
+		_, _ = io.Copy(ioutil.Discard, src)
+		return errNoSpace
+	}
+	return Gzip{}.Compress(dst, src)
+}
+
+func (failingCompressor) Suffix() string {
+	return compressSuffix
+}
+
+func TestCompressDirectFailed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressDirectFailed", t)
+	defer os.RemoveAll(dir)
+
+	fail := int32(1)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		MaxSize:           100,
+		Compress:          true,
+		CompressDirect:    true,
+		Compressor:        failingCompressor{&fail},
+		MillRetryInterval: time.Hour,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool { return l.Stats().MillFailures == 1 }, t)
+	notExist(backupFile(dir)+compressSuffix, t)
+	notExist(backupFile(dir), t)
+
+	// the backup is kept for the next mill run, which compresses it once
+	// there is room again.
+	atomic.StoreInt32(&fail, 0)
+	l.statsMu.Lock()
+	l.millRetryAt = time.Time{}
+	l.statsMu.Unlock()
+	l.mu.Lock()
+	l.mill()
+	l.mu.Unlock()
+	waitFor(func() bool { return l.Stats().Compressions == 1 }, t)
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir)+compressSuffix, gzipped(b, t), t)
+
+	// and if it still can't be compressed when the Logger is closed, it is
+	// written back uncompressed.
+	dir2 := makeTempDir("TestCompressDirectFailed2", t)
+	defer os.RemoveAll(dir2)
+	atomic.StoreInt32(&fail, 1)
+	l = &Logger{
+		Filename:       logFile(dir2),
+		MaxSize:        100,
+		Compress:       true,
+		CompressDirect: true,
+		Compressor:     failingCompressor{&fail},
+	}
+	defer l.Close()
+	_, err = l.Write(b)
+	isNil(err, t)
+	waitMill(l)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir2), b, t)
+	notExist(backupFile(dir2)+compressSuffix, t)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+)
+
+// freeSpace is not supported on this platform.
+func freeSpace(dir string) (free, total uint64, err error) {
+	return 0, 0, errors.New("free disk space is not supported on this platform")
+}
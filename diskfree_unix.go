@@ -0,0 +1,18 @@
+//go:build linux || darwin || dragonfly || freebsd
+// +build linux darwin dragonfly freebsd
+
+package lumberjack
+
+import (
+	"syscall"
+)
+
+// freeSpace returns the number of bytes available to unprivileged users on the
+// filesystem holding dir, and its size.
+func freeSpace(dir string) (free, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), uint64(st.Blocks) * uint64(st.Bsize), nil
+}
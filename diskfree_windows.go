@@ -0,0 +1,27 @@
+package lumberjack
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = modkernel32.NewProc("GetDiskFreeSpaceExW")
+
+// freeSpace returns the number of bytes available to the current user on the
+// volume holding dir, and its size.
+func freeSpace(dir string) (free, total uint64, err error) {
+	p, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, _, err := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&free)),
+		uintptr(unsafe.Pointer(&total)),
+		0,
+	)
+	if r == 0 {
+		return 0, 0, err
+	}
+	return free, total, nil
+}
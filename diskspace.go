@@ -0,0 +1,126 @@
+package lumberjack
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDiskLow is returned by Write with DenyWhenDiskLow while the free space on
+// the disk holding the log file is below MinDiskFree, MinFreeBytes or
+// MinFreePercent.
+var ErrDiskLow = errors.New("lumberjack: free disk space is below the minimum")
+
+// diskCheckInterval is how often writes check the free disk space.  It's a var
+// so we can change it during tests.
+var diskCheckInterval = time.Second
+
+// diskFreeTTL is how long the free space found on the disk holding a
+// directory stands, for all the Loggers in the process, before it is looked
+// up again, so that busy Loggers sharing a disk don't each add a statfs to
+// their writes and rotations.  It's a var so we can change it during tests.
+var diskFreeTTL = time.Second
+
+// diskFreeCache holds the free space last found on the disks holding the
+// directories looked up, and when.
+var diskFreeCache struct {
+	sync.Mutex
+	m map[string]diskFreeEntry
+}
+
+type diskFreeEntry struct {
+	free, total uint64
+	at          time.Time
+}
+
+// cachedDiskFree returns the free and total bytes of the disk holding dir, as
+// diskFree does, but as found in the last diskFreeTTL if they were.
+func cachedDiskFree(dir string) (free, total uint64, err error) {
+	c := &diskFreeCache
+	now := time.Now()
+	c.Lock()
+	e, ok := c.m[dir]
+	c.Unlock()
+	if ok && now.Sub(e.at) < diskFreeTTL {
+		return e.free, e.total, nil
+	}
+
+	free, total, err = diskFree(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.m == nil {
+		c.m = make(map[string]diskFreeEntry)
+	}
+	for d, e := range c.m {
+		if now.Sub(e.at) >= diskFreeTTL {
+			delete(c.m, d)
+		}
+	}
+	c.m[dir] = diskFreeEntry{free: free, total: total, at: now}
+	return free, total, nil
+}
+
+// forgetDiskFree drops the free space found on the disks holding dirs, once
+// backups there have been removed, so that it isn't taken for what is free
+// now.
+func forgetDiskFree(dirs ...string) {
+	c := &diskFreeCache
+	c.Lock()
+	defer c.Unlock()
+	for _, dir := range dirs {
+		delete(c.m, dir)
+	}
+}
+
+// minFreeSet reports whether any of the free space minimums is set.
+func (l *Logger) minFreeSet() bool {
+	return l.MinDiskFree > 0 || l.MinFreeBytes > 0 || l.MinFreePercent > 0
+}
+
+// minFree returns the free space in bytes to keep on a disk of total bytes:
+// MinFreeBytes, or else MinDiskFree, or MinFreePercent of total if that is
+// more.
+func (l *Logger) minFree(total uint64) int64 {
+	need := int64(l.MinDiskFree) * int64(megabyte)
+	if l.MinFreeBytes > 0 {
+		need = l.MinFreeBytes
+	}
+	if l.MinFreePercent > 0 {
+		if pct := int64(float64(total) * l.MinFreePercent / 100); pct > need {
+			need = pct
+		}
+	}
+	return need
+}
+
+// checkDisk checks, at most every diskCheckInterval, the free space on the disk
+// holding the log file before a write.  When it is below the minimum, it has
+// the oldest backups removed to make room, and returns ErrDiskLow with
+// DenyWhenDiskLow until a later check finds enough free space again.  It
+// assumes that writes are serialized.
+func (l *Logger) checkDisk() error {
+	if !l.minFreeSet() {
+		return nil
+	}
+	now := time.Now()
+	if l.diskCheckedAt.IsZero() || now.Sub(l.diskCheckedAt) >= diskCheckInterval {
+		l.diskCheckedAt = now
+		free, total, err := cachedDiskFree(l.dir())
+		if err != nil {
+			// Nothing to go by; the mill records the error.
+			l.diskLow = false
+			return nil
+		}
+		l.diskLow = int64(free) < l.minFree(total)
+		if l.diskLow {
+			l.mill()
+		}
+	}
+	if l.diskLow && l.DenyWhenDiskLow {
+		return ErrDiskLow
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMinFreePercent(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMinFreePercent", t)
+	defer os.RemoveAll(dir)
+
+	// three backups of 10 bytes, the newest first.
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := filepath.Join(dir, "foobar-"+fakeTime().Add(-time.Duration(i+1)*time.Hour).UTC().Format(backupTimeFormat)+".log")
+		isNil(ioutil.WriteFile(name, []byte("0123456789"), 0644), t)
+		names = append(names, name)
+	}
+
+	// 5% of 1000 bytes is 50, so 15 more are needed, which takes the two
+	// oldest backups.
+	free := uint64(35)
+	diskFree = func(string) (uint64, uint64, error) { return free, 1000, nil }
+	defer func() { diskFree = freeSpace }()
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		MinFreeBytes:   20,
+		MinFreePercent: 5,
+	}
+	defer l.Close()
+	isNil(l.millRunOnce(), t)
+	exists(names[0], t)
+	notExist(names[1], t)
+	notExist(names[2], t)
+
+	// MinFreeBytes applies when it is more.
+	equals(int64(20), l.minFree(100), t)
+}
+
+func TestDenyWhenDiskLow(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestDenyWhenDiskLow", t)
+	defer os.RemoveAll(dir)
+
+	free := uint64(1000)
+	diskFree = func(string) (uint64, uint64, error) { return atomic.LoadUint64(&free), 1 << 20, nil }
+	defer func() { diskFree = freeSpace }()
+	interval, ttl := diskCheckInterval, diskFreeTTL
+	diskCheckInterval, diskFreeTTL = 0, 0
+	defer func() { diskCheckInterval, diskFreeTTL = interval, ttl }()
+
+	backup := filepath.Join(dir, "foobar-"+fakeTime().Add(-time.Hour).UTC().Format(backupTimeFormat)+".log")
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MinFreeBytes:    100,
+		DenyWhenDiskLow: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// the disk filling up has writes refused, and backups removed without
+	// waiting for a rotation.
+	atomic.StoreUint64(&free, 10)
+	n, err := l.Write([]byte("lost"))
+	equals(ErrDiskLow, err, t)
+	equals(0, n, t)
+	waitFor(func() bool {
+		_, err := os.Stat(backup)
+		return os.IsNotExist(err)
+	}, t)
+
+	// and writes go through again once there is room.
+	atomic.StoreUint64(&free, 1000)
+	_, err = l.Write(b)
+	isNil(err, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("boo!boo!"), t)
+}
+
+func TestDiskFreeCache(t *testing.T) {
+	var calls int32
+	diskFree = func(string) (uint64, uint64, error) {
+		atomic.AddInt32(&calls, 1)
+		return 1000, 1 << 20, nil
+	}
+	defer func() { diskFree = freeSpace }()
+
+	// Loggers on the same disk share a look at it within diskFreeTTL.
+	dir := filepath.Join(os.TempDir(), "TestDiskFreeCache")
+	for i := 0; i < 3; i++ {
+		free, total, err := cachedDiskFree(dir)
+		isNil(err, t)
+		equals(uint64(1000), free, t)
+		equals(uint64(1<<20), total, t)
+	}
+	equals(int32(1), atomic.LoadInt32(&calls), t)
+
+	// removing backups there has it looked up afresh.
+	forgetDiskFree(dir)
+	_, _, err := cachedDiskFree(dir)
+	isNil(err, t)
+	equals(int32(2), atomic.LoadInt32(&calls), t)
+
+	ttl := diskFreeTTL
+	diskFreeTTL = 0
+	defer func() { diskFreeTTL = ttl }()
+	_, _, err = cachedDiskFree(dir)
+	isNil(err, t)
+	equals(int32(3), atomic.LoadInt32(&calls), t)
+	forgetDiskFree(dir)
+}
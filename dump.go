@@ -0,0 +1,87 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteDump writes p, such as a goroutine dump or the context of a crash, to a
+// file of its own among the backups, and returns its name.  It is named as a
+// backup of the log file rotated now would be, with a number added as by
+// CollisionSuffix, as in foo-<timestamp>.1.log, so that it neither overwrites
+// a backup nor gets overwritten by a rotation.  The dump is then compressed
+// and removed along with the backups, by the same rules, but it is never
+// written to again.  With Sequential naming, the dump takes the number 1, as the
+// newest backup.  The file is synced before it is given its name, so a dump
+// that is there is complete.
+func (l *Logger) WriteDump(p []byte) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return "", ErrClosed
+	}
+	if err := l.makeBackupDir(); err != nil {
+		return "", err
+	}
+	var name string
+	if l.sequential() {
+		// the dump becomes the newest backup.
+		if err := l.shiftBackups(); err != nil {
+			return "", err
+		}
+		name = l.backupName(l.filename())
+	} else {
+		name = l.numberedName(l.archivePath(l.backupName(l.filename())))
+	}
+
+	mode := os.FileMode(0644)
+	if l.fileModeIsSet() {
+		mode = l.fileMode()
+	}
+	if l.backupModeIsSet() {
+		mode = l.BackupMode
+	}
+	if err := writeDump(name, p, mode); err != nil {
+		l.recordError(writeError, err)
+		return "", err
+	}
+	if l.OwnerFromDir {
+		if err := l.chownToDir(name, l.backupDir()); err != nil {
+			l.recordError(otherError, fmt.Errorf("can't set owner of dump file: %s", err))
+		}
+	}
+	if !l.Compress {
+		l.writeMarker(name, []string{name})
+	}
+	l.mill()
+	return name, nil
+}
+
+// writeDump writes p to a temporary file next to name, syncs it and renames it
+// to name.
+func writeDump(name string, p []byte, mode os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+tmpSuffix)
+	if err != nil {
+		return fmt.Errorf("can't create dump file: %s", err)
+	}
+	_, err = f.Write(p)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("can't write dump file: %s", err)
+	}
+	return nil
+}
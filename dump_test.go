@@ -0,0 +1,42 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteDump(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWriteDump", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 100, MaxBackups: 1}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// a dump is named like a backup, and doesn't touch the log file.
+	newFakeTime()
+	name, err := l.WriteDump([]byte("goroutine 1 [running]:\n"))
+	isNil(err, t)
+	stamp := fakeTime().UTC().Format(backupTimeFormat)
+	equals(filepath.Join(dir, "foobar-"+stamp+".1.log"), name, t)
+	existsWithContent(name, []byte("goroutine 1 [running]:\n"), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// nor does a rotation at the same time overwrite it, even with the
+	// default BackupCollision.  The dump counts as the newer backup, so the
+	// rotated one goes by MaxBackups.
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	notExist(backupFile(dir), t)
+	existsWithContent(name, []byte("goroutine 1 [running]:\n"), t)
+	fileCount(dir, 2, t)
+
+	_, err = l.WriteDump([]byte("too late"))
+	equals(ErrClosed, err, t)
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// dupFile reports that duplicating the log file isn't supported on this
+// platform.
+func dupFile(f *os.File) (*os.File, error) {
+	return nil, &os.PathError{Op: "dup", Path: f.Name(), Err: errors.New("not supported on this platform")}
+}
@@ -0,0 +1,21 @@
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupFile duplicates the handle of f, so that the result shares the same
+// file object (and therefore the same offset) as f.
+func dupFile(f *os.File) (*os.File, error) {
+	p, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return nil, &os.PathError{Op: "dup", Path: f.Name(), Err: err}
+	}
+	var h syscall.Handle
+	err = syscall.DuplicateHandle(p, syscall.Handle(f.Fd()), p, &h, 0, false, syscall.DUPLICATE_SAME_ACCESS)
+	if err != nil {
+		return nil, &os.PathError{Op: "dup", Path: f.Name(), Err: err}
+	}
+	return os.NewFile(uintptr(h), f.Name()), nil
+}
@@ -0,0 +1,294 @@
+package lumberjack
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypter encrypts backups for a Logger as they are compressed, for keeping
+// archived logs encrypted at rest.  See Logger.Encrypter.
+type Encrypter interface {
+	// Encrypt returns a writer that writes the encrypted form of what is
+	// written to it to dst, and finishes it when closed, without closing dst.
+	Encrypt(dst io.Writer) (io.WriteCloser, error)
+
+	// Suffix is the extension added to encrypted backups, after that of the
+	// compression, such as ".enc".
+	Suffix() string
+}
+
+// Decrypter is implemented by Encrypters that can read back what they
+// encrypted, which Grep and OpenBackupAt need for encrypted backups.
+type Decrypter interface {
+	// Decrypt returns a reader of the decrypted form of src, which fails if
+	// src was tampered with or cut short.
+	Decrypt(src io.Reader) (io.ReadCloser, error)
+}
+
+// AESGCM is an Encrypter for AES-GCM with the given Key, of 16, 24 or 32 bytes
+// for AES-128, AES-192 or AES-256.  Backups are encrypted in chunks of 64 KiB,
+// each sealed with its own nonce, so that they can be encrypted and decrypted
+// without holding them in memory, and decrypting fails if chunks are altered,
+// reordered, or cut off at the end.
+type AESGCM struct {
+	Key []byte
+}
+
+const (
+	aesChunkSize   = 64 << 10
+	aesPrefixSize  = 7 // random start of the nonces of a backup
+	aesGCMOverhead = 16
+)
+
+// Encrypt implements Encrypter.
+func (a AESGCM) Encrypt(dst io.Writer) (io.WriteCloser, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	w := &aesWriter{dst: dst, aead: aead, buf: make([]byte, 0, aesChunkSize)}
+	if _, err := io.ReadFull(rand.Reader, w.prefix[:]); err != nil {
+		return nil, fmt.Errorf("can't make nonce: %v", err)
+	}
+	if _, err := dst.Write(w.prefix[:]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Suffix implements Encrypter.
+func (AESGCM) Suffix() string {
+	return ".enc"
+}
+
+// Decrypt implements Decrypter.
+func (a AESGCM) Decrypt(src io.Reader) (io.ReadCloser, error) {
+	aead, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	r := &aesReader{src: bufio.NewReader(src), aead: aead, ct: make([]byte, aesChunkSize+aesGCMOverhead)}
+	if _, err := io.ReadFull(r.src, r.prefix[:]); err != nil {
+		return nil, errTruncated
+	}
+	return r, nil
+}
+
+// aead returns the AES-GCM cipher for a's key.
+func (a AESGCM) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(a.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// errTruncated is returned when reading an encrypted backup that ends before
+// its last chunk.
+var errTruncated = errors.New("lumberjack: encrypted backup is truncated")
+
+// aesNonce returns the nonce of the chunk with the given number.  The last
+// chunk is told apart from the others, so that dropping chunks at the end
+// can't go unnoticed.
+func aesNonce(prefix [aesPrefixSize]byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix[:])
+	binary.BigEndian.PutUint32(nonce[aesPrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// aesWriter encrypts what is written to it in chunks.  It holds back a full
+// chunk until more is written, or it is closed, to know which one is last.
+type aesWriter struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	prefix  [aesPrefixSize]byte
+	counter uint32
+	buf     []byte
+	out     []byte
+	closed  bool
+}
+
+// Write implements io.Writer.
+func (w *aesWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("lumberjack: write to closed encrypter")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == aesChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):aesChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close seals the last chunk.
+func (w *aesWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.seal(true)
+}
+
+// seal encrypts and writes out the buffered chunk.
+func (w *aesWriter) seal(last bool) error {
+	w.out = w.aead.Seal(w.out[:0], aesNonce(w.prefix, w.counter, last), w.buf, nil)
+	w.buf = w.buf[:0]
+	w.counter++
+	if w.counter == 0 {
+		return errors.New("lumberjack: too much data to encrypt")
+	}
+	_, err := w.dst.Write(w.out)
+	return err
+}
+
+// aesReader decrypts what aesWriter wrote.
+type aesReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  [aesPrefixSize]byte
+	counter uint32
+	ct      []byte
+	buf     []byte // decrypted, not yet read
+	done    bool   // the last chunk was decrypted
+	err     error
+}
+
+// Read implements io.Reader.
+func (r *aesReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.open()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk.
+func (r *aesReader) open() error {
+	n, err := io.ReadFull(r.src, r.ct)
+	switch err {
+	case nil:
+		if _, errPeek := r.src.Peek(1); errPeek == io.EOF {
+			r.done = true
+		}
+	case io.ErrUnexpectedEOF:
+		r.done = true
+	case io.EOF:
+		return errTruncated
+	default:
+		return err
+	}
+	buf, err := r.aead.Open(r.ct[:0], aesNonce(r.prefix, r.counter, r.done), r.ct[:n], nil)
+	if err != nil {
+		return fmt.Errorf("lumberjack: can't decrypt backup: %v", err)
+	}
+	r.counter++
+	r.buf = buf
+	return nil
+}
+
+// Close implements io.Closer.
+func (r *aesReader) Close() error {
+	return nil
+}
+
+// encryptedCodec is the Compressor of a Logger with an Encrypter, which
+// compresses as the Logger otherwise would and encrypts the result.
+type encryptedCodec struct {
+	compress func(dst io.Writer, src io.Reader) error
+	suffix   string       // of the compression alone
+	d        Decompressor // of the compression alone, if any
+	e        Encrypter
+}
+
+// Compress implements Compressor.
+func (c encryptedCodec) Compress(dst io.Writer, src io.Reader) error {
+	w, err := c.e.Encrypt(dst)
+	if err != nil {
+		return err
+	}
+	if err := c.compress(w, src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Suffix implements Compressor.
+func (c encryptedCodec) Suffix() string {
+	return c.suffix + c.e.Suffix()
+}
+
+// Decompress implements Decompressor.
+func (c encryptedCodec) Decompress(src io.Reader) (io.ReadCloser, error) {
+	d, ok := c.e.(Decrypter)
+	if !ok {
+		return nil, errors.New("can't decrypt backup: the Encrypter has no Decrypt method")
+	}
+	if c.d == nil {
+		return nil, errors.New("can't decompress backup: the Compressor has no Decompress method")
+	}
+	plain, err := d.Decrypt(src)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.d.Decompress(plain)
+	if err != nil {
+		plain.Close()
+		return nil, err
+	}
+	return &decryptedFile{ReadCloser: r, plain: plain}, nil
+}
+
+// decryptedFile is a decompressing reader of decrypted data, which closes
+// the decrypting reader as well when closed.
+type decryptedFile struct {
+	io.ReadCloser
+	plain io.ReadCloser
+}
+
+func (f *decryptedFile) Close() error {
+	err := f.ReadCloser.Close()
+	if errClose := f.plain.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// codec returns the Compressor that backups are compressed with, which
+// encrypts them too if the Logger has an Encrypter, or nil for the gzip
+// compression of a Logger with neither.
+func (l *Logger) codec() Compressor {
+	if l.Encrypter == nil {
+		return l.Compressor
+	}
+	c := encryptedCodec{compress: l.plainCompressor(), suffix: compressSuffix, d: Gzip{}, e: l.Encrypter}
+	if l.Compressor != nil {
+		c.suffix = l.Compressor.Suffix()
+		c.d, _ = l.Compressor.(Decompressor)
+	}
+	return c
+}
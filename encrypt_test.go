@@ -0,0 +1,129 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestAESGCM(t *testing.T) {
+	a := AESGCM{Key: testKey}
+	for _, size := range []int{0, 1, aesChunkSize - 1, aesChunkSize, aesChunkSize + 1, 3*aesChunkSize + 5} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 7919 >> 3)
+		}
+		var enc bytes.Buffer
+		w, err := a.Encrypt(&enc)
+		isNil(err, t)
+		_, err = w.Write(data)
+		isNil(err, t)
+		isNil(w.Close(), t)
+		assert(!bytes.Contains(enc.Bytes(), data[:size/2]) || size < 2, t, "expected %d bytes to be encrypted", size)
+
+		r, err := a.Decrypt(bytes.NewReader(enc.Bytes()))
+		isNil(err, t)
+		got, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		assert(bytes.Equal(data, got), t, "expected %d bytes back, got %d", size, len(got))
+
+		// cutting off chunks at the end, or altering one, is noticed.
+		last := (enc.Len() - aesPrefixSize) % (aesChunkSize + aesGCMOverhead)
+		if last == 0 {
+			last = aesChunkSize + aesGCMOverhead
+		}
+		for _, bad := range [][]byte{
+			enc.Bytes()[:enc.Len()-1],
+			enc.Bytes()[:enc.Len()-last],
+			append(append([]byte{}, enc.Bytes()[:aesPrefixSize]...), enc.Bytes()[aesPrefixSize+1:]...),
+		} {
+			r, err := a.Decrypt(bytes.NewReader(bad))
+			if err == nil {
+				_, err = ioutil.ReadAll(r)
+			}
+			notNil(err, t)
+		}
+	}
+
+	// the wrong key can't decrypt.
+	var enc bytes.Buffer
+	w, err := a.Encrypt(&enc)
+	isNil(err, t)
+	_, err = io.WriteString(w, "boo!")
+	isNil(err, t)
+	isNil(w.Close(), t)
+	r, err := AESGCM{Key: bytes.Repeat([]byte{8}, 32)}.Decrypt(&enc)
+	isNil(err, t)
+	_, err = ioutil.ReadAll(r)
+	notNil(err, t)
+
+	_, err = AESGCM{Key: []byte("short")}.Encrypt(&enc)
+	notNil(err, t)
+}
+
+func TestEncrypter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestEncrypter", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:  filename,
+		MaxSize:   100,
+		Compress:  true,
+		Encrypter: AESGCM{Key: testKey},
+	}
+	defer l.Close()
+
+	// a backup compressed before encryption was turned on is still known.
+	old := backupFile(dir) + compressSuffix
+	isNil(ioutil.WriteFile(old, gzipped([]byte("old\n"), t), 0644), t)
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	backup := backupFile(dir)
+	isNil(l.Rotate(), t)
+
+	encrypted := backup + compressSuffix + ".enc"
+	waitFor(func() bool { return fileExists(encrypted) }, t)
+	notExist(backup, t)
+	notExist(backup+compressSuffix, t)
+	data, err := ioutil.ReadFile(encrypted)
+	isNil(err, t)
+	assert(!bytes.Equal(data, gzipped(b, t)), t, "expected the backup to be encrypted")
+
+	r, err := l.OpenBackupAt(fakeTime().Add(-time.Hour))
+	isNil(err, t)
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	isNil(err, t)
+	equals(string(b), string(got), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+	equals(encrypted, backups[0].Path, t)
+	equals(old, backups[1].Path, t)
+
+	// both are removed in turn by cleanup.
+	l.MaxBackups = 1
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool { return !fileExists(encrypted) }, t)
+	isNil(l.Close(), t)
+	notExist(old, t)
+	files, err := filepath.Glob(filepath.Join(dir, "*.enc"))
+	isNil(err, t)
+	equals(1, len(files), t)
+}
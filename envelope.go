@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envelope is the JSON object each write is wrapped in with Envelope, as wrap
+// writes it.
+type envelope struct {
+	Time    string          `json:"time"`
+	Host    string          `json:"host,omitempty"`
+	Seq     int64           `json:"seq"`
+	Source  string          `json:"source,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// wrap returns p wrapped in the JSON envelope of the next write, with the given
+// source, if any, if Envelope is set, or p itself otherwise.  The write only takes up its sequence number
+// if it isn't too long for the log file, so that the numbers have gaps only
+// where writes failed.  This method assumes that writes are serialized, by
+// l.mu or by the write buffer's lock.
+func (l *Logger) wrap(p []byte, source string) ([]byte, error) {
+	if !l.Envelope {
+		return p, nil
+	}
+	if l.hostname == "" {
+		l.hostname, _ = os.Hostname()
+	}
+	t := currentTime()
+	if !l.LocalTime {
+		t = t.UTC()
+	}
+
+	// Built by hand, in the order of the fields of envelope, rather than
+	// with json.Marshal, which costs several allocations a write.
+	payload := bytes.TrimRight(p, "\r\n")
+	rec := make([]byte, 0, len(payload)+len(l.hostname)+len(source)+96)
+	rec = append(rec, `{"time":"`...)
+	rec = t.AppendFormat(rec, time.RFC3339Nano)
+	rec = append(rec, '"')
+	if l.hostname != "" {
+		rec = append(rec, `,"host":`...)
+		rec = AppendJSONString(rec, l.hostname)
+	}
+	rec = append(rec, `,"seq":`...)
+	rec = strconv.AppendInt(rec, l.recordSeq+1, 10)
+	if source != "" {
+		rec = append(rec, `,"source":`...)
+		rec = AppendJSONString(rec, source)
+	}
+	rec = append(rec, `,"payload":`...)
+	if json.Valid(payload) {
+		rec = appendCompactJSON(rec, payload)
+	} else {
+		rec = appendJSONBytes(rec, payload)
+	}
+	rec = append(rec, '}', '\n')
+	if l.tooLong(int64(len(rec))) {
+		return nil, fmt.Errorf(
+			"write length %d in its envelope exceeds maximum file size %d", len(rec), l.max(),
+		)
+	}
+	l.recordSeq++
+	return rec, nil
+}
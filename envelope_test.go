@@ -0,0 +1,77 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvelope(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestEnvelope", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 400, Envelope: true}
+	defer l.Close()
+
+	for _, s := range []string{"GET / 200\n", `{"level":"info"}` + "\n"} {
+		n, err := l.Write([]byte(s))
+		isNil(err, t)
+		equals(len(s), n, t)
+	}
+
+	// too long once wrapped, which doesn't use up a sequence number.
+	_, err := l.Write([]byte(strings.Repeat("x", 390)))
+	notNil(err, t)
+	_, err = l.Write([]byte("last"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	b, err := ioutil.ReadFile(filename)
+	isNil(err, t)
+	lines := bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+	equals(3, len(lines), t)
+	host, _ := os.Hostname()
+	for i, want := range []string{`"GET / 200"`, `{"level":"info"}`, `"last"`} {
+		var e envelope
+		isNil(json.Unmarshal(lines[i], &e), t)
+		equals(fakeTime().UTC().Format(time.RFC3339Nano), e.Time, t)
+		equals(host, e.Host, t)
+		equals(int64(i+1), e.Seq, t)
+		equals(want, string(e.Payload), t)
+	}
+}
+
+func TestBufferedEnvelope(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferedEnvelope", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 10000, BufferSize: 100, Envelope: true}
+	defer l.Close()
+
+	for i := 0; i < 20; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		isNil(err, t)
+	}
+	isNil(l.Close(), t)
+
+	// the sequence numbers follow the order of the file.
+	b, err := ioutil.ReadFile(filename)
+	isNil(err, t)
+	lines := bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+	equals(20, len(lines), t)
+	for i, line := range lines {
+		var e envelope
+		isNil(json.Unmarshal(line, &e), t)
+		equals(int64(i+1), e.Seq, t)
+	}
+}
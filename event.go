@@ -0,0 +1,166 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+	// EventRotationLimited means that a rotation was skipped because
+	// MaxRotations rotations already happened within RotationLimitInterval.
+	// It is sent once when the limit is hit, not for every skipped rotation.
+	EventRotationLimited EventType = iota + 1
+
+	// EventClockStep means that the wall clock was found more than
+	// MaxClockSkew away from the monotonic clock while cleaning up by MaxAge.
+	// It is sent once per step, whichever way the clock moved.
+	EventClockStep
+
+	// EventChownFailed means that changing the owner of the log file in Path
+	// wasn't permitted, and ChownPolicy is ChownEvent.
+	EventChownFailed
+
+	// EventCompressed means that a backup was compressed into Path, as
+	// described by Compression.  With CompressPartSize, Path is the first
+	// part.
+	EventCompressed
+
+	// EventFallbackStarted means that the filesystem of the log file in Path
+	// turned out to be read-only, with the error in Err, so writes go to the
+	// Fallback until the log file can be written to again.
+	EventFallbackStarted
+
+	// EventFallbackEnded means that the log file in Path could be written to
+	// again, so writes no longer go to the Fallback.
+	EventFallbackEnded
+
+	// EventMillDegraded means that compression or removal of old log files
+	// failed several times in a row, with Err the last error.  See
+	// Logger.MillRetryInterval.
+	EventMillDegraded
+
+	// EventMillRecovered means that compression and removal of old log files
+	// succeeded again after EventMillDegraded.
+	EventMillRecovered
+
+	// EventRemoved means that the old log file in Path was removed by the
+	// retention rule given by Reason.
+	EventRemoved
+
+	// EventRotated means that the log file was rotated, and moved to the
+	// backup in Path.
+	EventRotated
+
+	// EventReopened means that, with ReopenOnMove, the log file was found
+	// moved, removed or truncated by something else, and the Logger opened
+	// Path afresh.
+	EventReopened
+
+	// EventSwitched means that the placeholders in Filename came to expand
+	// to another name, Path, so the Logger closed the log file for a new one
+	// of that name.
+	EventSwitched
+
+	// EventDirRecreated means that, with RecreateDir, the directory of the
+	// log file, Path, was found removed, so the Logger created it again.
+	EventDirRecreated
+
+	// EventDeprecated means that the Config the Logger was made from, or
+	// last updated with, gives an option in a deprecated form, as described
+	// by Deprecation.  It is sent once for each, when the log file is first
+	// opened, or by UpdateConfig.
+	EventDeprecated
+)
+
+// String returns a short lowercase description of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventRotationLimited:
+		return "rotation limited"
+	case EventClockStep:
+		return "clock step"
+	case EventChownFailed:
+		return "chown failed"
+	case EventCompressed:
+		return "compressed"
+	case EventFallbackStarted:
+		return "fallback started"
+	case EventFallbackEnded:
+		return "fallback ended"
+	case EventMillDegraded:
+		return "mill degraded"
+	case EventMillRecovered:
+		return "mill recovered"
+	case EventRemoved:
+		return "removed"
+	case EventRotated:
+		return "rotated"
+	case EventReopened:
+		return "reopened"
+	case EventSwitched:
+		return "switched"
+	case EventDirRecreated:
+		return "dir recreated"
+	case EventDeprecated:
+		return "deprecated"
+	}
+	return "unknown"
+}
+
+// Event describes something notable that happened inside a Logger.  See
+// Logger.OnEvent.
+type Event struct {
+	// Type is the kind of event.
+	Type EventType
+
+	// Time is when the event happened, and Monotonic the reading of the
+	// monotonic clock then, the time since the process started, which,
+	// unlike Time, keeps going forward evenly when the wall clock is set,
+	// so that events can be put in their true order afterwards.
+	Time      time.Time
+	Monotonic time.Duration
+
+	// Path is the file the event concerns, if any.
+	Path string
+
+	// Err is the error that caused the event, if any.
+	Err error
+
+	// Compression describes the compression, for EventCompressed.
+	Compression Compression
+
+	// Reason is the retention rule that removed the file, for EventRemoved.
+	Reason RemoveReason
+
+	// Deprecation describes the deprecated option, for EventDeprecated.
+	Deprecation Deprecation
+
+	// Labels are the Labels of the Logger, which must not be changed.
+	Labels map[string]string
+}
+
+// emit sends e to the OnEvent callback, and to the lifecycle callback for its
+// type, if they are set.
+func (l *Logger) emit(e Event) {
+	switch {
+	case e.Type == EventRotated && l.OnRotate != nil:
+		l.OnRotate(l.filename(), e.Path)
+	case e.Type == EventCompressed && l.OnCompress != nil:
+		l.OnCompress(e.Path)
+	case e.Type == EventRemoved && l.OnRemove != nil:
+		l.OnRemove(e.Path)
+	}
+	if l.OnEvent == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = currentTime()
+	}
+	if e.Monotonic == 0 {
+		e.Monotonic = monotonicNow()
+	}
+	e.Labels = l.Labels
+	l.OnEvent(e)
+}
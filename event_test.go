@@ -0,0 +1,148 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaxRotations(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMaxRotations", t)
+	defer os.RemoveAll(dir)
+
+	var events []Event
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:              filename,
+		MaxSize:               10,
+		MaxRotations:          1,
+		RotationLimitInterval: time.Hour,
+		OnEvent:               func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+
+	// the first rotation is fine.
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	backup := backupFile(dir)
+	existsWithContent(backup, b, t)
+	fileCount(dir, 2, t)
+
+	// the second one within the hour is skipped, and the file grows past
+	// MaxSize instead.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Minute)
+	b3 := []byte("baaaaar!")
+	_, err = l.Write(b3)
+	isNil(err, t)
+	_, err = l.Write(b3)
+	isNil(err, t)
+	existsWithContent(filename, append(append(b2, b3...), b3...), t)
+	fileCount(dir, 2, t)
+
+	equals(2, len(events), t)
+	equals(EventRotated, events[0].Type, t)
+	equals(backup, events[0].Path, t)
+	equals(EventRotationLimited, events[1].Type, t)
+	equals(filename, events[1].Path, t)
+	equals(fakeTime(), events[1].Time, t)
+
+	// once the hour has passed, rotations happen again.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Hour)
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+	fileCount(dir, 3, t)
+	equals(3, len(events), t)
+	equals(EventRotated, events[2].Type, t)
+}
+
+func TestMaxRotationsExplicitRotate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMaxRotationsExplicitRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      10,
+		MaxRotations: 1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// explicit rotations are never skipped.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	fakeCurrentTime = fakeCurrentTime.Add(time.Second)
+	isNil(l.Rotate(), t)
+	fileCount(dir, 3, t)
+}
+
+func TestEventTypeString(t *testing.T) {
+	equals("rotation limited", EventRotationLimited.String(), t)
+	equals("mill degraded", EventMillDegraded.String(), t)
+	equals("unknown", EventType(0).String(), t)
+}
+
+func TestLifecycleCallbacks(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestLifecycleCallbacks", t)
+	defer os.RemoveAll(dir)
+
+	var calls []string
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    100,
+		MaxBackups: 1,
+		Compress:   true,
+		OnRotate: func(oldPath, newPath string) {
+			calls = append(calls, "rotate "+oldPath+" "+newPath)
+		},
+		OnCompress: func(path string) { calls = append(calls, "compress "+path) },
+		OnRemove:   func(path string) { calls = append(calls, "remove "+path) },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	// a second Logger, without compression, removes the first backup.
+	l = &Logger{
+		Filename:   filename,
+		MaxSize:    100,
+		MaxBackups: 1,
+		OnRotate:   l.OnRotate,
+		OnCompress: l.OnCompress,
+		OnRemove:   l.OnRemove,
+	}
+	defer l.Close()
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	equals([]string{
+		"rotate " + filename + " " + first,
+		"compress " + first + compressSuffix,
+		"rotate " + filename + " " + second,
+		"remove " + first + compressSuffix,
+	}, calls, t)
+}
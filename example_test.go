@@ -15,3 +15,19 @@ func Example() {
 		Compress:   true, // disabled by default
 	})
 }
+
+// To split the output of a leveled logger into a file per level, write it to
+// a LevelSplitter, and configure the files with a MultiLogger.
+func ExampleLevelSplitter() {
+	log.SetOutput(&LevelSplitter{
+		Loggers: &MultiLogger{
+			NewLogger: func(level string) *Logger {
+				return &Logger{
+					Filename:   "/var/log/myapp/" + level + ".log",
+					MaxSize:    500, // megabytes
+					MaxBackups: 3,
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package lumberjack
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"strings"
+)
+
+// expandPath expands a leading ~ or ~user in path to the corresponding home
+// directory, and environment variables written as $VAR or ${VAR}, or %VAR% on
+// Windows.  Config files routinely contain these, and they would otherwise be
+// taken literally.  Variables that aren't set are left as they are, as is a
+// ~user for an unknown user.
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~") {
+		rest := path[1:]
+		name := rest
+		if i := strings.IndexAny(rest, `/\`); i >= 0 {
+			name = rest[:i]
+		}
+		if home, ok := homeDir(name); ok {
+			path = home + rest[len(name):]
+		}
+	}
+
+	path = expandDollarVars(path)
+	if runtime.GOOS == "windows" {
+		path = expandPercentVars(path)
+	}
+	return path
+}
+
+// pathExpansion is what a Filename, of, comes to with expandPath, and whether
+// that has placeholders.
+type pathExpansion struct {
+	of           string
+	path         string
+	placeholders bool
+}
+
+// expandedPath returns the expansion of Filename, working it out only when
+// Filename is first used or has changed since, so that writes don't pay for
+// it each time.  It is safe for concurrent use.
+func (l *Logger) expandedPath() *pathExpansion {
+	if exp, _ := l.pathExp.Load().(*pathExpansion); exp != nil && exp.of == l.Filename {
+		return exp
+	}
+	path := expandPath(l.Filename)
+	exp := &pathExpansion{of: l.Filename, path: path, placeholders: hasPlaceholders(path)}
+	l.pathExp.Store(exp)
+	return exp
+}
+
+// homeDir returns the home directory of the named user, or of the current
+// user if name is empty.
+func homeDir(name string) (string, bool) {
+	if name == "" {
+		home, err := os.UserHomeDir()
+		return home, err == nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", false
+	}
+	return u.HomeDir, true
+}
+
+// expandDollarVars expands environment variables written as $VAR or ${VAR}.
+func expandDollarVars(path string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(path, '$')
+		if i < 0 || i == len(path)-1 {
+			break
+		}
+		b.WriteString(path[:i])
+		var name, ref string
+		if path[i+1] == '{' {
+			if end := strings.IndexByte(path[i:], '}'); end > 0 {
+				name, ref = path[i+2:i+end], path[i:i+end+1]
+			}
+		} else {
+			end := i + 1
+			for end < len(path) && isVarChar(path[end]) {
+				end++
+			}
+			name, ref = path[i+1:end], path[i:end]
+		}
+		if v, ok := os.LookupEnv(name); ok && name != "" {
+			b.WriteString(v)
+			path = path[i+len(ref):]
+		} else {
+			// not a variable, so keep the $ and look again after it.
+			b.WriteByte('$')
+			path = path[i+1:]
+		}
+	}
+	b.WriteString(path)
+	return b.String()
+}
+
+// isVarChar reports whether c may appear in the name of a $VAR.
+func isVarChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// expandPercentVars expands environment variables written as %VAR%.
+func expandPercentVars(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(path, '%')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(path[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		b.WriteString(path[:start])
+		if v, ok := os.LookupEnv(path[start+1 : end]); ok && end > start+1 {
+			b.WriteString(v)
+			path = path[end+1:]
+		} else {
+			// not a variable, so keep the first % and look again after it.
+			b.WriteByte('%')
+			path = path[start+1:]
+		}
+	}
+	b.WriteString(path)
+	return b.String()
+}
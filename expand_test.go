@@ -0,0 +1,61 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	isNil(err, t)
+	isNil(os.Setenv("LUMBERJACK_TEST_DIR", "/var/log/app"), t)
+	defer os.Unsetenv("LUMBERJACK_TEST_DIR")
+	os.Unsetenv("LUMBERJACK_TEST_UNSET")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/var/log/foo.log", "/var/log/foo.log"},
+		{"~/foo.log", home + "/foo.log"},
+		{"~", home},
+		{"$LUMBERJACK_TEST_DIR/foo.log", "/var/log/app/foo.log"},
+		{"${LUMBERJACK_TEST_DIR}/foo.log", "/var/log/app/foo.log"},
+		{"$LUMBERJACK_TEST_UNSET/foo.log", "$LUMBERJACK_TEST_UNSET/foo.log"},
+		{"${LUMBERJACK_TEST_UNSET}/foo.log", "${LUMBERJACK_TEST_UNSET}/foo.log"},
+		{"/var/log/$$foo$.log", "/var/log/$$foo$.log"},
+		{"~lumberjack-no-such-user/foo.log", "~lumberjack-no-such-user/foo.log"},
+	}
+
+	for _, test := range tests {
+		equals(test.want, expandPath(test.path), t)
+	}
+}
+
+func TestExpandPercentVars(t *testing.T) {
+	isNil(os.Setenv("LUMBERJACK_TEST_DIR", `C:\logs`), t)
+	defer os.Unsetenv("LUMBERJACK_TEST_DIR")
+
+	equals(`C:\logs\foo.log`, expandPercentVars(`%LUMBERJACK_TEST_DIR%\foo.log`), t)
+	equals(`100%\foo%.log`, expandPercentVars(`100%\foo%.log`), t)
+	equals(`%%`, expandPercentVars(`%%`), t)
+}
+
+func TestExpandFilename(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestExpandFilename", t)
+	defer os.RemoveAll(dir)
+
+	isNil(os.Setenv("LUMBERJACK_TEST_DIR", dir), t)
+	defer os.Unsetenv("LUMBERJACK_TEST_DIR")
+
+	l := &Logger{
+		Filename: filepath.Join("$LUMBERJACK_TEST_DIR", "foobar.log"),
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(logFile(dir), b, t)
+}
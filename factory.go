@@ -0,0 +1,73 @@
+package lumberjack
+
+// Factory makes Loggers that share a set of defaults, such as the compression,
+// retention and sync policy of an organization, with the settings of each log
+// file on top, for services that keep many log files under one policy.
+type Factory struct {
+	defaults Config
+	setup    func(*Logger)
+}
+
+// NewFactory returns a Factory making Loggers with the given defaults.  Their
+// Filename is left for each Logger.  setup, if not nil, is called on every
+// Logger the Factory makes, for the options that take code, such as
+// Compressor or OnEvent, which a Config can't hold.
+func NewFactory(defaults Config, setup func(*Logger)) *Factory {
+	return &Factory{defaults: defaults.clone(), setup: setup}
+}
+
+// Defaults returns a copy of the defaults, for overriding some of them for a
+// log file, such as by decoding the section of a config file for that log
+// file onto it, which leaves the options it doesn't mention as they are, and
+// passing it to NewWith.
+func (f *Factory) Defaults() Config {
+	return f.defaults.clone()
+}
+
+// New returns a Logger writing to filename with the defaults, changed by
+// override, if it isn't nil.  It fails like New if the result doesn't pass
+// Validate.
+func (f *Factory) New(filename string, override func(*Config)) (*Logger, error) {
+	cfg := f.Defaults()
+	cfg.Filename = filename
+	if override != nil {
+		override(&cfg)
+	}
+	return f.NewWith(cfg)
+}
+
+// NewWith returns a Logger configured by cfg, usually got from Defaults, like
+// New, along with the setup of the Factory, which runs before Preopen starts
+// opening the log file.
+func (f *Factory) NewWith(cfg Config) (*Logger, error) {
+	l, err := newLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if f.setup != nil {
+		f.setup(l)
+	}
+	l.startPreopen()
+	return l, nil
+}
+
+// clone returns a copy of cfg that shares no maps or slices with it.
+func (cfg Config) clone() Config {
+	cfg.LegacyBackupFormats = append([]string(nil), cfg.LegacyBackupFormats...)
+	cfg.BackupXattrs = cloneMap(cfg.BackupXattrs)
+	cfg.HeaderFields = cloneMap(cfg.HeaderFields)
+	cfg.Labels = cloneMap(cfg.Labels)
+	return cfg
+}
+
+// cloneMap returns a copy of m, or nil if it is nil.
+func cloneMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestFactory(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFactory", t)
+	defer os.RemoveAll(dir)
+
+	var made []*Logger
+	f := NewFactory(Config{
+		MaxSize:      10,
+		MaxBackups:   5,
+		Compress:     true,
+		SyncInterval: Duration(time.Millisecond),
+		HeaderFields: map[string]string{"team": "platform"},
+	}, func(l *Logger) {
+		l.Compressor = Gzip{Level: 1}
+		made = append(made, l)
+	})
+
+	// a Logger with the defaults as they are.
+	access, err := f.New(filepath.Join(dir, "access.log"), nil)
+	isNil(err, t)
+	defer access.Close()
+	equals(filepath.Join(dir, "access.log"), access.Filename, t)
+	equals(int64(10), access.MaxBytes, t)
+	equals(5, access.MaxBackups, t)
+	equals(true, access.Compress, t)
+	equals(time.Millisecond, access.SyncInterval, t)
+	equals(Gzip{Level: 1}, access.Compressor, t)
+
+	// one with some of them overridden, which leaves the defaults alone.
+	audit, err := f.New(filepath.Join(dir, "audit.log"), func(c *Config) {
+		c.MaxBackups = 100
+		c.HeaderFields["kind"] = "audit"
+	})
+	isNil(err, t)
+	defer audit.Close()
+	equals(100, audit.MaxBackups, t)
+	equals(true, audit.Compress, t)
+	equals(map[string]string{"team": "platform", "kind": "audit"}, audit.HeaderFields, t)
+	equals(map[string]string{"team": "platform"}, access.HeaderFields, t)
+	equals(map[string]string{"team": "platform"}, f.Defaults().HeaderFields, t)
+
+	// and one whose overrides come from its section of a config file.
+	cfg := f.Defaults()
+	isNil(yaml.Unmarshal([]byte("filename: "+filepath.Join(dir, "debug.log")+"\ncompress: false\n"), &cfg), t)
+	debug, err := f.NewWith(cfg)
+	isNil(err, t)
+	defer debug.Close()
+	equals(false, debug.Compress, t)
+	equals(5, debug.MaxBackups, t)
+
+	equals(3, len(made), t)
+
+	// overrides that make the config invalid are refused.
+	_, err = f.New(filepath.Join(dir, "bad.log"), func(c *Config) { c.MaxBackups = -1 })
+	notNil(err, t)
+	_, err = f.New("", nil)
+	notNil(err, t)
+}
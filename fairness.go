@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"context"
+	"time"
+)
+
+// writeKeyType is the type of the context key WithWriteKey stores the key of
+// writes under.
+type writeKeyType struct{}
+
+// WithWriteKey returns a copy of ctx that makes the writes of WriteContext
+// count against the KeyQuota of the given key, such as the name of the
+// component doing them, and with EnvelopeSource, be marked as the key's.  Go has no goroutine identity to go by, so a
+// goroutine, or a group of them, that should get a quota of its own passes
+// its own key.  Writes without one all share the quota of the empty key.
+func WithWriteKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, writeKeyType{}, key)
+}
+
+// writeKey returns the key WithWriteKey set in ctx, or "" if none.
+func writeKey(ctx context.Context) string {
+	key, _ := ctx.Value(writeKeyType{}).(string)
+	return key
+}
+
+// overQuota reports whether a write of n bytes with the given key would take
+// that key past its KeyQuota of the buffer.  A key with nothing queued always
+// gets in, as any write does into an empty buffer.  It assumes that b.mu is
+// held.
+func (b *asyncBuffer) overQuota(key string, n int) bool {
+	if !b.quotas() {
+		return false
+	}
+	queued := b.keyQueued[key]
+	return queued > 0 && queued+n > b.l.KeyQuota
+}
+
+// quotas reports whether writes are held to KeyQuota.
+func (b *asyncBuffer) quotas() bool {
+	return b.l.KeyQuota > 0 && !b.l.StrictOrder
+}
+
+// addQueued counts n bytes queued with the given key, or, for a negative
+// n, written out.  It assumes that b.mu is held.
+func (b *asyncBuffer) addQueued(key string, n int) {
+	if b.keyQueued == nil {
+		b.keyQueued = make(map[string]int)
+	}
+	b.keyQueued[key] += n
+	if b.keyQueued[key] <= 0 {
+		delete(b.keyQueued, key)
+	}
+}
+
+// recordBufferWait counts a buffered write with the given key that waited
+// for d, for KeyQuota if quota is set, or else for room in the buffer.
+func (l *Logger) recordBufferWait(key string, quota bool, d time.Duration) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	if !quota {
+		l.stats.BufferWaits++
+		l.stats.BufferWaitTime += d
+		return
+	}
+	l.stats.QuotaWaits++
+	l.stats.QuotaWaitTime += d
+	if l.stats.QuotaWaitsByKey == nil {
+		l.stats.QuotaWaitsByKey = make(map[string]int64)
+	}
+	l.stats.QuotaWaitsByKey[key]++
+}
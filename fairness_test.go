@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKeyQuota(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestKeyQuota", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1000,
+		BufferSize: 100,
+		KeyQuota:   8,
+	}
+	defer l.Close()
+	chatty := WithWriteKey(context.Background(), "chatty")
+	quiet := WithWriteKey(context.Background(), "quiet")
+
+	// Stall the drain goroutine by holding the lock it needs to write.
+	l.mu.Lock()
+
+	_, err := l.WriteContext(chatty, []byte("12345678"))
+	isNil(err, t)
+
+	written := make(chan struct{})
+	go func() {
+		l.WriteContext(chatty, []byte("abc"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("expected write over its key's quota to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the buffer still has room for the writes of other keys.
+	_, err = l.WriteContext(quiet, []byte("xyz"))
+	isNil(err, t)
+	_, err = l.Write([]byte("!"))
+	isNil(err, t)
+
+	l.mu.Unlock()
+	<-written
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("12345678xyz!abc"), t)
+	s := l.Stats()
+	equals(int64(1), s.QuotaWaits, t)
+	equals(map[string]int64{"chatty": 1}, s.QuotaWaitsByKey, t)
+	assert(s.QuotaWaitTime > 0, t, "expected a quota wait time, got %v", s.QuotaWaitTime)
+	equals(int64(0), s.BufferWaits, t)
+}
+
+func TestBufferWaits(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBufferWaits", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    1000,
+		BufferSize: 8,
+		KeyQuota:   8,
+	}
+	defer l.Close()
+
+	l.mu.Lock()
+	_, err := l.WriteContext(WithWriteKey(context.Background(), "a"), []byte("12345678"))
+	isNil(err, t)
+
+	// a full buffer holds up the writes of every key.
+	ctx, cancel := context.WithTimeout(WithWriteKey(context.Background(), "b"), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.WriteContext(ctx, []byte("abc"))
+	equals(context.DeadlineExceeded, err, t)
+	l.mu.Unlock()
+
+	s := l.Stats()
+	equals(int64(1), s.BufferWaits, t)
+	assert(s.BufferWaitTime > 0, t, "expected a buffer wait time, got %v", s.BufferWaitTime)
+	equals(int64(0), s.QuotaWaits, t)
+}
@@ -0,0 +1,48 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// defaultFallbackRetryInterval is how often the log file is retried while
+// writing to the Fallback, if FallbackRetryInterval isn't set.
+const defaultFallbackRetryInterval = 10 * time.Second
+
+// writeOrFallback writes p to the log file, or to the Fallback if the log
+// file's filesystem is read-only.  It assumes that l.mu is held.
+func (l *Logger) writeOrFallback(p []byte) (int, error) {
+	now := currentTime()
+	if l.readOnly {
+		if now.Before(l.retryAt) {
+			return l.Fallback.Write(p)
+		}
+		// Retry with a fresh descriptor, in case the old one stays broken
+		// after the filesystem has been remounted.
+		_ = l.close()
+	}
+
+	n, err := l.writeFile(p)
+	if err == nil {
+		if l.readOnly {
+			l.readOnly = false
+			l.emit(Event{Type: EventFallbackEnded, Path: l.filename()})
+		}
+		return n, nil
+	}
+	if !isReadOnly(err) {
+		return n, err
+	}
+
+	if !l.readOnly {
+		l.readOnly = true
+		l.emit(Event{Type: EventFallbackStarted, Path: l.filename(), Err: err})
+	}
+	interval := l.FallbackRetryInterval
+	if interval <= 0 {
+		interval = defaultFallbackRetryInterval
+	}
+	l.retryAt = now.Add(interval)
+
+	m, err := l.Fallback.Write(p[n:])
+	return n + m, err
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package lumberjack
+
+// isReadOnly reports that errors aren't recognized as coming from a read-only
+// filesystem on this platform, so the Fallback is never used.
+func isReadOnly(err error) bool {
+	return false
+}
@@ -0,0 +1,82 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows
+// +build linux darwin dragonfly freebsd netbsd openbsd windows
+
+package lumberjack
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFallback", t)
+	defer os.RemoveAll(dir)
+
+	readOnly := true
+	osOpenFile = func(name string, flag int, perm fs.FileMode) (*os.File, error) {
+		if readOnly {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EROFS}
+		}
+		return os.OpenFile(name, flag, perm)
+	}
+	defer func() { osOpenFile = os.OpenFile }()
+
+	var fallback bytes.Buffer
+	var events []Event
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:              filename,
+		Fallback:              &fallback,
+		FallbackRetryInterval: time.Minute,
+		OnEvent:               func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	// writes to a read-only filesystem go to the fallback.
+	n, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	equals(1, len(events), t)
+	equals(EventFallbackStarted, events[0].Type, t)
+	notNil(events[0].Err, t)
+
+	// the log file isn't retried until the interval has passed.
+	readOnly = false
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	equals("boo!foo!", fallback.String(), t)
+	notExist(filename, t)
+
+	fakeCurrentTime = fakeCurrentTime.Add(time.Minute)
+	_, err = l.Write([]byte("bar!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar!"), t)
+	equals("boo!foo!", fallback.String(), t)
+	equals(2, len(events), t)
+	equals(EventFallbackEnded, events[1].Type, t)
+	equals(int64(0), l.Stats().WriteErrors, t)
+}
+
+func TestNoFallback(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNoFallback", t)
+	defer os.RemoveAll(dir)
+
+	osOpenFile = func(name string, flag int, perm fs.FileMode) (*os.File, error) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EROFS}
+	}
+	defer func() { osOpenFile = os.OpenFile }()
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	// without a fallback, the error is returned, and can be recognized.
+	_, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+	assert(isReadOnly(err), t, "expected a read-only error, got %v", err)
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isReadOnly reports whether err comes from the filesystem being read-only.
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
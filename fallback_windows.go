@@ -0,0 +1,16 @@
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorWriteProtect is ERROR_WRITE_PROTECT, which Windows reports for writes to
+// write-protected media.
+const errorWriteProtect syscall.Errno = 19
+
+// isReadOnly reports whether err comes from the filesystem being read-only or
+// the media write-protected.
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS) || errors.Is(err, errorWriteProtect)
+}
@@ -0,0 +1,37 @@
+package lumberjack
+
+import (
+	"os"
+)
+
+// The operations passed to Logger.Fault.
+const (
+	// FaultWrite is a write to the log file.
+	FaultWrite = "write"
+
+	// FaultRename is a rename of the log file or of a backup, as on rotation.
+	FaultRename = "rename"
+
+	// FaultChown is a change of the owner of a file or directory.
+	FaultChown = "chown"
+)
+
+// fault asks Fault, if set, whether op on the named file is to fail, returning
+// the error to fail with as an *os.PathError, or nil.
+func (l *Logger) fault(op, name string) error {
+	if l.Fault == nil {
+		return nil
+	}
+	if err := l.Fault(op, name); err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+// rename renames from to to, unless Fault makes it fail.
+func (l *Logger) rename(from, to string) error {
+	if err := l.fault(FaultRename, from); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
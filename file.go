@@ -0,0 +1,53 @@
+package lumberjack
+
+import (
+	"os"
+)
+
+// File returns the log file currently being written to, or nil if no file is
+// open yet (or the Logger has been closed).  The returned *os.File remains
+// owned by the Logger: it is closed on the next rotation or Close, so callers
+// must not close it and should not hold on to it beyond immediate use.  Use
+// DupFile to obtain a descriptor that can be handed to another process or kept
+// after the Logger moves on.
+func (l *Logger) File() *os.File {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file
+}
+
+// DupFile returns a duplicate of the descriptor for the current log file,
+// opening the file first if needed, so it can be passed to exec.Cmd.Stdout,
+// handed to a sandboxed helper, or otherwise written to directly.  The caller
+// owns the returned file and must close it.
+//
+// The duplicate shares its file offset with the Logger's own descriptor, so
+// writes through either are appended in order.  They are not, however, counted
+// towards MaxSize, and a duplicate does not follow rotations: once the Logger
+// rotates, anything written through an older duplicate lands in the file that
+// has since become a backup.  Call DupFile again after a rotation to follow the
+// new file.  On platforms other than Windows and the Unix ones, such as plan9,
+// DupFile returns an error.
+func (l *Logger) DupFile() (*os.File, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return nil, ErrClosed
+	}
+
+	if l.file == nil {
+		l.switchExpanded()
+		if err := l.openFirst(0); err != nil {
+			return nil, err
+		}
+	}
+	return dupFile(l.file)
+}
+
+// closeFile closes the log file, if it is open, without closing the Logger or
+// its write buffer; the next write opens the file again.
+func (l *Logger) closeFile() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.close()
+}
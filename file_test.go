@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFile", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	assert(l.File() == nil, t, "expected no file before the first write")
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	f := l.File()
+	notNil(f, t)
+	equals(filename, f.Name(), t)
+
+	isNil(l.Close(), t)
+	assert(l.File() == nil, t, "expected no file after close")
+}
+
+func TestDupFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestDupFile", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	// DupFile opens the file if nothing has been written yet.
+	f, err := l.DupFile()
+	isNil(err, t)
+	defer f.Close()
+
+	b := []byte("boo!")
+	_, err = f.Write(b)
+	isNil(err, t)
+
+	// The duplicate shares the Logger's offset, so writes interleave rather
+	// than overwrite each other.
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(filename, append(b, b2...), t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(10 * time.Millisecond)
+
+	// After a rotation the duplicate still refers to the old file, which is
+	// now the backup.
+	b3 := []byte("baz!")
+	_, err = f.Write(b3)
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("boo!foo!baz!"), t)
+	existsWithContent(filename, []byte{}, t)
+}
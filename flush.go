@@ -0,0 +1,127 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+)
+
+// Sync writes out everything queued in the write buffer, if writes are
+// buffered, and commits the current log file to stable storage.  Like Flush,
+// it returns the error from writing in the background, if there is one.
+func (l *Logger) Sync() error {
+	err := l.Flush()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return err
+	}
+	if errSync := l.syncFile(); err == nil {
+		err = errSync
+	}
+	return err
+}
+
+// flushRegistry holds the Loggers registered with RegisterFlush.
+var flushRegistry struct {
+	mu      sync.Mutex
+	loggers map[*Logger]bool
+}
+
+// RegisterFlush registers l to be synced by FlushAll, and so by FlushOnPanic
+// and FlushOnSignal, which makes sure that buffered writes explaining a crash
+// make it to disk.  Loggers are unregistered by Close, or by calling the
+// returned function.
+func RegisterFlush(l *Logger) (unregister func()) {
+	// a SingleWriter can't write without l.mu alongside FlushOnSignal.
+	atomic.StoreInt32(&l.signalled, 1)
+
+	flushRegistry.mu.Lock()
+	defer flushRegistry.mu.Unlock()
+	if flushRegistry.loggers == nil {
+		flushRegistry.loggers = make(map[*Logger]bool)
+	}
+	flushRegistry.loggers[l] = true
+	return func() { unregisterFlush(l) }
+}
+
+// unregisterFlush removes l from the Loggers synced by FlushAll.
+func unregisterFlush(l *Logger) {
+	flushRegistry.mu.Lock()
+	defer flushRegistry.mu.Unlock()
+	delete(flushRegistry.loggers, l)
+}
+
+// FlushAll syncs every Logger registered with RegisterFlush.  It returns the
+// first error, but syncs the remaining Loggers regardless.
+func FlushAll() error {
+	var err error
+	for _, l := range registered() {
+		if errSync := l.Sync(); err == nil {
+			err = errSync
+		}
+	}
+	return err
+}
+
+// registered returns the Loggers registered with RegisterFlush.
+func registered() []*Logger {
+	flushRegistry.mu.Lock()
+	defer flushRegistry.mu.Unlock()
+	loggers := make([]*Logger, 0, len(flushRegistry.loggers))
+	for l := range flushRegistry.loggers {
+		loggers = append(loggers, l)
+	}
+	return loggers
+}
+
+// FlushOnPanic syncs the registered Loggers, and the given ones, when the
+// calling goroutine panics, writes their TerminationFile with the panic as the
+// reason, and then panics again with the same value.  It must be deferred
+// directly, as in recover middleware or at the top of main:
+//
+//	defer lumberjack.FlushOnPanic(logger)
+func FlushOnPanic(loggers ...*Logger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	_ = FlushAll()
+	for _, l := range loggers {
+		_ = l.Sync()
+	}
+	reason := fmt.Sprintf("panic: %v", r)
+	for _, l := range append(registered(), loggers...) {
+		_ = l.WriteTermination(reason)
+	}
+	panic(r)
+}
+
+// FlushOnSignal syncs the registered Loggers when the process receives one of
+// the given signals, such as os.Interrupt or syscall.SIGTERM, and then raises
+// the signal again with the handler removed.  Unless something else is
+// listening for the signal, the process then does what it would have done
+// without the handler, which for those signals is to exit.  It handles one
+// signal at most.  Calling the returned function removes the handler.
+func FlushOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-ch:
+			_ = FlushAll()
+			signal.Stop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(sig)
+			}
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,62 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFlushOnPanic(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFlushOnPanic", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, BufferSize: 100}
+	defer l.Close()
+
+	// Stall the drain goroutine by holding the lock it needs to write, and
+	// let it go once the panic is under way.
+	l.mu.Lock()
+	_, err := l.Write([]byte("crashing!"))
+	isNil(err, t)
+
+	func() {
+		defer func() {
+			equals("boom", recover(), t)
+		}()
+		defer FlushOnPanic(l)
+		defer l.mu.Unlock()
+		panic("boom")
+	}()
+	existsWithContent(filename, []byte("crashing!"), t)
+
+	// without a panic, nothing happens.
+	func() {
+		defer FlushOnPanic(l)
+	}()
+}
+
+func TestFlushAll(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFlushAll", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, BufferSize: 100}
+	defer l.Close()
+	unregister := RegisterFlush(l)
+
+	l.mu.Lock()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	l.mu.Unlock()
+	isNil(FlushAll(), t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// unregistered Loggers are left alone, as are closed ones.
+	unregister()
+	equals(0, len(flushRegistry.loggers), t)
+	RegisterFlush(l)
+	isNil(l.Close(), t)
+	equals(0, len(flushRegistry.loggers), t)
+}
@@ -0,0 +1,164 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// forecastSample is the number of the newest backups that Forecast takes the
+// rotation frequency and backup size from.
+const forecastSample = 10
+
+// ageBounds are the upper bounds of the buckets of Forecast.Ages.
+var ageBounds = []time.Duration{
+	time.Hour, 6 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour,
+}
+
+// Forecast describes how much history the backups of a Logger hold and, going
+// by recent rotations, how much its retention limits keep and when they next
+// remove a backup.  See Logger.Forecast.
+type Forecast struct {
+	// Backups is the number of backups, Size their total size in bytes and
+	// Oldest the timestamp of the oldest one, leaving out those pinned with a
+	// .keep file, which cleanup doesn't count.
+	Backups int
+	Size    int64
+	Oldest  time.Time
+
+	// Ages is the histogram of the ages of those backups.
+	Ages []AgeBucket
+
+	// RotationInterval is the average time between the recent rotations, and
+	// BackupSize the average size of their backups, or 0 if there are too
+	// few backups to tell.  With RotationInterval set, that stands in for
+	// the time between rotations while there are too few backups.
+	RotationInterval time.Duration
+	BackupSize       int64
+
+	// Retained is how much history the retention limits keep once they come
+	// into play, and LimitedBy the limit that keeps the least, or both 0 if
+	// no limit is set, or none can be forecast yet.
+	// MinDiskFree and MinFreePercent depend on what else is on the disk, and
+	// aren't forecast.
+	Retained  time.Duration
+	LimitedBy RemoveReason
+
+	// NextRemoval is when cleanup is expected to remove a backup next, which
+	// may be now for backups already past a limit, or the zero time if that
+	// can't be forecast.
+	NextRemoval time.Time
+}
+
+// AgeBucket is a bucket of the histogram of backup ages in a Forecast.
+type AgeBucket struct {
+	// MaxAge is the upper bound of the ages in the bucket, which follows the
+	// bucket before it, or 0 for the last bucket, of the backups older than
+	// all of the others.
+	MaxAge time.Duration
+
+	// Backups is the number of backups in the bucket, and Size their total
+	// size in bytes.
+	Backups int
+	Size    int64
+}
+
+// Forecast answers how far back the logs go with the current configuration:
+// it looks at the backups there are now and at how often, and how large,
+// recent rotations have been, and works out how much history the retention
+// limits keep and when they next remove a backup.  It is only as good as the
+// recent rotations are a guide to those to come.
+func (l *Logger) Forecast() (Forecast, error) {
+	backups, err := l.Backups()
+	if err != nil {
+		return Forecast{}, err
+	}
+	now := currentTime()
+	var f Forecast
+	for _, bound := range ageBounds {
+		f.Ages = append(f.Ages, AgeBucket{MaxAge: bound})
+	}
+	f.Ages = append(f.Ages, AgeBucket{})
+
+	var kept []BackupInfo
+	for _, b := range backups {
+		if b.Pinned {
+			continue
+		}
+		kept = append(kept, b)
+		f.Backups++
+		f.Size += b.Size
+		f.Oldest = b.Timestamp
+		i := 0
+		for i < len(ageBounds) && now.Sub(b.Timestamp) > ageBounds[i] {
+			i++
+		}
+		f.Ages[i].Backups++
+		f.Ages[i].Size += b.Size
+	}
+
+	sample := kept
+	if len(sample) > forecastSample {
+		sample = sample[:forecastSample]
+	}
+	if n := len(sample); n > 1 {
+		f.RotationInterval = sample[0].Timestamp.Sub(sample[n-1].Timestamp) / time.Duration(n-1)
+	} else if l.RotationInterval > 0 {
+		f.RotationInterval = l.RotationInterval
+	}
+	if len(sample) > 0 {
+		var size int64
+		for _, b := range sample {
+			size += b.Size
+		}
+		f.BackupSize = size / int64(len(sample))
+	}
+
+	newest := now
+	if len(kept) > 0 {
+		newest = kept[0].Timestamp
+	}
+	// when returns the time of the nth rotation after the newest backup.
+	when := func(n int64) time.Time {
+		return newest.Add(time.Duration(n) * f.RotationInterval)
+	}
+	limit := func(reason RemoveReason, retained time.Duration, next time.Time) {
+		if f.LimitedBy == 0 || retained < f.Retained {
+			f.Retained, f.LimitedBy = retained, reason
+		}
+		if next.Before(now) {
+			next = now
+		}
+		if len(kept) > 0 && (f.NextRemoval.IsZero() || next.Before(f.NextRemoval)) {
+			f.NextRemoval = next
+		}
+	}
+	if l.MaxAge > 0 {
+		age := time.Duration(l.MaxAge) * 24 * time.Hour
+		limit(RemovedByAge, age, f.Oldest.Add(age))
+	}
+	if l.MaxHistory > 0 {
+		limit(RemovedByHistory, l.MaxHistory, f.Oldest.Add(l.MaxHistory))
+	}
+	if f.RotationInterval <= 0 {
+		return f, nil
+	}
+	if l.MaxBackups > 0 {
+		more := int64(l.MaxBackups-len(kept)) + 1
+		if more < 1 {
+			more = 1
+		}
+		limit(RemovedByCount, time.Duration(l.MaxBackups)*f.RotationInterval, when(more))
+	}
+	current := l.CurrentSize()
+	if maxTotal := l.maxTotal(current + f.Size); maxTotal > 0 && f.BackupSize > 0 {
+		fit := (maxTotal - current) / f.BackupSize
+		if fit < 0 {
+			fit = 0
+		}
+		more := (maxTotal-current-f.Size)/f.BackupSize + 1
+		if more < 1 {
+			more = 1
+		}
+		limit(RemovedBySize, time.Duration(fit)*f.RotationInterval, when(more))
+	}
+	return f, nil
+}
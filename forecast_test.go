@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestForecast(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestForecast", t)
+	defer os.RemoveAll(dir)
+
+	// four backups of 10 bytes, rotated an hour apart, the last an hour ago.
+	fakeCurrentTime = fakeCurrentTime.Truncate(time.Second)
+	now := fakeCurrentTime
+	for i := 1; i <= 4; i++ {
+		at := now.Add(-time.Duration(i) * time.Hour)
+		name := filepath.Join(dir, "foobar-"+at.UTC().Format(backupTimeFormat)+".log")
+		isNil(ioutil.WriteFile(name, []byte("0123456789"), 0644), t)
+	}
+
+	l := &Logger{Filename: logFile(dir), MaxBackups: 6}
+	defer l.Close()
+	f, err := l.Forecast()
+	isNil(err, t)
+	equals(4, f.Backups, t)
+	equals(int64(40), f.Size, t)
+	assert(f.Oldest.Equal(now.Add(-4*time.Hour)), t, "oldest backup at %v", f.Oldest)
+	equals(time.Hour, f.RotationInterval, t)
+	equals(int64(10), f.BackupSize, t)
+	equals([]AgeBucket{
+		{MaxAge: time.Hour, Backups: 1, Size: 10},
+		{MaxAge: 6 * time.Hour, Backups: 3, Size: 30},
+		{MaxAge: 24 * time.Hour},
+		{MaxAge: 7 * 24 * time.Hour},
+		{MaxAge: 30 * 24 * time.Hour},
+		{},
+	}, f.Ages, t)
+
+	// two more backups fit in MaxBackups, so the third rotation from now
+	// removes one.
+	equals(RemovedByCount, f.LimitedBy, t)
+	equals(6*time.Hour, f.Retained, t)
+	assert(f.NextRemoval.Equal(now.Add(2*time.Hour)), t, "next removal at %v", f.NextRemoval)
+
+	// MaxTotalSize keeps less: 5 backups, with room for one more.
+	l.MaxTotalSize = 55
+	l.MaxAge = 1
+	f, err = l.Forecast()
+	isNil(err, t)
+	equals(RemovedBySize, f.LimitedBy, t)
+	equals(5*time.Hour, f.Retained, t)
+	assert(f.NextRemoval.Equal(now.Add(time.Hour)), t, "next removal at %v", f.NextRemoval)
+}
+
+func TestForecastNoBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestForecastNoBackups", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxBackups: 3, MaxAge: 2}
+	defer l.Close()
+	f, err := l.Forecast()
+	isNil(err, t)
+	equals(0, f.Backups, t)
+	equals(time.Duration(0), f.RotationInterval, t)
+	equals(true, f.NextRemoval.IsZero(), t)
+
+	// without rotations to go by, only MaxAge can be forecast.
+	equals(RemovedByAge, f.LimitedBy, t)
+	equals(48*time.Hour, f.Retained, t)
+
+	l.RotationInterval = time.Hour
+	f, err = l.Forecast()
+	isNil(err, t)
+	equals(RemovedByCount, f.LimitedBy, t)
+	equals(3*time.Hour, f.Retained, t)
+}
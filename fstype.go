@@ -0,0 +1,134 @@
+package lumberjack
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Filesystem is the kind of filesystem the log file is on, which the Logger
+// tunes some of what it does for.  See Logger.Filesystem.
+type Filesystem int
+
+const (
+	// FSAuto detects the filesystem when the log file is opened.
+	FSAuto Filesystem = iota
+
+	// FSGeneric tunes for no filesystem in particular, as for one that
+	// can't be told apart.
+	FSGeneric
+
+	// FSTmpfs is a filesystem in memory, such as tmpfs or ramfs.
+	FSTmpfs
+
+	// FSNFS is a network filesystem, such as NFS or SMB.
+	FSNFS
+
+	// FSExt4 is ext4, or ext2 or ext3.
+	FSExt4
+
+	// FSBtrfs is btrfs.
+	FSBtrfs
+
+	// FSXFS is XFS.
+	FSXFS
+)
+
+// filesystemNames holds the text form of each Filesystem.
+var filesystemNames = []string{
+	FSAuto:    "auto",
+	FSGeneric: "generic",
+	FSTmpfs:   "tmpfs",
+	FSNFS:     "nfs",
+	FSExt4:    "ext4",
+	FSBtrfs:   "btrfs",
+	FSXFS:     "xfs",
+}
+
+// String returns the name of f: "auto", "generic", "tmpfs", "nfs", "ext4",
+// "btrfs" or "xfs".
+func (f Filesystem) String() string {
+	if f < 0 || int(f) >= len(filesystemNames) {
+		return fmt.Sprintf("Filesystem(%d)", int(f))
+	}
+	return filesystemNames[f]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Filesystem) MarshalText() ([]byte, error) {
+	if f < 0 || int(f) >= len(filesystemNames) {
+		return nil, fmt.Errorf("invalid filesystem %d", int(f))
+	}
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "auto",
+// "generic", "tmpfs", "nfs", "ext4", "btrfs" and "xfs", so that the filesystem
+// can be set from config files.
+func (f *Filesystem) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*f = FSAuto
+		return nil
+	}
+	for i, name := range filesystemNames {
+		if string(text) == name {
+			*f = Filesystem(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid filesystem %q, expected auto, generic, tmpfs, nfs, ext4, btrfs or xfs", text)
+}
+
+// nfsReopenInterval is how often ReopenOnMove checks the log file on FSNFS,
+// where every check is a round trip to the server.
+const nfsReopenInterval = time.Second
+
+// detectFilesystem detects the filesystem of the log file, with FSAuto, once
+// it has been opened.  This method assumes l.mu is held.
+func (l *Logger) detectFilesystem() {
+	if l.Filesystem != FSAuto {
+		return
+	}
+	atomic.StoreInt32(&l.detectedFS, int32(filesystemOf(l.dir())))
+}
+
+// filesystem returns the Filesystem to tune for: Filesystem, or with FSAuto,
+// the one detected when the log file was opened, if it has been.
+func (l *Logger) filesystem() Filesystem {
+	if l.Filesystem != FSAuto {
+		return l.Filesystem
+	}
+	if fs := Filesystem(atomic.LoadInt32(&l.detectedFS)); fs != FSAuto {
+		return fs
+	}
+	return FSGeneric
+}
+
+// skipSync reports whether syncing the log file is skipped, on FSTmpfs, where
+// there is no disk for it to reach.
+func (l *Logger) skipSync() bool {
+	return l.filesystem() == FSTmpfs
+}
+
+// reopenDue reports whether ReopenOnMove is to check the log file before this
+// write: every time, but at most every nfsReopenInterval on FSNFS.  This
+// method assumes l.mu is held.
+func (l *Logger) reopenDue() bool {
+	if l.filesystem() != FSNFS {
+		return true
+	}
+	now := time.Now()
+	if now.Sub(l.reopenChecked) < nfsReopenInterval {
+		return false
+	}
+	l.reopenChecked = now
+	return true
+}
+
+// reflinks reports whether backups are copied by reflink where they can't be
+// renamed, on FSBtrfs and FSXFS, which share the blocks of the copy with the
+// original rather than copying them.
+func (l *Logger) reflinks() bool {
+	fs := l.filesystem()
+	return fs == FSBtrfs || fs == FSXFS
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package lumberjack
+
+import (
+	"syscall"
+)
+
+// The f_type of statfs of the filesystems Filesystem tells apart.
+const (
+	tmpfsMagic = 0x01021994
+	ramfsMagic = 0x858458f6
+	nfsMagic   = 0x6969
+	smbMagic   = 0x517b
+	cifsMagic  = 0xff534d42
+	smb2Magic  = 0xfe534d42
+	ext4Magic  = 0xef53 // ext2 and ext3 too
+	btrfsMagic = 0x9123683e
+	xfsMagic   = 0x58465342
+)
+
+// filesystemOf returns the Filesystem that dir is on, or FSGeneric if it
+// can't be told.
+func filesystemOf(dir string) Filesystem {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return FSGeneric
+	}
+	switch uint32(st.Type) {
+	case tmpfsMagic, ramfsMagic:
+		return FSTmpfs
+	case nfsMagic, smbMagic, cifsMagic, smb2Magic:
+		return FSNFS
+	case ext4Magic:
+		return FSExt4
+	case btrfsMagic:
+		return FSBtrfs
+	case xfsMagic:
+		return FSXFS
+	}
+	return FSGeneric
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package lumberjack
+
+// filesystemOf returns FSGeneric, as filesystems are only told apart on
+// Linux.
+func filesystemOf(dir string) Filesystem {
+	return FSGeneric
+}
@@ -0,0 +1,156 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFilesystemText(t *testing.T) {
+	for _, f := range []Filesystem{FSAuto, FSGeneric, FSTmpfs, FSNFS, FSExt4, FSBtrfs, FSXFS} {
+		b, err := json.Marshal(f)
+		isNil(err, t)
+		var got Filesystem
+		isNil(json.Unmarshal(b, &got), t)
+		equals(f, got, t)
+	}
+	var f Filesystem
+	notNil(f.UnmarshalText([]byte("fat32")), t)
+	_, err := Filesystem(9).MarshalText()
+	notNil(err, t)
+}
+
+func TestFilesystemTmpfs(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFilesystemTmpfs", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var syncs int
+	l := &Logger{
+		Filename:   logFile(dir),
+		SyncWrites: true,
+		Filesystem: FSTmpfs,
+		Trace: func(ctx context.Context, op string) (context.Context, func()) {
+			if op == TraceSync {
+				mu.Lock()
+				syncs++
+				mu.Unlock()
+			}
+			return ctx, func() {}
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Sync(), t)
+	_, err = l.Write(b)
+	isNil(err, t)
+
+	// writes neither wait for syncs nor are they synced by rotations.
+	existsWithContent(backupFile(dir), b, t)
+	mu.Lock()
+	equals(0, syncs, t)
+	mu.Unlock()
+}
+
+func TestFilesystemNFS(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func(g string) { goos = g }(goos)
+	goos = "linux"
+
+	l := &Logger{Filesystem: FSNFS}
+	assert(!l.renameFirst(), t, "expected rotations on NFS to close first")
+	l.RotationOrder = OrderRenameFirst
+	assert(l.renameFirst(), t, "expected RotationOrder to override the filesystem")
+
+	dir := makeTempDir("TestFilesystemNFS", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l = &Logger{
+		Filename:     filename,
+		ReopenOnMove: true,
+		Filesystem:   FSNFS,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// within a second of the last check, the move goes unseen.
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(moved, []byte("boo!foo!"), t)
+
+	l.mu.Lock()
+	l.reopenChecked = time.Now().Add(-nfsReopenInterval)
+	l.mu.Unlock()
+	_, err = l.Write([]byte("bar!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("bar!"), t)
+}
+
+func TestFilesystemReflink(t *testing.T) {
+	for _, fs := range []Filesystem{FSBtrfs, FSXFS} {
+		assert((&Logger{Filesystem: fs}).reflinks(), t, "expected reflinks on %v", fs)
+	}
+	for _, fs := range []Filesystem{FSGeneric, FSExt4, FSNFS} {
+		assert(!(&Logger{Filesystem: fs}).reflinks(), t, "expected no reflinks on %v", fs)
+	}
+
+	dir := makeTempDir("TestFilesystemReflink", t)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	isNil(ioutil.WriteFile(src, []byte("boo!"), 0644), t)
+	in, err := os.Open(src)
+	isNil(err, t)
+	defer in.Close()
+	out, err := os.Create(filepath.Join(dir, "dst"))
+	isNil(err, t)
+	defer out.Close()
+	if err := reflink(out, in); err != nil {
+		t.Skipf("can't reflink in %s: %v", dir, err)
+	}
+	existsWithContent(out.Name(), []byte("boo!"), t)
+}
+
+func TestFilesystemAuto(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFilesystemAuto", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	equals(FSGeneric, l.filesystem(), t)
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(filesystemOf(dir), l.filesystem(), t)
+	if runtime.GOOS != "linux" {
+		equals(FSGeneric, l.filesystem(), t)
+	}
+
+	l2 := &Logger{Filename: logFile(dir), Filesystem: FSGeneric}
+	defer l2.Close()
+	_, err = l2.Write([]byte("foo!"))
+	isNil(err, t)
+	equals(FSGeneric, l2.filesystem(), t)
+	equals(int32(FSAuto), l2.detectedFS, t)
+}
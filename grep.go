@@ -0,0 +1,94 @@
+package lumberjack
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Match is a line found by Grep.
+type Match struct {
+	// File is the path of the log file the line was found in.
+	File string
+
+	// Line is the line number within the file, starting at 1.
+	Line int
+
+	// Text is the line, without its trailing newline.
+	Text string
+}
+
+// Grep returns the lines matching pattern in the current log file and its
+// backups, oldest first, decompressing backups as needed.  Files that can't
+// contain lines written between since and until are skipped, judging by the
+// rotation timestamps of the backups: a backup holds the lines written after
+// the previous rotation and before its own.  A zero since or until leaves that
+// end of the range open.  Note that lines within a file that is searched are
+// not filtered by time, since only the application knows where (and if) a line
+// holds its timestamp.
+//
+// Grep stops early, returning the context's error, if ctx is done.
+func (l *Logger) Grep(ctx context.Context, pattern *regexp.Regexp, since, until time.Time) ([]Match, error) {
+	spans, err := l.logSpans()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, s := range spans {
+		if s.covers(since, until) {
+			names = append(names, s.name)
+		}
+	}
+
+	var matches []Match
+	for _, name := range names {
+		m, err := grepFile(ctx, name, pattern, l.codec())
+		if os.IsNotExist(err) {
+			// removed by cleanup, or no log written yet.
+			continue
+		}
+		if err != nil {
+			return matches, err
+		}
+		matches = append(matches, m...)
+	}
+	return matches, nil
+}
+
+// grepFile returns the lines matching pattern in the named file, which may be
+// compressed with gzip or c.
+func grepFile(ctx context.Context, name string, pattern *regexp.Regexp, c Compressor) ([]Match, error) {
+	r, err := openLogReader(name, c)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var matches []Match
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return matches, err
+			}
+		}
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(line, "\n")
+			if pattern.MatchString(line) {
+				matches = append(matches, Match{File: name, Line: n, Text: line})
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return matches, nil
+			}
+			return matches, err
+		}
+	}
+}
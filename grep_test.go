@@ -0,0 +1,90 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGrep(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestGrep", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	// three rotations two days apart, the middle one compressed.
+	t1 := fakeTime()
+	first := backupFile(dir)
+	isNil(ioutil.WriteFile(first, []byte("error one\ninfo two\n"), 0644), t)
+
+	newFakeTime()
+	t2 := fakeTime()
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err := gz.Write([]byte("info three\nerror four\n"))
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	second := backupFile(dir) + compressSuffix
+	isNil(ioutil.WriteFile(second, bc.Bytes(), 0644), t)
+
+	newFakeTime()
+	isNil(ioutil.WriteFile(filename, []byte("error five\n"), 0644), t)
+
+	pattern := regexp.MustCompile("^error")
+	ctx := context.Background()
+
+	matches, err := l.Grep(ctx, pattern, time.Time{}, time.Time{})
+	isNil(err, t)
+	equals([]Match{
+		{File: first, Line: 1, Text: "error one"},
+		{File: second, Line: 2, Text: "error four"},
+		{File: filename, Line: 1, Text: "error five"},
+	}, matches, t)
+
+	// only the second backup covers the time between the two rotations.
+	matches, err = l.Grep(ctx, pattern, t1.Add(time.Hour), t2.Add(-time.Hour))
+	isNil(err, t)
+	equals([]Match{
+		{File: second, Line: 2, Text: "error four"},
+	}, matches, t)
+
+	// everything after the last rotation is in the current file.
+	matches, err = l.Grep(ctx, pattern, t2.Add(time.Hour), time.Time{})
+	isNil(err, t)
+	equals([]Match{
+		{File: filename, Line: 1, Text: "error five"},
+	}, matches, t)
+
+	// a backup that is being compressed is only searched once.
+	isNil(ioutil.WriteFile(first+compressSuffix, []byte("garbage"), 0644), t)
+	matches, err = l.Grep(ctx, pattern, time.Time{}, t1.Add(-time.Hour))
+	isNil(err, t)
+	equals([]Match{
+		{File: first, Line: 1, Text: "error one"},
+	}, matches, t)
+}
+
+func TestGrepCanceled(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestGrepCanceled", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, bytes.Repeat([]byte("line\n"), 2048), 0644), t)
+	l := &Logger{Filename: filepath.Join(dir, "foobar.log")}
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := l.Grep(ctx, regexp.MustCompile("line"), time.Time{}, time.Time{})
+	equals(context.Canceled, err, t)
+}
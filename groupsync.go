@@ -0,0 +1,168 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// groupSync tracks the syncing of writes with SyncInterval or SyncWrites.
+// Writes are numbered in the order they are done, and synced in groups by a
+// goroutine of their own, so that no write waits on the Logger's lock while
+// the disk is flushed.
+type groupSync struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	synced  int64 // writes up to this one are on disk
+	failed  int64 // writes up to this one, and after synced, failed to sync
+	err     error // the error that writes up to failed get
+	want    int64 // the last write waiting to be synced
+	started bool  // the syncer goroutine has been started
+	stopped bool  // the syncer goroutine has been told to exit
+	done    chan struct{}
+	last    time.Time
+}
+
+// durable reports whether writes wait to be synced to disk.
+func (l *Logger) durable() bool {
+	return l.SyncInterval > 0 || l.SyncWrites
+}
+
+// sequence returns the number of the write that just wrote n bytes with the
+// given error, if it has to wait to be synced, or 0.  This method assumes l.mu
+// is held.
+func (l *Logger) sequence(n int, err error) int64 {
+	if !l.durable() || err != nil || n == 0 {
+		return 0
+	}
+	l.written++
+	return l.written
+}
+
+// waitSynced waits until write number seq has been synced to disk, starting
+// the syncer goroutine if need be.
+func (l *Logger) waitSynced(seq int64) error {
+	g := &l.syncState
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cond == nil {
+		g.cond = sync.NewCond(&g.mu)
+	}
+	if !g.started && !g.stopped {
+		g.started = true
+		g.done = make(chan struct{})
+		go l.labeled("sync", l.runSyncer)
+	}
+	if seq > g.want {
+		g.want = seq
+		g.cond.Broadcast()
+	}
+	for g.synced < seq {
+		if seq <= g.failed {
+			return g.err
+		}
+		g.cond.Wait()
+	}
+	return nil
+}
+
+// runSyncer syncs the log file whenever writes are waiting for it, once
+// SyncInterval has passed since the last sync, until told to stop.  Writes
+// done while it syncs are left to the next sync, which they all share.
+func (l *Logger) runSyncer() {
+	g := &l.syncState
+	defer close(g.done)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for {
+		for !g.stopped && (g.want <= g.synced || g.want <= g.failed) {
+			g.cond.Wait()
+		}
+		if g.stopped {
+			return
+		}
+
+		wait := g.last.Add(l.SyncInterval).Sub(time.Now())
+		g.mu.Unlock()
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+		l.mu.Lock()
+		upTo, f := l.written, l.file
+		l.mu.Unlock()
+		var err error
+		if f != nil {
+			// Files rotated out of the way were synced when closed.
+			err = l.syncOpenFile(f)
+		}
+		g.mu.Lock()
+
+		g.last = time.Now()
+		g.mark(upTo, err)
+		if err != nil {
+			l.recordError(writeError, err)
+		}
+		l.recordSync()
+	}
+}
+
+// syncOpenFile syncs f, the log file when the syncer last looked, tracing it
+// as TraceSync.  A file that has been closed since was synced on closing.
+func (l *Logger) syncOpenFile(f *os.File) error {
+	if l.skipSync() {
+		return nil
+	}
+	if l.Trace != nil {
+		_, end := l.Trace(context.Background(), TraceSync)
+		defer end()
+	}
+	err := f.Sync()
+	if errors.Is(err, os.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// markSynced records that the writes up to the last one done were synced, or
+// failed to be with err, when the log file was closed.  This method assumes
+// l.mu is held.
+func (l *Logger) markSynced(err error) {
+	if !l.durable() {
+		return
+	}
+	g := &l.syncState
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.mark(l.written, err)
+}
+
+// mark records that the writes up to upTo were synced, or failed to be with
+// err, and wakes up the writes waiting for it.  It assumes that g.mu is held.
+func (g *groupSync) mark(upTo int64, err error) {
+	if err == nil && upTo > g.synced {
+		g.synced = upTo
+	} else if err != nil && upTo > g.failed {
+		g.failed, g.err = upTo, err
+	}
+	if g.cond != nil {
+		g.cond.Broadcast()
+	}
+}
+
+// stopSyncer tells the syncer goroutine, if it is running, to exit, and waits
+// for it to.
+func (l *Logger) stopSyncer() {
+	g := &l.syncState
+	g.mu.Lock()
+	g.stopped = true
+	done := g.done
+	if g.cond != nil {
+		g.cond.Broadcast()
+	}
+	g.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
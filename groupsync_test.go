@@ -0,0 +1,162 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSyncInterval(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSyncInterval", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      100000,
+		SyncInterval: 50 * time.Millisecond,
+	}
+	defer l.Close()
+
+	// writes from many goroutines share syncs.
+	const writers, writes = 20, 5
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				b := []byte(fmt.Sprintf("%02d %d\n", i, j))
+				n, err := l.Write(b)
+				isNil(err, t)
+				equals(len(b), n, t)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	syncs := l.Stats().Syncs
+	assert(syncs > 0 && syncs < writers*writes/2, t,
+		"expected writes to share syncs, got %d syncs for %d writes", syncs, writers*writes)
+
+	isNil(l.Close(), t)
+
+	var want []string
+	for i := 0; i < writers; i++ {
+		for j := 0; j < writes; j++ {
+			want = append(want, fmt.Sprintf("%02d %d", i, j))
+		}
+	}
+	b, err := ioutil.ReadFile(filename)
+	isNil(err, t)
+	got := strings.Split(string(bytes.TrimSpace(b)), "\n")
+	sort.Strings(got)
+	equals(want, got, t)
+}
+
+func TestSyncWrites(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSyncWrites", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    100000,
+		SyncWrites: true,
+		Filesystem: FSGeneric, // syncs are skipped where /tmp is tmpfs
+		Trace: func(ctx context.Context, op string) (context.Context, func()) {
+			if op == TraceSync {
+				// as slow as a disk, rather than a tmpfs.
+				time.Sleep(time.Millisecond)
+			}
+			return ctx, func() {}
+		},
+	}
+	defer l.Close()
+
+	const writers, writes = 20, 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				_, err := l.Write([]byte(fmt.Sprintf("%02d %02d\n", i, j)))
+				isNil(err, t)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	syncs := l.Stats().Syncs
+	assert(syncs > 0 && syncs < writers*writes, t,
+		"expected writes to share syncs, got %d syncs for %d writes", syncs, writers*writes)
+	isNil(l.Close(), t)
+
+	b, err := ioutil.ReadFile(filename)
+	isNil(err, t)
+	equals(writers*writes, bytes.Count(b, []byte("\n")), t)
+}
+
+func TestSyncWritesOffLock(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSyncWritesOffLock", t)
+	defer os.RemoveAll(dir)
+
+	syncing := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    100000,
+		SyncWrites: true,
+		Filesystem: FSGeneric,
+		Trace: func(ctx context.Context, op string) (context.Context, func()) {
+			if op == TraceSync {
+				once.Do(func() {
+					close(syncing)
+					<-release
+				})
+			}
+			return ctx, func() {}
+		},
+	}
+	defer l.Close()
+
+	written := make(chan error)
+	go func() {
+		_, err := l.Write([]byte("boo!\n"))
+		written <- err
+	}()
+	<-syncing
+
+	// the Logger's lock isn't held while the disk is flushed, so another
+	// write gets its data into the file, and waits for the next sync.
+	go func() {
+		_, err := l.Write([]byte("foo!\n"))
+		written <- err
+	}()
+	waitFor(func() bool { return l.CurrentSize() == 10 }, t)
+	select {
+	case <-written:
+		t.Fatal("expected the write to wait for the sync")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	isNil(<-written, t)
+	isNil(<-written, t)
+	existsWithContent(logFile(dir), []byte("boo!\nfoo!\n"), t)
+}
@@ -0,0 +1,73 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// geteuid is os.Geteuid, as a variable so that tests can pretend to run as
+// another user.
+var geteuid = os.Geteuid
+
+// UnsafeFileError is returned when the Logger, running as root, refuses to
+// open or rotate a log file in a world-writable directory that another user
+// could have planted there: a symbolic link, a file owned by someone else or
+// a hard link to another file.  See Logger.FollowSymlinks.
+type UnsafeFileError struct {
+	Path   string
+	Reason string
+}
+
+func (e *UnsafeFileError) Error() string {
+	return fmt.Sprintf("refusing log file %s in a world-writable directory: %s", e.Path, e.Reason)
+}
+
+// guarded reports whether the named file is in a directory that anyone can
+// write to while the Logger runs as root, without FollowSymlinks, so that it
+// is only opened without following symbolic links, and only if it is the
+// Logger's own.
+func (l *Logger) guarded(name string) bool {
+	if l.FollowSymlinks || geteuid() != 0 {
+		return false
+	}
+	info, err := osStat(filepath.Dir(name))
+	return err == nil && info.Mode().Perm()&0002 != 0
+}
+
+// checkSafe returns an *UnsafeFileError if the file described by info, at the
+// named path, isn't one the Logger can take to be its own: a regular file,
+// owned by the user it runs as, with no other names.
+func checkSafe(name string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return &UnsafeFileError{Path: name, Reason: "it is a symbolic link"}
+	}
+	if !info.Mode().IsRegular() {
+		return &UnsafeFileError{Path: name, Reason: "it isn't a regular file"}
+	}
+	uid, links, ok := fileOwner(info)
+	if !ok {
+		return nil
+	}
+	if uid != geteuid() {
+		return &UnsafeFileError{Path: name, Reason: fmt.Sprintf("it is owned by uid %d", uid)}
+	}
+	if links > 1 {
+		return &UnsafeFileError{Path: name, Reason: "it has other hard links"}
+	}
+	return nil
+}
+
+// openSafe checks that f, just opened at the named path, is safe to take as
+// the log file, closing it if it isn't.
+func openSafe(name string, f *os.File) (*os.File, error) {
+	info, err := f.Stat()
+	if err == nil {
+		err = checkSafe(name, info)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package lumberjack
+
+import (
+	"os"
+)
+
+// oNofollow is 0, as symbolic links are only guarded against on Unix, where
+// files have owners to check.
+const oNofollow = 0
+
+// fileOwner reports that the owner and link count of files aren't known on
+// this platform.
+func fileOwner(info os.FileInfo) (uid int, links uint64, ok bool) {
+	return 0, 0, false
+}
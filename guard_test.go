@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestGuardSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("symbolic links are only guarded against when running as root on Unix")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestGuardSymlink", t)
+	defer os.RemoveAll(dir)
+	isNil(os.Chmod(dir, 0777), t)
+	other := makeTempDir("TestGuardSymlinkOther", t)
+	defer os.RemoveAll(other)
+
+	secret := filepath.Join(other, "secret")
+	isNil(ioutil.WriteFile(secret, []byte("secret"), 0600), t)
+	filename := logFile(dir)
+	isNil(os.Symlink(secret, filename), t)
+
+	// a link planted in a world-writable directory isn't written through.
+	l := &Logger{Filename: filename}
+	_, err := l.Write([]byte("boo!"))
+	var unsafe *UnsafeFileError
+	assert(errors.As(err, &unsafe), t, "expected an UnsafeFileError, got %v", err)
+	equals(filename, unsafe.Path, t)
+	isNil(l.Close(), t)
+	existsWithContent(secret, []byte("secret"), t)
+
+	// unless links there are followed on purpose.
+	l = &Logger{Filename: filename, FollowSymlinks: true}
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(secret, []byte("secretboo!"), t)
+
+	// or the directory is the writer's own.
+	isNil(os.Chmod(dir, 0755), t)
+	l = &Logger{Filename: filename}
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(secret, []byte("secretboo!foo!"), t)
+}
+
+func TestGuardOwner(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("files are only guarded when running as root on Unix")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestGuardOwner", t)
+	defer os.RemoveAll(dir)
+	isNil(os.Chmod(dir, 0777), t)
+
+	// a file of another user's isn't taken as the log file, nor rotated.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("foo!"), 0644), t)
+	isNil(os.Chown(filename, 65534, 65534), t)
+	l := &Logger{Filename: filename, MaxSize: 5}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	var unsafe *UnsafeFileError
+	assert(errors.As(err, &unsafe), t, "expected an UnsafeFileError, got %v", err)
+	existsWithContent(filename, []byte("foo!"), t)
+	fileCount(dir, 1, t)
+
+	// nor is a hard link to another file.
+	isNil(os.Remove(filename), t)
+	isNil(ioutil.WriteFile(filename+".other", []byte("foo!"), 0644), t)
+	isNil(os.Link(filename+".other", filename), t)
+	_, err = l.Write([]byte("boo!"))
+	assert(errors.As(err, &unsafe), t, "expected an UnsafeFileError, got %v", err)
+	existsWithContent(filename, []byte("foo!"), t)
+
+	// the Logger's own file is.
+	isNil(os.Remove(filename), t)
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!"), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+}
+
+func TestGuardTruncate(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("files are only guarded when running as root on Unix")
+	}
+	dir := makeTempDir("TestGuardTruncate", t)
+	defer os.RemoveAll(dir)
+	isNil(os.Chmod(dir, 0777), t)
+
+	// a hard link planted after the file was checked is refused before it
+	// is truncated.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename+".other", []byte("foo!"), 0644), t)
+	isNil(os.Link(filename+".other", filename), t)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+	_, err := l.openAt(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	var unsafe *UnsafeFileError
+	assert(errors.As(err, &unsafe), t, "expected an UnsafeFileError, got %v", err)
+	existsWithContent(filename+".other", []byte("foo!"), t)
+
+	// while the Logger's own file is truncated.
+	isNil(os.Remove(filename), t)
+	isNil(ioutil.WriteFile(filename, []byte("foo!"), 0644), t)
+	f, err := l.openAt(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	isNil(err, t)
+	isNil(f.Close(), t)
+	existsWithContent(filename, []byte{}, t)
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+)
+
+const oNofollow = syscall.O_NOFOLLOW
+
+// fileOwner returns the owner of the file info describes and its number of
+// hard links.
+func fileOwner(info os.FileInfo) (uid int, links uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), uint64(stat.Nlink), true
+}
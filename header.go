@@ -0,0 +1,126 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HeaderData holds the fields available to the Header template.
+type HeaderData struct {
+	// Time is when the log file was started.
+	Time time.Time
+
+	// Filename is the name of the log file.
+	Filename string
+
+	// Hostname is the name of the host, as reported by os.Hostname.
+	Hostname string
+
+	// PID is the id of the process.
+	PID int
+
+	// Seq is the number of the log file: with RotationCounter, the counter
+	// its backup will get, and otherwise the number of log files the Logger
+	// has started, counting from 1.
+	Seq int64
+
+	// Fields holds the HeaderFields, such as an application version.
+	Fields map[string]string
+
+	// Labels holds the Labels of the Logger.
+	Labels map[string]string
+}
+
+// writeHeader writes the Header to the log file that was just created, if a
+// Header is set, followed by what WriteHeader writes.  A header that can't be
+// written is recorded as an error, but doesn't fail the write that opened the
+// file.  This method assumes l.mu is held.
+func (l *Logger) writeHeader() {
+	l.files++
+	if l.Header != "" {
+		b, err := l.header()
+		if err == nil {
+			_, err = logFileWriter{l}.Write(b)
+		}
+		if err != nil {
+			l.recordError(writeError, fmt.Errorf("can't write log file header: %v", err))
+		}
+	}
+	if l.WriteHeader != nil {
+		if err := l.WriteHeader(logFileWriter{l}); err != nil {
+			l.recordError(writeError, fmt.Errorf("can't write log file header: %v", err))
+		}
+	}
+}
+
+// writeFooter has WriteFooter write to the end of the current log file, which
+// is about to be rotated, if it is set and the file is open.  A footer that
+// can't be written is recorded as an error, but doesn't fail the rotation.
+// This method assumes l.mu is held.
+func (l *Logger) writeFooter() {
+	if l.WriteFooter == nil || l.file == nil {
+		return
+	}
+	if err := l.WriteFooter(logFileWriter{l}); err != nil {
+		l.recordError(writeError, fmt.Errorf("can't write log file footer: %v", err))
+	}
+}
+
+// logFileWriter writes to the current log file of a Logger directly, counting
+// towards its size, for headers and footers.  It assumes l.mu is held.
+type logFileWriter struct {
+	l *Logger
+}
+
+// Write implements io.Writer.
+func (w logFileWriter) Write(p []byte) (int, error) {
+	n, err := w.l.file.Write(p)
+	w.l.size += int64(n)
+	w.l.streamWrite(p[:n])
+	return n, err
+}
+
+// headerFuncs are the functions available to Header: json quotes a string
+// for a header that is JSON.
+var headerFuncs = template.FuncMap{
+	"json": func(s string) string { return string(AppendJSONString(nil, s)) },
+}
+
+// header renders the Header for the current log file, ending it with a
+// newline if it doesn't end with one.
+func (l *Logger) header() ([]byte, error) {
+	if l.headerTmpl == nil || l.headerSrc != l.Header {
+		t, err := template.New("header").Funcs(headerFuncs).Parse(l.Header)
+		if err != nil {
+			return nil, err
+		}
+		l.headerTmpl, l.headerSrc = t, l.Header
+	}
+
+	hostname, _ := os.Hostname()
+	seq := l.files
+	if l.RotationCounter {
+		seq = l.peekCounter()
+	}
+	var buf bytes.Buffer
+	err := l.headerTmpl.Execute(&buf, HeaderData{
+		Time:     currentTime(),
+		Filename: l.filename(),
+		Hostname: hostname,
+		PID:      os.Getpid(),
+		Seq:      seq,
+		Fields:   l.HeaderFields,
+		Labels:   l.Labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n") {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
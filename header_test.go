@@ -0,0 +1,155 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeader", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      1000,
+		Header:       "# {{.Fields.app}} {{.Fields.version}} file {{.Seq}} pid {{.PID}} at {{.Time.Unix}}",
+		HeaderFields: map[string]string{"app": "foo", "version": "1.2.3"},
+	}
+	defer l.Close()
+
+	header := func(seq int) string {
+		return fmt.Sprintf("# foo 1.2.3 file %d pid %d at %d\n", seq, os.Getpid(), fakeTime().Unix())
+	}
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte(header(1)+"boo!\n"), t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte(header(2)+"foo!\n"), t)
+
+	// a file that is carried on with doesn't get another header.
+	isNil(l.Close(), t)
+	l2 := &Logger{Filename: filename, MaxSize: 1000, Header: l.Header, HeaderFields: l.HeaderFields}
+	defer l2.Close()
+	_, err = l2.Write([]byte("again\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte(header(2)+"foo!\nagain\n"), t)
+}
+
+func TestHeaderJSON(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeaderJSON", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      1000,
+		Header:       `{"app":{{json .Fields.app}},"seq":{{.Seq}}}`,
+		HeaderFields: map[string]string{"app": `foo "bar"`},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte(`{"app":"foo \"bar\"","seq":1}`+"\nboo!\n"), t)
+}
+
+func TestHeaderRotationCounter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeaderRotationCounter", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         1000,
+		RotationCounter: true,
+		Header:          "file {{.Seq}}",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("file 1\nboo!\n"), t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(filename, []byte("file 2\n"), t)
+}
+
+func TestHeaderError(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeaderError", t)
+	defer os.RemoveAll(dir)
+
+	// a header that can't be rendered doesn't keep logs from being written.
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 1000, Header: "{{.Nope"}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!\n"), t)
+	notNil(l.LastError(), t)
+	equals(int64(1), l.Stats().WriteErrors, t)
+}
+
+func TestWriteHeaderFooter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWriteHeaderFooter", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var errs []error
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  30,
+		Header:   "#Version: 1.0",
+		WriteHeader: func(w io.Writer) error {
+			_, err := io.WriteString(w, "#Fields: a b\n")
+			return err
+		},
+		WriteFooter: func(w io.Writer) error {
+			_, err := io.WriteString(w, "#End\n")
+			return err
+		},
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("x y\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("#Version: 1.0\n#Fields: a b\nx y\n"), t)
+
+	// rotating for the size ends the file with the footer, and starts the
+	// next one with the header.
+	newFakeTime()
+	_, err = l.Write([]byte("z w\n"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("#Version: 1.0\n#Fields: a b\nx y\n#End\n"), t)
+	existsWithContent(filename, []byte("#Version: 1.0\n#Fields: a b\nz w\n"), t)
+
+	// a failing hook doesn't fail the write.
+	l.WriteHeader = func(io.Writer) error { return errors.New("boom") }
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("v u\n"))
+	isNil(err, t)
+	equals(1, len(errs), t)
+
+	// and there is no footer on close.
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("#Version: 1.0\nv u\n"), t)
+}
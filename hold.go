@@ -0,0 +1,107 @@
+package lumberjack
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// BeginRotate rotates the log file like Rotate, but holds the backup back from
+// compression, moving to BackupDir, RotationMarker and removal until
+// CompleteRotate is called with its name, so that an external archiver can
+// process it in place first.  It returns the name of the backup, or "" if
+// there was no log file to rotate.  The backup counts towards MaxBackups and
+// the other retention rules in the meantime, but isn't removed by them.
+// Backups still held when the Logger is closed are left as they are.
+func (l *Logger) BeginRotate() (string, error) {
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return "", ErrClosed
+	}
+	// Compressing the backup as it is rotated would get ahead of the caller.
+	l.abortStream()
+	l.holding = true
+	l.rotated = ""
+	err := l.rotate()
+	l.holding = false
+	return l.rotated, err
+}
+
+// CompleteRotate lets cleanup go ahead with the backup returned by
+// BeginRotate, and starts it.
+func (l *Logger) CompleteRotate(backup string) error {
+	l.heldMu.Lock()
+	held := l.held[backup]
+	delete(l.held, backup)
+	l.heldMu.Unlock()
+	if !held {
+		return fmt.Errorf("lumberjack: %s isn't waiting for CompleteRotate", backup)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.Compress && !l.staged() {
+		l.writeMarker(backup, []string{backup})
+	}
+	if !l.isClosed() {
+		l.mill()
+	}
+	return nil
+}
+
+// hold holds back the backup just rotated to, if BeginRotate is rotating.
+// This method assumes l.mu is held.
+func (l *Logger) hold(backup string) {
+	l.rotated = backup
+	if !l.holding {
+		return
+	}
+	l.heldMu.Lock()
+	defer l.heldMu.Unlock()
+	if l.held == nil {
+		l.held = make(map[string]bool)
+	}
+	l.held[backup] = true
+}
+
+// unhold undoes hold, for a rotation that failed.  This method assumes l.mu is
+// held.
+func (l *Logger) unhold(backup string) {
+	l.rotated = ""
+	l.heldMu.Lock()
+	defer l.heldMu.Unlock()
+	delete(l.held, backup)
+}
+
+// isHeld reports whether f is a backup held back by BeginRotate.
+func (l *Logger) isHeld(f logInfo) bool {
+	l.heldMu.Lock()
+	defer l.heldMu.Unlock()
+	return l.held[filepath.Join(f.dir, uncompressedName(f.Name(), l.compressSuffixes()))]
+}
+
+// withoutHeld returns the files to keep and to remove that retain returned,
+// less the backups held back by BeginRotate, which are left alone.
+func (l *Logger) withoutHeld(keep []logInfo, remove []removal) ([]logInfo, []removal) {
+	l.heldMu.Lock()
+	n := len(l.held)
+	l.heldMu.Unlock()
+	if n == 0 {
+		return keep, remove
+	}
+	var kept []logInfo
+	for _, f := range keep {
+		if !l.isHeld(f) {
+			kept = append(kept, f)
+		}
+	}
+	var removed []removal
+	for _, f := range remove {
+		if !l.isHeld(f.logInfo) {
+			removed = append(removed, f)
+		}
+	}
+	return kept, removed
+}
@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBeginRotate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBeginRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		MaxBackups:     1,
+		Compress:       true,
+		CompressDirect: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	first, err := l.BeginRotate()
+	isNil(err, t)
+	equals(backupFile(dir), first, t)
+
+	// the held backup is left uncompressed, and isn't removed by MaxBackups
+	// either.
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	second := backupFile(dir)
+	isNil(l.millRunOnce(), t)
+	existsWithContent(first, []byte("boo!"), t)
+	notExist(first+compressSuffix, t)
+
+	// once it's let go, cleanup catches up.
+	isNil(l.CompleteRotate(first), t)
+	notNil(l.CompleteRotate(first), t)
+	isNil(l.Close(), t)
+	notExist(first, t)
+	notExist(first+compressSuffix, t)
+	existsWithContent(second+compressSuffix, gzipped([]byte("foo!"), t), t)
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,43 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WriteHook is called with each write before it is written, and returns what
+// to write instead: p itself, a changed copy of it, or nil along with an
+// error to reject the write, which fails with a *RejectedError wrapping that
+// error.  See Logger.Hooks.
+type WriteHook func(p []byte) ([]byte, error)
+
+// RejectedError is returned by writes that one of the Hooks rejected.
+type RejectedError struct {
+	// Hook is the index in Hooks of the hook that rejected the write.
+	Hook int
+
+	// Err is the error the hook returned.
+	Err error
+}
+
+// Error implements error.
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("lumberjack: write rejected: %v", e.Err)
+}
+
+// Unwrap returns the error the hook returned, for errors.Is and errors.As.
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+// runHooks passes p through the Hooks in turn, returning what the last one
+// returned, and whether that differs from p.
+func (l *Logger) runHooks(p []byte) (rec []byte, changed bool, err error) {
+	rec = p
+	for i, hook := range l.Hooks {
+		if rec, err = hook(rec); err != nil {
+			return nil, false, &RejectedError{Hook: i, Err: err}
+		}
+	}
+	return rec, len(l.Hooks) > 0 && !bytes.Equal(rec, p), nil
+}
@@ -0,0 +1,81 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	errHold := errors.New("legal hold")
+	var held bool
+	for _, size := range []int{0, 100} {
+		dir := makeTempDir(fmt.Sprintf("TestHooks%d", size), t)
+		defer os.RemoveAll(dir)
+
+		held = false
+		var seen [][]byte
+		l := &Logger{
+			Filename:   logFile(dir),
+			MaxSize:    100,
+			BufferSize: size,
+			Hooks: []WriteHook{
+				func(p []byte) ([]byte, error) {
+					if held {
+						return nil, errHold
+					}
+					return bytes.ToUpper(p), nil
+				},
+				func(p []byte) ([]byte, error) {
+					// each hook gets what the one before returned.
+					seen = append(seen, append([]byte(nil), p...))
+					return p, nil
+				},
+			},
+		}
+		defer l.Close()
+
+		n, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		equals(4, n, t)
+
+		held = true
+		n, err = l.Write([]byte("foo"))
+		equals(0, n, t)
+		var rejected *RejectedError
+		assert(errors.As(err, &rejected), t, "expected a *RejectedError, got %v", err)
+		equals(0, rejected.Hook, t)
+		assert(errors.Is(err, errHold), t, "expected the hook's error, got %v", err)
+
+		isNil(l.Close(), t)
+		existsWithContent(logFile(dir), []byte("BOO!"), t)
+		equals(1, len(seen), t)
+		equals("BOO!", string(seen[0]), t)
+	}
+}
+
+func TestHooksWithSequence(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestHooksWithSequence", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+		Sequence: SequencePrefix,
+		Hooks: []WriteHook{func(p []byte) ([]byte, error) {
+			return append([]byte("hooked "), p...), nil
+		}},
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte("1 hooked boo!\n"), t)
+}
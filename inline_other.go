@@ -0,0 +1,8 @@
+//go:build !tinygo
+// +build !tinygo
+
+package lumberjack
+
+// inlineMill is unset outside TinyGo builds, where compression and removal of
+// old log files are done in the background.
+const inlineMill = false
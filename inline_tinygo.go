@@ -0,0 +1,10 @@
+//go:build tinygo
+// +build tinygo
+
+package lumberjack
+
+// inlineMill is set for TinyGo builds, where compression and removal of old
+// log files run right after rotation, on the writing goroutine, rather than on
+// a goroutine of their own, and failed runs aren't retried on a timer but on
+// the next rotation.
+const inlineMill = true
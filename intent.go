@@ -0,0 +1,97 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// intentSuffix is appended to the log file name to name the intent file.
+const intentSuffix = ".intent"
+
+// rotationIntent is what the intent file holds while a rotation is under way.
+type rotationIntent struct {
+	Backup string `json:"backup"`
+}
+
+// writeIntent records, if RotationIntent is set, that the named log file is
+// about to be renamed to backup, syncing the record to disk before the rename
+// happens.
+func (l *Logger) writeIntent(name, backup string) error {
+	if !l.RotationIntent {
+		return nil
+	}
+	b, _ := json.Marshal(rotationIntent{Backup: backup})
+	f, err := osOpenFile(name+intentSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, l.markerMode())
+	if err != nil {
+		return fmt.Errorf("can't write rotation intent: %s", err)
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return fmt.Errorf("can't write rotation intent: %s", err)
+	}
+	return nil
+}
+
+// clearIntent removes the intent file of the named log file once a rotation
+// is complete, with the new log file created and its header written.
+func (l *Logger) clearIntent(name string) {
+	if !l.RotationIntent {
+		return
+	}
+	if err := os.Remove(name + intentSuffix); err != nil && !os.IsNotExist(err) {
+		l.recordError(otherError, err)
+	}
+}
+
+// recoverRotation finishes a rotation that was interrupted, as by a crash,
+// according to the intent file left behind, if there is one.  If the backup
+// was made but the new log file wasn't, or was left empty, without its
+// header, it returns the info of the backup, for the new log file to be
+// created like it, and otherwise nil.  The intent file is removed either way,
+// so a broken one is only tried once.  This method assumes l.mu is held.
+func (l *Logger) recoverRotation() os.FileInfo {
+	if !l.RotationIntent {
+		return nil
+	}
+	name := l.filename()
+	b, err := ioutil.ReadFile(name + intentSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	defer l.clearIntent(name)
+	var intent rotationIntent
+	if err == nil {
+		err = json.Unmarshal(b, &intent)
+	}
+	if err != nil {
+		// cut short before the rename, which waits for the intent to be on
+		// disk.
+		return nil
+	}
+	backup, err := osStat(intent.Backup)
+	if err != nil {
+		// the log file was never renamed.
+		return nil
+	}
+	info, err := osStat(name)
+	if os.IsNotExist(err) {
+		return withSecurity(backup, intent.Backup)
+	}
+	if err == nil && info.Size() == 0 && !l.Adopt {
+		// created but not yet given its header, if any.
+		if err := os.Remove(name); err != nil {
+			l.recordError(otherError, fmt.Errorf("can't recover rotation: %v", err))
+			return nil
+		}
+		return withSecurity(backup, intent.Backup)
+	}
+	return nil
+}
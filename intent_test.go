@@ -0,0 +1,88 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestRotationIntent(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRotationIntent", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 100, RotationIntent: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the intent is gone once the rotation is done.
+	notExist(logFile(dir)+intentSuffix, t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	fileCount(dir, 2, t)
+}
+
+func TestRotationIntentRecovery(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for i, leftEmpty := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprintf("TestRotationIntentRecovery%d", i), t)
+		defer os.RemoveAll(dir)
+
+		// a crash after the log file was renamed to the backup, before the
+		// new log file was created or given its header.
+		backup := backupFile(dir)
+		isNil(ioutil.WriteFile(backup, []byte("boo!"), 0600), t)
+		isNil(os.Chmod(backup, 0600), t)
+		if leftEmpty {
+			isNil(ioutil.WriteFile(logFile(dir), nil, 0644), t)
+		}
+		isNil(ioutil.WriteFile(logFile(dir)+intentSuffix, []byte(`{"backup":`+fmt.Sprintf("%q", backup)+`}`), 0644), t)
+
+		l := &Logger{Filename: logFile(dir), MaxSize: 100, RotationIntent: true, Header: "header\n"}
+		_, err := l.Write([]byte("foo!"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+
+		existsWithContent(logFile(dir), []byte("header\nfoo!"), t)
+		existsWithContent(backup, []byte("boo!"), t)
+		notExist(logFile(dir)+intentSuffix, t)
+		fileCount(dir, 2, t)
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(logFile(dir))
+			isNil(err, t)
+			equals(os.FileMode(0600), info.Mode(), t)
+		}
+	}
+}
+
+func TestRotationIntentNotRenamed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for i, intent := range []string{`{"backup":"nowhere.log"}`, `{"bac`} {
+		dir := makeTempDir(fmt.Sprintf("TestRotationIntentNotRenamed%d", i), t)
+		defer os.RemoveAll(dir)
+
+		// a crash before the log file was renamed leaves it as it was.
+		isNil(ioutil.WriteFile(logFile(dir), []byte("boo!"), 0644), t)
+		isNil(ioutil.WriteFile(logFile(dir)+intentSuffix, []byte(intent), 0644), t)
+
+		l := &Logger{Filename: logFile(dir), MaxSize: 100, RotationIntent: true}
+		_, err := l.Write([]byte("foo!"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+
+		existsWithContent(logFile(dir), []byte("boo!foo!"), t)
+		notExist(logFile(dir)+intentSuffix, t)
+		fileCount(dir, 1, t)
+	}
+}
@@ -0,0 +1,83 @@
+package lumberjack
+
+import "time"
+
+// nextRotation returns the first RotationInterval boundary after t.  Intervals
+// shorter than a day are counted from midnight, starting over every midnight,
+// while longer ones are rounded to whole days, counted from the midnight
+// before t.  Midnight is in local time with LocalTime, otherwise UTC.
+func (l *Logger) nextRotation(t time.Time) time.Time {
+	loc := time.UTC
+	if l.LocalTime {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	tomorrow := midnight.AddDate(0, 0, 1)
+	if l.RotationInterval >= day {
+		return midnight.AddDate(0, 0, int(l.RotationInterval/day))
+	}
+	next := midnight.Add((t.Sub(midnight)/l.RotationInterval + 1) * l.RotationInterval)
+	if next.After(tomorrow) {
+		next = tomorrow
+	}
+	return next
+}
+
+// scheduleRotation arranges for the log file, last written at the given time,
+// to be rotated at the next RotationInterval boundary.  It returns false if
+// that boundary has already passed, so the file is due for rotation now.  This
+// method assumes l.mu is held.
+func (l *Logger) scheduleRotation(last time.Time) bool {
+	if l.RotationInterval <= 0 {
+		return true
+	}
+	l.stopRotationTimer()
+	l.rotateAt = l.nextRotation(last)
+	d := l.rotateAt.Sub(currentTime())
+	if d <= 0 {
+		return false
+	}
+	l.rotateTimer = time.AfterFunc(d, l.rotateOnTime)
+	return true
+}
+
+// rotationDue reports whether the current log file has reached the end of its
+// RotationInterval.  Empty files aren't worth rotating, they are just carried
+// over to the next interval.  This method assumes l.mu is held.
+func (l *Logger) rotationDue() bool {
+	if l.RotationInterval <= 0 || currentTime().Before(l.rotateAt) {
+		return false
+	}
+	if l.size == 0 {
+		return !l.scheduleRotation(currentTime())
+	}
+	return true
+}
+
+// rotateOnTime rotates the log file at the end of its RotationInterval, even
+// if nothing is being written, so that every interval gets a file of its own.
+func (l *Logger) rotateOnTime() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() || l.file == nil {
+		return
+	}
+	if !l.rotationDue() {
+		// The clock was behind the timer, or the file is empty.
+		l.scheduleRotation(currentTime())
+		return
+	}
+	if err := l.rotate(); err != nil {
+		l.recordError(writeError, err)
+	}
+}
+
+// stopRotationTimer cancels the rotation arranged by scheduleRotation, if any.
+// This method assumes l.mu is held.
+func (l *Logger) stopRotationTimer() {
+	if l.rotateTimer != nil {
+		l.rotateTimer.Stop()
+		l.rotateTimer = nil
+	}
+}
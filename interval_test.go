@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNextRotation(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		interval time.Duration
+		t, want  time.Time
+	}{
+		{time.Hour, at(1, 10, 30), at(1, 11, 0)},
+		{time.Hour, at(1, 11, 0), at(1, 12, 0)},
+		{15 * time.Minute, at(1, 23, 50), at(2, 0, 0)},
+		// intervals that don't divide a day start over at midnight.
+		{7 * time.Hour, at(1, 20, 0), at(1, 21, 0)},
+		{7 * time.Hour, at(1, 22, 0), at(2, 0, 0)},
+		{24 * time.Hour, at(1, 10, 30), at(2, 0, 0)},
+		{48 * time.Hour, at(1, 0, 0), at(3, 0, 0)},
+	}
+	for i, tt := range tests {
+		l := &Logger{RotationInterval: tt.interval}
+		got := l.nextRotation(tt.t)
+		assert(got.Equal(tt.want), t, "%d: expected %v, got %v", i, tt.want, got)
+	}
+}
+
+func TestRotationInterval(t *testing.T) {
+	defer func(t time.Time) { fakeCurrentTime = t }(fakeCurrentTime)
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRotationInterval", t)
+	defer os.RemoveAll(dir)
+
+	fakeCurrentTime = time.Date(2020, time.March, 1, 10, 59, 0, 0, time.UTC)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxSize:          1000,
+		RotationInterval: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo"))
+	isNil(err, t)
+
+	// the first write after the hour goes to a new file.
+	fakeCurrentTime = fakeCurrentTime.Add(2 * time.Minute)
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("foo"), t)
+	existsWithContent(filename, []byte("bar"), t)
+
+	// without writes, the file is rotated when the timer fires.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Hour)
+	l.rotateOnTime()
+	existsWithContent(backupFile(dir), []byte("bar"), t)
+	existsWithContent(filename, []byte{}, t)
+	fileCount(dir, 3, t)
+
+	// but an empty file is just carried over.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Hour)
+	l.rotateOnTime()
+	fileCount(dir, 3, t)
+}
+
+func TestRotationIntervalLeftover(t *testing.T) {
+	defer func(t time.Time) { fakeCurrentTime = t }(fakeCurrentTime)
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRotationIntervalLeftover", t)
+	defer os.RemoveAll(dir)
+
+	// a file written to yesterday.
+	fakeCurrentTime = time.Date(2020, time.March, 2, 9, 0, 0, 0, time.UTC)
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("foo"), 0644), t)
+	yesterday := fakeCurrentTime.Add(-12 * time.Hour)
+	isNil(os.Chtimes(filename, yesterday, yesterday), t)
+
+	l := &Logger{
+		Filename:         filename,
+		MaxSize:          1000,
+		RotationInterval: 24 * time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("foo"), t)
+	existsWithContent(filename, []byte("bar"), t)
+}
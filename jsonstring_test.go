@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendJSONString(t *testing.T) {
+	for _, s := range []string{
+		"", "boo!", `say "hi"\n`, "a\tb\r\nc", "\x00\x1f", "<a href='x'>&</a>",
+		"héllo, 世界", "line\u2028sep\u2029para",
+	} {
+		want, err := json.Marshal(s)
+		isNil(err, t)
+		equals(string(want), string(AppendJSONString(nil, s)), t)
+		equals(string(want), string(appendJSONBytes(nil, []byte(s))), t)
+	}
+	equals(`x"y"`, string(AppendJSONString([]byte("x"), "y")), t)
+
+	// encoding/json has written invalid UTF-8 as U+FFFD either escaped or
+	// not, depending on the version, which decode the same.
+	var got string
+	isNil(json.Unmarshal(AppendJSONString(nil, "bad \xff utf8 \xe2\x80"), &got), t)
+	equals("bad \ufffd utf8 \ufffd\ufffd", got, t)
+}
+
+func TestAppendJSONStringAllocs(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	s := `GET /<index>.html "200" & more` + "\n"
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendJSONString(buf[:0], s)
+		buf = appendJSONBytes(buf[:0], []byte("boo!"))
+		buf = appendCompactJSON(buf[:0], []byte(`{"a": [1, 2]}`))
+	})
+	equals(0.0, allocs, t)
+}
+
+func TestAppendCompactJSON(t *testing.T) {
+	for _, s := range []string{
+		`{"level":"info"}`,
+		"{\n  \"msg\": \"a b\\\"  c\",\n\t\"n\": [1, 2, 3]\n}",
+		`{"html":"<b>&</b>","sep":"` + "\u2028" + `"}`,
+		`"plain string"`,
+		`12.5`,
+	} {
+		want, err := json.Marshal(json.RawMessage(s))
+		isNil(err, t)
+		equals(string(want), string(appendCompactJSON(nil, []byte(s))), t)
+	}
+}
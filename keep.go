@@ -0,0 +1,65 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// keepSuffix is added to the name of a backup for the marker file that pins
+// it.
+const keepSuffix = ".keep"
+
+// pinned reports whether a backup, given by its files, is pinned by a marker
+// file next to it, named after the uncompressed backup or any of its files
+// with keepSuffix added, such as foo-2016-11-04T18-30-00.000.log.keep.
+// Pinned backups are left out of retention: they are never removed, and don't
+// count towards MaxBackups, MaxTotalSize or the others.
+func (l *Logger) pinned(files []logInfo) bool {
+	for i, f := range files {
+		names := []string{f.Name()}
+		if i == 0 {
+			names = append(names, uncompressedName(f.Name(), l.compressSuffixes()))
+		}
+		for _, name := range names {
+			if _, err := osStat(filepath.Join(f.dir, name+keepSuffix)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renameKeep renames the marker file pinning the backup from, if any, in dir,
+// for the backup having been renamed to the name to.
+func (l *Logger) renameKeep(dir, from, to string) error {
+	suffixes := l.compressSuffixes()
+	for _, keep := range [][2]string{{from, to}, {uncompressedName(from, suffixes), uncompressedName(to, suffixes)}} {
+		src := filepath.Join(dir, keep[0]+keepSuffix)
+		if _, err := osStat(src); err != nil {
+			continue
+		}
+		if err := os.Rename(src, filepath.Join(dir, keep[1]+keepSuffix)); err != nil {
+			return fmt.Errorf("can't rename keep file: %s", err)
+		}
+	}
+	return nil
+}
+
+// moveKeep moves the marker file pinning the backup f, if any, to the backup
+// directory along with it.
+func (l *Logger) moveKeep(f logInfo) error {
+	if f.dir == l.backupDir() {
+		return nil
+	}
+	for _, name := range []string{f.Name(), uncompressedName(f.Name(), l.compressSuffixes())} {
+		src := filepath.Join(f.dir, name+keepSuffix)
+		if _, err := osStat(src); err != nil {
+			continue
+		}
+		if err := moveFile(src, l.archivePath(src), l.reflinks(), l.chown); err != nil {
+			return fmt.Errorf("can't move keep file: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeepFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestKeepFile", t)
+	defer os.RemoveAll(dir)
+
+	// four backups, the newest first, rotated days ago.
+	var names []string
+	for _, days := range []int{0, 1, 2, 10} {
+		age := time.Duration(days) * 24 * time.Hour
+		name := filepath.Join(dir, "foobar-"+fakeTime().Add(-age).UTC().Format(backupTimeFormat)+".log")
+		isNil(ioutil.WriteFile(name, []byte("boo!"), 0644), t)
+		names = append(names, name)
+	}
+	// the second and the oldest are pinned.
+	isNil(ioutil.WriteFile(names[1]+keepSuffix, nil, 0644), t)
+	isNil(ioutil.WriteFile(names[3]+keepSuffix, nil, 0644), t)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxAge:     5,
+		MaxBackups: 1,
+	}
+	defer l.Close()
+	isNil(l.millRunOnce(), t)
+
+	// the pinned backups stay, past MaxAge too, and don't count towards
+	// MaxBackups, so the newest stays as well.
+	existsWithContent(names[0], []byte("boo!"), t)
+	existsWithContent(names[1], []byte("boo!"), t)
+	notExist(names[2], t)
+	existsWithContent(names[3], []byte("boo!"), t)
+
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(3, len(backups), t)
+	equals(false, backups[0].Pinned, t)
+	equals(true, backups[1].Pinned, t)
+	equals(true, backups[2].Pinned, t)
+
+	// removing the .keep file lets cleanup have it.
+	isNil(os.Remove(names[3]+keepSuffix), t)
+	isNil(l.millRunOnce(), t)
+	notExist(names[3], t)
+	existsWithContent(names[1], []byte("boo!"), t)
+}
+
+func TestKeepFileCompressed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestKeepFileCompressed", t)
+	defer os.RemoveAll(dir)
+
+	backupDir := filepath.Join(dir, "old")
+	name := filepath.Join(dir, "foobar-"+fakeTime().Add(-10*24*time.Hour).UTC().Format(backupTimeFormat)+".log")
+	isNil(ioutil.WriteFile(name, []byte("boo!"), 0644), t)
+	isNil(ioutil.WriteFile(name+keepSuffix, nil, 0644), t)
+
+	l := &Logger{
+		Filename:  logFile(dir),
+		BackupDir: backupDir,
+		MaxAge:    5,
+		Compress:  true,
+	}
+	defer l.Close()
+	isNil(l.millRunOnce(), t)
+
+	// a pinned backup is still compressed and moved, along with its .keep
+	// file, and stays pinned.
+	archived := filepath.Join(backupDir, filepath.Base(name))
+	exists(archived+compressSuffix, t)
+	exists(archived+keepSuffix, t)
+	notExist(name+keepSuffix, t)
+	isNil(l.millRunOnce(), t)
+	exists(archived+compressSuffix, t)
+}
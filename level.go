@@ -0,0 +1,211 @@
+package lumberjack
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// The levels returned by DetectLevel.
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// levelAliases maps the lowercase level names used by common logging packages
+// to the levels returned by DetectLevel.
+var levelAliases = map[string]string{
+	"trace":    LevelTrace,
+	"trc":      LevelTrace,
+	"debug":    LevelDebug,
+	"dbg":      LevelDebug,
+	"info":     LevelInfo,
+	"inf":      LevelInfo,
+	"notice":   LevelInfo,
+	"warn":     LevelWarn,
+	"warning":  LevelWarn,
+	"wrn":      LevelWarn,
+	"error":    LevelError,
+	"err":      LevelError,
+	"eror":     LevelError,
+	"erro":     LevelError,
+	"fatal":    LevelFatal,
+	"ftl":      LevelFatal,
+	"crit":     LevelFatal,
+	"critical": LevelFatal,
+	"panic":    LevelFatal,
+	"dpanic":   LevelFatal,
+}
+
+var (
+	// jsonLevel matches the level field of JSON lines, as written by slog's
+	// JSONHandler ("level":"INFO"), zap ("level":"info"), logrus
+	// ("level":"warning") and others.
+	jsonLevel = regexp.MustCompile(`"(?:level|lvl|severity)"\s*:\s*"([A-Za-z]+)`)
+
+	// keyValueLevel matches the level of logfmt lines, as written by slog's
+	// TextHandler (level=INFO) and logrus' text formatter (level=info).
+	keyValueLevel = regexp.MustCompile(`(?:^|\s)(?:level|lvl)="?([A-Za-z]+)`)
+)
+
+// prefixFields is how many fields at the start of a line DetectLevel looks at
+// for a leveled text prefix, which allows for a date and time before it.
+const prefixFields = 3
+
+// DetectLevel returns the level of a log line, or "" if it has none.  It
+// recognizes the level field of JSON lines and logfmt style level=... pairs,
+// as written by slog, zap and logrus, and otherwise a level among the first
+// few fields of the line, such as "ERROR", "[WARN]" or "info:".  The level is
+// returned as one of LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError
+// and LevelFatal, with aliases such as "warning" or "panic" mapped to them.
+func DetectLevel(line []byte) string {
+	trimmed := bytes.TrimLeft(line, " \t")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if m := jsonLevel.FindSubmatch(trimmed); m != nil {
+			return levelAliases[strings.ToLower(string(m[1]))]
+		}
+		return ""
+	}
+	if m := keyValueLevel.FindSubmatch(trimmed); m != nil {
+		if level := levelAliases[strings.ToLower(string(m[1]))]; level != "" {
+			return level
+		}
+	}
+	fields := bytes.Fields(trimmed)
+	if len(fields) > prefixFields {
+		fields = fields[:prefixFields]
+	}
+	for _, f := range fields {
+		f = bytes.Trim(f, "[]():|<>")
+		if level := levelAliases[strings.ToLower(string(f))]; level != "" {
+			return level
+		}
+	}
+	return ""
+}
+
+// LevelSplitter is an io.Writer that routes each line written to it to a file
+// for its level, such as error.log and info.log, by way of a MultiLogger.
+//
+// Lines without a level, such as the continuation lines of a stack trace, go
+// with the line before them.  Writes don't need to be split on lines: a
+// partial line is held until the rest of it is written, or until Close.
+type LevelSplitter struct {
+	// Loggers holds the Logger for each level, named after the level, or after
+	// what Files maps the level to.  Use its NewLogger to turn those names
+	// into file names, e.g. /var/log/myapp/<name>.log.  It mustn't be nil.
+	Loggers *MultiLogger
+
+	// Files maps levels to Logger names, which makes it possible to send
+	// several levels to one file, e.g. both LevelWarn and LevelError to
+	// "error".  Levels that aren't in Files use the level as the name.
+	Files map[string]string
+
+	// Default is the level of lines that have none, when there is no line
+	// before them to go with.  The default is LevelInfo.
+	Default string
+
+	// Level returns the level of a line, or "" if it has none.  The default
+	// is DetectLevel.
+	Level func(line []byte) string
+
+	mu      sync.Mutex
+	last    string
+	partial []byte
+}
+
+// Write implements io.Writer.  It writes every complete line in p to the
+// Logger for its level, writing runs of lines with the same level at once.
+func (s *LevelSplitter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+
+	var err error
+	start, i := 0, 0
+	runLevel := ""
+	for {
+		n := bytes.IndexByte(data[i:], '\n')
+		if n < 0 {
+			break
+		}
+		level := s.levelOf(data[i : i+n+1])
+		if i > start && level != runLevel {
+			if errWrite := s.writeLevel(runLevel, data[start:i]); err == nil {
+				err = errWrite
+			}
+			start = i
+		}
+		runLevel = level
+		i += n + 1
+	}
+	if i > start {
+		if errWrite := s.writeLevel(runLevel, data[start:i]); err == nil {
+			err = errWrite
+		}
+	}
+	if i < len(data) {
+		s.partial = append([]byte(nil), data[i:]...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes out a partial line held from the last Write, if any, and closes
+// all the Loggers.
+func (s *LevelSplitter) Close() error {
+	s.mu.Lock()
+	var err error
+	if len(s.partial) > 0 {
+		err = s.writeLevel(s.levelOf(s.partial), s.partial)
+		s.partial = nil
+	}
+	s.mu.Unlock()
+	if errClose := s.Loggers.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// levelOf returns the level to file line under, and remembers it for the lines
+// after it.  It assumes that s.mu is held.
+func (s *LevelSplitter) levelOf(line []byte) string {
+	detect := s.Level
+	if detect == nil {
+		detect = DetectLevel
+	}
+	level := detect(line)
+	if level == "" {
+		level = s.last
+	}
+	if level == "" {
+		level = s.Default
+	}
+	if level == "" {
+		level = LevelInfo
+	}
+	s.last = level
+	return level
+}
+
+// writeLevel writes p to the Logger for level.
+func (s *LevelSplitter) writeLevel(level string, p []byte) error {
+	name := level
+	if f, ok := s.Files[level]; ok {
+		name = f
+	}
+	_, err := s.Loggers.Logger(name).Write(p)
+	return err
+}
@@ -0,0 +1,68 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"time":"2024-01-02T03:04:05Z","level":"INFO","msg":"hi"}`, LevelInfo},
+		{`{"level":"warning","msg":"logrus"}`, LevelWarn},
+		{`{"ts":1.5,"level":"dpanic","msg":"zap"}`, LevelFatal},
+		{`{"severity": "ERROR", "message": "gcp"}`, LevelError},
+		{`{"msg":"level=error in the message"}`, ""},
+		{`time=2024-01-02T03:04:05Z level=WARN msg=hi`, LevelWarn},
+		{`time="2024-01-02T03:04:05Z" level=info msg="logrus text"`, LevelInfo},
+		{`level="debug" msg=quoted`, LevelDebug},
+		{`2024/01/02 03:04:05 [ERROR] failed`, LevelError},
+		{`ERROR: failed`, LevelError},
+		{`2024-01-02 03:04:05 warn something`, LevelWarn},
+		{`just a message with an error in it`, ""},
+		{`goroutine 1 [running]:`, ""},
+		{``, ""},
+	}
+	for _, test := range tests {
+		equals(test.want, DetectLevel([]byte(test.line)), t)
+	}
+}
+
+func TestLevelSplitter(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestLevelSplitter", t)
+	defer os.RemoveAll(dir)
+
+	name := func(level string) string { return filepath.Join(dir, level+".log") }
+	s := &LevelSplitter{
+		Loggers: &MultiLogger{
+			NewLogger: func(level string) *Logger { return &Logger{Filename: name(level)} },
+		},
+		Files: map[string]string{LevelWarn: LevelError},
+	}
+	defer s.Close()
+
+	lines := "no level yet\n" +
+		"INFO starting\n" +
+		"ERROR crashed\n" +
+		"goroutine 1 [running]:\n" +
+		"main.main()\n" +
+		"WARN careful\n" +
+		"INF"
+	n, err := s.Write([]byte(lines))
+	isNil(err, t)
+	equals(len(lines), n, t)
+
+	// the rest of a partial line is written later.
+	_, err = s.Write([]byte("O done\nDEBUG partial"))
+	isNil(err, t)
+	isNil(s.Close(), t)
+
+	existsWithContent(name(LevelInfo), []byte("no level yet\nINFO starting\nINFO done\n"), t)
+	existsWithContent(name(LevelError), []byte("ERROR crashed\ngoroutine 1 [running]:\nmain.main()\nWARN careful\n"), t)
+	existsWithContent(name(LevelDebug), []byte("DEBUG partial"), t)
+	fileCount(dir, 3, t)
+}
@@ -4,8 +4,12 @@
 package lumberjack
 
 import (
+	"fmt"
 	"io/fs"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
 	"syscall"
 	"testing"
 	"time"
@@ -113,6 +117,217 @@ func TestMaintainOwner(t *testing.T) {
 	equals(666, fakeFS.files[filename].gid, t)
 }
 
+func TestOwnerFromDir(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing owners requires root")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOwnerFromDir", t)
+	defer os.RemoveAll(dir)
+	isNil(os.Chown(dir, 555, 666), t)
+
+	// the previous log file belongs to someone else.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+	isNil(os.Chown(filename, 777, 888), t)
+
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      100,
+		Compress:     true,
+		OwnerFromDir: true,
+	}
+	defer l.Close()
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	for _, name := range []string{filename, backupFile(dir) + compressSuffix} {
+		info, err := os.Stat(name)
+		isNil(err, t)
+		stat := info.Sys().(*syscall.Stat_t)
+		equals(uint32(555), stat.Uid, t)
+		equals(uint32(666), stat.Gid, t)
+	}
+}
+
+func TestSetgidDir(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing owners requires root")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, skip := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprintf("TestSetgidDir%v", skip), t)
+		defer os.RemoveAll(dir)
+		isNil(os.Chown(dir, 0, 666), t)
+		isNil(os.Chmod(dir, 0755|os.ModeSetgid), t)
+
+		// the previous log file predates the setgid bit.
+		filename := logFile(dir)
+		isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+		isNil(os.Chown(filename, 777, 888), t)
+
+		l := &Logger{
+			Filename:             filename,
+			MaxSize:              100,
+			Compress:             true,
+			SkipChownInSetgidDir: skip,
+		}
+		defer l.Close()
+
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		isNil(l.Close(), t)
+
+		// the group is the directory's either way, and the owner is only
+		// copied over if chown isn't skipped.
+		uid := uint32(777)
+		if skip {
+			uid = 0
+		}
+		for _, name := range []string{filename, backupFile(dir) + compressSuffix} {
+			info, err := os.Stat(name)
+			isNil(err, t)
+			stat := info.Sys().(*syscall.Stat_t)
+			equals(uid, stat.Uid, t)
+			equals(uint32(666), stat.Gid, t)
+		}
+	}
+}
+
+func TestNoChown(t *testing.T) {
+	fakeFS := newFakeFS()
+	osChown = fakeFS.Chown
+	osStat = fakeFS.Stat
+	defer func() {
+		osChown = os.Chown
+		osStat = os.Stat
+	}()
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestNoChown", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+		Compress: true,
+		NoChown:  true,
+	}
+	defer l.Close()
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	exists(backupFile(dir)+compressSuffix, t)
+	equals(0, len(fakeFS.files), t)
+}
+
+func TestChownPolicy(t *testing.T) {
+	osChown = func(name string, uid, gid int) error {
+		return &os.PathError{Op: "chown", Path: name, Err: syscall.EPERM}
+	}
+	defer func() { osChown = os.Chown }()
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, policy := range []ChownPolicy{ChownFail, ChownIgnore, ChownEvent} {
+		dir := makeTempDir("TestChownPolicy"+policy.String(), t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+
+		var events []Event
+		l := &Logger{
+			Filename:    filename,
+			MaxSize:     100,
+			ChownPolicy: policy,
+			OnEvent: func(e Event) {
+				if e.Type != EventRotated {
+					events = append(events, e)
+				}
+			},
+		}
+		defer l.Close()
+
+		newFakeTime()
+		err := l.Rotate()
+		if policy == ChownFail {
+			notNil(err, t)
+			continue
+		}
+		isNil(err, t)
+		existsWithContent(backupFile(dir), []byte("boo!"), t)
+		if policy == ChownEvent {
+			equals(1, len(events), t)
+			equals(EventChownFailed, events[0].Type, t)
+			equals(filename, events[0].Path, t)
+		} else {
+			equals(0, len(events), t)
+		}
+	}
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFlushOnSignal", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, BufferSize: 100}
+	defer l.Close()
+	defer RegisterFlush(l)()
+
+	// SIGWINCH is ignored by default, so raising it again is harmless.
+	stop := FlushOnSignal(syscall.SIGWINCH)
+	defer stop()
+	l.mu.Lock()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	l.mu.Unlock()
+	isNil(syscall.Kill(os.Getpid(), syscall.SIGWINCH), t)
+
+	for i := 0; i < 100; i++ {
+		if b, _ := ioutil.ReadFile(filename); string(b) == "boo!" {
+			break
+		}
+		<-time.After(time.Millisecond)
+	}
+	existsWithContent(filename, []byte("boo!"), t)
+}
+
+func TestFlushOnSignalSingleWriter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFlushOnSignalSingleWriter", t)
+	defer os.RemoveAll(dir)
+
+	// the handler syncs the Logger while it writes and rotates, which a
+	// SingleWriter only does under the mutex once it is registered.
+	l := &Logger{Filename: logFile(dir), MaxSize: 100, SingleWriter: true}
+	defer l.Close()
+	defer RegisterFlush(l)()
+	stop := FlushOnSignal(syscall.SIGWINCH)
+	defer stop()
+
+	for i := 0; i < 2000; i++ {
+		if i == 100 {
+			isNil(syscall.Kill(os.Getpid(), syscall.SIGWINCH), t)
+		}
+		_, err := l.Write([]byte("boo!\n"))
+		isNil(err, t)
+	}
+}
+
 func testCompressMaintainMode(t *testing.T, fileMode fs.FileMode) {
 	currentTime = fakeTime
 
@@ -150,9 +365,8 @@ func testCompressMaintainMode(t *testing.T, fileMode fs.FileMode) {
 	err = l.Rotate()
 	isNil(err, t)
 
-	// we need to wait a little bit since the files get compressed on a different
-	// goroutine.
-	<-time.After(10 * time.Millisecond)
+	// Close waits for the files to get compressed on a different goroutine.
+	isNil(l.Close(), t)
 
 	// a compressed version of the log file should now exist with the correct
 	// mode.
@@ -231,6 +445,129 @@ func TestCompressMaintainOwner(t *testing.T) {
 	equals(666, fakeFS.files[filename2+compressSuffix+tmpSuffix].gid, t)
 }
 
+func TestBackupMode(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupMode", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		BackupMode: 0440,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	info, err := os.Stat(backupFile(dir))
+	isNil(err, t)
+	equals(os.FileMode(0440), info.Mode(), t)
+
+	// the active file keeps its regular mode.
+	info, err = os.Stat(filename)
+	isNil(err, t)
+	equals(os.FileMode(0644), info.Mode(), t)
+}
+
+func TestCompressBackupMode(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressBackupMode", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress:   true,
+		Filename:   filename,
+		MaxSize:    10,
+		BackupMode: 0440,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	notExist(backupFile(dir), t)
+	info, err := os.Stat(backupFile(dir) + compressSuffix)
+	isNil(err, t)
+	equals(os.FileMode(0440), info.Mode(), t)
+}
+
+func TestBackupAttr(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupAttr", t)
+	defer os.RemoveAll(dir)
+
+	// Skip if this filesystem or process can't set inode flags.
+	probe := filepath.Join(dir, "probe")
+	isNil(ioutil.WriteFile(probe, nil, 0644), t)
+	if err := setFileAttr(probe, AttrAppendOnly); err != nil {
+		t.Skipf("can't set inode flags: %v", err)
+	}
+	isNil(removeFile(probe), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		MaxBackups: 1,
+		BackupAttr: AttrAppendOnly,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+	defer unprotect(first)
+
+	// we need to wait a little bit since the attributes get set on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	equals(int32(fsAppendFl), inodeFlags(first, t)&fsAppendFl, t)
+	assert(os.Remove(first) != nil, t, "expected append-only backup to resist removal")
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	second := backupFile(dir)
+	defer unprotect(second)
+
+	<-time.After(10 * time.Millisecond)
+
+	// the first backup is protected, but still gets cleaned up.
+	notExist(first, t)
+	equals(int32(fsAppendFl), inodeFlags(second, t)&fsAppendFl, t)
+}
+
+func inodeFlags(name string, t testing.TB) int32 {
+	f, err := os.Open(name)
+	isNilUp(err, t, 1)
+	defer f.Close()
+	var flags int32
+	isNilUp(ioctl(f, fsIocGetFlags, &flags), t, 1)
+	return flags
+}
+
 type fakeFile struct {
 	uid int
 	gid int
@@ -292,3 +629,87 @@ func TestFileModeIsSet(t *testing.T) {
 		equals(c.isSet, l.fileModeIsSet(), t)
 	}
 }
+
+func TestLowerThreadPriority(t *testing.T) {
+	done := make(chan error)
+	go func() {
+		// never unlocked, so the thread exits along with the goroutine.
+		runtime.LockOSThread()
+		err := lowerThreadPriority()
+		if err == nil {
+			// the nice value of this thread alone should have changed.
+			var prio int
+			prio, err = syscall.Getpriority(syscall.PRIO_PROCESS, syscall.Gettid())
+			if err == nil && prio != 20-19 {
+				err = fmt.Errorf("expected priority %d, got %d", 20-19, prio)
+			}
+		}
+		done <- err
+	}()
+	isNil(<-done, t)
+
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, syscall.Gettid())
+	isNil(err, t)
+	assert(prio != 20-19, t, "expected other threads to keep their priority")
+}
+
+func TestBackupXattrs(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupXattrs", t)
+	defer os.RemoveAll(dir)
+
+	// Skip if this filesystem doesn't support user extended attributes.
+	probe := filepath.Join(dir, "probe")
+	isNil(ioutil.WriteFile(probe, nil, 0644), t)
+	if err := setXattr(probe, "user.probe", "x"); err != nil {
+		t.Skipf("can't set extended attributes: %v", err)
+	}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		Compress:      true,
+		BackupXattrs:  map[string]string{"user.app": "billing"},
+		BackupXattrID: "user.rotation",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	compressed := backup + compressSuffix
+	waitFor(func() bool { return xattr(compressed, "user.app") != "" }, t)
+
+	// only the compressed backup is tagged, with the rotation ID of the
+	// uncompressed one.
+	notExist(backup, t)
+	equals("billing", xattr(compressed, "user.app"), t)
+	id := xattr(compressed, "user.rotation")
+	equals(l.rotationID(backup), id, t)
+	assert(len(id) == 36 && id[14] == '5', t, "expected a version 5 UUID, got %q", id)
+
+	// the next rotation gets another ID.
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	second := backupFile(dir) + compressSuffix
+	waitFor(func() bool { return xattr(second, "user.rotation") != "" }, t)
+	assert(xattr(second, "user.rotation") != id, t, "expected a new rotation ID")
+
+	// and the current log file isn't tagged.
+	equals("", xattr(filename, "user.app"), t)
+}
+
+// xattr returns the named extended attribute of a file, or "" if it has none.
+func xattr(name, attr string) string {
+	buf := make([]byte, 256)
+	n, err := syscall.Getxattr(name, attr, buf)
+	if err != nil {
+		return ""
+	}
+	return string(buf[:n])
+}
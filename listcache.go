@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// backupList is the list of backups that oldLogFiles keeps with
+// RescanInterval, newest first, as of the last time the directories were read
+// and the changes the Logger has made to them since.
+type backupList struct {
+	mu    sync.Mutex
+	files []logInfo
+	read  time.Time // when the directories were read, or zero to read them next
+}
+
+// listCached reports whether oldLogFiles keeps the list of backups between
+// calls.  Sequential backups are renamed on every rotation, so they are always
+// read afresh.
+func (l *Logger) listCached() bool {
+	return l.RescanInterval > 0 && !l.sequential()
+}
+
+// cachedLogFiles returns a copy of the kept list of backups, reading the
+// directories again if it is older than RescanInterval, or has been dropped.
+func (l *Logger) cachedLogFiles() ([]logInfo, error) {
+	c := &l.backupList
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.read.IsZero() || time.Since(c.read) >= l.RescanInterval {
+		files, err := l.readLogFiles()
+		if err != nil {
+			c.files, c.read = nil, time.Time{}
+			return nil, err
+		}
+		c.files, c.read = files, time.Now()
+	}
+	return append([]logInfo(nil), c.files...), nil
+}
+
+// listAdd adds the named backups, which the Logger has just made, to the kept
+// list, in their place by age, rather than reading the directories for them.
+func (l *Logger) listAdd(names ...string) {
+	if !l.listCached() {
+		return
+	}
+	c := &l.backupList
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.read.IsZero() {
+		return
+	}
+	prefix, ext := l.prefixAndExt()
+	for _, name := range names {
+		dir := l.listDir(name)
+		info, err := osStat(name)
+		if dir == "" || err != nil {
+			// read the directories next time instead.
+			c.files, c.read = nil, time.Time{}
+			return
+		}
+		f, ok := l.backupInfo(info, dir, prefix, ext)
+		if !ok {
+			continue
+		}
+		c.files = listWithout(c.files, name)
+		i := sort.Search(len(c.files), func(i int) bool {
+			return !byFormatTime([]logInfo{c.files[i], f}).Less(0, 1)
+		})
+		c.files = append(c.files, logInfo{})
+		copy(c.files[i+1:], c.files[i:])
+		c.files[i] = f
+	}
+}
+
+// listRemove drops the named backups, which the Logger has just removed or
+// moved, from the kept list.
+func (l *Logger) listRemove(names ...string) {
+	if !l.listCached() {
+		return
+	}
+	c := &l.backupList
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		c.files = listWithout(c.files, name)
+	}
+}
+
+// forgetBackups drops the kept list, so that the directories are read again
+// the next time the backups are listed, after changes that the list isn't
+// kept up with.
+func (l *Logger) forgetBackups() {
+	c := &l.backupList
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files, c.read = nil, time.Time{}
+}
+
+// listDir returns the directory of the named backup as oldLogFiles gives it,
+// or "" if it isn't one of the directories that backups are kept in.
+func (l *Logger) listDir(name string) string {
+	dir := filepath.Dir(name)
+	switch {
+	case dir == filepath.Clean(l.dir()):
+		return l.dir()
+	case l.staged() && dir == filepath.Clean(l.backupDir()):
+		return l.backupDir()
+	}
+	return ""
+}
+
+// listWithout returns files without the one at the named path, if any.
+func listWithout(files []logInfo, name string) []logInfo {
+	for i, f := range files {
+		if f.path() == filepath.Clean(name) {
+			return append(files[:i], files[i+1:]...)
+		}
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRescanInterval(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRescanInterval", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		MaxSize:        1000,
+		MaxBackups:     2,
+		Compress:       true,
+		RescanInterval: time.Hour,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	for i := 0; i < 4; i++ {
+		_, err := l.Write(b)
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		waitFor(func() bool {
+			_, err := os.Stat(backupFile(dir) + compressSuffix)
+			return err == nil
+		}, t)
+	}
+	waitFor(func() bool {
+		files, _ := ioutil.ReadDir(dir)
+		return len(files) == 3
+	}, t)
+
+	// the kept list has followed the rotations, compressions and removals.
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+	equals(backupFile(dir)+compressSuffix, backups[0].Path, t)
+	assert(backups[0].Compressed && backups[1].Compressed, t, "expected compressed backups")
+
+	// a backup made by someone else goes unseen until the list is old.
+	newFakeTime()
+	other := backupFile(dir)
+	isNil(ioutil.WriteFile(other, b, 0644), t)
+	backups, err = l.Backups()
+	isNil(err, t)
+	equals(2, len(backups), t)
+
+	l.backupList.mu.Lock()
+	l.backupList.read = time.Now().Add(-2 * time.Hour)
+	l.backupList.mu.Unlock()
+	backups, err = l.Backups()
+	isNil(err, t)
+	equals(3, len(backups), t)
+	equals(other, backups[0].Path, t)
+}
+
+func TestRescanIntervalUpdateConfig(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRescanIntervalUpdateConfig", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		RescanInterval: time.Hour,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	backups, err := l.Backups()
+	isNil(err, t)
+	equals(0, len(backups), t)
+
+	isNil(ioutil.WriteFile(backupFile(dir), []byte("foo"), 0644), t)
+	isNil(l.UpdateConfig(Config{Filename: l.Filename, RescanInterval: Duration(time.Hour)}), t)
+	backups, err = l.Backups()
+	isNil(err, t)
+	equals(1, len(backups), t)
+}
@@ -0,0 +1,87 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockSuffix is appended to the log file name to name the lock file.
+const lockSuffix = ".lock"
+
+// millLockName is the name of the lock file of MillLock, in the directory of
+// the log file.
+const millLockName = ".lumberjack-mill.lock"
+
+// errNoLocking is returned by lockFile and waitLockFile on platforms without
+// file locking.
+var errNoLocking = errors.New("file locking is not supported on this platform")
+
+// ErrLocked is returned, wrapped, by writes when LockFile is set and another
+// Logger, likely in another process, already owns the log file.
+var ErrLocked = errors.New("lumberjack: log file is owned by another logger")
+
+// lock takes the exclusive lock on the lock file, if LockFile is set and the
+// lock isn't held already.  Where file locking isn't supported, the lock file
+// is kept open without a lock, as MillLock does without one, so that writes
+// go ahead rather than failing.  It assumes that l.mu is held.
+func (l *Logger) lock() error {
+	if !l.LockFile || l.lockFile != nil {
+		return nil
+	}
+	if err := os.MkdirAll(l.dir(), l.dirMode()); err != nil {
+		return fmt.Errorf("can't make directories for lock file: %s", err)
+	}
+	name := l.filename() + lockSuffix
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open lock file: %s", err)
+	}
+	if err := lockFile(f); err != nil && err != errNoLocking {
+		f.Close()
+		if err == errWouldBlock {
+			return fmt.Errorf("can't lock %s: %w", name, ErrLocked)
+		}
+		return fmt.Errorf("can't lock %s: %s", name, err)
+	}
+	l.lockFile = f
+	return nil
+}
+
+// unlock releases the lock on the lock file, if it is held.  The lock file
+// itself is left in place, since removing it could race with another Logger
+// taking the lock.  It assumes that l.mu is held.
+func (l *Logger) unlock() error {
+	if l.lockFile == nil {
+		return nil
+	}
+	err := l.lockFile.Close()
+	l.lockFile = nil
+	return err
+}
+
+// lockMill takes the lock of MillLock, waiting for it if need be, and returns
+// the function that releases it.  Without MillLock, or where file locking
+// isn't supported, there is nothing to take, and nothing to release.
+func (l *Logger) lockMill() (func(), error) {
+	if !l.MillLock {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(l.dir(), l.dirMode()); err != nil {
+		return nil, fmt.Errorf("can't make directories for mill lock file: %s", err)
+	}
+	name := filepath.Join(l.dir(), millLockName)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open mill lock file: %s", err)
+	}
+	if err := waitLockFile(f); err != nil {
+		f.Close()
+		if err == errNoLocking {
+			return func() {}, nil
+		}
+		return nil, fmt.Errorf("can't lock %s: %s", name, err)
+	}
+	return func() { f.Close() }, nil
+}
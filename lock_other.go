@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// errWouldBlock is never returned on platforms without file locking.
+var errWouldBlock = errors.New("file is locked")
+
+// lockFile reports that locking isn't supported on this platform.
+func lockFile(_ *os.File) error {
+	return errNoLocking
+}
+
+// waitLockFile reports that locking isn't supported on this platform.
+func waitLockFile(_ *os.File) error {
+	return errNoLocking
+}
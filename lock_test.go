@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestLockFile", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l1 := &Logger{Filename: filename, LockFile: true}
+	defer l1.Close()
+	l2 := &Logger{Filename: filename, LockFile: true}
+	defer l2.Close()
+
+	_, err := l1.Write([]byte("boo!"))
+	isNil(err, t)
+	exists(filename+lockSuffix, t)
+
+	// the second Logger fails fast, without touching the log file.
+	_, err = l2.Write([]byte("foo!"))
+	assert(errors.Is(err, ErrLocked), t, "expected ErrLocked, got %v", err)
+	assert(errors.Is(l2.Rotate(), ErrLocked), t, "expected Rotate to fail with ErrLocked")
+	existsWithContent(filename, []byte("boo!"), t)
+	fileCount(dir, 2, t)
+
+	// once the first Logger is closed, the lock can be taken.
+	isNil(l1.Close(), t)
+	_, err = l2.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!foo!"), t)
+}
+
+func TestMillLock(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMillLock", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	other := &Logger{Filename: filename, MillLock: true}
+	unlock, err := other.lockMill()
+	isNil(err, t)
+	exists(filepath.Join(dir, millLockName), t)
+
+	l := &Logger{Filename: filename, MaxSize: 100, MaxBackups: 1, MillLock: true}
+	defer l.Close()
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the old backup is kept while another pass holds the lock.
+	<-time.After(50 * time.Millisecond)
+	fileCount(dir, 4, t)
+
+	unlock()
+	waitFor(func() bool {
+		files, err := ioutil.ReadDir(dir)
+		return err == nil && len(files) == 3
+	}, t)
+	exists(filename, t)
+}
@@ -0,0 +1,34 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+)
+
+// errWouldBlock is returned by lockFile when another descriptor holds the lock.
+var errWouldBlock error = syscall.EWOULDBLOCK
+
+// lockFile takes an exclusive advisory lock on f without waiting for it.  The
+// lock is released when f is closed.
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// waitLockFile takes an exclusive advisory lock on f, waiting for it if
+// another descriptor holds it.  The lock is released when f is closed.
+func waitLockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+
+	errorLockViolation syscall.Errno = 33
+)
+
+var procLockFileEx = modkernel32.NewProc("LockFileEx")
+
+// errWouldBlock is returned by lockFile when another handle holds the lock.
+var errWouldBlock error = errorLockViolation
+
+// lockFile takes an exclusive lock on the first byte of f without waiting for
+// it.  The lock is released when f is closed.
+func lockFile(f *os.File) error {
+	return lockFileEx(f, lockfileExclusiveLock|lockfileFailImmediately)
+}
+
+// waitLockFile takes an exclusive lock on the first byte of f, waiting for it
+// if another handle holds it.  The lock is released when f is closed.
+func waitLockFile(f *os.File) error {
+	return lockFileEx(f, lockfileExclusiveLock)
+}
+
+// lockFileEx locks the first byte of f with LockFileEx and the given flags.
+func lockFileEx(f *os.File, flags uint32) error {
+	var ol syscall.Overlapped
+	r, _, err := procLockFileEx.Call(
+		f.Fd(),
+		uintptr(flags),
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&ol)),
+	)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -19,20 +19,31 @@
 // Lumberjack assumes that only one process is writing to the output files.
 // Using the same lumberjack configuration from multiple processes on the same
 // machine will result in improper behavior.
+//
+// Built with TinyGo, the Logger starts no goroutines of its own unless asked
+// to, by BufferSize, RotationInterval and the like: compression and removal of
+// old log files run right after rotation, on the goroutine that is writing,
+// and CompressWindow, CompressAfter and retries after failures wait for the
+// next rotation rather than for a timer.
 package lumberjack
 
 import (
-	"compress/gzip"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
@@ -76,18 +87,145 @@ var _ io.WriteCloser = (*Logger)(nil)
 // with an encoded timestamp older than MaxAge days are deleted, regardless of
 // MaxBackups.  Note that the time encoded in the timestamp is the rotation
 // time, which may differ from the last time that file was written to.
+// MaxHistory instead keeps however many backups it takes to cover a length
+// of time.
+//
+// MaxTotalSize and MinDiskFree limit the space taken by backups.  All the
+// rules are applied in one pass: a backup is deleted if it is older than
+// MaxAge, or outside MaxHistory, or MaxBackups newer backups are retained, or
+// it doesn't fit in
+// MaxTotalSize along with the newer ones, in that order of precedence.  The
+// oldest backups left are then deleted until MinDiskFree, MinFreeBytes and
+// MinFreePercent are met.  OnEvent is
+// told which rule deleted each file.
+//
+// A backup can be pinned, such as to keep it as evidence during an
+// investigation, by creating an empty file next to it named after it with
+// .keep added, like foo-2016-11-04T18-30-00.000.log.keep.  Pinned backups are
+// still compressed, moved to BackupDir and so on, but aren't deleted by any of
+// the rules, nor counted towards them, until the .keep file is removed.
 //
-// If MaxBackups and MaxAge are both 0, no old log files will be deleted.
+// If MaxBackups, MaxAge, MaxHistory, MaxTotalSize and the free space minimums
+// are all 0, no old log files will be deleted.
 type Logger struct {
 	// Filename is the file to write logs to.  Backup log files will be retained
 	// in the same directory.  It uses <processname>-lumberjack.log in
-	// os.TempDir() if empty.
+	// os.TempDir() if empty.  A leading ~ or ~user is expanded to the home
+	// directory, and environment variables written as $VAR or ${VAR} (or %VAR%
+	// on Windows) are expanded to their values, once, when the Logger first
+	// uses Filename or after Filename has changed.
+	//
+	// Filename may also have placeholders for the time the log file is opened,
+	// %Y, %m, %d, %H and %M, for the year, month, day, hour and minute, in
+	// local time if LocalTime is set, as well as {hostname} and %% for a %,
+	// such as /var/log/app/%Y-%m-%d/app-{hostname}.log.  Directories are made
+	// as needed.  When the placeholders come to expand to another name, the
+	// log file is closed as it is, not rotated, right at the end of its
+	// minute, hour, day, month or year, even if nothing is being written, and
+	// the next write starts the file of the new name.  So app-%Y-%m-%d-%H.log
+	// gives every hour a file of its own, with boundaries that batch jobs can
+	// rely on: once the next hour's file could have been started, the last
+	// one is complete.  Cleanup only looks after the backups of the current
+	// file.
 	Filename string `json:"filename" yaml:"filename"`
 
+	// Dir, if set instead of Filename, is a directory given over to the log,
+	// in which the Logger names every file itself: the log file is
+	// BaseName.log, and the backups and the files that go with them are named
+	// after it.  As well as the backups, Dir may only hold the compressed
+	// backups and their parts, and the keep, marker, lock, partial and
+	// temporary files of the log.  Cleanup and compression check that before
+	// every run, and while there is any other file, they leave Dir alone and
+	// record an error naming it, so that a Logger pointed at the wrong
+	// directory can't remove anyone else's files, even ones that look like
+	// backups.  BackupDir can't be used with it.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// BaseName is the name of the log file in Dir, without the .log
+	// extension.  The default is the name of the program.
+	BaseName string `json:"basename" yaml:"basename"`
+
+	// BackupDir, if set, is the directory backups are kept in, rather than
+	// that of the log file, for keeping the log file on fast local storage,
+	// such as tmpfs or NVMe scratch space, and backups on a slower durable
+	// volume.  Rotation still renames the log file next to itself, which is
+	// quick, and the backup is moved to BackupDir afterwards, along with
+	// compression and removal of old log files, off the write path.  If
+	// Compress is set, backups are compressed straight into BackupDir.  Moves
+	// across filesystems copy the backup and then remove it.  A relative
+	// BackupDir is taken to be relative to the directory of the log file.
+	// Cleanup counts the backups in both directories, and moves those left
+	// next to the log file, such as from before BackupDir was set, over too.
+	BackupDir string `json:"backupdir" yaml:"backupdir"`
+
 	// MaxSize is the maximum size in megabytes of the log file before it gets
-	// rotated. It defaults to 100 megabytes.
+	// rotated. It defaults to 100 megabytes.  A negative MaxSize, such as -1,
+	// never rotates the log file for its size, so that the Logger can keep
+	// managing a single file, with its FileMode, owner, syncing and Stats,
+	// where rotation isn't wanted.  Rotate, RotationInterval and the like
+	// still rotate it.
 	MaxSize int `json:"maxsize" yaml:"maxsize"`
 
+	// MaxBytes, if positive, is the maximum size in bytes of the log file
+	// before it gets rotated, and takes precedence over MaxSize, for limits
+	// that aren't whole megabytes, such as on devices with little storage.
+	// Everything said of MaxSize here then applies to MaxBytes.
+	MaxBytes int64 `json:"maxbytes" yaml:"maxbytes"`
+
+	// OversizeWrites, if set, writes a Write that is longer than MaxSize to a
+	// file of its own instead of refusing it, rotating the log file before and
+	// after it, so that no record is lost.  A Write is never split across log
+	// files either way: one that doesn't fit in what is left of the log file
+	// rotates it first.
+	OversizeWrites bool `json:"oversizewrites" yaml:"oversizewrites"`
+
+	// TrustedWrites skips checking each Write against MaxSize, for callers
+	// that guarantee that their writes are small, saving a little on every
+	// write.  A Write longer than MaxSize is then written as OversizeWrites
+	// would write it, the log file getting rotated before and after it.  The
+	// default is false.
+	TrustedWrites bool `json:"trustedwrites" yaml:"trustedwrites"`
+
+	// RotationInterval, if set, also rotates the log file on a schedule,
+	// whatever its size, for pipelines that expect a file per hour or per day.
+	// Intervals shorter than a day are counted from midnight, so an interval
+	// of an hour rotates on the hour, and intervals of a day or longer rotate
+	// at midnight, every so many days.  Midnight is in local time with
+	// LocalTime, otherwise UTC.  The file is rotated at the boundary even if
+	// nothing is written, unless it is empty, and a log file left over from an
+	// earlier interval is rotated when it's first written to.
+	RotationInterval time.Duration `json:"rotationinterval" yaml:"rotationinterval"`
+
+	// TriggerFile, if set, is the name of a file whose appearance rotates the
+	// log file, for where signals can't be sent, such as to Windows services
+	// or into some containers: create the file, as with touch app.log.rotate,
+	// and the Logger removes it and rotates.  A relative name is taken to be
+	// in the directory of the log file.  The file is looked for from when the
+	// log file is first opened until Close, every TriggerInterval.
+	TriggerFile string `json:"triggerfile" yaml:"triggerfile"`
+
+	// ResolveOnRotate makes the Logger look up where Filename points afresh
+	// on every rotation, if it is a symbolic link, so that pointing the link
+	// at another path, such as the log directory of a new release, moves the
+	// log file there at the next rotation, after the old one has been rotated
+	// in place.  Backups left next to the old log file are then no longer
+	// cleaned up.  By default, a Filename that is a symbolic link is followed
+	// when it's first used, and the Logger sticks to the file it points at,
+	// rotating it, rather than the link, next to itself.
+	ResolveOnRotate bool `json:"resolveonrotate" yaml:"resolveonrotate"`
+
+	// Symlink, if set, is the name of a symbolic link kept pointing at the
+	// current log file, such as app.log.current, for collectors and for
+	// tail -F to follow.  A relative name is taken to be in the directory of
+	// the log file.  The link is checked, and replaced atomically if it's
+	// missing or points elsewhere, whenever the log file is opened or
+	// rotated.  Failing to make it doesn't stop writes.
+	Symlink string `json:"symlink" yaml:"symlink"`
+
+	// TriggerInterval is how often to look for the TriggerFile.  It defaults
+	// to one second.
+	TriggerInterval time.Duration `json:"triggerinterval" yaml:"triggerinterval"`
+
 	// MaxAge is the maximum number of days to retain old log files based on the
 	// timestamp encoded in their filename.  Note that a day is defined as 24
 	// hours and may not exactly correspond to calendar days due to daylight
@@ -95,32 +233,985 @@ type Logger struct {
 	// based on age.
 	MaxAge int `json:"maxage" yaml:"maxage"`
 
+	// MaxHistory keeps the backups that hold the most recent MaxHistory of
+	// log, going by their rotation timestamps, however many files that is.
+	// Unlike MaxAge it is measured back from the newest backup rather than
+	// from now, so backups aren't removed just because the application
+	// wasn't running for a while; a backup goes once the backups rotated
+	// after it cover MaxHistory on their own.  The default is not to remove
+	// old log files based on history.
+	MaxHistory time.Duration `json:"maxhistory" yaml:"maxhistory"`
+
+	// MaxClockSkew makes MaxAge robust to steps of the system clock.  When
+	// the wall clock moves more than MaxClockSkew away from the time kept by
+	// the monotonic clock since the Logger started cleaning up, as it does
+	// after an NTP correction or a manual change, age is measured against the
+	// earlier of the two.  A step forward then doesn't remove backups early.
+	// The default is to trust the wall clock.
+	MaxClockSkew time.Duration `json:"maxclockskew" yaml:"maxclockskew"`
+
 	// MaxBackups is the maximum number of old log files to retain.  The default
 	// is to retain all old log files (though MaxAge may still cause them to get
 	// deleted.)
 	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
 
+	// MaxTotalSize is the maximum total size in megabytes of the log files,
+	// the current one and the old ones to retain, as they are on disk, so
+	// compressed backups count with their compressed size.  Unlike
+	// MaxBackups, it bounds the disk use however much the sizes of backups
+	// vary.  The oldest backups are removed first, when cleaning up after a
+	// rotation, so the current log file can take the total up to MaxSize
+	// over the limit between rotations.  The default is not to remove old log
+	// files based on their size.
+	MaxTotalSize int `json:"maxtotalsize" yaml:"maxtotalsize"`
+
+	// ProjectQuota makes cleanup read the project quota of the directory
+	// backups are kept in, on Linux filesystems with project quotas, such as
+	// XFS and ext4, and keep the log files within it as within MaxTotalSize,
+	// less what the other files of the project take, so that the backups are
+	// removed before the kernel starts refusing writes.  The smaller of the
+	// quota and MaxTotalSize applies.  It has no effect on a directory
+	// without a project quota, or elsewhere.  The default is false.
+	ProjectQuota bool `json:"projectquota" yaml:"projectquota"`
+
+	// MinDiskFree is the amount of free space in megabytes to keep on the
+	// disk holding the log files, by removing the oldest backups as needed.
+	// It is ignored on platforms where the free space can't be found out.
+	// The default is not to remove old log files based on free space.
+	MinDiskFree int `json:"mindiskfree" yaml:"mindiskfree"`
+
+	// MinFreeBytes is like MinDiskFree, but in bytes, and takes precedence
+	// over it when set.
+	MinFreeBytes int64 `json:"minfreebytes" yaml:"minfreebytes"`
+
+	// MinFreePercent is the free space to keep on the disk holding the log
+	// files as a percentage of its size, such as 5 for 5%.  When set along
+	// with MinDiskFree or MinFreeBytes, the larger amount applies.  Writes
+	// check the free space on the disk holding the log file too, at most once
+	// a second, and have the oldest backups removed right away when it is
+	// below the minimum, rather than after the next rotation.
+	MinFreePercent float64 `json:"minfreepercent" yaml:"minfreepercent"`
+
+	// DenyWhenDiskLow makes writes fail with ErrDiskLow, rather than go to
+	// the log file, while the free space on its disk is below MinDiskFree,
+	// MinFreeBytes or MinFreePercent even after removing backups, so that
+	// logging doesn't fill up the disk.  The writes are lost.
+	DenyWhenDiskLow bool `json:"denywhendisklow" yaml:"denywhendisklow"`
+
 	// LocalTime determines if the time used for formatting the timestamps in
 	// backup files is the computer's local time.  The default is to use UTC
 	// time.
 	LocalTime bool `json:"localtime" yaml:"localtime"`
 
+	// ZoneOffset determines if local timestamps in backup names end with the
+	// offset from UTC, as in foo-2024-11-03T01-30-00.000-0400.log.  Without
+	// it, the hour repeated when daylight saving time ends gives backups the
+	// names of earlier ones, which they then overwrite, or names that sort
+	// before them.  It has no effect unless LocalTime is set.
+	ZoneOffset bool `json:"zoneoffset" yaml:"zoneoffset"`
+
+	// BackupTimePrecision is the sub-second precision of the timestamps in
+	// backup names.  Finer precision keeps names unique and their order stable
+	// when rotations happen in rapid succession.  The default is milliseconds.
+	BackupTimePrecision Precision `json:"backuptimeprecision" yaml:"backuptimeprecision"`
+
+	// BackupTimeFormat, if set, is the layout of the timestamps in backup
+	// names, in the format of the time package, such as "20060102" for names
+	// like foo-20240131.log.  Backups named with it are recognized when old
+	// log files are cleaned up, as are those with the default layouts.  It
+	// takes the place of BackupTimePrecision and ZoneOffset, so a layout that
+	// needs an offset has to include one.  A layout coarser than the rotation
+	// rate gives successive backups the same name; see BackupCollision.
+	BackupTimeFormat string `json:"backuptimeformat" yaml:"backuptimeformat"`
+
+	// BackupCollision is what to do when the name a rotation would give the
+	// backup is taken by an existing backup, as when a process restarted
+	// within the same millisecond rotates again.  The default is to overwrite
+	// the existing backup.
+	BackupCollision Collision `json:"backupcollision" yaml:"backupcollision"`
+
+	// RotationCounter determines if backup names start with a counter that
+	// goes up by one on every rotation, as in foo-000042-<timestamp>.log.
+	// Backups are then ordered by the counter rather than the timestamp, which
+	// keeps the order strict even when the clock is stepped back.  The counter
+	// continues from the highest one among the existing backups.  The default
+	// is not to use a counter.
+	RotationCounter bool `json:"rotationcounter" yaml:"rotationcounter"`
+
+	// LegacyBackupFormats are timestamp layouts, in the format of the time
+	// package, that backups may have been named with before BackupTimeFormat
+	// or the other naming options were changed, such as "20060102" for
+	// foo-20240131.log.  Backups named with them are still recognized, so
+	// that cleanup counts and removes them, rather than leaving them forever.
+	LegacyBackupFormats []string `json:"legacybackupformats" yaml:"legacybackupformats"`
+
+	// MigrateBackups determines if cleanup renames the backups named with
+	// LegacyBackupFormats, along with their keep files, to the names that
+	// the current naming options give them, keeping the time and rotation
+	// counter in the name.  A backup whose new name is taken is left as it
+	// is.  The default is to leave the names as they are.
+	MigrateBackups bool `json:"migratebackups" yaml:"migratebackups"`
+
+	// StrictBackupNames determines if the only files taken to be backups,
+	// and so compressed and removed by cleanup, are those named exactly as
+	// the current naming options would name them: with the timestamp in its
+	// layout and precision, a rotation counter only with RotationCounter, a
+	// collision number only with CollisionSuffix, a hash only with
+	// ContentHash and parts only with CompressPartSize.  This keeps cleanup
+	// from taking unrelated files that happen to look like backups, such as
+	// those of another log file with a similar name, at the cost of leaving
+	// behind the backups named before the options changed, other than those
+	// renamed by MigrateBackups.  The default is to recognize backups named
+	// with any of the options.
+	StrictBackupNames bool `json:"strictbackupnames" yaml:"strictbackupnames"`
+
+	// RotationOrder is whether a rotation closes the log file before renaming
+	// it to the backup name, which Windows needs, or renames it first, so
+	// that writes resume sooner.  Closing it first also syncs it to disk.
+	// The default, OrderAuto, closes it first on Windows and on FSNFS only.
+	RotationOrder RotationOrder `json:"rotationorder" yaml:"rotationorder"`
+
+	// Filesystem is the filesystem that the log file is on, which the Logger
+	// tunes for: on FSTmpfs it doesn't sync the log file, as there is no
+	// disk to sync it to; on FSNFS rotations close the log file before
+	// renaming it, unless RotationOrder says otherwise, and ReopenOnMove
+	// checks the log file at most once a second; and on FSBtrfs and FSXFS
+	// backups moved across directories that can't be renamed are copied by
+	// reflink where the filesystem can.  The default, FSAuto, detects the
+	// filesystem when the log file is opened, on Linux; FSGeneric tunes for
+	// none.
+	Filesystem Filesystem `json:"filesystem" yaml:"filesystem"`
+
+	// NamingScheme is how backups are named: by the time of their rotation,
+	// the default, or by number, as logrotate names them, for tools that
+	// expect foo.log.1, foo.log.2.gz and so on.  Sequential backups are
+	// ordered by number and dated by when they were last written, and every
+	// rotation renumbers them all, waiting for cleanup under way to finish
+	// first.  It doesn't go with BackupDir, RotationCounter, BackupTimeFormat,
+	// LegacyBackupFormats, ContentHash, CompressPartSize, CompressOnWrite or
+	// CompressDirect, and the name BeginRotate returns goes out of date with
+	// the next rotation.
+	NamingScheme NamingScheme `json:"namingscheme" yaml:"namingscheme"`
+
 	// Compress determines if the rotated log files should be compressed
-	// using gzip. The default is not to perform compression.
+	// using gzip, or the Compressor if one is set. The default is not to
+	// perform compression.
 	Compress bool `json:"compress" yaml:"compress"`
 
+	// RotationMarker determines if a marker file, named after the backup with
+	// .ready added, as in foo-<timestamp>.log.ready, is created once a
+	// backup is complete: right after rotation, or once it has been
+	// compressed.  It lists the files the backup ended up in, which
+	// ReadMarker returns, so that a collector watching the directory, such as
+	// with inotify, can pick up backups as they are finished without polling
+	// and without ever seeing a file that is still being written.  Markers
+	// appear by being renamed into place, and are removed along with their
+	// backups.  See MarkerPattern.
+	RotationMarker bool `json:"rotationmarker" yaml:"rotationmarker"`
+
+	// StateFile, if set, is the path of a file that is rewritten with the
+	// State of the log, in JSON, after every rotation, once the backups have
+	// been compressed, moved or removed as configured, so that a collector
+	// in a sidecar container, say, can follow rotations by watching or
+	// polling one well-known file, without signals or exec.  Like
+	// RotationMarker files, it is renamed into place and has the mode of
+	// backups.  See ReadState.
+	StateFile string `json:"statefile" yaml:"statefile"`
+
+	// Manifest makes the Logger keep an index of the backups of the log
+	// file, with their timestamps, sizes and the SHA-256 sums of their
+	// files, in JSON, in a file named like the log file with
+	// ".manifest.json" appended, so that other systems can read one
+	// authoritative list rather than globbing the directory.  It is
+	// rewritten, by being renamed into place, after every rotation once the
+	// backups have been compressed, moved or removed as configured, only
+	// summing the files that changed.  Whatever ships backups elsewhere can
+	// record that it has with MarkShipped.  See ReadManifest.
+	Manifest bool `json:"manifest" yaml:"manifest"`
+
+	// PromFile, if set, is the path of a file that is rewritten with the
+	// Logger's key metrics, in the Prometheus text format, after every
+	// rotation, once the backups have been compressed, moved or removed as
+	// configured: the counts of rotations and failures from Stats, whether
+	// the Logger is degraded, and the number and total size of the backups,
+	// labeled with the log file and the Labels.  Pointed at a file ending in
+	// ".prom" in the directory of node_exporter's textfile collector, it
+	// makes the Logger visible to Prometheus without an HTTP endpoint.  It is
+	// renamed into place, so the collector never reads half of it.
+	PromFile string `json:"promfile" yaml:"promfile"`
+
+	// Compressor, if set, is the codec backups are compressed with instead
+	// of gzip, such as zstd from a third-party package, or Gzip at another
+	// level.  Backups are named with its Suffix, and those already compressed
+	// with gzip are still recognized for cleanup.  Grep and OpenBackupAt can
+	// only read backups compressed with it if it is also a Decompressor.
+	// CompressConcurrency, CompressOnWrite and CompressRsyncable only work
+	// with gzip, and have no effect with a Compressor other than Gzip.
+	Compressor Compressor `json:"-" yaml:"-"`
+
+	// ArchiveTag, if set, is put in the names of compressed backups, and
+	// only those, in front of the compression suffix, as in
+	// foo-<timestamp>.log.web1.gz, so that backups shipped from many hosts
+	// to one place have names of their own while the log file and its
+	// uncompressed backups keep short ones.  {hostname} in it is replaced by
+	// the name of the host, and $VAR or ${VAR} by the environment variable,
+	// such as a pod name or instance ID.  Backups compressed before it was
+	// set are still recognized, but those compressed under another tag
+	// aren't, and are left alone by cleanup.
+	ArchiveTag string `json:"archivetag" yaml:"archivetag"`
+
+	// Encrypter, if set, encrypts backups as they are compressed, so that no
+	// compressed backup is ever on disk in the clear, for archives that must
+	// be encrypted at rest.  Encrypted backups are named with its Suffix
+	// after that of the compression, as in foo-<timestamp>.log.gz.enc, and
+	// those compressed without encryption are still recognized for cleanup.
+	// It only applies with Compress, and the log file and backups waiting to
+	// be compressed are not encrypted.  Grep and OpenBackupAt can only read
+	// encrypted backups if it is also a Decrypter.  CompressOnWrite has no
+	// effect with it.  See AESGCM.
+	Encrypter Encrypter `json:"-" yaml:"-"`
+
+	// CompressConcurrency is the number of cores used to compress a single
+	// backup.  With more than one, the backup is split into blocks which are
+	// compressed in parallel, shortening the window during which a huge
+	// uncompressed backup occupies the disk, at the cost of slightly larger
+	// output.  The default is to compress serially.
+	CompressConcurrency int `json:"compressconcurrency" yaml:"compressconcurrency"`
+
+	// CompressRsyncable determines if backups are compressed in a way that
+	// rsync can sync efficiently, like gzip --rsyncable, for sites that ship
+	// their archives offsite over slow links.  The compressor is flushed at
+	// points picked by the content of the log, about every 4KB, so that a
+	// change to a backup, or one that shares content with another, only
+	// changes the compressed output around it.  The output is slightly
+	// larger.  It compresses on a single core, whatever CompressConcurrency
+	// is, and has no effect unless Compress is set.
+	CompressRsyncable bool `json:"compressrsyncable" yaml:"compressrsyncable"`
+
+	// VerifyCompression determines if compressed backups are read back and
+	// decompressed once written, and checked against what was compressed,
+	// before the uncompressed backup is removed, so that a bug in a codec, a
+	// flaky disk or bad memory can't lose any of the log.  A backup that
+	// fails the check is left uncompressed, and the error is reported as for
+	// any other failure to compress.  It costs another read of every
+	// compressed backup.  With a Compressor, it needs a Decompressor, and an
+	// Encrypter needs to be a Decrypter.  It has no effect unless Compress is
+	// set.
+	VerifyCompression bool `json:"verifycompression" yaml:"verifycompression"`
+
+	// CompressPartSize is the maximum size in megabytes of a compressed backup
+	// file.  Bigger compressed backups are split into parts named
+	// foo-<timestamp>.log.gz.000, .gz.001 and so on, which concatenated make
+	// up the gzip file, for systems with a limit on the size of the files
+	// they ingest.  Cleanup keeps or removes the parts of a backup together.
+	// The default is not to split compressed backups.
+	CompressPartSize int `json:"compresspartsize" yaml:"compresspartsize"`
+
+	// CompressPadSize is the size in megabytes that compressed backups are
+	// padded to a multiple of, as for object stores and backup systems that
+	// bill or dedupe by the block, with empty gzip members that decompress to
+	// nothing.  A backup that falls a few bytes short of a multiple is padded
+	// to the next one.  With CompressPartSize, the parts together are padded,
+	// so that a part size that is a multiple of CompressPadSize gives parts
+	// all of that size.  Only gzip output is padded: it has no effect with a
+	// Compressor other than Gzip, or with an Encrypter.  The default is not
+	// to pad compressed backups.
+	CompressPadSize int `json:"compresspadsize" yaml:"compresspadsize"`
+
+	// ContentHash, if set, puts the start of the SHA-256 of the content of
+	// each backup in the name of its compressed version, as in
+	// foo-<timestamp>-<hash>.log.gz, for object stores that dedupe or check
+	// files by name.  The hash is worked out while compressing, so it only
+	// applies with Compress, and the backups are still recognized, ordered
+	// and cleaned up by their timestamps.
+	ContentHash bool `json:"contenthash" yaml:"contenthash"`
+
+	// CompressOnWrite determines if log data is compressed as it is written,
+	// alongside the log file, so that the compressed backup is ready when the
+	// file is rotated and the uncompressed backup is removed right away,
+	// instead of being read back in to be compressed.  This saves a pass over
+	// every backup, in exchange for compressing in Write, which also holds
+	// some memory for the compressor.  It always uses a single core, whatever
+	// CompressConcurrency is.  Log files the Logger didn't start itself, such
+	// as one it carries on with after a restart, are compressed after rotation
+	// as usual.  It has no effect unless Compress is set, or with Adopt.
+	CompressOnWrite bool `json:"compressonwrite" yaml:"compressonwrite"`
+
+	// CompressDirect determines if rotated log files are compressed straight
+	// from an open descriptor, with the log file already removed, so that no
+	// uncompressed backup ever takes up room under a name of its own, for
+	// hosts with disks so small that it could fill them.  The cost is that a
+	// backup that hasn't been compressed yet is lost if the process dies.  A
+	// backup that fails to be compressed, as when the disk is full, is kept
+	// open and tried again on the next cleanup, and Close writes it back
+	// uncompressed under its backup name if it still fails then.  On
+	// Windows, where open files can't be removed, backups are renamed and
+	// compressed as usual.  CompressOnWrite takes precedence, and it has no
+	// effect unless Compress is set.
+	CompressDirect bool `json:"compressdirect" yaml:"compressdirect"`
+
+	// CompressWindow is the time of day during which backups are compressed,
+	// such as 02:00-05:00 in a config file, for latency-sensitive services
+	// that would rather keep uncompressed backups around during the day than
+	// spend the CPU time.  Backups rotated outside the window wait for it to
+	// open.  Backups compressed by CompressOnWrite or CompressDirect don't
+	// wait.  The default is to compress backups right away.
+	CompressWindow Window `json:"compresswindow" yaml:"compresswindow"`
+
+	// CompressAfter is how long backups are left uncompressed after they are
+	// rotated, going by the timestamp in their name, such as to give a log
+	// shipper time to finish reading the newest backup.  Backups are then
+	// compressed once they are old enough, whether or not anything rotates in
+	// the meantime.  Like CompressWindow, it doesn't hold back backups
+	// compressed by CompressOnWrite or CompressDirect.  The default is to
+	// compress backups right away.
+	CompressAfter time.Duration `json:"compressafter" yaml:"compressafter"`
+
+	// CompressMinSize is the size in bytes below which backups are left
+	// uncompressed, since compressing a tiny file costs more than it saves,
+	// if it doesn't make the file bigger, as happens with frequent
+	// time-based rotation.  Those backups are cleaned up like any other.
+	// With CompressOnWrite, what was compressed of a small log file is
+	// dropped at rotation.  The default is to compress backups of any size.
+	CompressMinSize int64 `json:"compressminsize" yaml:"compressminsize"`
+
 	// FileMode is the file's mode and permission bits of the log file. If set
-	// it will be used as the specified mode.
+	// it will be used as the specified mode.  On Windows, where only the
+	// write bit maps onto file attributes, a mode that gives the group and
+	// others no access, such as 0600, also limits the access control list of
+	// a log file that is created without an earlier one to copy it from to
+	// the file's owner, the system and administrators.
 	FileMode fs.FileMode
 
+	// BackupMode is the file's mode and permission bits applied to backup files
+	// once they have been rotated and, if enabled, compressed.  Setting it to a
+	// read-only mode such as 0440 guards historical logs against accidental
+	// edits or truncation.  If unset, backups keep the mode of the log file.
+	BackupMode fs.FileMode `json:"backupmode" yaml:"backupmode"`
+
+	// DirMode is the mode and permission bits of the directories the Logger
+	// creates, for the log file, BackupDir, the spill file and the lock
+	// file.  The default is 0755.
+	DirMode fs.FileMode
+
+	// Permissions picks the permissions of the log file and of the
+	// directories the Logger creates by what they are for rather than by
+	// mode bits, which mean little on Windows: "private" lets only the owner
+	// in, "group-readable" also lets the group read the log, and
+	// "world-readable" everyone.  Each stands for a FileMode and a DirMode,
+	// and on Windows, for the access control list of a log file that is
+	// created without an earlier one to copy it from.  FileMode and DirMode,
+	// where set, take precedence over the modes of the profile.  The default
+	// leaves permissions to FileMode and DirMode.
+	Permissions PermissionProfile `json:"permissions" yaml:"permissions"`
+
+	// BackupAttr is a filesystem attribute set on backup files once they have
+	// been rotated and, if enabled, compressed.  Marking backups append-only or
+	// immutable hardens audit logs against tampering by other processes running
+	// as the same user.  It is only supported on Linux, and requires the
+	// CAP_LINUX_IMMUTABLE capability.  The attribute is lifted again when a
+	// backup is removed by the cleanup of old log files.
+	BackupAttr FileAttr `json:"backupattr" yaml:"backupattr"`
+
+	// BackupXattrs are extended attributes set on backup files once they have
+	// been rotated and, if enabled, compressed, such as
+	// {"user.app": "billing", "user.schema": "v2"}, so that indexing and
+	// tiering tools working on the filesystem can classify them.  On Linux the
+	// names need a namespace, which is user. for unprivileged processes, and
+	// the filesystem has to support extended attributes.  They are only
+	// supported on Linux, and ignored elsewhere.
+	BackupXattrs map[string]string `json:"backupxattrs" yaml:"backupxattrs"`
+
+	// BackupXattrID is the name of an extended attribute, such as
+	// user.rotation, set on backup files along with BackupXattrs to a UUID
+	// identifying the rotation that made the backup.  All the files of a
+	// backup, such as the parts of a split one, get the same UUID.
+	BackupXattrID string `json:"backupxattrid" yaml:"backupxattrid"`
+
+	// Adopt determines if the Logger shares the log file with another writer
+	// that may still have it open, such as the previous process during a
+	// blue/green deploy.  An existing log file is always appended to, never
+	// truncated, and the Logger reconciles its idea of the file size with the
+	// file itself before every write, so that what the other writer appends
+	// counts towards MaxSize.  A rotation moves the file out from under the
+	// other writer, whose remaining writes then end up in the backup.  This
+	// costs a stat per write.  The default is false.
+	Adopt bool `json:"adopt" yaml:"adopt"`
+
+	// ReopenOnMove determines if the Logger checks before every write that
+	// Filename still refers to the file it has open, so that it coexists with
+	// rotation done by something else, such as logrotate.  If the file was
+	// moved, removed or replaced, or truncated, as with copytruncate, the
+	// Logger reopens Filename, creating it if need be, rather than writing to
+	// a file nobody reads any more.  OnEvent is told with EventReopened.  This
+	// costs two stats per write.  The default is false.
+	ReopenOnMove bool `json:"reopenonmove" yaml:"reopenonmove"`
+
+	// RecreateDir makes the Logger check that the directory of the log file
+	// is still there before every write, and if it was removed, as by a
+	// cleanup script or tmpwatch, create it again, with DirMode, or else the
+	// mode of the directory that was removed, and the owner of that, and a
+	// new log file in it, rather than writing to a file nobody can read any
+	// more.  OnEvent is told with EventDirRecreated.  This costs a stat per
+	// write.  The default is false.
+	RecreateDir bool `json:"recreatedir" yaml:"recreatedir"`
+
+	// Preopen makes New, and Factory, open the log file in the background as
+	// soon as they have made the Logger, creating its directory and setting
+	// its owner as need be, rather than leaving that to the first write, so
+	// that the first line logged doesn't wait for it.  Writes that come in
+	// before it is done wait for it as they would for each other.  A Logger
+	// made otherwise opens the log file on the first write as usual.  It has
+	// no effect with SingleWriter, whose writes don't wait for anything.  The
+	// default is false.
+	Preopen bool `json:"preopen" yaml:"preopen"`
+
+	// PinDir makes the Logger resolve the directory of the log file once,
+	// when it first opens the log file there, and open the log file, and
+	// rename it and set the owner of the new one on rotation, relative to the
+	// directory it resolved, with openat and renameat, rather than by path.  Renaming the directory, or
+	// swapping it or a directory above it for a symbolic link, while the
+	// Logger runs then can't redirect the log file elsewhere: it stays in
+	// the directory first resolved.  The directory is resolved again if the
+	// log file moves to another, as with placeholders in Filename, or it is
+	// recreated with RecreateDir.  Backups in other directories, and the
+	// cleanup of old log files, still go by path.  It is only supported on
+	// Linux, and ignored elsewhere.  The default is false.
+	PinDir bool `json:"pindir" yaml:"pindir"`
+
+	// FollowSymlinks determines if the Logger, running as root on Unix,
+	// follows a symbolic link at the path of the log file in a directory
+	// that anyone can write to, such as /tmp, and takes a file there that
+	// isn't its own as the log file.  By default it refuses to, so that
+	// another user can't plant a link, or a hard link, there to have root
+	// write to, or rotate away, a file of the attacker's choosing: the log
+	// file is opened without following links, and a file there that is a
+	// link, is owned by another user or has other hard links makes opening
+	// and rotating fail with an *UnsafeFileError.  Links in the directories
+	// leading up to that one aren't guarded against; see PinDir.  Setting it
+	// relaxes this for setups that put a link there on purpose.
+	FollowSymlinks bool `json:"followsymlinks" yaml:"followsymlinks"`
+
+	// RecentSize is the number of bytes of the most recent writes to keep in
+	// memory, for RecentLines.  They are kept whether or not they could be
+	// written to disk.  The default is not to keep any.
+	RecentSize int `json:"recentsize" yaml:"recentsize"`
+
+	// MillRetryInterval is how long compression and removal of old log files
+	// wait after they failed, before they are tried again.  The wait doubles
+	// with every failure in a row, up to an hour, so that a broken setup,
+	// such as a permission problem, doesn't get the filesystem hammered on
+	// every rotation.  After three failures in a row the Logger counts as
+	// degraded, in Stats and through OnEvent, until a run succeeds.  The
+	// default is 10 seconds.
+	MillRetryInterval time.Duration `json:"millretryinterval" yaml:"millretryinterval"`
+
+	// Fallback, if set, receives what is written while the filesystem of the
+	// log file is read-only, as it often becomes after disk errors, instead
+	// of every write failing.  It could be os.Stderr, or a writer to syslog
+	// or memory.  While in fallback, writing to the log file is retried every
+	// FallbackRetryInterval, and once that succeeds the Logger goes back to
+	// it.  OnEvent is told about both transitions.
+	Fallback io.Writer `json:"-" yaml:"-"`
+
+	// FallbackRetryInterval is how often to retry the log file while writing
+	// to the Fallback.  The default is 10 seconds.
+	FallbackRetryInterval time.Duration `json:"fallbackretryinterval" yaml:"fallbackretryinterval"`
+
+	// Tee, if set, are writers that get a copy of everything written to the
+	// log file, or the Fallback, once it has been written there, such as
+	// os.Stdout for container logs alongside the files kept on the node.  A
+	// write that fails doesn't reach them.  They are written to in turn,
+	// while Write holds the Logger, so a slow one slows down logging, and
+	// they are not closed by Close.  With BufferSize, they get writes as they
+	// are written out, several at a time.  Headers, footers and summaries
+	// aren't copied to them.
+	Tee []io.Writer `json:"-" yaml:"-"`
+
+	// TeeErrors makes Write return the error from writing to a Tee writer,
+	// once the write has made it to the log file.  By default such errors
+	// are only counted in Stats, as TeeErrors rather than WriteErrors, and
+	// passed to the ErrorHandler.
+	TeeErrors bool `json:"teeerrors" yaml:"teeerrors"`
+
+	// LockFile determines if the Logger takes an exclusive advisory lock on
+	// a lock file next to the log file, named like it with ".lock" appended,
+	// when it first opens the log file, and holds it until Close.  Writes then
+	// fail fast with an error wrapping ErrLocked if another Logger already
+	// holds the lock, rather than two replicas silently writing to and
+	// rotating the same file.  It has no effect where file locking isn't
+	// supported, other than creating the lock file.  The default is false.
+	LockFile bool `json:"lockfile" yaml:"lockfile"`
+
+	// RotationIntent makes the Logger record each rotation in an intent
+	// file next to the log file, named like it with ".intent" appended,
+	// synced to disk before the log file is renamed, and removed once the
+	// new log file has been created and given its header.  When the Logger
+	// opens the log file and finds an intent file left behind by a crash, it
+	// finishes the rotation: a new log file missing, or left empty, is
+	// created with the mode and owner of the backup, and given its header.
+	// The default is false.
+	RotationIntent bool `json:"rotationintent" yaml:"rotationintent"`
+
+	// MillLock makes each pass of compression and removal of old log files
+	// take an exclusive advisory lock on a lock file in the directory of the
+	// log file, named .lumberjack-mill.lock, waiting for it if another pass
+	// holds it.  Processes managing the backups of the same directory, such
+	// as two replicas, or a service and a cleanup run from the command line,
+	// then take turns rather than racing to remove or compress the same
+	// backups.  It has no effect where file locking isn't supported.  The
+	// default is false.
+	MillLock bool `json:"milllock" yaml:"milllock"`
+
+	// RescanInterval, if positive, makes the Logger keep the list of backups
+	// in memory between rotations, adding and dropping backups as it makes,
+	// compresses, moves and removes them, and only read the directories they
+	// are kept in again once the list is RescanInterval old, to pick up what
+	// other processes have done.  On directories with thousands of files, or
+	// on network filesystems, that saves reading and sorting the whole
+	// directory on every rotation.  Backups added or removed by anything else
+	// may go unseen until then.  It has no effect with the Sequential
+	// NamingScheme, whose backups are renamed on every rotation.  The
+	// default, 0, reads the directories every time.
+	RescanInterval time.Duration `json:"rescaninterval" yaml:"rescaninterval"`
+
+	// OwnerFromDir determines if new log files and backups are owned by the
+	// owner and group of the log directory, rather than keeping the owner of
+	// the previous log file.  This gives the group inheritance of a setgid
+	// directory, for the owner as well, without setting the bit.  It is only
+	// supported on Linux, and requires the privilege to change owners.  The
+	// default is false.
+	OwnerFromDir bool `json:"ownerfromdir" yaml:"ownerfromdir"`
+
+	// NoChown disables copying the owner of the previous log file onto the
+	// new log file and compressed backups, or on Windows, its access control
+	// list.  Use it where the chown would fail or isn't wanted, such as in
+	// containers running as non-root.  The default is false.
+	NoChown bool `json:"nochown" yaml:"nochown"`
+
+	// ChownPolicy decides what happens when changing the owner of a log file
+	// fails for lack of permission: fail the rotation or compression, which
+	// is the default, carry on regardless, or carry on and report it to
+	// OnEvent.  Other chown errors always fail.
+	ChownPolicy ChownPolicy `json:"chownpolicy" yaml:"chownpolicy"`
+
+	// SkipChownInSetgidDir determines if the owner of the previous log file
+	// is left alone when the log directory has the setgid bit set, so new
+	// log files and compressed backups keep the owner and group they are
+	// created with.  Either way, files in a setgid directory keep the
+	// directory's group.  The default is false.
+	SkipChownInSetgidDir bool `json:"skipchowninsetgiddir" yaml:"skipchowninsetgiddir"`
+
+	// LowPriority determines if post-rotation work such as compression and
+	// removal of old log files runs at reduced CPU and IO priority, so that it
+	// doesn't compete with latency-sensitive application threads.  This is
+	// supported on Linux and Windows; elsewhere it has no effect.
+	LowPriority bool `json:"lowpriority" yaml:"lowpriority"`
+
+	// BufferSize is the maximum number of bytes of written data to hold in
+	// memory.  If set, Write only queues the data and returns, while a
+	// background goroutine writes it to the log file.  Once the buffer is full,
+	// Write blocks until there is room again, unless BufferSpill is set.  A
+	// write larger than BufferSize is spilled with BufferSpill, and otherwise
+	// waits for the buffer to drain and is written to the file directly, so
+	// memory use never goes past BufferSize.  Errors from writing in the
+	// background are returned by the next call to Write or Close.  The default
+	// is to write to the file directly.
+	BufferSize int `json:"buffersize" yaml:"buffersize"`
+
+	// BufferSpill determines if writes that don't fit in the buffer are spilled
+	// to a temporary file next to the log file rather than blocking, so that a
+	// prolonged disk stall can't grow memory use past BufferSize while the order
+	// of writes is still kept.  It has no effect unless BufferSize is set.
+	BufferSpill bool `json:"bufferspill" yaml:"bufferspill"`
+
+	// FlushInterval is how long buffered writes may wait in the buffer for
+	// more to join them, so that many small writes reach the log file in one
+	// larger write, for services doing so many writes that writing each to
+	// the file is the bottleneck.  The buffer is written out sooner once it is
+	// full, and by Flush, Sync, Rotate and Close.  What is still in the
+	// buffer is lost if the process dies.  It has no effect unless BufferSize
+	// is set.  The default is to write buffered data out as soon as possible.
+	FlushInterval time.Duration `json:"flushinterval" yaml:"flushinterval"`
+
+	// StrictOrder makes buffered writes reach the log file in the order the
+	// calls to Write started, first in, first out.  Otherwise a short write
+	// can get ahead of a longer one that is waiting for room in a full
+	// buffer.  Each write is written whole either way, never interleaved
+	// with another.  A write waiting its turn gives up like one waiting for
+	// room, so WriteContext still bounds the wait.  It has no effect unless
+	// BufferSize is set; without it, writes reach the log file in the order
+	// they get hold of the Logger's lock.
+	StrictOrder bool `json:"strictorder" yaml:"strictorder"`
+
+	// KeyQuota is the maximum number of bytes of the buffer that the writes
+	// of any one key may hold, so that a chatty component can't fill the
+	// buffer and starve the writes of others while the Logger is saturated.
+	// Writes are given a key with WithWriteKey and WriteContext; those
+	// without one share the quota of the empty key.  A write that would take
+	// its key past the quota waits, like one that doesn't fit in the buffer,
+	// or with BufferSpill, is spilled, while the writes of other keys go
+	// ahead.  The waits are counted in Stats.  It has no effect unless
+	// BufferSize is set, nor with StrictOrder, under which a write held back
+	// would hold back all those after it too.  The default is no quota.
+	KeyQuota int `json:"keyquota" yaml:"keyquota"`
+
+	// SingleWriter asserts that Write is only ever called from one goroutine at
+	// a time, so that it can skip acquiring the Logger's mutex.  This is meant
+	// for hot pipelines that already serialize writes upstream.  Write then
+	// does no synchronization at all, so it must also not be called
+	// concurrently with Rotate or Close; any such misuse is a data race, which
+	// the race detector reports.  It has no effect if BufferSize is set, and
+	// Write takes the mutex after all with the options that rotate, switch or
+	// sync the log file in the background: RotationInterval, placeholders in
+	// Filename, TriggerFile, SyncInterval and SyncWrites, as well as once
+	// RotateOnSignal or ReopenOnSignal has been called or the Logger has been
+	// registered with RegisterFlush, for FlushOnSignal, and for the Loggers of
+	// a MultiLogger with MaxOpenFiles, whose files other Loggers' writes
+	// close.
+	SingleWriter bool `json:"singlewriter" yaml:"singlewriter"`
+
+	// Envelope wraps each write in a JSON object on a line of its own, with
+	// the time of the write, the host name, a sequence number counting the
+	// Logger's writes and the write itself as the payload, as in:
+	//
+	//	{"time":"2016-11-04T18:30:00Z","host":"web1","seq":42,"payload":"GET / 200"}
+	//
+	// so that plain text output becomes machine-readable without changing
+	// the code producing it.  Trailing newlines are dropped from the payload,
+	// and one that is a JSON value already is embedded as it is, rather than
+	// as a string.  MaxSize applies to the wrapped write.  The default is to
+	// write writes as they are.
+	Envelope bool `json:"envelope" yaml:"envelope"`
+
+	// EnvelopeSource adds the key of each write, given with WithWriteKey or
+	// SourceWriter, to its envelope as "source", so that when several
+	// components share one log file, which wrote each record can be told
+	// later, and their output taken apart again.  Writes without a key get
+	// none.  It has no effect unless Envelope is set.
+	EnvelopeSource bool `json:"envelopesource" yaml:"envelopesource"`
+
+	// InvalidUTF8 is what to do with writes that aren't valid UTF-8, such as
+	// binary data logged by accident, which would break consumers of JSON
+	// lines: keep them as they are, replace the invalid bytes, or reject the
+	// write with an *InvalidUTF8Error.  The default is to keep them.
+	InvalidUTF8 UTF8Policy `json:"invalidutf8" yaml:"invalidutf8"`
+
+	// Hooks, if set, are called in turn with each write, before InvalidUTF8,
+	// Sequence and Envelope apply, each with what the one before it
+	// returned, and can leave the write as it is, change it, or reject it,
+	// for policies kept outside the Logger, such as refusing writes once a
+	// legal hold is in place.  A rejected write fails with a
+	// *RejectedError, writing none of it.  Hooks are called one write at a
+	// time, under the same conditions as OnEvent, and must not change the
+	// slice they are given, but may return a changed copy.
+	Hooks []WriteHook `json:"-" yaml:"-"`
+
+	// PartialLines is what to do with a partial line, one without a newline,
+	// at the end of an existing log file the Logger carries on with, as left
+	// by a crash in the middle of a write, so that line-based parsers don't
+	// choke on it run together with the next write: keep it as it is, end it
+	// with a marker, or move it to foo.log.partial next to the log file.  The
+	// default is to keep it.
+	PartialLines PartialLinePolicy `json:"partiallines" yaml:"partiallines"`
+
+	// ShortWrites is what to do with a record that the log file took only
+	// part of, as when the disk fills up in the middle of it, which fails
+	// with a *ShortWriteError telling how much of it was written: leave it
+	// cut short, write the rest of it, or end it with a marker and a newline
+	// before the next write to the same log file, so that it isn't silently
+	// run together with the next record.  Writes fail as long as that can't
+	// be done.  Short writes are counted in Stats.  The default is to leave
+	// the record as it is.
+	ShortWrites ShortWritePolicy `json:"shortwrites" yaml:"shortwrites"`
+
+	// Sequence numbers each write, counting up from 1 for each Logger, at
+	// the start or at the end of it, as in "42 GET / 200" or "GET / 200 42",
+	// so that whatever reads the log files can tell where writes went
+	// missing, whether they were dropped, lost in a crash or not shipped.
+	// The number is placed before the trailing newline of a write, if there
+	// is one, and counts towards MaxSize.  A write that fails leaves a gap.
+	// With Envelope, the number goes in the envelope instead.  The default is
+	// not to number writes.
+	Sequence SequencePosition `json:"sequence" yaml:"sequence"`
+
+	// MaxRotations is the maximum number of rotations allowed within
+	// RotationLimitInterval.  Once it is reached, rotations that MaxSize calls
+	// for are skipped, and the current log file is allowed to grow past MaxSize
+	// until the interval has passed, protecting against log storms or
+	// pathological configurations that would otherwise create thousands of
+	// backups.  Explicit calls to Rotate are never skipped, but they do count
+	// towards the limit.  The default is not to limit rotations.
+	MaxRotations int `json:"maxrotations" yaml:"maxrotations"`
+
+	// RotationLimitInterval is the interval MaxRotations applies to.  It
+	// defaults to one minute.
+	RotationLimitInterval time.Duration `json:"rotationlimitinterval" yaml:"rotationlimitinterval"`
+
+	// SyncInterval makes writes durable: Write only returns once what it
+	// wrote has been synced to disk.  Writes that come in within SyncInterval
+	// of the last sync are synced together, in a group commit, so that the
+	// disk is synced at most once per SyncInterval however many goroutines
+	// write, at the cost of those writes waiting for up to SyncInterval.  It
+	// has no effect with BufferSize, where writes return before they reach
+	// the file.  The default is to leave syncing to the operating system.
+	SyncInterval time.Duration `json:"syncinterval" yaml:"syncinterval"`
+
+	// SyncWrites makes writes durable like SyncInterval, but without waiting
+	// between syncs: the log file is synced as soon as a write is waiting
+	// for it, and the writes that come in while the disk is being flushed
+	// are synced together by the next sync.  Either way, the syncing is done
+	// by a goroutine of its own, off the Logger's lock, so other writes
+	// carry on while it flushes.  With SyncInterval as well, syncs are still
+	// at least SyncInterval apart.  It has no effect with BufferSize.  The
+	// default is false.
+	SyncWrites bool `json:"syncwrites" yaml:"syncwrites"`
+
+	// Header, if set, is written at the start of every log file the Logger
+	// creates, so that each file describes itself, for auditors and for
+	// whoever reads a backup out of context.  It is a text/template, executed
+	// with a HeaderData, as in:
+	//
+	//	# {{.Fields.app}} {{.Fields.version}} on {{.Hostname}}, file {{.Seq}} started {{.Time}}
+	//
+	// The json function quotes a string for a header that is a line of JSON,
+	// as in {"host":{{json .Hostname}},"seq":{{.Seq}}}.  A newline is added if
+	// the header doesn't end with one.
+	Header string `json:"header" yaml:"header"`
+
+	// HeaderFields are the values available to Header as .Fields, such as the
+	// application's name and version.
+	HeaderFields map[string]string `json:"headerfields" yaml:"headerfields"`
+
+	// WriteHeader, if set, is called to write the start of every log file the
+	// Logger creates, after the Header, for formats that need one per file,
+	// such as the field line of W3C extended logs or the magic number of a
+	// binary format.  What it writes counts towards MaxSize.  An error it
+	// returns is recorded, but doesn't fail the write that opened the file.
+	WriteHeader func(w io.Writer) error `json:"-" yaml:"-"`
+
+	// WriteFooter, if set, is called to write the end of the log file just
+	// before it is rotated, after the end Summary, if any.  The log file isn't
+	// given a footer on Close, since the Logger may carry on writing to it
+	// after a restart.  An error it returns is recorded, but doesn't fail the
+	// rotation.
+	WriteFooter func(w io.Writer) error `json:"-" yaml:"-"`
+
+	// Summary, if set, writes a summary of each log file as it is rotated,
+	// as the last line of the file, the first line of the new one, or both,
+	// so that whatever ingests the files can check that it got everything:
+	//
+	//	{"lumberjack":"rotation summary","lines":1200,"bytes":98304,"first":"...","last":"..."}
+	//
+	// It counts the lines and bytes the Logger wrote to the file and the
+	// times of the first and last of those writes, leaving out the Header,
+	// summaries and whatever the file held when the Logger opened it.  The
+	// summary counts towards the size of the file it is written to, so the
+	// one at the end can take a file past MaxSize.  The default is not to
+	// write summaries.
+	Summary SummaryPlacement `json:"summary" yaml:"summary"`
+
+	// TerminationFile, if set, is where a short account of how the Logger
+	// ended goes when it is closed, or when WriteTermination is called, such
+	// as by FlushOnPanic: why it ended, the state of rotation, and the last
+	// TerminationLines lines at error level or above, as DetectLevel tells
+	// them.  This is for Kubernetes' terminationMessagePath, by default
+	// /dev/termination-log, which makes it the pod's termination message.
+	// Only the first 4096 bytes of that are read, so the oldest lines are
+	// dropped to fit.  The default is not to write one.
+	TerminationFile string `json:"terminationfile" yaml:"terminationfile"`
+
+	// TerminationLines is how many error lines go in the TerminationFile.
+	// It defaults to 10.
+	TerminationLines int `json:"terminationlines" yaml:"terminationlines"`
+
+	// OnEvent, if set, is called with notable events, such as hitting the
+	// rotation limit.  It is called synchronously, possibly while the Logger
+	// holds its lock or from the goroutine doing post-rotation work, so it
+	// should return quickly and must not call methods on the Logger.
+	OnEvent func(Event) `json:"-" yaml:"-"`
+
+	// ErrorHandler, if set, is called with every error the Logger runs into,
+	// as counted in Stats, so that failures of the compression, moving and
+	// removal of old log files in the background, which no caller gets to
+	// see, can be logged elsewhere or alerted on.  Errors that Write returns
+	// are passed to it as well.  It is called under the same conditions as
+	// OnEvent.
+	ErrorHandler func(error) `json:"-" yaml:"-"`
+
+	// OnRotate, if set, is called after each rotation, with the path of the
+	// log file and that of the backup it was moved to, such as to upload the
+	// backup.  Like the other lifecycle callbacks, it is called under the
+	// same conditions as OnEvent, and only once the file is in place, never
+	// while it is still being written under a temporary name.  With Compress
+	// or BackupDir, the backup named is not final yet; wait for OnCompress or
+	// use RotationMarker for that.
+	OnRotate func(oldPath, newPath string) `json:"-" yaml:"-"`
+
+	// OnCompress, if set, is called with the path of each compressed backup,
+	// or that of its first part with CompressPartSize, once it is complete.
+	OnCompress func(path string) `json:"-" yaml:"-"`
+
+	// OnRemove, if set, is called with the path of each old log file removed
+	// by cleanup.
+	OnRemove func(path string) `json:"-" yaml:"-"`
+
+	// Trace, if set, is called at the start of the operations that can make
+	// logging stall, one of TraceWrite, TraceRotate or TraceSync, with the
+	// context of the write they are part of, and returns a context for the
+	// operations nested in it along with the function to call when it ends,
+	// so that time spent logging can be told apart in traces, such as by
+	// starting an OpenTelemetry span.  A rotation done by a write comes
+	// under its TraceWrite.  It is called under the same conditions as
+	// OnEvent.  See RuntimeTrace.
+	Trace func(ctx context.Context, op string) (context.Context, func()) `json:"-" yaml:"-"`
+
+	// Fault, if set, is called before each write to the log file, rename of
+	// it or of a backup, and change of owner, with one of FaultWrite,
+	// FaultRename or FaultChown and the name of the file, and if it returns
+	// an error, the operation fails with that error instead of being done,
+	// wrapped in an *os.PathError, as if the filesystem had returned it.  It
+	// is meant for testing how code around the Logger handles failures; see
+	// lumberjacktest.Faults.
+	Fault func(op, name string) error `json:"-" yaml:"-"`
+
+	// Metrics, if set, is told of the bytes written, rotations, removals of
+	// old log files and compressions, such as to export them as counters.  See
+	// ExpvarMetrics.
+	Metrics MetricsRecorder `json:"-" yaml:"-"`
+
+	// Labels are static labels of the Logger, such as the service, component
+	// and instance it logs for, so that what it reports can be told apart in
+	// a process with many Loggers.  They are passed on with every Event, to
+	// Metrics if it is a MetricsLabeler, to the Header as .Labels, in the
+	// rotation summaries and as profiler labels of the Logger's goroutines.
+	// They must not be changed once the Logger is used.
+	Labels map[string]string `json:"labels" yaml:"labels"`
+
+	// Scheduler, if set, runs the compression and removal of old log files
+	// on its workers, shared with other Loggers, rather than on a goroutine
+	// of this Logger's own.  See Scheduler.
+	Scheduler *Scheduler `json:"-" yaml:"-"`
+
 	size int64
 	file *os.File
 	mu   sync.Mutex
 
-	millCh    chan bool
-	startMill sync.Once
+	millCh   chan bool
+	millDone chan struct{}
+	millMu   sync.Mutex // guards millCh and millDone, for a SingleWriter
+
+	triggerStop chan struct{}
+	triggerDone chan struct{}
+
+	signalStop chan struct{} // closed by Close, to stop RotateOnSignal
+	signalled  int32         // set atomically once signals are handled or FlushAll syncs it
+
+	holding bool   // BeginRotate is rotating
+	rotated string // the backup the last rotation moved the log file to
+	held    map[string]bool
+	heldMu  sync.Mutex
+
+	written    int64 // writes done with SyncInterval or SyncWrites
+	syncState  groupSync
+	files      int64 // log files started, for HeaderData.Seq
+	headerTmpl *template.Template
+	headerSrc  string // the Header headerTmpl was parsed from
+	fresh      bool   // nothing but the header has been written to file yet
+
+	target   string // the log file that Filename, targetOf, points at
+	targetOf string
+	targetMu sync.Mutex
+
+	pathExp atomic.Value // the *pathExpansion of Filename
+
+	expandedName string // what Filename's placeholders, expandedOf, expanded to; guarded by targetMu
+	expandedOf   string
+	expandedAt   int64 // the second the expansion was last checked
+
+	hostname  string
+	recordSeq int64 // the last sequence number given to a write
+	summary   fileSummary
+	term      termination
+
+	stream    *compressStream
+	streamMu  sync.Mutex
+	finishing string // the backup the stream is being finished for
+
+	dirInfo os.FileInfo // the directory of the log file, for RecreateDir
+	pin     *pinnedDir  // the directory of the log file, for PinDir
+
+	short     []byte   // what ShortWrites still has to write
+	shortFile *os.File // the log file that a record was cut short in
+
+	detached   []detachedBackup
+	detachedMu sync.Mutex
+
+	manifestMu sync.Mutex // serializes updates of the Manifest file
+
+	marks   []RotationMark // the rotations done, for the StateFile
+	marksMu sync.Mutex
+
+	backupList backupList // the backups, with RescanInterval
+
+	tag   string // what ArchiveTag, tagOf, expanded to
+	tagOf string
+	tagMu sync.Mutex
+
+	millTimer   *time.Timer
+	millTimerAt time.Time
+	millTimerMu sync.Mutex
+	millRetryAt time.Time // guarded by statsMu
+
+	buf   *asyncBuffer
+	bufMu sync.Mutex
+
+	stats   Stats
+	statsMu sync.Mutex
+
+	rotateAt    time.Time
+	rotateTimer *time.Timer
+	switchTimer *time.Timer
+
+	rotations []time.Time
+	limited   bool
+	counter   int64
+
+	segStart int64     // the offset the log file starts at, for OffsetWriter
+	segments []segment // the backups rotated to, for OffsetWriter
+	segMu    sync.Mutex
+
+	openFiles *openFiles // of the MultiLogger it belongs to, if it has MaxOpenFiles
+
+	labelMetrics sync.Once // gives Metrics the Labels
+
+	clock clockRef
+
+	closed int32 // set atomically by Close
+
+	detectedFS    int32     // the Filesystem detected with FSAuto, set atomically
+	reopenChecked time.Time // when ReopenOnMove last checked, for FSNFS
+
+	deprecations []Deprecation // of the Config, for EventDeprecated
+
+	lockFile *os.File
+
+	readOnly bool
+	retryAt  time.Time
+
+	diskLow       bool // the last check found too little free disk space
+	diskCheckedAt time.Time
+
+	recent     *Ring
+	recentOnce sync.Once
+
+	traceCtx context.Context // of the write under way, for Trace and EnvelopeSource
 }
 
+// ErrClosed is returned by Write, Rotate and DupFile once the Logger has been
+// closed.
+var ErrClosed = errors.New("lumberjack: logger is closed")
+
 var (
 	// currentTime exists so it can be mocked out by tests.
 	currentTime = time.Now
@@ -128,6 +1219,9 @@ var (
 	// os_Stat exists so it can be mocked out by tests.
 	osStat = os.Stat
 
+	// osOpenFile exists so it can be mocked out by tests.
+	osOpenFile = os.OpenFile
+
 	// megabyte is the conversion factor between MaxSize and bytes.  It is a
 	// variable so tests can mock it out and not need to write megabytes of data
 	// to disk.
@@ -138,291 +1232,1059 @@ var (
 // than MaxSize, the file is closed, renamed to include a timestamp of the
 // current time, and a new log file is created using the original log file name.
 // If the length of the write is greater than MaxSize, an error is returned.
+// After Close, Write returns ErrClosed.
 func (l *Logger) Write(p []byte) (n int, err error) {
+	return l.WriteContext(context.Background(), p)
+}
+
+// WriteContext is like Write, but gives up if ctx is done before p could be
+// written, returning the context's error, so that a caller serving a request
+// can keep its latency bounded even when logging is the bottleneck.  With
+// BufferSize, that covers waiting for room in a full buffer.  Otherwise the
+// context is checked before the write starts, including once other writes
+// have been waited for, since a write under way to the log file can't be
+// abandoned.
+func (l *Logger) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if l.openFiles != nil {
+		l.openFiles.touch(l)
+	}
+	if l.Trace != nil {
+		var end func()
+		ctx, end = l.Trace(ctx, TraceWrite)
+		defer end()
+	}
+	if l.RecentSize > 0 {
+		l.recentRing().Write(p)
+	}
+	if l.BufferSize > 0 {
+		b := l.buffer()
+		if b == nil {
+			return 0, ErrClosed
+		}
+		return b.write(ctx, p)
+	}
+	if l.lockFree() {
+		if l.isClosed() {
+			return 0, ErrClosed
+		}
+		l.traceCtx = ctx
+		n, err = l.writeRecord(p)
+		l.traceCtx = nil
+		if seq := l.sequence(n, err); seq > 0 {
+			err = l.waitSynced(seq)
+		}
+		return n, err
+	}
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	if l.isClosed() {
+		l.mu.Unlock()
+		return 0, ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		// done while waiting for other writes.
+		l.mu.Unlock()
+		return 0, err
+	}
+	l.traceCtx = ctx
+	n, err = l.writeRecord(p)
+	l.traceCtx = nil
+	seq := l.sequence(n, err)
+	l.mu.Unlock()
+	if seq > 0 {
+		err = l.waitSynced(seq)
+	}
+	return n, err
+}
+
+// writeRecord writes p, as the caller passed it to Write, checking and
+// wrapping it as configured first.  It returns the number of bytes of p
+// written; if it had to be changed, that is all of them if it was written in
+// full and none otherwise.  It
+// assumes that l.mu is held, or that there is a SingleWriter.
+func (l *Logger) writeRecord(p []byte) (n int, err error) {
+	rec, changed, err := l.record(p, l.source())
+	if err != nil {
+		l.recordError(writeError, err)
+		return 0, err
+	}
+	if !changed {
+		return l.write(p)
+	}
+	if n, err = l.write(rec); n < len(rec) {
+		return 0, err
+	}
+	return len(p), err
+}
 
+// record returns what to write to the log file for p, as the caller passed it
+// to Write, after passing it through the Hooks, checking that it's valid UTF-8
+// and numbering it or wrapping it in its envelope, with the given source, as
+// configured, and whether that differs from p.  It assumes that writes are
+// serialized, as wrap does.
+func (l *Logger) record(p []byte, source string) (rec []byte, changed bool, err error) {
+	hooked, changed, err := l.runHooks(p)
+	if err != nil {
+		return nil, false, err
+	}
+	numbered := l.Sequence != SequenceNone && !l.Envelope
+	if l.InvalidUTF8 == UTF8Keep && !l.Envelope && !numbered {
+		return hooked, changed, nil
+	}
+	if rec, err = l.checkUTF8(hooked); err != nil {
+		return nil, false, err
+	}
+	if numbered {
+		rec, err = l.number(rec)
+	} else {
+		rec, err = l.wrap(rec, source)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, changed || l.Envelope || numbered || l.InvalidUTF8 == UTF8Replace && !bytes.Equal(rec, p), nil
+}
+
+// lockFree reports whether Write can go without l.mu, with a SingleWriter and
+// nothing rotating, switching, syncing or, for a MultiLogger with
+// MaxOpenFiles, closing the log file in the background, which would race with
+// it.
+func (l *Logger) lockFree() bool {
+	return l.SingleWriter && l.openFiles == nil && l.RotationInterval <= 0 && l.TriggerFile == "" && !l.durable() &&
+		!l.expandedPath().placeholders && atomic.LoadInt32(&l.signalled) == 0
+}
+
+// isClosed reports whether Close has been called.
+func (l *Logger) isClosed() bool {
+	return atomic.LoadInt32(&l.closed) != 0
+}
+
+// write writes p to the current log file, rotating first if needed, or to the
+// Fallback while the filesystem is read-only, and then to the Tee.  It assumes
+// that l.mu is held.
+func (l *Logger) write(p []byte) (n int, err error) {
+	teeFailed := false
+	defer func() {
+		if err != nil && !teeFailed {
+			l.recordError(writeError, err)
+		}
+	}()
+
+	if err = l.checkDisk(); err != nil {
+		return 0, err
+	}
+	if l.Fallback != nil {
+		n, err = l.writeOrFallback(p)
+	} else {
+		n, err = l.writeFile(p)
+	}
+	if err == nil && len(l.Tee) > 0 {
+		if errTee := l.tee(p[:n]); errTee != nil {
+			// the write made it to the log file, so it isn't a write error.
+			l.recordError(teeError, errTee)
+			if l.TeeErrors {
+				teeFailed = true
+				return n, errTee
+			}
+		}
+	}
+	return n, err
+}
+
+// tee writes p, just written to the log file, to each of the Tee writers,
+// returning the first error.  It assumes that l.mu is held.
+func (l *Logger) tee(p []byte) error {
+	var err error
+	for _, w := range l.Tee {
+		if _, errTee := w.Write(p); errTee != nil && err == nil {
+			err = fmt.Errorf("can't write to tee: %w", errTee)
+		}
+	}
+	return err
+}
+
+// writeFile writes p to the current log file, rotating first if needed.  It
+// assumes that l.mu is held.
+func (l *Logger) writeFile(p []byte) (n int, err error) {
 	writeLen := int64(len(p))
-	if writeLen > l.max() {
+	max := l.max()
+	if !l.TrustedWrites && writeLen > max && !l.OversizeWrites {
 		return 0, fmt.Errorf(
-			"write length %d exceeds maximum file size %d", writeLen, l.max(),
+			"write length %d exceeds maximum file size %d", writeLen, max,
 		)
 	}
 
+	l.switchExpanded()
 	if l.file == nil {
-		if err = l.openExistingOrNew(len(p)); err != nil {
+		if err = l.openFirst(len(p)); err != nil {
 			return 0, err
 		}
 	}
 
-	if l.size+writeLen > l.max() {
+	if l.RecreateDir {
+		if err := l.recreateDirIfRemoved(len(p)); err != nil {
+			return 0, err
+		}
+	}
+	if l.ReopenOnMove && l.reopenDue() {
+		if err := l.reopenIfMoved(len(p)); err != nil {
+			return 0, err
+		}
+	}
+
+	if l.Adopt {
+		// Another writer may have appended since the last write.
+		if info, errStat := l.file.Stat(); errStat == nil {
+			l.size = info.Size()
+		}
+	}
+
+	// An oversized write already has a fresh file to itself.
+	oversizedAlone := writeLen > max && l.fresh
+	if l.rotationDue() || l.size+writeLen > max && !l.rotationLimited() && !oversizedAlone {
 		if err := l.rotate(); err != nil {
 			return 0, err
 		}
 	}
 
+	if err = l.resumeShort(); err != nil {
+		return 0, err
+	}
+	if err = l.fault(FaultWrite, l.file.Name()); err != nil {
+		return 0, err
+	}
 	n, err = l.file.Write(p)
 	l.size += int64(n)
+	if err != nil && n > 0 && n < len(p) {
+		err = l.cutShort(p, n, err)
+	}
+	if n > 0 {
+		l.fresh = false
+	}
+	l.streamWrite(p[:n])
+	l.countWrite(p[:n])
+	l.noteErrorLines(p[:n])
+	if m := l.metrics(); m != nil && n > 0 {
+		m.BytesWritten(n)
+	}
 
 	return n, err
 }
 
-// Close implements io.Closer, and closes the current logfile.
+// openFirst opens the log file for the first write, of writeLen bytes, or the
+// first one since it was closed.  This method assumes l.mu is held.
+func (l *Logger) openFirst(writeLen int) error {
+	if err := l.openExistingOrNew(writeLen); err != nil {
+		return err
+	}
+	l.detectFilesystem()
+	l.noteDir()
+	l.scheduleSwitch()
+	l.watchTrigger()
+	l.linkCurrent()
+	l.reportDeprecations()
+	return nil
+}
+
+// Close implements io.Closer, and closes the current logfile.  If writes are
+// buffered, everything still queued is written out first.  Compression and
+// removal of old log files that is under way is finished before Close returns,
+// and no goroutine of the Logger's outlives it.
+//
+// A Logger can't be used after Close: writes that start once Close has been
+// called return ErrClosed instead of opening the file again, while writes
+// already under way finish first.  Calling Close again does nothing.  See
+// CloseContext for bounding how long Close waits.
 func (l *Logger) Close() error {
+	atomic.StoreInt32(&l.closed, 1)
+	unregisterFlush(l)
+	err := l.closeBuffer()
+	l.stopMillTimer()
+
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	return l.close()
+	l.stopRotationTimer()
+	l.stopSwitchTimer()
+	if errClose := l.close(); err == nil {
+		err = errClose
+	}
+	l.unpinDir()
+	l.abortStream()
+	if errUnlock := l.unlock(); err == nil {
+		err = errUnlock
+	}
+	done := l.stopMill()
+	trigger := l.stopTrigger()
+	l.stopSignals()
+	l.mu.Unlock()
+	l.stopSyncer()
+
+	// Wait for the post-rotation work that is already under way, so that
+	// nothing keeps running once Close returns.
+	if done != nil {
+		<-done
+	}
+	if trigger != nil {
+		<-trigger
+	}
+	if l.Scheduler != nil {
+		l.Scheduler.waitFor(l)
+	}
+	// Don't leave a detached backup behind, it would be lost.
+	if l.compressDetached() != nil {
+		_ = l.restoreDetached()
+	}
+	l.closeTermination()
+	return err
 }
 
-// close closes the file if it is open.
+// CloseContext is like Close, but gives up waiting for buffered writes and for
+// compression and removal of old log files when ctx is done, returning the
+// context's error, so that shutting down can be kept within a deadline.  The
+// Logger is closed to writes either way, and the work it gave up waiting for
+// carries on in the background.
+func (l *Logger) CloseContext(ctx context.Context) error {
+	atomic.StoreInt32(&l.closed, 1)
+	done := make(chan error, 1)
+	go func() { done <- l.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close closes the file if it is open, syncing it first with SyncInterval or
+// SyncWrites.
 func (l *Logger) close() error {
 	if l.file == nil {
 		return nil
 	}
+	l.finishShort()
+	var errSync error
+	if l.durable() {
+		errSync = l.syncFile()
+		l.markSynced(errSync)
+	}
 	err := l.file.Close()
 	l.file = nil
+	if err == nil {
+		err = errSync
+	}
 	return err
 }
 
 // Rotate causes Logger to close the existing log file and immediately create a
 // new one.  This is a helper function for applications that want to initiate
 // rotations outside of the normal rotation rules, such as in response to
-// SIGHUP.  After rotating, this initiates compression and removal of old log
-// files according to the configuration.
+// SIGHUP; see RotateOnSignal.  After rotating, this initiates compression and
+// removal of old log files according to the configuration.
 func (l *Logger) Rotate() error {
+	// Get anything written before the call into the file being rotated.
+	l.flushBuffer()
+	if l.openFiles != nil {
+		l.openFiles.touch(l)
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.isClosed() {
+		return ErrClosed
+	}
 	return l.rotate()
 }
 
 // rotate closes the current file, moves it aside with a timestamp in the name,
 // (if it exists), opens a new file with the original filename, and then runs
-// post-rotation processing and removal.
+// post-rotation processing and removal.  With RotationOrder, the current file
+// may be closed only once the new one is open.
 func (l *Logger) rotate() error {
-	if err := l.close(); err != nil {
+	defer l.trace(TraceRotate)()
+	l.finishShort()
+	summary := l.endSummary()
+	l.writeFooter()
+	var renamed *os.File
+	if l.renameFirst() {
+		renamed, l.file = l.file, nil
+	} else if err := l.closeBeforeRename(); err != nil {
 		return err
 	}
-	if err := l.openNew(); err != nil {
+	err := l.openNew()
+	if renamed != nil {
+		if errClose := l.closeRenamed(renamed); err == nil {
+			err = errClose
+		}
+	}
+	if err != nil {
 		return err
 	}
+	l.startSummary(summary)
+	l.linkCurrent()
+	l.recordRotation()
+	l.countRotation()
+	l.term.rotations++
+	l.term.lastRotation = currentTime()
+	if m := l.metrics(); m != nil {
+		m.Rotated()
+	}
 	l.mill()
 	return nil
 }
 
+// recordRotation notes the time of a rotation for MaxRotations.
+func (l *Logger) recordRotation() {
+	if l.MaxRotations <= 0 {
+		return
+	}
+	l.rotations = append(l.rotations, currentTime())
+	if len(l.rotations) > l.MaxRotations {
+		l.rotations = l.rotations[len(l.rotations)-l.MaxRotations:]
+	}
+	l.limited = false
+}
+
+// rotationLimited reports whether MaxRotations rotations already happened
+// within RotationLimitInterval, sending EventRotationLimited when the limit is
+// first hit.
+func (l *Logger) rotationLimited() bool {
+	if l.MaxRotations <= 0 || len(l.rotations) < l.MaxRotations {
+		return false
+	}
+	interval := l.RotationLimitInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	oldest := l.rotations[len(l.rotations)-l.MaxRotations]
+	if currentTime().Sub(oldest) >= interval {
+		return false
+	}
+	if !l.limited {
+		l.limited = true
+		l.emit(Event{Type: EventRotationLimited, Path: l.filename()})
+	}
+	return true
+}
+
 // openNew opens a new log file for writing, moving any old log file out of the
 // way.  This methods assumes the file has already been closed.
 func (l *Logger) openNew() error {
-	err := os.MkdirAll(l.dir(), 0755)
+	return l.openNewLike(nil)
+}
+
+// openNewLike is like openNew, but if there is no log file to move aside,
+// and prev, the info of the backup of an interrupted rotation, isn't nil,
+// creates the new file with the mode and owner of prev, as rotating would
+// have.  This method assumes l.mu is held.
+func (l *Logger) openNewLike(prev os.FileInfo) error {
+	err := os.MkdirAll(l.dir(), l.dirMode())
 	if err != nil {
-		return fmt.Errorf("can't make directories for new logfile: %s", err)
+		return fmt.Errorf("can't make directories for new logfile: %w", err)
+	}
+	if err := l.lock(); err != nil {
+		return err
 	}
 
 	name := l.filename()
 	mode := os.FileMode(0644)
 
 	if l.fileModeIsSet() {
-		mode = l.FileMode
+		mode = l.fileMode()
 	}
 
-	info, err := osStat(name)
-	if err == nil {
+	info, err := l.statAt(name)
+	var unsafe *UnsafeFileError
+	if errors.As(err, &unsafe) {
+		return err
+	}
+	existed := err == nil
+	var intentOf string   // the log file whose rotation intent is recorded
+	var owner os.FileInfo // whose owner the new file gets once open
+	if existed {
+		info = withSecurity(info, name)
 		// Copy the mode off the old logfile.
 		mode = info.Mode()
 		// move the existing file
-		newname := backupName(name, l.LocalTime)
-		if err := os.Rename(name, newname); err != nil {
-			return fmt.Errorf("can't rename log file: %s", err)
+		if l.sequential() {
+			if err := l.shiftBackups(); err != nil {
+				return err
+			}
 		}
-
-		// this is a no-op anywhere but linux
-		if err := chown(name, info); err != nil {
+		newname, err := l.resolveCollision(l.backupName(name))
+		if err != nil {
 			return err
 		}
+		if l.stream != nil {
+			l.setFinishing(newname)
+			defer l.setFinishing("")
+		}
+		if err := l.writeIntent(name, newname); err != nil {
+			return err
+		}
+		intentOf = name
+		// Hold it before it's there, so that a mill run under way can't get
+		// to it.
+		l.hold(newname)
+		if l.holding || !l.detachBackup(name, newname) {
+			if err := l.moveAside(name, newname); err != nil {
+				l.unhold(newname)
+				return err
+			}
+			l.listAdd(newname)
+		}
+		if l.ResolveOnRotate {
+			// the link may point elsewhere by now.
+			l.resolveAgain()
+			name = l.filename()
+			if err := os.MkdirAll(filepath.Dir(name), l.dirMode()); err != nil {
+				return fmt.Errorf("can't make directories for new logfile: %w", err)
+			}
+		}
+		if !l.OwnerFromDir && l.chownOnOpen(name) {
+			owner = info
+		} else if !l.OwnerFromDir {
+			// this is a no-op anywhere but Linux and Windows
+			if err := l.chown(name, info); err != nil {
+				return err
+			}
+		}
+		l.finishStream(newname, info.Size())
+		l.addSegment(newname, info.Size())
+		now, mono := currentTime(), monotonicNow()
+		l.markRotation(newname, now, mono)
+		l.emit(Event{Type: EventRotated, Path: newname, Time: now, Monotonic: mono})
+		if !l.Compress && !l.staged() && !l.holding {
+			l.writeMarker(newname, []string{newname})
+		}
+	} else if prev != nil {
+		mode = prev.Mode()
+		if !l.OwnerFromDir && l.chownOnOpen(name) {
+			owner = prev
+		} else if !l.OwnerFromDir {
+			if err := l.chown(name, prev); err != nil {
+				return err
+			}
+		}
 	}
 
 	// we use truncate here because this should only get called when we've moved
 	// the file ourselves. if someone else creates the file in the meantime,
-	// just wipe out the contents.
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	// just wipe out the contents, unless we are sharing the file, in which
+	// case we append to what the other writer wrote.
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if l.Adopt {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := l.openAt(name, flag, mode)
 	if err != nil {
-		return fmt.Errorf("can't open new logfile: %s", err)
+		return fmt.Errorf("can't open new logfile: %w", err)
+	}
+	if owner != nil {
+		if err := l.chownOpen(f, owner); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if l.OwnerFromDir {
+		if err := l.chownToDir(name, l.dir()); err != nil {
+			f.Close()
+			return fmt.Errorf("can't set owner of new logfile: %s", err)
+		}
+	}
+	if !existed && l.fileModeIsSet() {
+		if err := restrictMode(name, mode, l.Permissions); err != nil {
+			f.Close()
+			return fmt.Errorf("can't set mode of new logfile: %s", err)
+		}
 	}
 	l.file = f
 	l.size = 0
+	l.summary = fileSummary{}
+	l.scheduleRotation(currentTime())
+	info, errStat := f.Stat()
+	if errStat == nil && !l.Adopt {
+		l.startStream(withSecurity(info, name))
+	} else {
+		l.abortStream()
+	}
+	if errStat == nil && info.Size() == 0 {
+		l.writeHeader()
+	}
+	l.fresh = true
+	if intentOf != "" {
+		l.clearIntent(intentOf)
+	}
+	return nil
+}
+
+// tooLong reports whether a write of n bytes is refused for being longer than
+// MaxSize.
+func (l *Logger) tooLong(n int64) bool {
+	return !l.TrustedWrites && n > l.max() && !l.OversizeWrites
+}
+
+// moveAside renames the log file to the backup name, and gives the backup its
+// mode and owner.
+func (l *Logger) moveAside(name, backup string) error {
+	if err := l.renameAt(name, backup); err != nil {
+		return fmt.Errorf("can't rename log file: %w", err)
+	}
+	if l.backupModeIsSet() {
+		if err := os.Chmod(backup, l.BackupMode); err != nil {
+			return fmt.Errorf("can't set mode of backup file: %s", err)
+		}
+	}
+	if l.OwnerFromDir {
+		if err := l.chownToDir(backup, l.dir()); err != nil {
+			return fmt.Errorf("can't set owner of backup file: %s", err)
+		}
+	}
 	return nil
 }
 
 // backupName creates a new filename from the given name, inserting a timestamp
 // between the filename and the extension, using the local time if requested
-// (otherwise UTC).
-func backupName(name string, local bool) string {
+// (otherwise UTC).  It uses up the next rotation counter, if enabled.
+func (l *Logger) backupName(name string) string {
+	var counter int64
+	if l.RotationCounter {
+		counter = l.nextCounter()
+	}
+	return l.backupNameWith(name, counter)
+}
+
+// backupNameWith is like backupName, but uses the given rotation counter.
+func (l *Logger) backupNameWith(name string, counter int64) string {
+	if l.sequential() {
+		return sequentialName(name, 1)
+	}
 	dir := filepath.Dir(name)
 	filename := filepath.Base(name)
 	ext := filepath.Ext(filename)
 	prefix := filename[:len(filename)-len(ext)]
 	t := currentTime()
-	if !local {
+	if !l.LocalTime {
 		t = t.UTC()
 	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, l.formatStamp(t, counter), ext))
+}
 
-	timestamp := t.Format(backupTimeFormat)
-	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+// formatStamp formats the part of a backup name between the prefix and the
+// extension, for the time t and the rotation counter, if it isn't 0.
+func (l *Logger) formatStamp(t time.Time, counter int64) string {
+	layout := l.BackupTimePrecision.layout()
+	if l.LocalTime && l.ZoneOffset {
+		layout += zoneOffsetLayout
+	}
+	if l.BackupTimeFormat != "" {
+		layout = l.BackupTimeFormat
+	}
+	timestamp := t.Format(layout)
+	if counter > 0 {
+		timestamp = formatCounter(counter) + "-" + timestamp
+	}
+	return timestamp
 }
 
 // openExistingOrNew opens the logfile if it exists and if the current write
 // would not put it over MaxSize.  If there is no such file or the write would
 // put it over the MaxSize, a new file is created.
 func (l *Logger) openExistingOrNew(writeLen int) error {
+	if err := l.lock(); err != nil {
+		return err
+	}
+	prev := l.recoverRotation()
 	l.mill()
 
 	filename := l.filename()
-	info, err := osStat(filename)
+	info, err := l.statAt(filename)
 	if os.IsNotExist(err) {
-		return l.openNew()
+		return l.openNewLike(prev)
 	}
 	if err != nil {
-		return fmt.Errorf("error getting log file info: %s", err)
+		return fmt.Errorf("error getting log file info: %w", err)
 	}
+	// the time it was last written to, before any partial line is fixed.
+	last := info.ModTime()
+	info = l.recoverPartial(filename, info)
 
-	if info.Size()+int64(writeLen) >= l.max() {
+	if info.Size()+int64(writeLen) >= l.max() && !l.rotationLimited() {
+		return l.rotate()
+	}
+	if info.Size() == 0 {
+		last = currentTime()
+	}
+	if !l.scheduleRotation(last) {
+		// written to in an earlier RotationInterval.
 		return l.rotate()
 	}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	file, err := l.openAt(filename, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		// if we fail to open the old log file for some reason, just ignore
 		// it and open a new log file.
 		return l.openNew()
 	}
+	if l.stream != nil && l.stream.in != info.Size() {
+		// not the file the stream was started for.
+		l.abortStream()
+	}
 	l.file = file
 	l.size = info.Size()
+	l.fresh = false
+	l.summary = fileSummary{}
 	return nil
 }
 
-// filename generates the name of the logfile from the current time.
+// filename generates the name of the logfile from the current time.  On
+// Windows, names that are too long to be used as is, as they easily are in
+// deeply nested container paths, are converted to the extended-length form so
+// that opening, renaming and scanning for backups keep working.
 func (l *Logger) filename() string {
+	name := l.configuredFilename()
+	l.targetMu.Lock()
+	defer l.targetMu.Unlock()
+	if l.targetOf != name {
+		l.targetOf, l.target = name, name
+		if !l.guarded(name) {
+			l.target = resolveLink(name)
+		}
+	}
+	return l.target
+}
+
+// configuredFilename is like filename, but doesn't follow Filename if it is a
+// symbolic link.
+func (l *Logger) configuredFilename() string {
 	if l.Filename != "" {
-		return l.Filename
+		exp := l.expandedPath()
+		name := exp.path
+		if exp.placeholders {
+			name = l.expanded(name)
+		}
+		return longPath(name)
+	}
+	if l.Dir != "" {
+		return longPath(l.managedName())
 	}
 	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
-	return filepath.Join(os.TempDir(), name)
+	return longPath(filepath.Join(os.TempDir(), name))
 }
 
 // fileModeIsSet checks if the file mode of the log file was set. If so
 // it returns true. It does not validate the mode.
 func (l *Logger) fileModeIsSet() bool {
-	if uint32(l.FileMode) != 0 {
+	if uint32(l.fileMode()) != 0 {
 		return true
 	}
 
 	return false
 }
 
+// backupModeIsSet checks if a mode for backup files was set.
+func (l *Logger) backupModeIsSet() bool {
+	return uint32(l.BackupMode) != 0
+}
+
+// compressor returns the function used to compress backups.
+func (l *Logger) compressor() func(dst io.Writer, src io.Reader) error {
+	if l.Encrypter != nil {
+		return l.codec().Compress
+	}
+	if pad := l.padSize(); pad > 0 {
+		return padGzip(l.plainCompressor(), pad)
+	}
+	return l.plainCompressor()
+}
+
+// plainCompressor is like compressor, but leaves out the encryption.
+func (l *Logger) plainCompressor() func(dst io.Writer, src io.Reader) error {
+	if g, ok := l.Compressor.(Gzip); l.CompressRsyncable && (ok || l.Compressor == nil) {
+		return rsyncableGzip(g.level())
+	}
+	if l.Compressor != nil {
+		return l.Compressor.Compress
+	}
+	if l.CompressConcurrency > 1 {
+		return parallelGzip(l.CompressConcurrency)
+	}
+	return gzipCopy
+}
+
 // millRunOnce performs compression and removal of stale log files.
 // Log files are compressed if enabled via configuration and old log
 // files are removed, keeping at most l.MaxBackups files, as long as
 // none of them are older than MaxAge.
 func (l *Logger) millRunOnce() error {
-	if l.MaxBackups == 0 && l.MaxAge == 0 && !l.Compress {
+	if !l.millNeeded() {
 		return nil
 	}
-
-	files, err := l.oldLogFiles()
+	if err := l.checkManaged(); err != nil {
+		l.recordError(otherError, err)
+		return err
+	}
+	unlock, err := l.lockMill()
 	if err != nil {
+		l.recordError(otherError, err)
 		return err
 	}
+	defer unlock()
 
-	var compress, remove []logInfo
-
-	if l.MaxBackups > 0 && l.MaxBackups < len(files) {
-		preserved := make(map[string]bool)
-		var remaining []logInfo
-		for _, f := range files {
-			// Only count the uncompressed log file or the
-			// compressed log file, not both.
-			fn := f.Name()
-			if strings.HasSuffix(fn, compressSuffix) {
-				fn = fn[:len(fn)-len(compressSuffix)]
-			}
-			preserved[fn] = true
+	// Rename the backups with legacy names first, so that they are seen under
+	// their new names, and compress the detached backups, so that they are
+	// counted.
+	errMigrate := l.migrateBackups()
+	errDetached := l.compressDetached()
 
-			if len(preserved) > l.MaxBackups {
-				remove = append(remove, f)
-			} else {
-				remaining = append(remaining, f)
-			}
-		}
-		files = remaining
+	files, err := l.oldLogFiles()
+	if err != nil {
+		l.recordError(otherError, err)
+		return err
 	}
-	if l.MaxAge > 0 {
-		diff := time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
-		cutoff := currentTime().Add(-1 * diff)
-
-		var remaining []logInfo
-		for _, f := range files {
-			if f.timestamp.Before(cutoff) {
-				remove = append(remove, f)
-			} else {
-				remaining = append(remaining, f)
-			}
-		}
-		files = remaining
+	err = errDetached
+	if err == nil {
+		err = errMigrate
 	}
 
+	files, remove := l.withoutHeld(l.retain(files))
+	var compress []logInfo
+
+	skip := make(map[string]bool) // backups to leave without BackupAttr and BackupXattrs
 	if l.Compress {
+		allowed := l.compressAllowed()
+		now := currentTime()
+		var due time.Time // when the next backup held back by CompressAfter is
 		for _, f := range files {
-			if !strings.HasSuffix(f.Name(), compressSuffix) {
+			if isCompressed(f.Name(), l.compressSuffixes()) {
+				continue
+			}
+			switch {
+			case l.tooSmallToCompress(f.Size()):
+				// left as it is for good.
+			case !allowed:
+				// gets BackupAttr once it has been compressed.
+				skip[f.Name()] = true
+			case l.CompressAfter > 0 && now.Sub(f.timestamp) < l.CompressAfter:
+				skip[f.Name()] = true
+				if at := f.timestamp.Add(l.CompressAfter); due.IsZero() || at.Before(due) {
+					due = at
+				}
+			default:
 				compress = append(compress, f)
 			}
 		}
+		if !due.IsZero() {
+			// compress it then, even if nothing rotates in the meantime.
+			l.millAfter(due.Sub(now))
+		}
 	}
 
 	for _, f := range remove {
-		errRemove := os.Remove(filepath.Join(l.dir(), f.Name()))
+		fn := f.path()
+		errRemove := removeFile(fn)
+		if errRemove != nil {
+			l.recordError(removeError, errRemove)
+		} else {
+			l.listRemove(fn)
+			l.emit(Event{Type: EventRemoved, Path: fn, Reason: f.reason})
+			l.removeMarker(fn)
+			l.forgetSegment(fn)
+			if m := l.metrics(); m != nil {
+				m.BackupRemoved()
+			}
+		}
 		if err == nil && errRemove != nil {
 			err = errRemove
 		}
 	}
+	placed := make(map[string][]string) // where backups went, if moved
 	for _, f := range compress {
-		fn := filepath.Join(l.dir(), f.Name())
-		errCompress := compressLogFile(fn, fn+compressSuffix)
+		fn := f.path()
+		if l.isFinishing(fn) {
+			// compressed by CompressOnWrite.
+			skip[f.Name()] = true
+			continue
+		}
+		start := time.Now()
+		partSize := int64(l.CompressPartSize) * int64(megabyte)
+		dst := filepath.Join(l.backupDir(), f.Name()+l.compressSuffix())
+		errCompress := l.makeBackupDir()
+		var names []string
+		if errCompress == nil {
+			names, errCompress = compressFile(fn, dst, partSize, l.hashBefore(), l.BackupMode, l.compressor(), l.verifier(), l.chown)
+		}
+		if errCompress != nil {
+			if _, err := os.Stat(fn); os.IsNotExist(err) {
+				// compressed by CompressOnWrite since it was listed.
+				skip[f.Name()] = true
+				continue
+			}
+			skip[f.Name()] = true
+			l.recordError(compressError, errCompress)
+		} else {
+			placed[f.Name()] = names
+			l.listRemove(fn)
+			l.compressionDone(f.Size(), time.Since(start), names)
+			if errKeep := l.moveKeep(f); errKeep != nil {
+				l.recordError(otherError, errKeep)
+			}
+		}
 		if err == nil && errCompress != nil {
 			err = errCompress
 		}
 	}
+	if len(remove) > 0 || len(compress) > 0 {
+		// what was found free before is out of date.
+		forgetDiskFree(l.dir(), l.backupDir())
+	}
+	if l.staged() {
+		if errMove := l.archiveStaged(files, placed, skip); err == nil {
+			err = errMove
+		}
+	}
+	if l.BackupAttr != AttrNone || l.tagged() {
+		for _, f := range files {
+			if skip[f.Name()] {
+				continue
+			}
+			names, ok := placed[f.Name()]
+			if !ok {
+				names = []string{f.path()}
+			}
+			for _, name := range names {
+				// before BackupAttr, which may make the file immutable.
+				errAttr := l.setXattrs(name)
+				if errAttr == nil {
+					errAttr = setFileAttr(name, l.BackupAttr)
+				}
+				if errAttr != nil {
+					l.recordError(otherError, errAttr)
+				}
+				if err == nil && errAttr != nil {
+					err = errAttr
+				}
+			}
+		}
+	}
+	if errState := l.writeState(); err == nil {
+		err = errState
+	}
+	if errManifest := l.writeManifest(); err == nil {
+		err = errManifest
+	}
+	if errProm := l.writePromFile(); err == nil {
+		err = errProm
+	}
 
 	return err
 }
 
+// millNeeded reports whether the configuration calls for any post-rotation
+// work at all.
+func (l *Logger) millNeeded() bool {
+	return l.MaxBackups != 0 || l.MaxAge != 0 || l.MaxHistory != 0 || l.MaxTotalSize != 0 || l.ProjectQuota || l.minFreeSet() ||
+		l.Compress || l.BackupAttr != AttrNone || l.tagged() || l.staged() ||
+		l.MigrateBackups || l.StateFile != "" || l.Manifest || l.PromFile != ""
+}
+
 // millRun runs in a goroutine to manage post-rotation compression and removal
-// of old log files.
-func (l *Logger) millRun() {
-	for range l.millCh {
-		// what am I going to do, log this?
-		_ = l.millRunOnce()
+// of old log files, until ch is closed.  It closes done when it returns.
+func (l *Logger) millRun(ch <-chan bool, done chan<- struct{}) {
+	defer close(done)
+	if l.LowPriority {
+		// The thread is never unlocked, so it exits along with this goroutine
+		// rather than going back to the scheduler with a lowered priority.
+		runtime.LockOSThread()
+		_ = lowerThreadPriority()
+	}
+	for range ch {
+		l.millRunBackoff()
 	}
 }
 
 // mill performs post-rotation compression and removal of stale log files,
-// starting the mill goroutine if necessary.
+// starting the mill goroutine if necessary.  This method assumes l.mu is held.
 func (l *Logger) mill() {
-	l.startMill.Do(func() {
+	if !l.millNeeded() {
+		return
+	}
+	if l.Scheduler != nil {
+		l.Scheduler.schedule(l)
+		return
+	}
+	if inlineMill {
+		l.millRunBackoff()
+		return
+	}
+	// The mill timer starts runs too, while a SingleWriter writes without
+	// l.mu.
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	if l.millCh == nil {
 		l.millCh = make(chan bool, 1)
-		go l.millRun()
-	})
+		l.millDone = make(chan struct{})
+		ch, done := l.millCh, l.millDone
+		go l.labeled("mill", func() { l.millRun(ch, done) })
+	}
 	select {
 	case l.millCh <- true:
 	default:
 	}
 }
 
+// stopMill tells the mill goroutine, if it is running, to exit once it has
+// done the work asked of it so far, and returns a channel that is closed when
+// it has.  This method assumes l.mu is held.
+func (l *Logger) stopMill() <-chan struct{} {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	if l.millCh == nil {
+		return nil
+	}
+	close(l.millCh)
+	done := l.millDone
+	l.millCh, l.millDone = nil, nil
+	return done
+}
+
 // oldLogFiles returns the list of backup log files stored in the same
-// directory as the current log file, sorted by ModTime
+// directory as the current log file, sorted by ModTime.  With RescanInterval,
+// the list is kept up to date as the Logger makes or removes backups, and the
+// directories are only read again once it is RescanInterval old.
 func (l *Logger) oldLogFiles() ([]logInfo, error) {
-	files, err := ioutil.ReadDir(l.dir())
+	if l.listCached() {
+		return l.cachedLogFiles()
+	}
+	return l.readLogFiles()
+}
+
+// readLogFiles reads the list of backup log files from their directories.
+func (l *Logger) readLogFiles() ([]logInfo, error) {
+	logFiles, err := l.backupsIn(l.dir())
 	if err != nil {
 		return nil, fmt.Errorf("can't read log file directory: %s", err)
 	}
+	if l.staged() {
+		archived, err := l.backupsIn(l.backupDir())
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("can't read backup directory: %s", err)
+		}
+		logFiles = append(logFiles, archived...)
+	}
+
+	sort.Sort(byFormatTime(logFiles))
+
+	return logFiles, nil
+}
+
+// backupsIn returns the backup files in the given directory.
+func (l *Logger) backupsIn(dir string) ([]logInfo, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
 	logFiles := []logInfo{}
 
 	prefix, ext := l.prefixAndExt()
@@ -431,42 +2293,96 @@ func (l *Logger) oldLogFiles() ([]logInfo, error) {
 		if f.IsDir() {
 			continue
 		}
-		if t, err := l.timeFromName(f.Name(), prefix, ext); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
+		if info, ok := l.backupInfo(f, dir, prefix, ext); ok {
+			logFiles = append(logFiles, info)
 		}
-		if t, err := l.timeFromName(f.Name(), prefix, ext+compressSuffix); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
-		}
-		// error parsing means that the suffix at the end was not generated
-		// by lumberjack, and therefore it's not a backup file.
 	}
-
-	sort.Sort(byFormatTime(logFiles))
-
 	return logFiles, nil
 }
 
+// backupInfo returns the logInfo of f, in dir, and whether it is a backup,
+// given the prefix and extension of the log file.
+func (l *Logger) backupInfo(f os.FileInfo, dir, prefix, ext string) (logInfo, bool) {
+	if l.sequential() {
+		// the numbered backups are dated by when they were last written.
+		seq, ok := l.sequenceOf(f.Name())
+		return logInfo{timestamp: f.ModTime(), dir: dir, FileInfo: f, seq: seq}, ok
+	}
+	if t, n, dup, err := l.backupStamp(f.Name(), prefix, ext); err == nil {
+		return logInfo{t, n, dup, dir, f, 0}, true
+	}
+	name := f.Name()
+	if archive, part, ok := splitPart(name, l.compressSuffixes()); ok {
+		if l.StrictBackupNames && (l.CompressPartSize <= 0 || name != archive+"."+formatPart(part)) {
+			return logInfo{}, false
+		}
+		name = archive
+	}
+	for _, suffix := range l.compressSuffixes() {
+		if t, n, dup, err := l.backupStamp(name, prefix, ext+suffix); err == nil {
+			return logInfo{t, n, dup, dir, f, 0}, true
+		}
+	}
+	// error parsing means that the suffix at the end was not generated by
+	// lumberjack, and therefore it's not a backup file.
+	return logInfo{}, false
+}
+
 // timeFromName extracts the formatted time from the filename by stripping off
 // the filename's prefix and extension. This prevents someone's filename from
 // confusing time.parse.
 func (l *Logger) timeFromName(filename, prefix, ext string) (time.Time, error) {
+	t, _, err := l.stampFromName(filename, prefix, ext)
+	return t, err
+}
+
+// stampFromName is like timeFromName, but also returns the rotation counter in
+// the name, or 0 if there is none.
+func (l *Logger) stampFromName(filename, prefix, ext string) (time.Time, int64, error) {
 	if !strings.HasPrefix(filename, prefix) {
-		return time.Time{}, errors.New("mismatched prefix")
+		return time.Time{}, 0, errors.New("mismatched prefix")
 	}
 	if !strings.HasSuffix(filename, ext) {
-		return time.Time{}, errors.New("mismatched extension")
+		return time.Time{}, 0, errors.New("mismatched extension")
 	}
 	ts := filename[len(prefix) : len(filename)-len(ext)]
-	return time.Parse(backupTimeFormat, ts)
+	t, n, err := parseBackupStamp(ts, l.backupLayouts())
+	if err == nil && l.StrictBackupNames && !l.roundTrips(ts, t, n) {
+		return time.Time{}, 0, errors.New("not named under the current naming options")
+	}
+	return t, n, err
+}
+
+// backupStamp is like stampFromName, but also accepts names that
+// CollisionSuffix added a number to, and returns that number, or 0 if there is
+// none, as well as names that ContentHash added a hash to.
+func (l *Logger) backupStamp(filename, prefix, ext string) (time.Time, int64, int, error) {
+	t, n, err := l.stampFromName(filename, prefix, ext)
+	if err == nil || !strings.HasSuffix(filename, ext) {
+		return t, n, 0, err
+	}
+	if stem, ok := splitHash(filename[:len(filename)-len(ext)]); ok && (!l.StrictBackupNames || l.ContentHash) {
+		return l.backupStamp(stem+ext, prefix, ext)
+	}
+	stem, dup, ok := splitDup(filename[:len(filename)-len(ext)])
+	if !ok || l.StrictBackupNames && l.BackupCollision != CollisionSuffix {
+		return t, n, 0, err
+	}
+	t, n, err = l.stampFromName(stem+ext, prefix, ext)
+	return t, n, dup, err
 }
 
 // max returns the maximum size in bytes of log files before rolling.
 func (l *Logger) max() int64 {
+	if l.MaxBytes > 0 {
+		return l.MaxBytes
+	}
 	if l.MaxSize == 0 {
 		return int64(defaultMaxSize * megabyte)
 	}
+	if l.MaxSize < 0 {
+		return math.MaxInt64
+	}
 	return int64(l.MaxSize) * int64(megabyte)
 }
 
@@ -484,96 +2400,50 @@ func (l *Logger) prefixAndExt() (prefix, ext string) {
 	return prefix, ext
 }
 
-// compressLogFile compresses the given log file, removing the
-// uncompressed log file if successful.
-func compressLogFile(src, dst string) (err error) {
-	f, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %v", err)
-	}
-	defer f.Close()
-
-	fi, err := osStat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat log file: %v", err)
-	}
-
-	// Use a different filename to write the file, so that anything looking for
-	// "*.gz" only sees the compressed file after it's been finished writing to.
-	tmpDst := dst + tmpSuffix
-
-	if err := chown(tmpDst, fi); err != nil {
-		return fmt.Errorf("failed to chown compressed log file: %v", err)
-	}
-
-	// If this file already exists, we presume it was created by
-	// a previous attempt to compress the log file.
-	gzf, err := os.OpenFile(tmpDst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
-	if err != nil {
-		return fmt.Errorf("failed to open compressed log file: %v", err)
-	}
-	defer gzf.Close()
-
-	gz := gzip.NewWriter(gzf)
-
-	defer func() {
-		if err != nil {
-			os.Remove(tmpDst)
-			err = fmt.Errorf("failed to compress log file: %v", err)
+// removeFile removes the named file.  If the file is protected against removal,
+// by being read-only on Windows or by BackupAttr on Linux, the protection is
+// lifted and the removal retried.
+func removeFile(name string) error {
+	err := os.Remove(name)
+	if err != nil && !os.IsNotExist(err) {
+		if unprotect(name) == nil {
+			err = os.Remove(name)
 		}
-	}()
-
-	if _, err := io.Copy(gz, f); err != nil {
-		return err
 	}
-
-	// Close the gzip writer.
-	// Closing also triggers a Flush to the underlying
-	// io.Writer, and doesnot close the underlying io.Writer.
-	// We must Close() or Flush() the gz writer before Sync()ing otherwise we may
-	// see partially written data and a corrupt gzip archive.
-	if err := gz.Close(); err != nil {
-		return err
-	}
-
-	// fsync is important, otherwise os.Rename could rename a zero-length file
-	if err := gzf.Sync(); err != nil {
-		return err
-	}
-
-	// close the underlying gzip file
-	if err := gzf.Close(); err != nil {
-		return err
-	}
-
-	// close the source file we copied from
-	if err := f.Close(); err != nil {
-		return err
-	}
-
-	// Atomically replace the destination file
-	if err := os.Rename(tmpDst, dst); err != nil {
-		return err
-	}
-
-	if err := os.Remove(src); err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
 // logInfo is a convenience struct to return the filename and its embedded
 // timestamp.
 type logInfo struct {
 	timestamp time.Time
+	counter   int64 // the rotation counter in the name, 0 if there is none
+	dup       int   // the number CollisionSuffix added to the name, if any
+	dir       string
 	os.FileInfo
+	seq int // the number of the backup with Sequential naming
 }
 
-// byFormatTime sorts by newest time formatted in the name.
+// path returns the path of the backup file.
+func (f logInfo) path() string {
+	return filepath.Join(f.dir, f.Name())
+}
+
+// byFormatTime sorts by newest time formatted in the name, or by the highest
+// rotation counter when both names have one.  Of backups with the same time,
+// the ones CollisionSuffix added a higher number to are newer.
 type byFormatTime []logInfo
 
 func (b byFormatTime) Less(i, j int) bool {
+	if b[i].seq > 0 && b[j].seq > 0 {
+		return b[i].seq < b[j].seq
+	}
+	if b[i].counter > 0 && b[j].counter > 0 {
+		return b[i].counter > b[j].counter
+	}
+	if b[i].timestamp.Equal(b[j].timestamp) {
+		return b[i].dup > b[j].dup
+	}
 	return b[i].timestamp.After(b[j].timestamp)
 }
 
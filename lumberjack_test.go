@@ -3,11 +3,16 @@ package lumberjack
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -94,6 +99,153 @@ func TestWriteTooLong(t *testing.T) {
 	assert(os.IsNotExist(err), t, "File exists, but should not have been created")
 }
 
+func TestMaxBytes(t *testing.T) {
+	currentTime = fakeTime
+	defer func(mb int) { megabyte = mb }(megabyte)
+	megabyte = 1024 * 1024
+	dir := makeTempDir("TestMaxBytes", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  1,
+		MaxBytes: 10,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// MaxBytes takes precedence over MaxSize.
+	newFakeTime()
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(filename, b2, t)
+
+	_, err = l.Write([]byte("booooooooooooooo!"))
+	notNil(err, t)
+}
+
+func TestOversizeWrites(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOversizeWrites", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        10,
+		OversizeWrites: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// a write longer than MaxSize gets a file of its own...
+	newFakeTime()
+	first := backupFile(dir)
+	big := []byte("booooooooooooooo!")
+	n, err := l.Write(big)
+	isNil(err, t)
+	equals(len(big), n, t)
+	existsWithContent(first, []byte("boo!"), t)
+	existsWithContent(filename, big, t)
+
+	// ...and the next write goes to a new one.
+	newFakeTime()
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), big, t)
+	existsWithContent(filename, []byte("foo!"), t)
+	fileCount(dir, 3, t)
+
+	// a fresh file takes an oversized write without rotating first.
+	isNil(l.Close(), t)
+	isNil(os.Remove(filename), t)
+	l = &Logger{
+		Filename:       filename,
+		MaxSize:        10,
+		OversizeWrites: true,
+	}
+	defer l.Close()
+	_, err = l.Write(big)
+	isNil(err, t)
+	existsWithContent(filename, big, t)
+	fileCount(dir, 3, t)
+}
+
+func TestTrustedWrites(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTrustedWrites", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		MaxSize:       10,
+		TrustedWrites: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// a write longer than MaxSize isn't refused, but is rotated around.
+	newFakeTime()
+	first := backupFile(dir)
+	big := []byte("booooooooooooooo!")
+	n, err := l.Write(big)
+	isNil(err, t)
+	equals(len(big), n, t)
+	existsWithContent(first, []byte("boo!"), t)
+	existsWithContent(filename, big, t)
+
+	newFakeTime()
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), big, t)
+	existsWithContent(filename, []byte("foo!"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestNoSizeRotation(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestNoSizeRotation", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  -1,
+	}
+	defer l.Close()
+
+	// however much is written, it all goes to the one file.
+	var want []byte
+	for i := 0; i < 100; i++ {
+		b := []byte("booooooooooooooo!")
+		want = append(want, b...)
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+	existsWithContent(filename, want, t)
+	fileCount(dir, 1, t)
+
+	// but it can still be rotated explicitly.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(backupFile(dir), want, t)
+	fileCount(dir, 2, t)
+}
+
 func TestMakeLogDir(t *testing.T) {
 	currentTime = fakeTime
 	dir := time.Now().Format("TestMakeLogDir" + backupTimeFormat)
@@ -751,6 +903,299 @@ compress = true`[1:]
 	equals(0, len(md.Undecoded()), t)
 }
 
+func TestSingleWriter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestSingleWriter", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      10,
+		SingleWriter: true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, b, t)
+
+	newFakeTime()
+
+	b2 := []byte("foooooo!")
+	n, err = l.Write(b2)
+	isNil(err, t)
+	equals(len(b2), n, t)
+	existsWithContent(filename, b2, t)
+	existsWithContent(backupFile(dir), b, t)
+	fileCount(dir, 2, t)
+}
+
+func TestSingleWriterBackground(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestSingleWriterBackground", t)
+	defer os.RemoveAll(dir)
+
+	// what rotates, switches or syncs the log file in the background makes
+	// writes take the lock after all.
+	filename := logFile(dir)
+	equals(true, (&Logger{Filename: filename, SingleWriter: true}).lockFree(), t)
+	for _, l := range []*Logger{
+		{Filename: filename, SingleWriter: true, RotationInterval: time.Hour},
+		{Filename: filepath.Join(dir, "foo-%Y%m%d.log"), SingleWriter: true},
+		{Filename: filename, SingleWriter: true, TriggerFile: filename + ".rotate"},
+		{Filename: filename, SingleWriter: true, SyncInterval: time.Millisecond},
+		{Filename: filename, SingleWriter: true, SyncWrites: true},
+	} {
+		equals(false, l.lockFree(), t)
+	}
+	l := &Logger{Filename: filename, SingleWriter: true}
+	stop := l.RotateOnSignal(os.Interrupt)
+	stop()
+	equals(false, l.lockFree(), t)
+
+	// so that rotations on time don't race the writes, as the race detector
+	// would tell.
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+	l = &Logger{
+		Filename:            filename,
+		SingleWriter:        true,
+		RotationInterval:    5 * time.Millisecond,
+		BackupTimePrecision: Nanoseconds,
+	}
+	defer l.Close()
+	for end := time.Now().Add(50 * time.Millisecond); time.Now().Before(end); {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseStopsMill(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCloseStopsMill", t)
+	defer os.RemoveAll(dir)
+
+	before := runtime.NumGoroutine()
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  10,
+		Compress: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// Close waits for the compression, and leaves no goroutine behind.
+	isNil(l.Close(), t)
+	notExist(backupFile(dir), t)
+	exists(backupFile(dir)+compressSuffix, t)
+	goroutinesExit(before, t)
+}
+
+func TestCloseContext(t *testing.T) {
+	if inlineMill {
+		t.Skip("compression runs on the writing goroutine in TinyGo builds")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCloseContext", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	release := make(chan struct{})
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		Compress:   true,
+		Compressor: blockingCompressor{release},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// CloseContext gives up on the stalled compression, but the Logger is
+	// closed all the same.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	equals(context.DeadlineExceeded, l.CloseContext(ctx), t)
+	_, err = l.Write([]byte("foo!"))
+	equals(ErrClosed, err, t)
+
+	// and the compression finishes in the background.
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for fileExists(backupFile(dir)) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	notExist(backupFile(dir), t)
+	exists(backupFile(dir)+compressSuffix, t)
+
+	// without a stall, it's just Close.
+	l = &Logger{Filename: filename}
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	isNil(l.CloseContext(context.Background()), t)
+}
+
+// blockingCompressor is a gzip Compressor that waits for its channel to be
+// closed before compressing.
+type blockingCompressor struct {
+	release chan struct{}
+}
+
+func (c blockingCompressor) Compress(dst io.Writer, src io.Reader) error {
+	<-c.release
+	return Gzip{}.Compress(dst, src)
+}
+
+func (blockingCompressor) Suffix() string { return compressSuffix }
+
+func TestWriteAfterClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteAfterClose", t)
+	defer os.RemoveAll(dir)
+
+	for _, l := range []*Logger{
+		{Filename: logFile(dir)},
+		{Filename: logFile(dir), BufferSize: 100},
+		{Filename: logFile(dir), SingleWriter: true},
+	} {
+		b := []byte("boo!")
+		_, err := l.Write(b)
+		isNil(err, t)
+		isNil(l.Close(), t)
+
+		// nothing reopens the file once the Logger is closed.
+		n, err := l.Write(b)
+		equals(ErrClosed, err, t)
+		equals(0, n, t)
+		equals(ErrClosed, l.Rotate(), t)
+		_, err = l.DupFile()
+		equals(ErrClosed, err, t)
+		assert(l.File() == nil, t, "expected no open file after Close")
+		isNil(l.Close(), t)
+
+		existsWithContent(logFile(dir), b, t)
+		isNil(os.Remove(logFile(dir)), t)
+	}
+}
+
+func TestConcurrentClose(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestConcurrentClose", t)
+	defer os.RemoveAll(dir)
+
+	for _, bufferSize := range []int{0, 64} {
+		filename := logFile(dir)
+		l := &Logger{Filename: filename, MaxSize: 10000, BufferSize: bufferSize}
+
+		// every write either makes it into the file whole, or fails with
+		// ErrClosed and writes nothing.
+		var wg sync.WaitGroup
+		var written int64
+		b := []byte("boo!\n")
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if _, err := l.Write(b); err == nil {
+						atomic.AddInt64(&written, 1)
+					} else if err != ErrClosed {
+						t.Errorf("expected ErrClosed, got %v", err)
+					}
+				}
+			}()
+		}
+		<-time.After(time.Millisecond)
+		isNil(l.Close(), t)
+		wg.Wait()
+
+		data, err := ioutil.ReadFile(filename)
+		isNil(err, t)
+		equals(bytes.Repeat(b, int(written)), data, t)
+		isNil(os.Remove(filename), t)
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestAdopt", t)
+	defer os.RemoveAll(dir)
+
+	// another writer has the log file open.
+	filename := logFile(dir)
+	other, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	isNil(err, t)
+	defer other.Close()
+	_, err = other.Write([]byte("old!"))
+	isNil(err, t)
+
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  10,
+		Adopt:    true,
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("new!"))
+	isNil(err, t)
+	_, err = other.Write([]byte("ol"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("old!new!ol"), t)
+
+	// what the other writer appended counts towards MaxSize.
+	newFakeTime()
+	_, err = l.Write([]byte("ne"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("ne"), t)
+
+	// the other writer follows the file into the backup.
+	_, err = other.Write([]byte("d"))
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("old!new!old"), t)
+}
+
+// goroutinesExit checks that the number of goroutines drops back to n, giving
+// the ones that are on their way out a moment to finish.
+func goroutinesExit(n int, t testing.TB) {
+	for i := 0; i < 100 && runtime.NumGoroutine() > n; i++ {
+		<-time.After(time.Millisecond)
+	}
+	assert(runtime.NumGoroutine() <= n, t, "expected %d goroutines, got %d", n, runtime.NumGoroutine())
+}
+
+func TestNoMillWithoutCleanup(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNoMillWithoutCleanup", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	assert(l.millCh == nil, t, "expected no mill goroutine without compression or removal to do")
+}
+
 // makeTempDir creates a file with a semi-unique name in the OS temp directory.
 // It should be based on the name of the test, to keep parallel tests from
 // colliding, and must be cleaned up after the test is finished.
@@ -813,4 +1258,4 @@ func notExist(path string, t testing.TB) {
 func exists(path string, t testing.TB) {
 	_, err := os.Stat(path)
 	assertUp(err == nil, t, 1, "expected file to exist, but got error from os.Stat: %v", err)
-}
\ No newline at end of file
+}
@@ -0,0 +1,79 @@
+package lumberjacktest
+
+import (
+	"sync"
+)
+
+// Faults makes storage operations of a Logger fail on demand, so that the
+// error handling of the code around the Logger can be tested without filling
+// up a disk or taking away permissions.  Its Fault method is set as the
+// Logger's Fault, as in:
+//
+//	f := new(lumberjacktest.Faults)
+//	f.FailNth(lumberjack.FaultRename, 2, syscall.EXDEV)
+//	f.FailAll(lumberjack.FaultChown, syscall.EPERM)
+//	f.FailAll(lumberjack.FaultWrite, syscall.ENOSPC)
+//	l.Fault = f.Fault
+//
+// Operations fail with the error given, wrapped in an *os.PathError, so that
+// errors.Is finds it.  The zero Faults fails nothing.  A Faults is safe for
+// concurrent use.
+type Faults struct {
+	mu     sync.Mutex
+	counts map[string]int
+	rules  []faultRule
+}
+
+// faultRule is an operation that Faults makes fail.
+type faultRule struct {
+	op  string
+	nth int // the number of the operation to fail, or 0 for all of them
+	err error
+}
+
+// FailNth makes the nth operation op from now on fail with err, counting
+// from 1, and only that one.
+func (f *Faults) FailNth(op string, n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, faultRule{op: op, nth: f.counts[op] + n, err: err})
+}
+
+// FailAll makes every operation op from now on fail with err, until Reset.
+func (f *Faults) FailAll(op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, faultRule{op: op, err: err})
+}
+
+// Reset makes operations succeed again.
+func (f *Faults) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = nil
+}
+
+// Count returns the number of operations op that the Logger has done, or
+// tried to, since f was first used.
+func (f *Faults) Count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[op]
+}
+
+// Fault is the Logger.Fault that counts the operations and makes them fail as
+// set up.
+func (f *Faults) Fault(op, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[op]++
+	for _, r := range f.rules {
+		if r.op == op && (r.nth == 0 || r.nth == f.counts[op]) {
+			return r.err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package lumberjacktest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/khulnasoft-lab/lumberjack.v2"
+)
+
+// errCrossDevice and errNoSpace stand in for EXDEV and ENOSPC, which not
+// every platform has.
+var (
+	errCrossDevice = errors.New("invalid cross-device link")
+	errNoSpace     = errors.New("no space left on device")
+)
+
+func TestFaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestFaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := new(Faults)
+	l := &lumberjack.Logger{Filename: filepath.Join(dir, "app.log"), Fault: f.Fault}
+	defer l.Close()
+	if _, err := l.Write([]byte("boo!\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second rename from now on fails, and only that one.
+	f.FailNth(lumberjack.FaultRename, 2, errCrossDevice)
+	for i, want := range []error{nil, errCrossDevice, nil} {
+		if err := l.Rotate(); !errors.Is(err, want) || (want == nil) != (err == nil) {
+			t.Fatalf("rotation %d: expected %v, got %v", i, want, err)
+		}
+	}
+	if n := f.Count(lumberjack.FaultRename); n != 3 {
+		t.Fatalf("expected 3 renames, got %d", n)
+	}
+
+	f.FailAll(lumberjack.FaultWrite, errNoSpace)
+	if _, err := l.Write([]byte("boo!\n")); !errors.Is(err, errNoSpace) {
+		t.Fatalf("expected %v, got %v", errNoSpace, err)
+	}
+	var pathErr *os.PathError
+	if _, err := l.Write([]byte("boo!\n")); !errors.As(err, &pathErr) {
+		t.Fatalf("expected an *os.PathError, got %v", err)
+	}
+	f.Reset()
+	if _, err := l.Write([]byte("boo!\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// chown failing for lack of permission is up to the ChownPolicy.
+	f.FailAll(lumberjack.FaultChown, os.ErrPermission)
+	if err := l.Rotate(); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected EPERM, got %v", err)
+	}
+	l.ChownPolicy = lumberjack.ChownIgnore
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package lumberjacktest provides tools for testing code that uses
+// lumberjack, and for testing lumberjack itself.
+package lumberjacktest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/khulnasoft-lab/lumberjack.v2"
+)
+
+// StressConfig configures Stress.
+type StressConfig struct {
+	// Writers is the number of goroutines writing concurrently.  The default
+	// is 8.
+	Writers int
+
+	// Records is the number of records each writer writes.  The default is
+	// 1000.
+	Records int
+
+	// RecordSize is the size of each record in bytes, including its trailing
+	// newline.  It is at least large enough for the writer and sequence
+	// number that every record starts with.  The default is 100.
+	RecordSize int
+}
+
+// StressResult describes a run of Stress.
+type StressResult struct {
+	// Records is the number of records written.
+	Records int
+
+	// Bytes is the number of bytes written.
+	Bytes int64
+
+	// Files is the number of log files the records ended up in, including
+	// the current one.
+	Files int
+
+	// Duration is how long writing took, not counting Close or checking the
+	// files afterwards.
+	Duration time.Duration
+}
+
+// Throughput returns the number of bytes written per second.
+func (r StressResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Duration.Seconds()
+}
+
+// recordPrefix starts every record written by Stress, so that its records can
+// be told apart from anything else in the files.
+const recordPrefix = "lumberjacktest"
+
+// Stress drives concurrent writers through l, across as many rotations as l's
+// MaxSize calls for, then closes l and reads back the log file and all of its
+// backups, decompressing them as needed.  It returns an error if any record is
+// missing, appears more than once, was torn or interleaved with another, or
+// ended up out of order relative to the records of the same writer in the same
+// file.  This makes it possible to check that custom settings, such as
+// buffering or compression, don't lose records under pressure.
+//
+// The log file's directory should hold nothing but the files of l, and l
+// mustn't remove backups, so MaxBackups, MaxAge, MaxHistory, MaxTotalSize and
+// MinDiskFree must be 0.  Compressed backups are read back with gzip, so the Compressor
+// must be nil or a Gzip.
+func Stress(l *lumberjack.Logger, cfg StressConfig) (StressResult, error) {
+	if l.MaxBackups != 0 || l.MaxAge != 0 || l.MaxHistory != 0 || l.MaxTotalSize != 0 || l.MinDiskFree != 0 {
+		return StressResult{}, errors.New("the logger must not remove backups: MaxBackups, MaxAge, MaxHistory, MaxTotalSize and MinDiskFree must be 0")
+	}
+	if _, ok := l.Compressor.(lumberjack.Gzip); l.Compressor != nil && !ok {
+		return StressResult{}, errors.New("the logger must compress with gzip, if at all: Compressor must be nil or a Gzip")
+	}
+	if cfg.Writers <= 0 {
+		cfg.Writers = 8
+	}
+	if cfg.Records <= 0 {
+		cfg.Records = 1000
+	}
+	if cfg.RecordSize <= 0 {
+		cfg.RecordSize = 100
+	}
+	if min := len(record(cfg.Writers, cfg.Records, 0)); cfg.RecordSize < min {
+		cfg.RecordSize = min
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, cfg.Writers)
+	for w := 0; w < cfg.Writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for seq := 0; seq < cfg.Records; seq++ {
+				if _, err := l.Write(record(w, seq, cfg.RecordSize)); err != nil {
+					errs <- fmt.Errorf("writer %d, record %d: %s", w, seq, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	res := StressResult{
+		Records:  cfg.Writers * cfg.Records,
+		Bytes:    int64(cfg.Writers) * int64(cfg.Records) * int64(cfg.RecordSize),
+		Duration: time.Since(start),
+	}
+	close(errs)
+	if err := <-errs; err != nil {
+		l.Close()
+		return res, err
+	}
+	if err := l.Close(); err != nil {
+		return res, err
+	}
+
+	files, err := logFiles(l.Filename)
+	if err != nil {
+		return res, err
+	}
+	res.Files = len(files)
+	seen := make([][]bool, cfg.Writers)
+	for w := range seen {
+		seen[w] = make([]bool, cfg.Records)
+	}
+	for _, name := range files {
+		if err := check(name, cfg, seen); err != nil {
+			return res, err
+		}
+	}
+	for w := range seen {
+		for seq, ok := range seen[w] {
+			if !ok {
+				return res, fmt.Errorf("writer %d, record %d is missing", w, seq)
+			}
+		}
+	}
+	return res, nil
+}
+
+// record returns record seq of writer w, padded to size bytes.
+func record(w, seq, size int) []byte {
+	b := []byte(fmt.Sprintf("%s w=%d seq=%d ", recordPrefix, w, seq))
+	for len(b) < size-1 {
+		b = append(b, 'x')
+	}
+	return append(b, '\n')
+}
+
+// logFiles returns the log file named filename and its backups, compressed or
+// not.
+func logFiles(filename string) ([]string, error) {
+	dir := filepath.Dir(filename)
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filepath.Base(filename), ext)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz") {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	return names, nil
+}
+
+// check reads the records in the named file, marking them in seen, and returns
+// an error for the first record that is malformed, a duplicate, or out of
+// order.
+func check(name string, cfg StressConfig, seen [][]bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	last := make([]int, cfg.Writers)
+	for w := range last {
+		last[w] = -1
+	}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF && len(b) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		var w, seq int
+		_, errScan := fmt.Sscanf(string(b), recordPrefix+" w=%d seq=%d ", &w, &seq)
+		if errScan != nil || w < 0 || w >= cfg.Writers || seq < 0 || seq >= cfg.Records ||
+			!bytes.Equal(b, record(w, seq, cfg.RecordSize)) {
+			return fmt.Errorf("%s:%d: torn or interleaved record %q", name, line, b)
+		}
+		if seen[w][seq] {
+			return fmt.Errorf("%s:%d: writer %d, record %d appears more than once", name, line, w, seq)
+		}
+		if seq < last[w] {
+			return fmt.Errorf("%s:%d: writer %d, record %d comes after record %d", name, line, w, seq, last[w])
+		}
+		seen[w][seq] = true
+		last[w] = seq
+	}
+}
+
+// Benchmark measures writes of records of the given size to the Logger
+// returned by newLogger, which is called with an empty directory to put its
+// files in, from b.RunParallel's goroutines.  Use it from a benchmark
+// function to compare the cost of settings:
+//
+//	func BenchmarkCompressed(b *testing.B) {
+//		lumberjacktest.Benchmark(b, 100, func(dir string) *lumberjack.Logger {
+//			return &lumberjack.Logger{Filename: filepath.Join(dir, "app.log"), Compress: true}
+//		})
+//	}
+func Benchmark(b *testing.B, recordSize int, newLogger func(dir string) *lumberjack.Logger) {
+	dir, err := ioutil.TempDir("", "lumberjacktest")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := newLogger(dir)
+	defer l.Close()
+
+	p := record(0, 0, recordSize)
+	b.SetBytes(int64(len(p)))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := l.Write(p); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	if err := l.Close(); err != nil {
+		b.Error(err)
+	}
+}
@@ -0,0 +1,79 @@
+package lumberjacktest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/khulnasoft-lab/lumberjack.v2"
+)
+
+func TestStress(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		bufferSize int
+		compress   bool
+	}{
+		{name: "plain"},
+		{name: "buffered", bufferSize: 4096},
+		{name: "compressed", compress: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "TestStress")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			l := &lumberjack.Logger{
+				Filename:   filepath.Join(dir, "app.log"),
+				MaxSize:    1,
+				BufferSize: test.bufferSize,
+				Compress:   test.compress,
+			}
+			res, err := Stress(l, StressConfig{Writers: 4, Records: 3000, RecordSize: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Records != 12000 || res.Bytes != 1200000 {
+				t.Fatalf("expected 12000 records in 1200000 bytes, got %d in %d", res.Records, res.Bytes)
+			}
+			if res.Files < 2 {
+				t.Fatalf("expected the records to span a rotation, got %d files", res.Files)
+			}
+		})
+	}
+}
+
+func TestStressDetectsLoss(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestStressDetectsLoss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a file holding a torn record is caught.
+	filename := filepath.Join(dir, "app.log")
+	torn := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log")
+	if err := ioutil.WriteFile(torn, []byte("lumberjacktest w=0 seq=0 xx\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Stress(&lumberjack.Logger{Filename: filename}, StressConfig{Writers: 1, Records: 1})
+	if err == nil || !strings.Contains(err.Error(), "torn") {
+		t.Fatalf("expected a torn record, got %v", err)
+	}
+
+	// Loggers that remove backups are refused.
+	_, err = Stress(&lumberjack.Logger{Filename: filename, MaxBackups: 1}, StressConfig{})
+	if err == nil {
+		t.Fatal("expected an error for a Logger with MaxBackups")
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	Benchmark(b, 100, func(dir string) *lumberjack.Logger {
+		return &lumberjack.Logger{Filename: filepath.Join(dir, "app.log")}
+	})
+}
@@ -0,0 +1,83 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// companionSuffixes are added to the names of the log file and its backups to
+// name the files that go with them.
+var companionSuffixes = []string{tmpSuffix, keepSuffix, markerSuffix, lockSuffix, partialSuffix, intentSuffix, manifestSuffix}
+
+// managedName returns the name of the log file in Dir.
+func (l *Logger) managedName() string {
+	base := l.BaseName
+	if base == "" {
+		base = filepath.Base(os.Args[0])
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return filepath.Join(expandPath(l.Dir), base+".log")
+}
+
+// checkManaged returns an error naming a file in Dir that isn't one of the
+// log's own, if Dir is set and there is one, so that cleanup doesn't go ahead
+// in a directory that the Logger doesn't have to itself.
+func (l *Logger) checkManaged() error {
+	if l.Dir == "" {
+		return nil
+	}
+	dir := l.dir()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("can't read log file directory: %s", err)
+	}
+	prefix, ext := l.prefixAndExt()
+	for _, f := range files {
+		if !l.ownFile(f, dir, prefix, ext) {
+			return fmt.Errorf("won't clean up log file directory: %s isn't a file of the log", filepath.Join(dir, f.Name()))
+		}
+	}
+	return nil
+}
+
+// ownFile reports whether f, in dir, is one of the log's own files: the log
+// file, a backup, or a file that goes with either, given the prefix and
+// extension of the log file.
+func (l *Logger) ownFile(f os.FileInfo, dir, prefix, ext string) bool {
+	if f.IsDir() {
+		return false
+	}
+	name := f.Name()
+	for _, suffix := range companionSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	// the log file as it is compressed by CompressOnWrite.
+	archive := name
+	if a, _, ok := splitPart(name, l.compressSuffixes()); ok {
+		archive = a
+	}
+	if uncompressedName(archive, l.compressSuffixes()) == filepath.Base(l.filename()) {
+		return true
+	}
+	_, ok := l.backupInfo(namedInfo{f, name}, dir, prefix, ext)
+	return ok
+}
+
+// namedInfo is an os.FileInfo under another name.
+type namedInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (f namedInfo) Name() string {
+	return f.name
+}
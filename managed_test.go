@@ -0,0 +1,81 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagedDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestManagedDir", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Dir:        dir,
+		BaseName:   "app",
+		MaxSize:    10,
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	filename := filepath.Join(dir, "app.log")
+	backup := func() string {
+		return filepath.Join(dir, "app-"+fakeTime().UTC().Format(backupTimeFormat)+".log")
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!"), t)
+
+	// the Logger names its backups after BaseName, and cleans them up as
+	// usual while the directory holds nothing else.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backup()
+	existsWithContent(first, []byte("boo!"), t)
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool { _, err := os.Stat(first); return os.IsNotExist(err) }, t)
+	existsWithContent(backup(), []byte("foo!"), t)
+
+	// with a file that isn't the log's, nothing is removed any more.
+	other := filepath.Join(dir, "app-notes.log")
+	isNil(ioutil.WriteFile(other, []byte("mine"), 0644), t)
+	second := backup()
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool { return l.Stats().MillFailures > 0 }, t)
+	exists(second, t)
+	existsWithContent(other, []byte("mine"), t)
+}
+
+func TestOwnFile(t *testing.T) {
+	dir := makeTempDir("TestOwnFile", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Dir: dir, BaseName: "app", Compress: true, CompressPartSize: 1}
+	prefix, ext := l.prefixAndExt()
+	for name, want := range map[string]bool{
+		"app.log":                                    true,
+		"app.log.lock":                               true,
+		"app.log.partial":                            true,
+		"app.log.gz.tmp":                             true,
+		"app-2016-11-04T18-30-00.000.log":            true,
+		"app-2016-11-04T18-30-00.000.log.gz":         true,
+		"app-2016-11-04T18-30-00.000.log.gz.001.tmp": true,
+		"app-2016-11-04T18-30-00.000.log.keep":       true,
+		"app-2016-11-04T18-30-00.000.log.ready":      true,
+		"app-notes.log":                              false,
+		"other.log":                                  false,
+		"app.log.bak":                                false,
+	} {
+		isNil(ioutil.WriteFile(filepath.Join(dir, name), nil, 0644), t)
+		info, err := os.Stat(filepath.Join(dir, name))
+		isNil(err, t)
+		equals(want, l.ownFile(info, dir, prefix, ext), t)
+	}
+}
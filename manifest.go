@@ -0,0 +1,187 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// manifestSuffix is appended to the log file name to name its Manifest.
+const manifestSuffix = ".manifest.json"
+
+// Manifest is the index of the backups of a log file that the Logger keeps
+// with Logger.Manifest.  See ReadManifest.
+type Manifest struct {
+	// File is the path of the log file.
+	File string `json:"file"`
+
+	// Updated is when the manifest was written.
+	Updated time.Time `json:"updated"`
+
+	// Backups are the backups of the log file, newest first, as
+	// Logger.Backups returns them.
+	Backups []ManifestBackup `json:"backups"`
+}
+
+// ManifestBackup is a backup in a Manifest.
+type ManifestBackup struct {
+	// Path, Timestamp, Size, Compressed and Pinned are as in BackupInfo.
+	Path       string    `json:"path"`
+	Timestamp  time.Time `json:"timestamp"`
+	Size       int64     `json:"size"`
+	Compressed bool      `json:"compressed"`
+	Pinned     bool      `json:"pinned"`
+
+	// Files are the files of the backup.
+	Files []ManifestFile `json:"files"`
+
+	// Shipped reports whether the backup has been marked as shipped with
+	// Logger.MarkShipped.
+	Shipped bool `json:"shipped"`
+}
+
+// ManifestFile is a file of a backup in a Manifest.
+type ManifestFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+
+	// SHA256 is the hex SHA-256 sum of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// manifestName returns the name of the Manifest of the log file.
+func (l *Logger) manifestName() string {
+	return l.filename() + manifestSuffix
+}
+
+// writeManifest brings the Manifest up to date with the backups there are
+// now, if Manifest is set.  The sums of files that haven't changed since the
+// last Manifest, and whether backups were shipped, are carried over from it.
+// Failing to is recorded as an error.
+func (l *Logger) writeManifest() error {
+	if !l.Manifest {
+		return nil
+	}
+	l.manifestMu.Lock()
+	defer l.manifestMu.Unlock()
+	err := l.updateManifest()
+	if err != nil {
+		err = fmt.Errorf("can't write manifest: %v", err)
+		l.recordError(otherError, err)
+	}
+	return err
+}
+
+// updateManifest does the work of writeManifest.  It assumes that
+// l.manifestMu is held.
+func (l *Logger) updateManifest() error {
+	backups, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	old, err := ReadManifest(l.manifestName())
+	if err != nil && !os.IsNotExist(err) {
+		// rebuilt from scratch.
+		old = Manifest{}
+	}
+	files := make(map[string]ManifestFile)
+	shipped := make(map[string]bool)
+	for _, b := range old.Backups {
+		for _, f := range b.Files {
+			files[f.Path] = f
+		}
+		if b.Shipped {
+			shipped[uncompressedName(b.Path, l.compressSuffixes())] = true
+		}
+	}
+
+	m := Manifest{File: l.filename(), Updated: l.stateTime(currentTime()), Backups: []ManifestBackup{}}
+	for _, b := range backups {
+		mb := ManifestBackup{
+			Path:       b.Path,
+			Timestamp:  l.stateTime(b.Timestamp),
+			Size:       b.Size,
+			Compressed: b.Compressed,
+			Pinned:     b.Pinned,
+			Shipped:    shipped[uncompressedName(b.Path, l.compressSuffixes())],
+		}
+		for _, name := range b.Files {
+			f, err := manifestFile(name, files[name])
+			if os.IsNotExist(err) {
+				// removed since it was listed.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			mb.Files = append(mb.Files, f)
+		}
+		m.Backups = append(m.Backups, mb)
+	}
+	b, _ := json.MarshalIndent(m, "", "  ")
+	return writeFileAtomic(l.manifestName(), append(b, '\n'), l.markerMode())
+}
+
+// manifestFile returns the ManifestFile of the named file, reusing the sum of
+// old, its entry in the last Manifest, if the file hasn't changed since.
+func manifestFile(name string, old ManifestFile) (ManifestFile, error) {
+	info, err := osStat(name)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	f := ManifestFile{Path: name, Size: info.Size(), Modified: info.ModTime().UTC()}
+	if old.SHA256 != "" && old.Size == f.Size && old.Modified.Equal(f.Modified) {
+		f.SHA256 = old.SHA256
+		return f, nil
+	}
+	src, err := os.Open(name)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer src.Close()
+	h := sha256.New()
+	if _, err := copyBuffered(h, src); err != nil {
+		return ManifestFile{}, err
+	}
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return f, nil
+}
+
+// MarkShipped marks the backup that the named file is, or is one of the files
+// of, as shipped in the Manifest, for whatever ships backups elsewhere to
+// record its progress where other consumers of the Manifest see it.  The mark
+// is kept until the backup is removed.
+func (l *Logger) MarkShipped(name string) error {
+	l.manifestMu.Lock()
+	defer l.manifestMu.Unlock()
+	m, err := ReadManifest(l.manifestName())
+	if err != nil {
+		return err
+	}
+	for i, b := range m.Backups {
+		for _, f := range b.Files {
+			if f.Path == name {
+				m.Backups[i].Shipped = true
+				data, _ := json.MarshalIndent(m, "", "  ")
+				return writeFileAtomic(l.manifestName(), append(data, '\n'), l.markerMode())
+			}
+		}
+	}
+	return fmt.Errorf("%s isn't a backup in the manifest", name)
+}
+
+// ReadManifest returns the Manifest in the named file.
+func ReadManifest(name string) (Manifest, error) {
+	var m Manifest
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestManifest", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+		Compress: true,
+		Manifest: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	compressed := backupFile(dir) + compressSuffix
+	var m Manifest
+	waitFor(func() bool {
+		m, err = ReadManifest(logFile(dir) + manifestSuffix)
+		return err == nil && len(m.Backups) == 1 && m.Backups[0].Path == compressed
+	}, t)
+	equals(logFile(dir), m.File, t)
+	b := m.Backups[0]
+	equals(true, b.Compressed, t)
+	equals(false, b.Shipped, t)
+	equals(1, len(b.Files), t)
+	data, err := ioutil.ReadFile(compressed)
+	isNil(err, t)
+	sum := sha256.Sum256(data)
+	equals(hex.EncodeToString(sum[:]), b.Files[0].SHA256, t)
+	equals(int64(len(data)), b.Files[0].Size, t)
+	equals(b.Size, b.Files[0].Size, t)
+
+	// shipping is kept across updates.
+	isNil(l.MarkShipped(compressed), t)
+	notNil(l.MarkShipped(logFile(dir)), t)
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	waitFor(func() bool {
+		m, err = ReadManifest(logFile(dir) + manifestSuffix)
+		return err == nil && len(m.Backups) == 2 && m.Backups[0].Compressed
+	}, t)
+	equals(false, m.Backups[0].Shipped, t)
+	equals(true, m.Backups[1].Shipped, t)
+	equals(hex.EncodeToString(sum[:]), m.Backups[1].Files[0].SHA256, t)
+}
+
+func TestManifestFileReusesSum(t *testing.T) {
+	dir := makeTempDir("TestManifestFileReusesSum", t)
+	defer os.RemoveAll(dir)
+
+	name := logFile(dir)
+	isNil(ioutil.WriteFile(name, []byte("boo!"), 0644), t)
+	f, err := manifestFile(name, ManifestFile{})
+	isNil(err, t)
+	sum := sha256.Sum256([]byte("boo!"))
+	equals(hex.EncodeToString(sum[:]), f.SHA256, t)
+
+	// an unchanged file isn't summed again.
+	old := f
+	old.SHA256 = "cached"
+	f, err = manifestFile(name, old)
+	isNil(err, t)
+	equals("cached", f.SHA256, t)
+}
@@ -0,0 +1,78 @@
+package lumberjack
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// markerSuffix is added to the name of a backup for its RotationMarker.
+const markerSuffix = ".ready"
+
+// writeMarker writes the RotationMarker for the backup with the given name,
+// listing the named files it ended up in, once the backup is in the backup
+// directory.  The marker is written under a
+// temporary name and renamed into place, so that a watcher sees it appear
+// complete, as a single rename, and never a partial one.
+func (l *Logger) writeMarker(backup string, files []string) {
+	if !l.RotationMarker || filepath.Dir(backup) != l.backupDir() {
+		return
+	}
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(filepath.Base(f))
+		b.WriteByte('\n')
+	}
+	if err := writeFileAtomic(backup+markerSuffix, []byte(b.String()), l.markerMode()); err != nil {
+		l.recordError(otherError, err)
+	}
+}
+
+// markerMode returns the mode of marker files: that of backups, if set.
+func (l *Logger) markerMode() os.FileMode {
+	if l.backupModeIsSet() {
+		return l.BackupMode
+	}
+	return 0644
+}
+
+// removeMarker removes the RotationMarker of the backup that the named file
+// is, or is part of, if there is one.
+func (l *Logger) removeMarker(name string) {
+	if !l.RotationMarker {
+		return
+	}
+	err := os.Remove(uncompressedName(name, l.compressSuffixes()) + markerSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		l.recordError(removeError, err)
+	}
+}
+
+// MarkerPattern returns the glob pattern, as for filepath.Match, that the
+// names of the RotationMarker files of the Logger match, for a watcher, such
+// as one using fsnotify on the log directory, to pick them out.
+func (l *Logger) MarkerPattern() string {
+	prefix, ext := l.prefixAndExt()
+	return filepath.Join(l.backupDir(), prefix+"*"+ext+markerSuffix)
+}
+
+// ReadMarker returns the paths of the files that the backup whose
+// RotationMarker is the named file ended up in: the backup itself, or its
+// compressed version, or the parts of that.
+func ReadMarker(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if s.Text() != "" {
+			files = append(files, filepath.Join(filepath.Dir(name), s.Text()))
+		}
+	}
+	return files, s.Err()
+}
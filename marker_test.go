@@ -0,0 +1,59 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotationMarker(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRotationMarker", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        100,
+		RotationMarker: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the marker is there as soon as the backup is.
+	first := backupFile(dir)
+	marker := first + markerSuffix
+	files, err := ReadMarker(marker)
+	isNil(err, t)
+	equals([]string{first}, files, t)
+	ok, err := filepath.Match(l.MarkerPattern(), marker)
+	isNil(err, t)
+	assert(ok, t, "expected %s to match %s", marker, l.MarkerPattern())
+	ok, _ = filepath.Match(l.MarkerPattern(), filename)
+	assert(!ok, t, "expected %s not to match %s", filename, l.MarkerPattern())
+
+	// with compression, it waits for the compressed backup.
+	l.Compress = true
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	second := backupFile(dir)
+	files, err = ReadMarker(second + markerSuffix)
+	isNil(err, t)
+	equals([]string{second + compressSuffix}, files, t)
+
+	// and markers are removed along with their backups.
+	l.MaxBackups = 1
+	isNil(l.millRunOnce(), t)
+	notExist(first, t)
+	notExist(marker, t)
+	exists(second+markerSuffix, t)
+	fileCount(dir, 3, t)
+}
@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"io"
+	"strings"
+	"time"
+)
+
+// TimeFunc extracts the timestamp from a log line, reporting false if the line
+// doesn't have one.
+type TimeFunc func(line []byte) (time.Time, bool)
+
+// PrefixTime returns a TimeFunc that parses the timestamp at the start of each
+// line using the given time.Parse layout, e.g. time.RFC3339 or the
+// "2006/01/02 15:04:05" used by the standard library's log package.  The
+// timestamp is taken to span as many space separated fields as the layout
+// does.
+func PrefixTime(layout string) TimeFunc {
+	fields := len(strings.Fields(layout))
+	return func(line []byte) (time.Time, bool) {
+		end := 0
+		for i := 0; i < fields; i++ {
+			for end < len(line) && line[end] == ' ' {
+				end++
+			}
+			next := bytes.IndexAny(line[end:], " \r\n")
+			if next < 0 {
+				end = len(line)
+				break
+			}
+			end += next
+		}
+		t, err := time.Parse(layout, string(bytes.TrimLeft(line[:end], " ")))
+		return t, err == nil
+	}
+}
+
+// MergeFiles merges the lines of the named log files into w, in chronological
+// order according to the timestamps extracted by ts.  This is meant for
+// incident analysis across files from several hosts, such as logs that have
+// been synced into one directory.  Compressed backups are decompressed on the
+// fly.
+//
+// Every file is expected to be in chronological order already, as log files
+// are.  Lines that have no timestamp, such as the continuation lines of a
+// multi-line record, are kept together with the line before them.  Lines with
+// equal timestamps are written in the order the files were given.
+func MergeFiles(w io.Writer, ts TimeFunc, names ...string) error {
+	var cursors []*mergeCursor
+	defer func() {
+		for _, c := range cursors {
+			c.r.Close()
+		}
+	}()
+
+	var h mergeHeap
+	for i, name := range names {
+		r, err := openLogReader(name, nil)
+		if err != nil {
+			return err
+		}
+		c := &mergeCursor{r: r, br: bufio.NewReader(r), ts: ts, order: i}
+		cursors = append(cursors, c)
+		if err := c.first(); err != nil {
+			return err
+		}
+		if c.rec != nil {
+			h = append(h, c)
+		}
+	}
+
+	heap.Init(&h)
+	bw := bufio.NewWriter(w)
+	for len(h) > 0 {
+		c := h[0]
+		if _, err := bw.Write(c.rec); err != nil {
+			return err
+		}
+		if err := c.advance(); err != nil {
+			return err
+		}
+		if c.rec == nil {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return bw.Flush()
+}
+
+// mergeCursor reads one file being merged, a record at a time.  A record is a
+// line with a timestamp together with the lines without one that follow it.
+type mergeCursor struct {
+	r     io.ReadCloser
+	br    *bufio.Reader
+	ts    TimeFunc
+	order int
+
+	rec  []byte    // the current record, or nil at the end of the file
+	t    time.Time // timestamp of the current record
+	next []byte    // the first line of the next record
+	eof  bool
+}
+
+// first reads the first record.  Leading lines without a timestamp form a
+// record of their own, with the zero time.
+func (c *mergeCursor) first() error {
+	line, err := c.readLine()
+	if err != nil || line == nil {
+		return err
+	}
+	c.next = line
+	return c.advance()
+}
+
+// advance moves on to the next record.
+func (c *mergeCursor) advance() error {
+	c.rec = nil
+	if c.next == nil {
+		return nil
+	}
+	c.rec = c.next
+	c.t, _ = c.ts(c.next)
+	c.next = nil
+	for {
+		line, err := c.readLine()
+		if err != nil || line == nil {
+			return err
+		}
+		if _, ok := c.ts(line); ok {
+			c.next = line
+			return nil
+		}
+		c.rec = append(c.rec, line...)
+	}
+}
+
+// readLine returns the next line including its newline, adding one if the
+// file doesn't end with one, or nil at the end of the file.
+func (c *mergeCursor) readLine() ([]byte, error) {
+	if c.eof {
+		return nil, nil
+	}
+	line, err := c.br.ReadBytes('\n')
+	if err == io.EOF {
+		c.eof = true
+		if len(line) == 0 {
+			return nil, nil
+		}
+		return append(line, '\n'), nil
+	}
+	return line, err
+}
+
+// mergeHeap orders cursors by the timestamp of their current record.
+type mergeHeap []*mergeCursor
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].t.Equal(h[j].t) {
+		return h[i].t.Before(h[j].t)
+	}
+	return h[i].order < h[j].order
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeCursor)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMergeFiles(t *testing.T) {
+	dir := makeTempDir("TestMergeFiles", t)
+	defer os.RemoveAll(dir)
+
+	host1 := filepath.Join(dir, "host1.log")
+	isNil(ioutil.WriteFile(host1, []byte(
+		"2024-01-31T10:00:00Z one\n"+
+			"2024-01-31T10:00:02Z three\n"+
+			"  continued\n"+
+			"2024-01-31T10:00:04Z five"), 0644), t)
+
+	// the second file is a compressed backup.
+	host2 := filepath.Join(dir, "host2.log"+compressSuffix)
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err := gz.Write([]byte(
+		"preamble\n" +
+			"2024-01-31T10:00:01Z two\n" +
+			"2024-01-31T10:00:02Z four\n"))
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	isNil(ioutil.WriteFile(host2, bc.Bytes(), 0644), t)
+
+	var out bytes.Buffer
+	isNil(MergeFiles(&out, PrefixTime(time.RFC3339), host1, host2), t)
+	equals(
+		"preamble\n"+
+			"2024-01-31T10:00:00Z one\n"+
+			"2024-01-31T10:00:01Z two\n"+
+			"2024-01-31T10:00:02Z three\n"+
+			"  continued\n"+
+			"2024-01-31T10:00:02Z four\n"+
+			"2024-01-31T10:00:04Z five\n",
+		out.String(), t)
+}
+
+func TestMergeFilesMissing(t *testing.T) {
+	dir := makeTempDir("TestMergeFilesMissing", t)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	err := MergeFiles(&out, PrefixTime(time.RFC3339), filepath.Join(dir, "nope.log"))
+	notNil(err, t)
+}
+
+func TestPrefixTime(t *testing.T) {
+	ts := PrefixTime("2006/01/02 15:04:05")
+
+	got, ok := ts([]byte("2024/01/31 10:00:00 hello world\n"))
+	equals(true, ok, t)
+	equals(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC), got, t)
+
+	_, ok = ts([]byte("hello world\n"))
+	equals(false, ok, t)
+
+	_, ok = ts([]byte("\n"))
+	equals(false, ok, t)
+}
@@ -0,0 +1,109 @@
+package lumberjack
+
+import (
+	"expvar"
+	"time"
+)
+
+// MetricsRecorder is told of what a Logger does as it happens, so that it can
+// be exported to a monitoring system.  Its methods may be called from the
+// goroutine doing cleanup as well as from writes, so they must be safe for
+// concurrent use.  See ExpvarMetrics.
+type MetricsRecorder interface {
+	// BytesWritten is called with the number of bytes of each write to the
+	// log file.
+	BytesWritten(n int)
+
+	// Rotated is called after each rotation of the log file.
+	Rotated()
+
+	// BackupRemoved is called after each old log file removed by cleanup.
+	BackupRemoved()
+
+	// CompressDuration is called with the time taken by each compression of
+	// a backup.
+	CompressDuration(d time.Duration)
+}
+
+// MetricsLabeler is implemented by MetricsRecorders that export the Labels of
+// the Logger along with its counts.
+type MetricsLabeler interface {
+	// SetLabels is called with the Labels of the Logger, if it has any,
+	// before the recorder is first told of anything.
+	SetLabels(labels map[string]string)
+}
+
+// metrics returns the Metrics, having given it the Labels first if it is a
+// MetricsLabeler.
+func (l *Logger) metrics() MetricsRecorder {
+	if l.Metrics == nil {
+		return nil
+	}
+	l.labelMetrics.Do(func() {
+		if m, ok := l.Metrics.(MetricsLabeler); ok && len(l.Labels) > 0 {
+			m.SetLabels(l.Labels)
+		}
+	})
+	return l.Metrics
+}
+
+// ExpvarMetrics is a MetricsRecorder that keeps the counts in an expvar.Map,
+// so that they are served, along with the other expvar variables, as JSON on
+// /debug/vars.  The map holds bytes_written, rotations, backups_removed,
+// compressions and compress_seconds, the total time spent compressing, as well
+// as labels, the Labels of the Logger, if it has any.
+type ExpvarMetrics struct {
+	vars            *expvar.Map
+	bytesWritten    expvar.Int
+	rotations       expvar.Int
+	backupsRemoved  expvar.Int
+	compressions    expvar.Int
+	compressSeconds expvar.Float
+}
+
+// NewExpvarMetrics returns an ExpvarMetrics published as the expvar variable
+// with the given name.  Like expvar.Publish, it panics if the name is already
+// in use, so it should be called once for each name, such as once for each
+// log file.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	vars := expvar.NewMap(name)
+	m := &ExpvarMetrics{vars: vars}
+	vars.Set("bytes_written", &m.bytesWritten)
+	vars.Set("rotations", &m.rotations)
+	vars.Set("backups_removed", &m.backupsRemoved)
+	vars.Set("compressions", &m.compressions)
+	vars.Set("compress_seconds", &m.compressSeconds)
+	return m
+}
+
+// SetLabels implements MetricsLabeler, setting labels.
+func (m *ExpvarMetrics) SetLabels(labels map[string]string) {
+	vars := new(expvar.Map).Init()
+	for k, v := range labels {
+		s := new(expvar.String)
+		s.Set(v)
+		vars.Set(k, s)
+	}
+	m.vars.Set("labels", vars)
+}
+
+// BytesWritten adds n to bytes_written.
+func (m *ExpvarMetrics) BytesWritten(n int) {
+	m.bytesWritten.Add(int64(n))
+}
+
+// Rotated adds one to rotations.
+func (m *ExpvarMetrics) Rotated() {
+	m.rotations.Add(1)
+}
+
+// BackupRemoved adds one to backups_removed.
+func (m *ExpvarMetrics) BackupRemoved() {
+	m.backupsRemoved.Add(1)
+}
+
+// CompressDuration adds one to compressions and d to compress_seconds.
+func (m *ExpvarMetrics) CompressDuration(d time.Duration) {
+	m.compressions.Add(1)
+	m.compressSeconds.Add(d.Seconds())
+}
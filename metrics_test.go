@@ -0,0 +1,100 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"expvar"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestExpvarMetrics", t)
+	defer os.RemoveAll(dir)
+
+	m := NewExpvarMetrics(dir)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		MaxBackups: 1,
+		Compress:   true,
+		Metrics:    m,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// each rotation compresses the backup, and the second removes the first
+	// backup.
+	for i := 0; i < 2; i++ {
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		waitFor(func() bool { return m.compressions.Value() == int64(i+1) }, t)
+	}
+	waitFor(func() bool { return m.backupsRemoved.Value() == 1 }, t)
+
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+
+	vars := expvar.Get(dir).(*expvar.Map)
+	equals("7", vars.Get("bytes_written").String(), t)
+	equals("2", vars.Get("rotations").String(), t)
+	equals("1", vars.Get("backups_removed").String(), t)
+	equals("2", vars.Get("compressions").String(), t)
+	assert(m.compressSeconds.Value() > 0, t, "expected time spent compressing, got %v", m.compressSeconds.Value())
+}
+
+func TestLabels(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestLabels", t)
+	defer os.RemoveAll(dir)
+
+	labels := map[string]string{"service": "api", "instance": "7"}
+	var events []Event
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+		Metrics:  NewExpvarMetrics(dir),
+		Labels:   labels,
+		OnEvent:  func(e Event) { events = append(events, e) },
+		Header:   "# {{.Labels.service}}",
+		Summary:  SummaryEnd,
+	}
+	defer l.Close()
+
+	written := fakeTime().UTC().Format(time.RFC3339Nano)
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// they are in the header and the summary of the backup,
+	summary, err := json.Marshal(summaryRecord{
+		Summary: "rotation summary",
+		Lines:   1,
+		Bytes:   5,
+		First:   written,
+		Last:    written,
+		Labels:  labels,
+	})
+	isNil(err, t)
+	existsWithContent(backupFile(dir), []byte("# api\nboo!\n"+string(summary)+"\n"), t)
+
+	// the events,
+	assert(len(events) > 0, t, "expected events")
+	for _, e := range events {
+		equals(labels, e.Labels, t)
+	}
+
+	// and the metrics.
+	vars := expvar.Get(dir).(*expvar.Map).Get("labels").(*expvar.Map)
+	equals(`"api"`, vars.Get("service").String(), t)
+	equals(`"7"`, vars.Get("instance").String(), t)
+}
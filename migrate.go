@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// migrateBackups renames the backups named with LegacyBackupFormats, in the
+// log directory and the backup directory, to their names under the current
+// naming options, if MigrateBackups is set.  It carries on past the backups
+// that can't be renamed, returning the first error.
+func (l *Logger) migrateBackups() error {
+	if !l.MigrateBackups || len(l.LegacyBackupFormats) == 0 {
+		return nil
+	}
+	dirs := []string{l.dir()}
+	if l.staged() {
+		dirs = append(dirs, l.backupDir())
+	}
+	var err error
+	for _, dir := range dirs {
+		files, errRead := ioutil.ReadDir(dir)
+		if errRead != nil {
+			if !os.IsNotExist(errRead) && err == nil {
+				err = fmt.Errorf("can't read log file directory: %s", errRead)
+			}
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			name, ok := l.migratedName(f.Name())
+			if !ok {
+				continue
+			}
+			if errMove := l.migrate(dir, f.Name(), name); errMove != nil {
+				l.recordError(otherError, errMove)
+				if err == nil {
+					err = errMove
+				}
+			}
+		}
+	}
+	return err
+}
+
+// migratedName returns the name under the current naming options of the
+// backup with the given name, and whether it is a backup named with
+// LegacyBackupFormats, as opposed to one named under the current options, or
+// not a backup at all.  It recognizes compressed backups, but not those with
+// a number or hash added to the name, which are left as they are.
+func (l *Logger) migratedName(name string) (string, bool) {
+	prefix, ext := l.prefixAndExt()
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+	for _, suffix := range append([]string{""}, l.compressSuffixes()...) {
+		if !strings.HasSuffix(name, ext+suffix) || len(name) < len(prefix)+len(ext+suffix) {
+			continue
+		}
+		stamp := name[len(prefix) : len(name)-len(ext+suffix)]
+		var current []string
+		if l.BackupTimeFormat != "" {
+			current = []string{l.BackupTimeFormat}
+		}
+		if _, _, err := parseBackupStamp(stamp, current); err == nil {
+			return "", false
+		}
+		t, counter, err := parseBackupStamp(stamp, l.LegacyBackupFormats)
+		if err != nil {
+			continue
+		}
+		newName := prefix + l.formatStamp(t, counter) + ext + suffix
+		return newName, newName != name
+	}
+	return "", false
+}
+
+// migrate renames the backup from to the name to, in dir, along with the keep
+// file pinning it, if any.  A backup whose new name is taken is left alone.
+func (l *Logger) migrate(dir, from, to string) error {
+	dst := filepath.Join(dir, to)
+	if _, err := osStat(dst); err == nil {
+		return nil
+	}
+	if err := l.rename(filepath.Join(dir, from), dst); err != nil {
+		return fmt.Errorf("can't rename backup: %s", err)
+	}
+	l.listRemove(filepath.Join(dir, from))
+	l.listAdd(dst)
+	return l.renameKeep(dir, from, to)
+}
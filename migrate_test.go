@@ -0,0 +1,101 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLegacyBackupFormats(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestLegacyBackupFormats", t)
+	defer os.RemoveAll(dir)
+
+	// backups named by an earlier BackupTimeFormat.
+	legacy := filepath.Join(dir, "foobar-"+fakeTime().Add(-48*time.Hour).UTC().Format("20060102")+".log")
+	isNil(ioutil.WriteFile(legacy, []byte("old"), 0644), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:            filename,
+		MaxBackups:          1,
+		LegacyBackupFormats: []string{"20060102"},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the legacy backup counts, and is removed as the oldest.
+	waitFor(func() bool { _, err := os.Stat(legacy); return os.IsNotExist(err) }, t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	fileCount(dir, 2, t)
+}
+
+func TestMigrateBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMigrateBackups", t)
+	defer os.RemoveAll(dir)
+
+	day := fakeTime().Add(-48 * time.Hour).UTC().Truncate(24 * time.Hour)
+	legacy := filepath.Join(dir, "foobar-"+day.Format("20060102")+".log")
+	isNil(ioutil.WriteFile(legacy, []byte("old"), 0644), t)
+	isNil(ioutil.WriteFile(legacy+keepSuffix, nil, 0644), t)
+	compressed := filepath.Join(dir, "foobar-000007-"+day.Add(time.Hour).Format("20060102T15")+".log"+compressSuffix)
+	isNil(ioutil.WriteFile(compressed, []byte("older"), 0644), t)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:            filename,
+		MaxBackups:          5,
+		LegacyBackupFormats: []string{"20060102", "20060102T15"},
+		MigrateBackups:      true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// backups are renamed to the current layout, keeping the time, counter,
+	// compression and keep file.
+	migrated := filepath.Join(dir, "foobar-"+day.Format(backupTimeFormat)+".log")
+	waitFor(func() bool { _, err := os.Stat(migrated); return err == nil }, t)
+	existsWithContent(migrated, []byte("old"), t)
+	exists(migrated+keepSuffix, t)
+	notExist(legacy, t)
+	notExist(legacy+keepSuffix, t)
+	migrated = filepath.Join(dir, "foobar-000007-"+day.Add(time.Hour).Format(backupTimeFormat)+".log"+compressSuffix)
+	waitFor(func() bool { _, err := os.Stat(migrated); return err == nil }, t)
+	existsWithContent(migrated, []byte("older"), t)
+	notExist(compressed, t)
+}
+
+func TestMigratedName(t *testing.T) {
+	l := &Logger{
+		Filename:            "/var/log/foo.log",
+		LegacyBackupFormats: []string{"20060102"},
+	}
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"foo-20240131.log", "foo-2024-01-31T00-00-00.000.log", true},
+		{"foo-20240131.log.gz", "foo-2024-01-31T00-00-00.000.log.gz", true},
+		{"foo-2024-01-31T00-00-00.000.log", "", false},
+		{"foo-2024.log", "", false},
+		{"bar-20240131.log", "", false},
+		{"foo.log", "", false},
+	}
+	for _, test := range tests {
+		got, ok := l.migratedName(test.name)
+		equals(test.ok, ok, t)
+		equals(test.want, got, t)
+	}
+}
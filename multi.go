@@ -0,0 +1,160 @@
+package lumberjack
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// MultiLogger manages a set of Loggers by name, creating each one the first
+// time it's asked for, so that an application writing to several log files can
+// rotate and close them all together.
+//
+// The zero value is ready to use, and names the file of each Logger after its
+// name.
+type MultiLogger struct {
+	// NewLogger returns a new Logger for the given name, which it can use to
+	// pick the Logger's Filename and give all Loggers the same MaxSize,
+	// MaxBackups and so on.  The default is a Logger with the name as its
+	// Filename and default settings otherwise.
+	NewLogger func(name string) *Logger
+
+	// MaxOpenFiles is the maximum number of log files to keep open at once,
+	// for services with a log file per tenant or per job, which could
+	// otherwise run out of file descriptors.  When a write or a rotation of
+	// one Logger would exceed it, the file of the Logger written to least
+	// recently is closed; it's reopened the next time that Logger is written
+	// to.  With many concurrent writers the limit can be exceeded briefly.
+	// It only applies to the Loggers created after it's set.  The default is
+	// no limit.
+	MaxOpenFiles int
+
+	mu      sync.Mutex
+	loggers map[string]*Logger
+	files   *openFiles
+	closed  bool
+}
+
+// Logger returns the Logger with the given name, creating it if needed.
+func (m *MultiLogger) Logger(name string) *Logger {
+	return m.loggerOr(name, func() *Logger {
+		if m.NewLogger != nil {
+			return m.NewLogger(name)
+		}
+		return &Logger{Filename: name}
+	})
+}
+
+// loggerOr returns the Logger with the given name, creating it with newLogger
+// if needed.
+func (m *MultiLogger) loggerOr(name string, newLogger func() *Logger) *Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.loggers[name]; ok {
+		return l
+	}
+	l := newLogger()
+	if m.closed {
+		l.closed = 1
+	}
+	if m.MaxOpenFiles > 0 {
+		if m.files == nil {
+			m.files = &openFiles{max: m.MaxOpenFiles, lru: list.New(), in: make(map[*Logger]*list.Element)}
+		}
+		l.openFiles = m.files
+	}
+	if m.loggers == nil {
+		m.loggers = make(map[string]*Logger)
+	}
+	m.loggers[name] = l
+	return l
+}
+
+// Names returns the names of the Loggers created so far, sorted.
+func (m *MultiLogger) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.loggers))
+	for name := range m.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rotate rotates every Logger created so far.  It returns the first error, but
+// rotates the remaining Loggers regardless.
+func (m *MultiLogger) Rotate() error {
+	return m.each(func(l *Logger) error { return l.Rotate() })
+}
+
+// Close closes every Logger created so far.  It returns the first error, but
+// closes the remaining Loggers regardless.  Like a Logger, a MultiLogger can't
+// be used after Close: the Loggers it returns from then on, old or new, are
+// closed, and return ErrClosed when written to.
+func (m *MultiLogger) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	files := m.files
+	m.mu.Unlock()
+	if files != nil {
+		files.reset()
+	}
+	return m.each(func(l *Logger) error { return l.Close() })
+}
+
+// each calls fn for every Logger, in the order of their names.
+func (m *MultiLogger) each(fn func(l *Logger) error) error {
+	var err error
+	for _, name := range m.Names() {
+		m.mu.Lock()
+		l := m.loggers[name]
+		m.mu.Unlock()
+		if errFn := fn(l); err == nil {
+			err = errFn
+		}
+	}
+	return err
+}
+
+// openFiles keeps the Loggers of a MultiLogger with MaxOpenFiles to within
+// that many open files.
+type openFiles struct {
+	mu  sync.Mutex
+	max int
+	lru *list.List // of *Logger, most recently written first
+	in  map[*Logger]*list.Element
+}
+
+// touch marks l as the most recently written Logger, about to be written to,
+// and closes the file of the least recently written ones to stay within max.
+// It must not be called with the mutex of a Logger held.
+func (f *openFiles) touch(l *Logger) {
+	f.mu.Lock()
+	if e, ok := f.in[l]; ok {
+		f.lru.MoveToFront(e)
+	} else {
+		f.in[l] = f.lru.PushFront(l)
+	}
+	var victims []*Logger
+	for f.lru.Len() > f.max {
+		victim := f.lru.Remove(f.lru.Back()).(*Logger)
+		delete(f.in, victim)
+		victims = append(victims, victim)
+	}
+	f.mu.Unlock()
+
+	// Closing can be slow, as over NFS, so it is left until the other
+	// Loggers can go ahead.
+	for _, victim := range victims {
+		_ = victim.closeFile()
+	}
+}
+
+// reset forgets all the Loggers, whose files are being closed.
+func (f *openFiles) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lru.Init()
+	f.in = make(map[*Logger]*list.Element)
+}
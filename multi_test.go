@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestMultiLogger(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMultiLogger", t)
+	defer os.RemoveAll(dir)
+
+	m := &MultiLogger{
+		NewLogger: func(name string) *Logger {
+			return &Logger{Filename: filepath.Join(dir, name+".log")}
+		},
+	}
+	defer m.Close()
+
+	a := m.Logger("a")
+	assert(a == m.Logger("a"), t, "expected the same Logger for the same name")
+	_, err := a.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = m.Logger("b").Write([]byte("foo!"))
+	isNil(err, t)
+	equals([]string{"a", "b"}, m.Names(), t)
+
+	newFakeTime()
+	isNil(m.Rotate(), t)
+	existsWithContent(filepath.Join(dir, "a-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("boo!"), t)
+	existsWithContent(filepath.Join(dir, "b-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("foo!"), t)
+	fileCount(dir, 4, t)
+
+	isNil(m.Close(), t)
+	assert(a.file == nil, t, "expected Close to close every Logger")
+
+	// Loggers can't be used after Close, including new ones.
+	_, err = a.Write([]byte("boo!"))
+	equals(ErrClosed, err, t)
+	_, err = m.Logger("c").Write([]byte("boo!"))
+	equals(ErrClosed, err, t)
+	notExist(filepath.Join(dir, "c.log"), t)
+}
+
+func TestMultiLoggerMaxOpenFiles(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMultiLoggerMaxOpenFiles", t)
+	defer os.RemoveAll(dir)
+
+	m := &MultiLogger{
+		NewLogger: func(name string) *Logger {
+			return &Logger{Filename: filepath.Join(dir, name+".log")}
+		},
+		MaxOpenFiles: 2,
+	}
+	defer m.Close()
+
+	for _, name := range []string{"a", "b", "a", "c"} {
+		_, err := m.Logger(name).Write([]byte(name + "!"))
+		isNil(err, t)
+	}
+
+	// b was written to least recently, so its file was closed to stay within
+	// MaxOpenFiles, and is reopened when needed.
+	b := m.Logger("b")
+	assert(b.File() == nil, t, "expected the file of b to be closed")
+	assert(m.Logger("a").File() != nil, t, "expected the file of a to be open")
+	_, err := b.Write([]byte("b!"))
+	isNil(err, t)
+	existsWithContent(filepath.Join(dir, "b.log"), []byte("b!b!"), t)
+	assert(m.Logger("a").File() == nil, t, "expected the file of a to be closed")
+
+	// rotating counts as a write.
+	newFakeTime()
+	isNil(m.Logger("a").Rotate(), t)
+	assert(m.Logger("a").File() != nil, t, "expected the file of a to be open")
+	assert(m.Logger("c").File() == nil, t, "expected the file of c to be closed")
+	existsWithContent(filepath.Join(dir, "a-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("a!a!"), t)
+}
+
+func TestMultiLoggerMaxOpenFilesSingleWriter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMultiLoggerMaxOpenFilesSingleWriter", t)
+	defer os.RemoveAll(dir)
+
+	m := &MultiLogger{
+		NewLogger: func(name string) *Logger {
+			return &Logger{Filename: filepath.Join(dir, name+".log"), MaxSize: 1000, SingleWriter: true}
+		},
+		MaxOpenFiles: 1,
+	}
+	defer m.Close()
+
+	// each Logger has a writer of its own, while the other's writes close its
+	// file, which the race detector would catch if its writes didn't lock.
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for _, name := range []string{"a", "b"} {
+		l := m.Logger(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, err := l.Write([]byte("boo!")); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		isNil(err, t)
+	}
+	existsWithContent(filepath.Join(dir, "a.log"), bytes.Repeat([]byte("boo!"), 100), t)
+	existsWithContent(filepath.Join(dir, "b.log"), bytes.Repeat([]byte("boo!"), 100), t)
+}
@@ -0,0 +1,185 @@
+package lumberjack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Precision is the sub-second precision of the timestamps in backup names.
+// See Logger.BackupTimePrecision.
+type Precision int
+
+const (
+	// Milliseconds formats timestamps like 2006-01-02T15-04-05.000.
+	Milliseconds Precision = iota
+
+	// Microseconds formats timestamps like 2006-01-02T15-04-05.000000.
+	Microseconds
+
+	// Nanoseconds formats timestamps like 2006-01-02T15-04-05.000000000.
+	Nanoseconds
+)
+
+// backupTimeLayouts holds the timestamp layout for each Precision.  Backups are
+// recognized by any of them, so changing the precision doesn't orphan the
+// backups named under the old one.
+var backupTimeLayouts = []string{
+	Milliseconds: backupTimeFormat,
+	Microseconds: "2006-01-02T15-04-05.000000",
+	Nanoseconds:  "2006-01-02T15-04-05.000000000",
+}
+
+// zoneOffsetLayout is appended to the timestamp layout with ZoneOffset.
+const zoneOffsetLayout = "-0700"
+
+// layout returns the timestamp layout for p, falling back to milliseconds for
+// unknown values.
+func (p Precision) layout() string {
+	if p < 0 || int(p) >= len(backupTimeLayouts) {
+		return backupTimeFormat
+	}
+	return backupTimeLayouts[p]
+}
+
+// String returns the abbreviated unit of p: "ms", "us" or "ns".
+func (p Precision) String() string {
+	switch p {
+	case Milliseconds:
+		return "ms"
+	case Microseconds:
+		return "us"
+	case Nanoseconds:
+		return "ns"
+	}
+	return fmt.Sprintf("Precision(%d)", int(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Precision) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(backupTimeLayouts) {
+		return nil, fmt.Errorf("invalid precision %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "ms", "us" (or
+// "µs") and "ns", so that the precision can be set from config files.
+func (p *Precision) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "ms", "":
+		*p = Milliseconds
+	case "us", "µs":
+		*p = Microseconds
+	case "ns":
+		*p = Nanoseconds
+	default:
+		return fmt.Errorf("invalid precision %q, expected ms, us or ns", text)
+	}
+	return nil
+}
+
+// parseBackupTime parses the timestamp of a backup name, in any of the given
+// custom layouts, or any of the supported precisions, with or without a zone
+// offset.  Timestamps without one are taken to be UTC.
+func parseBackupTime(ts string, custom []string) (time.Time, error) {
+	for _, layout := range custom {
+		if t, err := time.Parse(layout, ts); err == nil {
+			return t, nil
+		}
+	}
+	var err error
+	for _, layout := range backupTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, ts); err == nil {
+			return t, nil
+		}
+		if t, err = time.Parse(layout+zoneOffsetLayout, ts); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseBackupStamp parses the part of a backup name between the prefix and the
+// extension: a timestamp, optionally preceded by a rotation counter, as
+// formatCounter formats it, and a dash, so that the backups of foo.log aren't
+// confused with those of foo-1.log.  The counter is 0 if there is none.  The
+// timestamp may be in the custom layouts.
+func parseBackupStamp(stamp string, custom []string) (time.Time, int64, error) {
+	t, err := parseBackupTime(stamp, custom)
+	if err == nil {
+		return t, 0, nil
+	}
+	i := strings.IndexByte(stamp, '-')
+	if i <= 0 {
+		return time.Time{}, 0, err
+	}
+	n, errN := strconv.ParseInt(stamp[:i], 10, 64)
+	if errN != nil || n <= 0 || formatCounter(n) != stamp[:i] {
+		return time.Time{}, 0, err
+	}
+	if t, err = parseBackupTime(stamp[i+1:], custom); err != nil {
+		return time.Time{}, 0, err
+	}
+	return t, n, nil
+}
+
+// roundTrips reports whether stamp, the part of a backup name parsed as the
+// time t and the rotation counter, is what the current naming options would
+// name a backup rotated at t with that counter, for StrictBackupNames.
+func (l *Logger) roundTrips(stamp string, t time.Time, counter int64) bool {
+	return (counter > 0) == l.RotationCounter && l.formatStamp(t, counter) == stamp
+}
+
+// backupLayouts returns the custom timestamp layouts that backup names are
+// parsed with: BackupTimeFormat, if set, and LegacyBackupFormats.
+func (l *Logger) backupLayouts() []string {
+	if l.BackupTimeFormat == "" {
+		return l.LegacyBackupFormats
+	}
+	return append([]string{l.BackupTimeFormat}, l.LegacyBackupFormats...)
+}
+
+// formatCounter formats a rotation counter for a backup name, zero-padded so
+// that names sort the same way lexically.
+func formatCounter(n int64) string {
+	return fmt.Sprintf("%06d", n)
+}
+
+// nextCounter returns the rotation counter for the next backup, and uses it up.
+func (l *Logger) nextCounter() int64 {
+	l.counter = l.peekCounter()
+	return l.counter
+}
+
+// peekCounter returns the rotation counter for the next backup, continuing from
+// the highest counter among the existing backups the first time it's called.
+func (l *Logger) peekCounter() int64 {
+	if l.counter == 0 {
+		if files, err := l.oldLogFiles(); err == nil {
+			for _, f := range files {
+				if f.counter > l.counter {
+					l.counter = f.counter
+				}
+			}
+		}
+	}
+	return l.counter + 1
+}
+
+// NextBackupName returns the name that a rotation happening right now would
+// give the current log file, so that something outside the Logger can prepare
+// for it, such as by reserving an object store key before calling Rotate.  The
+// name depends on the current time, so a later rotation generally produces a
+// different name, unless the time is frozen, as it can be in tests.
+func (l *Logger) NextBackupName() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var counter int64
+	if l.RotationCounter {
+		counter = l.peekCounter()
+	}
+	return l.backupNameWith(l.filename(), counter)
+}
@@ -0,0 +1,337 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+func TestBackupTimePrecision(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestBackupTimePrecision", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:            filename,
+		MaxSize:             10,
+		BackupTimePrecision: Nanoseconds,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	backup := filepath.Join(dir, "foobar-"+fakeTime().UTC().Format("2006-01-02T15-04-05.000000000")+".log")
+	existsWithContent(backup, b, t)
+
+	// backups are found and ordered whatever their precision.
+	newFakeTime()
+	l.BackupTimePrecision = Milliseconds
+	isNil(l.Rotate(), t)
+
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(2, len(files), t)
+	equals(filepath.Base(backupFile(dir)), files[0].Name(), t)
+	equals(filepath.Base(backup), files[1].Name(), t)
+	equals(fakeTime().Add(-2*24*time.Hour).UTC(), files[1].timestamp, t)
+}
+
+func TestPrecisionText(t *testing.T) {
+	for _, p := range []Precision{Milliseconds, Microseconds, Nanoseconds} {
+		text, err := p.MarshalText()
+		isNil(err, t)
+		var got Precision
+		isNil(got.UnmarshalText(text), t)
+		equals(p, got, t)
+	}
+
+	var p Precision
+	isNil(p.UnmarshalText([]byte("µs")), t)
+	equals(Microseconds, p, t)
+	notNil(p.UnmarshalText([]byte("s")), t)
+	_, err := Precision(7).MarshalText()
+	notNil(err, t)
+}
+
+func TestPrecisionConfig(t *testing.T) {
+	var l Logger
+	isNil(json.Unmarshal([]byte(`{"backuptimeprecision": "us"}`), &l), t)
+	equals(Microseconds, l.BackupTimePrecision, t)
+
+	l = Logger{}
+	isNil(yaml.Unmarshal([]byte(`backuptimeprecision: ns`), &l), t)
+	equals(Nanoseconds, l.BackupTimePrecision, t)
+
+	l = Logger{}
+	_, err := toml.Decode(`backuptimeprecision = "us"`, &l)
+	isNil(err, t)
+	equals(Microseconds, l.BackupTimePrecision, t)
+}
+
+func TestRotationCounter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRotationCounter", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         10,
+		MaxBackups:      2,
+		RotationCounter: true,
+	}
+	defer l.Close()
+
+	// the clock is stepped back between the rotations, so the timestamps go
+	// backwards while the counter keeps going up.
+	var names []string
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte(fmt.Sprintf("boo %d", i)))
+		isNil(err, t)
+		fakeCurrentTime = fakeCurrentTime.Add(-time.Hour)
+		names = append(names, fmt.Sprintf("foobar-%06d-%s.log", i+1, fakeTime().UTC().Format(backupTimeFormat)))
+		isNil(l.Rotate(), t)
+	}
+
+	// the oldest by counter is the one removed, even though its timestamp is
+	// the newest.
+	<-time.After(10 * time.Millisecond)
+	notExist(filepath.Join(dir, names[0]), t)
+	existsWithContent(filepath.Join(dir, names[1]), []byte("boo 1"), t)
+	existsWithContent(filepath.Join(dir, names[2]), []byte("boo 2"), t)
+
+	// a new Logger continues from the highest counter.
+	isNil(l.Close(), t)
+	l2 := &Logger{
+		Filename:        filename,
+		MaxSize:         10,
+		RotationCounter: true,
+	}
+	defer l2.Close()
+	newFakeTime()
+	isNil(l2.Rotate(), t)
+	existsWithContent(filepath.Join(dir, fmt.Sprintf("foobar-%06d-%s.log", 4, fakeTime().UTC().Format(backupTimeFormat))), []byte{}, t)
+}
+
+func TestStampFromName(t *testing.T) {
+	l := &Logger{Filename: "/var/log/myfoo/foo.log"}
+	prefix, ext := l.prefixAndExt()
+	ts := time.Date(2014, 5, 4, 14, 44, 33, 555000000, time.UTC)
+
+	tests := []struct {
+		filename string
+		want     time.Time
+		counter  int64
+		wantErr  bool
+	}{
+		{"foo-2014-05-04T14-44-33.555.log", ts, 0, false},
+		{"foo-000042-2014-05-04T14-44-33.555.log", ts, 42, false},
+		{"foo-1234567-2014-05-04T14-44-33.555000.log", ts, 1234567, false},
+		{"foo-000000-2014-05-04T14-44-33.555.log", time.Time{}, 0, true},
+		{"foo-abc-2014-05-04T14-44-33.555.log", time.Time{}, 0, true},
+		{"foo-000042-.log", time.Time{}, 0, true},
+		// the backups of foo-1.log and foo-+42.log.
+		{"foo-1-2014-05-04T14-44-33.555.log", time.Time{}, 0, true},
+		{"foo-+00042-2014-05-04T14-44-33.555.log", time.Time{}, 0, true},
+	}
+
+	for _, test := range tests {
+		got, n, err := l.stampFromName(test.filename, prefix, ext)
+		equals(test.want, got, t)
+		equals(test.counter, n, t)
+		equals(test.wantErr, err != nil, t)
+	}
+}
+
+func TestNextBackupName(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestNextBackupName", t)
+	defer os.RemoveAll(dir)
+
+	for _, counter := range []bool{false, true} {
+		l := &Logger{
+			Filename:        logFile(dir),
+			MaxSize:         10,
+			RotationCounter: counter,
+		}
+		defer l.Close()
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+
+		// asking doesn't use up the counter, and the rotation then
+		// produces the name predicted.
+		newFakeTime()
+		name := l.NextBackupName()
+		equals(name, l.NextBackupName(), t)
+		isNil(l.Rotate(), t)
+		existsWithContent(name, []byte("boo!"), t)
+		isNil(l.Close(), t)
+	}
+	exists(filepath.Join(dir, "foobar-000001-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), t)
+}
+
+func TestZoneOffset(t *testing.T) {
+	megabyte = 1
+	dir := makeTempDir("TestZoneOffset", t)
+	defer os.RemoveAll(dir)
+
+	// 01:30 comes around twice on the night daylight saving time ends.
+	edt := time.Date(2024, 11, 3, 1, 30, 0, 0, time.FixedZone("EDT", -4*60*60))
+	est := time.Date(2024, 11, 3, 1, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	now := edt
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    100,
+		LocalTime:  true,
+		ZoneOffset: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("first"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	now = est
+	_, err = l.Write([]byte("second"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+
+	first := filepath.Join(dir, "foobar-2024-11-03T01-30-00.000-0400.log")
+	second := filepath.Join(dir, "foobar-2024-11-03T01-30-00.000-0500.log")
+	existsWithContent(first, []byte("first"), t)
+	existsWithContent(second, []byte("second"), t)
+
+	// the later backup sorts first, as the newest.
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(2, len(files), t)
+	equals(filepath.Base(second), files[0].Name(), t)
+	equals(filepath.Base(first), files[1].Name(), t)
+	assert(files[0].timestamp.Equal(est), t, "expected %v, got %v", est, files[0].timestamp)
+
+	// without LocalTime, names are UTC and the offset is left out.
+	l.LocalTime = false
+	equals(filepath.Join(dir, "foobar-2024-11-03T06-30-00.000.log"), l.NextBackupName(), t)
+}
+
+func TestBackupTimeFormat(t *testing.T) {
+	megabyte = 1
+	dir := makeTempDir("TestBackupTimeFormat", t)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxSize:          100,
+		BackupTimeFormat: "20060102",
+	}
+	defer l.Close()
+
+	for _, day := range []int{0, 1, 2} {
+		now = now.AddDate(0, 0, day)
+		_, err := l.Write([]byte("foo"))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	// an old backup with the default layout is still recognized.
+	old := filepath.Join(dir, "foobar-2024-01-01T00-00-00.000.log")
+	isNil(ioutil.WriteFile(old, []byte("old"), 0644), t)
+
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(4, len(files), t)
+	equals("foobar-20240203.log", files[0].Name(), t)
+	equals("foobar-20240201.log", files[1].Name(), t)
+	equals("foobar-20240131.log", files[2].Name(), t)
+	equals(filepath.Base(old), files[3].Name(), t)
+
+	// and the oldest are removed by MaxBackups.
+	l.MaxBackups = 2
+	isNil(l.millRunOnce(), t)
+	exists(filepath.Join(dir, "foobar-20240203.log"), t)
+	exists(filepath.Join(dir, "foobar-20240201.log"), t)
+	notExist(filepath.Join(dir, "foobar-20240131.log"), t)
+	notExist(old, t)
+}
+
+func TestStrictBackupNames(t *testing.T) {
+	dir := makeTempDir("TestStrictBackupNames", t)
+	defer os.RemoveAll(dir)
+
+	ts := "2014-05-04T14-44-33.555"
+	names := []string{
+		"foo-" + ts + ".log",
+		"foo-" + ts + ".log.gz",
+		"foo-" + ts + "000.log",
+		"foo-000042-" + ts + ".log",
+		"foo-" + ts + ".2.log",
+		"foo-" + ts + "-0123456789abcdef.log.gz",
+		"foo-" + ts + ".log.gz.001",
+	}
+	for _, name := range names {
+		isNil(ioutil.WriteFile(filepath.Join(dir, name), nil, 0644), t)
+	}
+	backups := func(l *Logger) []string {
+		files, err := l.oldLogFiles()
+		isNil(err, t)
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Name())
+		}
+		return got
+	}
+
+	// they all look like backups,
+	l := &Logger{Filename: filepath.Join(dir, "foo.log")}
+	equals(sortedNames(append([]string(nil), names...)), sortedNames(backups(l)), t)
+
+	// but only two are named as the options would name them,
+	l.StrictBackupNames = true
+	equals([]string{names[0], names[1]}, sortedNames(backups(l)), t)
+
+	// with the others taking options of their own.
+	l = &Logger{
+		Filename:          filepath.Join(dir, "foo.log"),
+		StrictBackupNames: true,
+		RotationCounter:   true,
+	}
+	equals([]string{names[3]}, backups(l), t)
+	l = &Logger{
+		Filename:          filepath.Join(dir, "foo.log"),
+		StrictBackupNames: true,
+		BackupCollision:   CollisionSuffix,
+		ContentHash:       true,
+		CompressPartSize:  1,
+	}
+	equals(sortedNames([]string{names[0], names[1], names[4], names[5], names[6]}), sortedNames(backups(l)), t)
+}
+
+// sortedNames returns names, sorted.
+func sortedNames(names []string) []string {
+	sort.Strings(names)
+	return names
+}
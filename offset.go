@@ -0,0 +1,142 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// ErrOffset is returned by OffsetWriter.WriteAt for an offset other than the
+// end of the log.
+var ErrOffset = errors.New("lumberjack: write isn't at the end of the log")
+
+// OffsetWriter is an append-only io.WriterAt on a Logger, for consumers that
+// keep track of their position in the log, such as a write-ahead log reader
+// checkpointing how far it got.  Its offsets count the bytes in the log file
+// and all of its backups since the Logger first opened it, so that they carry
+// on across rotations.  Locate maps them back to a file and a position in it.
+// Offsets start over with each Logger, and they count everything written to
+// the log file, including by Write and the Logger itself, such as headers.
+type OffsetWriter struct {
+	l *Logger
+}
+
+// segment is a log file the Logger rotated away, with the logical offset it
+// started at and its size.
+type segment struct {
+	name  string // the name it was rotated to, uncompressed
+	start int64
+	size  int64
+}
+
+// OffsetWriter returns an OffsetWriter on l.
+func (l *Logger) OffsetWriter() *OffsetWriter {
+	return &OffsetWriter{l: l}
+}
+
+// WriteAt writes p to the end of the log, which must be at off, returning
+// ErrOffset if it isn't, as when another write got there first.  Writes
+// queued with BufferSize before the call are written out first, so that they
+// count.
+func (w *OffsetWriter) WriteAt(p []byte, off int64) (int, error) {
+	l := w.l
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return 0, ErrClosed
+	}
+	end, err := l.endOffset()
+	if err != nil {
+		return 0, err
+	}
+	if off != end {
+		return 0, fmt.Errorf("%w: at %d, not %d", ErrOffset, end, off)
+	}
+	return l.writeRecord(p)
+}
+
+// Offset returns the offset of the end of the log, where the next write goes.
+func (w *OffsetWriter) Offset() (int64, error) {
+	l := w.l
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return 0, ErrClosed
+	}
+	return l.endOffset()
+}
+
+// Locate returns the name of the file holding the byte at off, and its
+// position in that file: the current log file, or a backup, by the name it
+// was rotated to, which OpenBackup opens wherever and however cleanup has
+// stored it since.  The backup may have been removed by cleanup.  It is an
+// error for off to be at or past the end of the log, or before the first
+// backup still around.
+func (w *OffsetWriter) Locate(off int64) (name string, pos int64, err error) {
+	l := w.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if off >= l.segStart && off < l.segStart+l.size {
+		return l.filename(), off - l.segStart, nil
+	}
+	l.segMu.Lock()
+	defer l.segMu.Unlock()
+	for _, s := range l.segments {
+		if off >= s.start && off < s.start+s.size {
+			return s.name, off - s.start, nil
+		}
+	}
+	return "", 0, fmt.Errorf("lumberjack: offset %d isn't in the log", off)
+}
+
+// endOffset returns the offset of the end of the log, opening the log file if
+// it isn't open yet, so that what is already in it counts.  It assumes that
+// l.mu is held.
+func (l *Logger) endOffset() (int64, error) {
+	if l.file == nil {
+		if err := l.openFirst(0); err != nil {
+			return 0, err
+		}
+	}
+	return l.segStart + l.size, nil
+}
+
+// addSegment records that the log file was rotated to name, at size bytes,
+// so that its offsets can be located.  This method assumes l.mu is held.
+func (l *Logger) addSegment(name string, size int64) {
+	l.segMu.Lock()
+	defer l.segMu.Unlock()
+	l.segments = append(l.segments, segment{name: name, start: l.segStart, size: size})
+	l.segStart += size
+}
+
+// renameSegment follows a backup renamed from one name to another, both as
+// they were rotated to.  This method assumes l.mu is held.
+func (l *Logger) renameSegment(from, to string) {
+	l.segMu.Lock()
+	defer l.segMu.Unlock()
+	for i := range l.segments {
+		if l.segments[i].name == from {
+			l.segments[i].name = to
+		}
+	}
+}
+
+// forgetSegment drops the segment of the backup that the removed file of the
+// given name was, or was part of.
+func (l *Logger) forgetSegment(removed string) {
+	base := filepath.Base(uncompressedName(removed, l.compressSuffixes()))
+	l.segMu.Lock()
+	defer l.segMu.Unlock()
+	kept := l.segments[:0]
+	for _, s := range l.segments {
+		if filepath.Base(s.name) != base {
+			kept = append(kept, s)
+		}
+	}
+	l.segments = kept
+}
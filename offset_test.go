@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOffsetWriter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOffsetWriter", t)
+	defer os.RemoveAll(dir)
+
+	// what is in the log file already counts.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("old"), 0644), t)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  10,
+	}
+	defer l.Close()
+	w := l.OffsetWriter()
+
+	off, err := w.Offset()
+	isNil(err, t)
+	equals(int64(3), off, t)
+
+	n, err := w.WriteAt([]byte("boo!"), 3)
+	isNil(err, t)
+	equals(4, n, t)
+
+	// a write somewhere other than the end is refused.
+	_, err = w.WriteAt([]byte("x"), 3)
+	assert(errors.Is(err, ErrOffset), t, "expected ErrOffset, got %v", err)
+
+	// offsets carry on across the rotation.
+	newFakeTime()
+	_, err = w.WriteAt([]byte("foooo!"), 7)
+	isNil(err, t)
+	off, err = w.Offset()
+	isNil(err, t)
+	equals(int64(13), off, t)
+
+	name, pos, err := w.Locate(4)
+	isNil(err, t)
+	equals(backupFile(dir), name, t)
+	equals(int64(4), pos, t)
+	name, pos, err = w.Locate(9)
+	isNil(err, t)
+	equals(filename, name, t)
+	equals(int64(2), pos, t)
+
+	_, _, err = w.Locate(13)
+	notNil(err, t)
+}
+
+func TestOffsetWriterSequential(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOffsetWriterSequential", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxBackups:   1,
+		NamingScheme: Sequential,
+	}
+	defer l.Close()
+	w := l.OffsetWriter()
+
+	for i, s := range []string{"one", "two", "three"} {
+		off, err := w.Offset()
+		isNil(err, t)
+		_, err = w.WriteAt([]byte(s), off)
+		isNil(err, t)
+		if i < 2 {
+			isNil(l.Rotate(), t)
+		}
+	}
+
+	// backups are followed through their renumbering, and forgotten when
+	// removed.
+	waitFor(func() bool { _, err := os.Stat(filename + ".2"); return os.IsNotExist(err) }, t)
+	name, pos, err := w.Locate(4)
+	isNil(err, t)
+	equals(filename+".1", name, t)
+	equals(int64(1), pos, t)
+	name, _, err = w.Locate(6)
+	isNil(err, t)
+	equals(filename, name, t)
+	waitFor(func() bool {
+		_, _, err := w.Locate(0)
+		return err != nil
+	}, t)
+}
@@ -0,0 +1,113 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// RotationOrder is the order in which a rotation closes the log file and
+// renames it to the backup name.  See Logger.RotationOrder.
+type RotationOrder int
+
+const (
+	// OrderAuto closes the log file first on Windows, and renames it first
+	// everywhere else.
+	OrderAuto RotationOrder = iota
+
+	// OrderCloseFirst syncs the log file to disk and closes it before
+	// renaming it.  Windows can't rename a file that is open without
+	// FILE_SHARE_DELETE, and virus scanners and indexers that open it too
+	// can make the rename fail while it is open, which this avoids.
+	OrderCloseFirst
+
+	// OrderRenameFirst renames the log file while it is still open, and only
+	// closes it once the new log file is open, so that the close, and the
+	// sync for SyncInterval, aren't paid for before writes can resume.
+	OrderRenameFirst
+)
+
+// rotationOrderNames holds the text form of each RotationOrder.
+var rotationOrderNames = []string{
+	OrderAuto:        "auto",
+	OrderCloseFirst:  "closefirst",
+	OrderRenameFirst: "renamefirst",
+}
+
+// String returns the name of o: "auto", "closefirst" or "renamefirst".
+func (o RotationOrder) String() string {
+	if o < 0 || int(o) >= len(rotationOrderNames) {
+		return fmt.Sprintf("RotationOrder(%d)", int(o))
+	}
+	return rotationOrderNames[o]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (o RotationOrder) MarshalText() ([]byte, error) {
+	if o < 0 || int(o) >= len(rotationOrderNames) {
+		return nil, fmt.Errorf("invalid rotation order %d", int(o))
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "auto",
+// "closefirst" and "renamefirst", so that the order can be set from config
+// files.
+func (o *RotationOrder) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*o = OrderAuto
+		return nil
+	}
+	for i, name := range rotationOrderNames {
+		if string(text) == name {
+			*o = RotationOrder(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid rotation order %q, expected auto, closefirst or renamefirst", text)
+}
+
+// goos is runtime.GOOS, as a variable so that tests can pretend to be on
+// another platform.
+var goos = runtime.GOOS
+
+// renameFirst reports whether a rotation renames the log file before closing
+// it.
+func (l *Logger) renameFirst() bool {
+	switch l.RotationOrder {
+	case OrderCloseFirst:
+		return false
+	case OrderRenameFirst:
+		return true
+	}
+	return goos != "windows" && l.filesystem() != FSNFS
+}
+
+// closeBeforeRename syncs and closes the log file ahead of a rotation that
+// closes it first.  It assumes that l.mu is held.
+func (l *Logger) closeBeforeRename() error {
+	if l.file != nil && !l.durable() {
+		// close only syncs for SyncInterval and SyncWrites.
+		if err := l.syncFile(); err != nil {
+			return err
+		}
+	}
+	return l.close()
+}
+
+// closeRenamed closes f, the log file before a rotation that renamed it
+// first, once the new log file is open, syncing it first for SyncInterval or
+// SyncWrites, as close does.
+func (l *Logger) closeRenamed(f *os.File) error {
+	var errSync error
+	if l.durable() && !l.skipSync() {
+		end := l.trace(TraceSync)
+		errSync = f.Sync()
+		end()
+	}
+	err := f.Close()
+	if err == nil {
+		err = errSync
+	}
+	return err
+}
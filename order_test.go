@@ -0,0 +1,84 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRotationOrder(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	defer func(g string) { goos = g }(goos)
+
+	for _, tt := range []struct {
+		goos  string
+		order RotationOrder
+		syncs int
+	}{
+		{"linux", OrderAuto, 0},
+		{"windows", OrderAuto, 1},
+		{"linux", OrderCloseFirst, 1},
+		{"windows", OrderRenameFirst, 0},
+	} {
+		if tt.order == OrderRenameFirst && runtime.GOOS == "windows" {
+			// the file can't be renamed while it's open.
+			continue
+		}
+		goos = tt.goos
+		dir := makeTempDir("TestRotationOrder"+tt.goos+tt.order.String(), t)
+		defer os.RemoveAll(dir)
+
+		var mu sync.Mutex
+		var syncs int
+		filename := logFile(dir)
+		l := &Logger{
+			Filename:      filename,
+			RotationOrder: tt.order,
+			Filesystem:    FSGeneric, // syncs are skipped where /tmp is tmpfs
+			Trace: func(ctx context.Context, op string) (context.Context, func()) {
+				if op == TraceSync {
+					mu.Lock()
+					syncs++
+					mu.Unlock()
+				}
+				return ctx, func() {}
+			},
+		}
+
+		b := []byte("boo!")
+		_, err := l.Write(b)
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		b2 := []byte("foo!")
+		_, err = l.Write(b2)
+		isNil(err, t)
+
+		// either way, what was written ends up in the backup, and only
+		// closing first syncs it.
+		existsWithContent(backupFile(dir), b, t)
+		existsWithContent(filename, b2, t)
+		mu.Lock()
+		equals(tt.syncs, syncs, t)
+		mu.Unlock()
+		isNil(l.Close(), t)
+	}
+}
+
+func TestRotationOrderText(t *testing.T) {
+	for _, o := range []RotationOrder{OrderAuto, OrderCloseFirst, OrderRenameFirst} {
+		b, err := json.Marshal(o)
+		isNil(err, t)
+		var got RotationOrder
+		isNil(json.Unmarshal(b, &got), t)
+		equals(o, got, t)
+	}
+	var o RotationOrder
+	notNil(o.UnmarshalText([]byte("sideways")), t)
+	_, err := RotationOrder(7).MarshalText()
+	notNil(err, t)
+}
@@ -0,0 +1,120 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ChownPolicy decides what happens when lumberjack isn't permitted to change
+// the owner of a log file.  See Logger.ChownPolicy.
+type ChownPolicy int
+
+const (
+	// ChownFail fails the rotation or compression that needed the chown.
+	ChownFail ChownPolicy = iota
+
+	// ChownIgnore carries on, leaving the file with the owner it was created
+	// with.
+	ChownIgnore
+
+	// ChownEvent carries on like ChownIgnore, but sends an EventChownFailed
+	// to OnEvent.
+	ChownEvent
+)
+
+// String returns the name of p: "fail", "ignore" or "event".
+func (p ChownPolicy) String() string {
+	switch p {
+	case ChownFail:
+		return "fail"
+	case ChownIgnore:
+		return "ignore"
+	case ChownEvent:
+		return "event"
+	}
+	return fmt.Sprintf("ChownPolicy(%d)", int(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p ChownPolicy) MarshalText() ([]byte, error) {
+	if p < ChownFail || p > ChownEvent {
+		return nil, fmt.Errorf("invalid chown policy %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "fail",
+// "ignore" and "event", so that the policy can be set from config files.
+func (p *ChownPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "fail", "":
+		*p = ChownFail
+	case "ignore":
+		*p = ChownIgnore
+	case "event":
+		*p = ChownEvent
+	default:
+		return fmt.Errorf("invalid chown policy %q, expected fail, ignore or event", text)
+	}
+	return nil
+}
+
+// chown gives the file name the owner of the file described by info, according
+// to the Logger's configuration.  On Windows, it copies the access control
+// list instead.  This is a no-op anywhere else.
+func (l *Logger) chown(name string, info os.FileInfo) error {
+	if l.NoChown || l.SkipChownInSetgidDir && setgidDir(filepath.Dir(name)) {
+		return nil
+	}
+	if err := l.fault(FaultChown, name); err != nil {
+		return l.chownFailed(name, err)
+	}
+	return l.chownFailed(name, chown(name, info))
+}
+
+// chownDir gives the directory name the owner of the directory described by
+// info, according to the Logger's configuration.  On Windows, it copies the
+// access control list instead.  This is a no-op anywhere else.
+func (l *Logger) chownDir(name string, info os.FileInfo) error {
+	if l.NoChown {
+		return nil
+	}
+	if err := l.fault(FaultChown, name); err != nil {
+		return l.chownFailed(name, err)
+	}
+	return l.chownFailed(name, chownDir(name, info))
+}
+
+// chownToDir gives the file name the owner of the directory dir.  This is a
+// no-op anywhere but Linux.
+func (l *Logger) chownToDir(name, dir string) error {
+	if err := l.fault(FaultChown, name); err != nil {
+		return l.chownFailed(name, err)
+	}
+	return l.chownFailed(name, chownToDir(name, dir))
+}
+
+// chownFailed applies the ChownPolicy to err, the result of changing the owner
+// of name, returning the error to fail with, if any.
+func (l *Logger) chownFailed(name string, err error) error {
+	if err == nil || !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	switch l.ChownPolicy {
+	case ChownIgnore:
+		return nil
+	case ChownEvent:
+		l.emit(Event{Type: EventChownFailed, Path: name, Err: err})
+		return nil
+	}
+	return err
+}
+
+// setgidDir reports whether dir has the setgid bit set, in which case new files
+// in it get the directory's group.
+func setgidDir(dir string) bool {
+	info, err := osStat(dir)
+	return err == nil && info.Mode()&os.ModeSetgid != 0
+}
@@ -0,0 +1,20 @@
+package lumberjack
+
+import (
+	"testing"
+)
+
+func TestChownPolicyText(t *testing.T) {
+	for _, p := range []ChownPolicy{ChownFail, ChownIgnore, ChownEvent} {
+		text, err := p.MarshalText()
+		isNil(err, t)
+		var got ChownPolicy
+		isNil(got.UnmarshalText(text), t)
+		equals(p, got, t)
+	}
+
+	var p ChownPolicy
+	notNil(p.UnmarshalText([]byte("panic")), t)
+	_, err := ChownPolicy(7).MarshalText()
+	notNil(err, t)
+}
@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The sizes of the empty gzip members that pad compressed backups with
+// CompressPadSize: the header with an extra field holding a single subfield,
+// the empty final deflate block and the trailer around its data.
+const (
+	padMemberMin = 10 + 2 + 4 + 2 + 8
+	padMemberMax = padMemberMin + 0xffff - 4
+)
+
+// padSize returns the size in bytes that compressed backups are padded to a
+// multiple of, or 0 if they aren't: without CompressPadSize, or with a
+// Compressor other than Gzip or an Encrypter, whose output can't be padded.
+func (l *Logger) padSize() int64 {
+	if l.CompressPadSize <= 0 {
+		return 0
+	}
+	if _, ok := l.gzipCompressor(); !ok || l.Encrypter != nil {
+		return 0
+	}
+	return int64(l.CompressPadSize) * int64(megabyte)
+}
+
+// padGzip returns compress, writing gzip, followed by the padding that makes
+// its output a multiple of size bytes.
+func padGzip(compress func(dst io.Writer, src io.Reader) error, size int64) func(dst io.Writer, src io.Reader) error {
+	return func(dst io.Writer, src io.Reader) error {
+		w := &countingWriter{w: dst}
+		if err := compress(w, src); err != nil {
+			return err
+		}
+		_, err := dst.Write(gzipPadding(w.n, size))
+		return err
+	}
+}
+
+// gzipPadding returns what makes n bytes of gzip a multiple of size bytes:
+// empty gzip members, which decompress to nothing, with their extra fields
+// filled with zeros to make up the difference.  Empty members can't be
+// smaller than padMemberMin, so a backup that falls short of a multiple by
+// less than that ends up padded to the next one.
+func gzipPadding(n, size int64) []byte {
+	need := (size - n%size) % size
+	for need > 0 && need < padMemberMin {
+		need += size
+	}
+	pad := make([]byte, 0, need)
+	for need > 0 {
+		m := need
+		if m > padMemberMax {
+			m = padMemberMax
+			if need-m < padMemberMin {
+				m = need - padMemberMin
+			}
+		}
+		pad = appendPadMember(pad, int(m))
+		need -= m
+	}
+	return pad
+}
+
+// appendPadMember appends an empty gzip member of m bytes to b.
+func appendPadMember(b []byte, m int) []byte {
+	xlen := m - padMemberMin + 4
+	b = append(b, 0x1f, 0x8b, 8, 0x04, 0, 0, 0, 0, 0, 0xff) // FEXTRA, no mtime, unknown OS
+	b = append(b, 0, 0, 'L', 'J', 0, 0)
+	binary.LittleEndian.PutUint16(b[len(b)-6:], uint16(xlen))
+	binary.LittleEndian.PutUint16(b[len(b)-2:], uint16(xlen-4))
+	b = append(b, make([]byte, xlen-4)...)
+	b = append(b, 0x03, 0x00)         // an empty final block with fixed codes
+	b = append(b, make([]byte, 8)...) // the CRC-32 and size of nothing
+	return b
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,69 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGzipPadding(t *testing.T) {
+	content := gzipped([]byte("boo!\n"), t)
+	for _, size := range []int64{1, 16, 1000, 128 << 10, 1 << 20} {
+		for _, n := range []int64{0, 1, int64(len(content)), size - 1, size, 3*size + 7} {
+			pad := gzipPadding(n, size)
+			equals(int64(0), (n+int64(len(pad)))%size, t)
+			assert(len(pad) == 0 || len(pad) >= padMemberMin, t, "padding of %d bytes is shorter than a member", len(pad))
+			assert(int64(len(pad)) < size+padMemberMin, t, "padding of %d bytes to %d is longer than needed", len(pad), size)
+		}
+
+		// the padding decompresses to nothing.
+		b := append(append([]byte(nil), content...), gzipPadding(int64(len(content)), size)...)
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		isNil(err, t)
+		got, err := ioutil.ReadAll(gz)
+		isNil(err, t)
+		equals("boo!\n", string(got), t)
+	}
+}
+
+func TestCompressPadSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, onWrite := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprint("TestCompressPadSize", onWrite), t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename:        logFile(dir),
+			Compress:        true,
+			CompressOnWrite: onWrite,
+			CompressPadSize: 4096,
+		}
+		_, err := l.Write([]byte("boo!\n"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		isNil(l.Close(), t)
+
+		name := backupFile(dir) + compressSuffix
+		info, err := os.Stat(name)
+		isNil(err, t)
+		equals(int64(4096), info.Size(), t)
+		f, err := os.Open(name)
+		isNil(err, t)
+		gz, err := gzip.NewReader(f)
+		isNil(err, t)
+		got, err := ioutil.ReadAll(gz)
+		isNil(err, t)
+		isNil(f.Close(), t)
+		equals("boo!\n", string(got), t)
+	}
+
+	// with an Encrypter, backups aren't padded.
+	l := &Logger{Compress: true, CompressPadSize: 4096, Encrypter: AESGCM{Key: testKey}}
+	equals(int64(0), l.padSize(), t)
+}
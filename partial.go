@@ -0,0 +1,153 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// PartialLinePolicy is what to do with a partial line at the end of a log
+// file the Logger carries on with.  See Logger.PartialLines.
+type PartialLinePolicy int
+
+const (
+	// PartialKeep leaves a partial line as it is, so that the next write
+	// carries on from it.
+	PartialKeep PartialLinePolicy = iota
+
+	// PartialMark ends a partial line with partialMarker and a newline.
+	PartialMark
+
+	// PartialMove cuts a partial line off the end of the log file, and adds
+	// it, as a line, to a file named after the log file with ".partial"
+	// added, as in foo.log.partial.
+	PartialMove
+)
+
+// partialLineNames holds the text form of each PartialLinePolicy.
+var partialLineNames = []string{
+	PartialKeep: "keep",
+	PartialMark: "mark",
+	PartialMove: "move",
+}
+
+// String returns the name of p: "keep", "mark" or "move".
+func (p PartialLinePolicy) String() string {
+	if p < 0 || int(p) >= len(partialLineNames) {
+		return fmt.Sprintf("PartialLinePolicy(%d)", int(p))
+	}
+	return partialLineNames[p]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p PartialLinePolicy) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(partialLineNames) {
+		return nil, fmt.Errorf("invalid partial line policy %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "keep", "mark"
+// and "move", so that the policy can be set from config files.
+func (p *PartialLinePolicy) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = PartialKeep
+		return nil
+	}
+	for i, name := range partialLineNames {
+		if string(text) == name {
+			*p = PartialLinePolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid partial line policy %q, expected keep, mark or move", text)
+}
+
+// partialMarker is what PartialMark ends a partial line with.
+const partialMarker = " [lumberjack: line cut short]"
+
+// partialSuffix is added to the name of the log file for PartialMove.
+const partialSuffix = ".partial"
+
+// recoverPartial applies the PartialLines policy to the named log file, of the
+// given info, returning its info afterwards.  Failing to is recorded as an
+// error, leaving the file as it is, rather than keeping the log from being
+// written to.  This method assumes l.mu is held.
+func (l *Logger) recoverPartial(name string, info os.FileInfo) os.FileInfo {
+	if l.PartialLines == PartialKeep || info.Size() == 0 {
+		return info
+	}
+	f, err := osOpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		l.recordError(otherError, fmt.Errorf("can't recover partial line: %v", err))
+		return info
+	}
+	defer f.Close()
+	start, err := partialStart(f, info.Size())
+	if err == nil && start < info.Size() {
+		err = l.fixPartial(f, name, start, info.Size())
+	}
+	if err != nil {
+		l.recordError(otherError, fmt.Errorf("can't recover partial line: %v", err))
+		return info
+	}
+	if fixed, err := f.Stat(); err == nil {
+		return fixed
+	}
+	return info
+}
+
+// partialStart returns the offset of the partial line at the end of f, of the
+// given size, just after its last newline, or size if it ends with one.
+func partialStart(f *os.File, size int64) (int64, error) {
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		n := int64(len(buf))
+		if n > end {
+			n = end
+		}
+		if _, err := f.ReadAt(buf[:n], end-n); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] == '\n' {
+				return end - n + i + 1, nil
+			}
+		}
+		end -= n
+	}
+	return 0, nil
+}
+
+// fixPartial marks or moves the partial line from start to size in f, the
+// named log file.
+func (l *Logger) fixPartial(f *os.File, name string, start, size int64) error {
+	if l.PartialLines == PartialMark {
+		_, err := f.WriteAt([]byte(partialMarker+"\n"), size)
+		return err
+	}
+	line := make([]byte, size-start, size-start+1)
+	if _, err := f.ReadAt(line, start); err != nil && err != io.EOF {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if l.fileModeIsSet() {
+		mode = l.fileMode()
+	}
+	sidecar, err := osOpenFile(name+partialSuffix, os.O_CREATE|os.O_APPEND|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = sidecar.Write(append(line, '\n'))
+	if err == nil {
+		err = sidecar.Sync()
+	}
+	if errClose := sidecar.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+	return f.Truncate(start)
+}
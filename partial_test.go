@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPartialLines(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, tt := range []struct {
+		policy  PartialLinePolicy
+		want    string
+		sidecar string
+	}{
+		{PartialKeep, "one\ntwo\nthreefour\n", ""},
+		{PartialMark, "one\ntwo\nthree" + partialMarker + "\nfour\n", ""},
+		{PartialMove, "one\ntwo\nfour\n", "three\n"},
+	} {
+		dir := makeTempDir(fmt.Sprintf("TestPartialLines%v", tt.policy), t)
+		defer os.RemoveAll(dir)
+
+		// left by a crash in the middle of writing three.
+		filename := logFile(dir)
+		isNil(ioutil.WriteFile(filename, []byte("one\ntwo\nthree"), 0644), t)
+
+		l := &Logger{Filename: filename, MaxSize: 100, PartialLines: tt.policy}
+		_, err := l.Write([]byte("four\n"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+		existsWithContent(filename, []byte(tt.want), t)
+		if tt.sidecar == "" {
+			notExist(filename+partialSuffix, t)
+		} else {
+			existsWithContent(filename+partialSuffix, []byte(tt.sidecar), t)
+		}
+	}
+}
+
+func TestPartialLinesWhole(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestPartialLinesWhole", t)
+	defer os.RemoveAll(dir)
+
+	// a file that ends with a newline is left alone, and one that is all one
+	// partial line is moved whole.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("one\n"), 0644), t)
+	l := &Logger{Filename: filename, MaxSize: 100, PartialLines: PartialMove}
+	_, err := l.Write([]byte("two\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("one\ntwo\n"), t)
+	notExist(filename+partialSuffix, t)
+
+	isNil(ioutil.WriteFile(filename, []byte("on"), 0644), t)
+	l = &Logger{Filename: filename, MaxSize: 100, PartialLines: PartialMove}
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("two\n"), t)
+	existsWithContent(filename+partialSuffix, []byte("on\n"), t)
+}
+
+func TestPartialLinePolicyText(t *testing.T) {
+	for _, p := range []PartialLinePolicy{PartialKeep, PartialMark, PartialMove} {
+		text, err := p.MarshalText()
+		isNil(err, t)
+		var got PartialLinePolicy
+		isNil(got.UnmarshalText(text), t)
+		equals(p, got, t)
+	}
+	var p PartialLinePolicy
+	notNil(p.UnmarshalText([]byte("fix")), t)
+	_, err := PartialLinePolicy(7).MarshalText()
+	notNil(err, t)
+	equals("PartialLinePolicy(7)", PartialLinePolicy(7).String(), t)
+}
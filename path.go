@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+// longPath returns path unchanged; only Windows limits the length of paths.
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,35 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is the longest path that works on Windows without the \\?\
+// prefix.  Directories are limited to MAX_PATH minus the 12 characters of an
+// 8.3 filename, so that is what is used here.
+const maxShortPath = 260 - 12
+
+// longPath returns path in the extended-length form (\\?\C:\... or
+// \\?\UNC\server\share\...) if it is too long to be used as is.  Such paths
+// must be absolute and can't contain . or .. elements or forward slashes, so
+// the path is made absolute and cleaned first.
+func longPath(path string) string {
+	if len(path) < maxShortPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return extendedPath(abs)
+}
+
+// extendedPath converts the absolute path abs to the extended-length form.
+func extendedPath(abs string) string {
+	if strings.HasPrefix(abs, `\\`) {
+		// UNC path, \\server\share\...
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,24 @@
+package lumberjack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	long := strings.Repeat(`x\`, 150) + "foo.log"
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:\logs\foo.log`, `C:\logs\foo.log`},
+		{`C:\` + long, `\\?\C:\` + long},
+		{`\\server\share\` + long, `\\?\UNC\server\share\` + long},
+		{`\\?\C:\` + long, `\\?\C:\` + long},
+		{`C:\logs\..\` + long, `\\?\C:\` + long},
+	}
+
+	for _, test := range tests {
+		equals(test.want, longPath(test.path), t)
+	}
+}
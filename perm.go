@@ -0,0 +1,100 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// PermissionProfile is a named set of permissions for the log file and the
+// directories the Logger creates, which stands for the modes, and on Windows
+// the access control lists, that make sense on each system.  See
+// Logger.Permissions.
+type PermissionProfile int
+
+const (
+	// PermissionsDefault leaves the permissions to FileMode and DirMode, or
+	// their defaults.
+	PermissionsDefault PermissionProfile = iota
+
+	// PermissionsPrivate lets only the owner of the log file in: mode 0600,
+	// in directories of mode 0700, and on Windows, only the owner, the system
+	// and administrators.
+	PermissionsPrivate
+
+	// PermissionsGroupReadable also lets the group read the log file: mode
+	// 0640, in directories of mode 0750, and on Windows, also the Event Log
+	// Readers group.
+	PermissionsGroupReadable
+
+	// PermissionsWorldReadable lets everyone read the log file: mode 0644, in
+	// directories of mode 0755, and on Windows, also all users.
+	PermissionsWorldReadable
+)
+
+// permissionNames holds the text form of each PermissionProfile.
+var permissionNames = []string{
+	PermissionsDefault:       "default",
+	PermissionsPrivate:       "private",
+	PermissionsGroupReadable: "group-readable",
+	PermissionsWorldReadable: "world-readable",
+}
+
+// profileModes holds the modes of the log file and of directories of each
+// PermissionProfile.
+var profileModes = []struct{ file, dir os.FileMode }{
+	PermissionsPrivate:       {0600, 0700},
+	PermissionsGroupReadable: {0640, 0750},
+	PermissionsWorldReadable: {0644, 0755},
+}
+
+// String returns the name of p: "default", "private", "group-readable" or
+// "world-readable".
+func (p PermissionProfile) String() string {
+	if p < 0 || int(p) >= len(permissionNames) {
+		return fmt.Sprintf("PermissionProfile(%d)", int(p))
+	}
+	return permissionNames[p]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p PermissionProfile) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(permissionNames) {
+		return nil, fmt.Errorf("invalid permission profile %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "default",
+// "private", "group-readable" and "world-readable", so that the profile can be
+// set from config files.
+func (p *PermissionProfile) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = PermissionsDefault
+		return nil
+	}
+	for i, name := range permissionNames {
+		if string(text) == name {
+			*p = PermissionProfile(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid permission profile %q, expected default, private, group-readable or world-readable", text)
+}
+
+// fileMode returns the mode of new log files: FileMode, or that of the
+// Permissions, or 0 if neither is set.
+func (l *Logger) fileMode() os.FileMode {
+	if l.FileMode != 0 || l.Permissions <= PermissionsDefault || int(l.Permissions) >= len(profileModes) {
+		return l.FileMode
+	}
+	return profileModes[l.Permissions].file
+}
+
+// profileDirMode returns the mode of directories of the Permissions, or 0 if
+// it isn't set.
+func (l *Logger) profileDirMode() os.FileMode {
+	if l.Permissions <= PermissionsDefault || int(l.Permissions) >= len(profileModes) {
+		return 0
+	}
+	return profileModes[l.Permissions].dir
+}
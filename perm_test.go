@@ -0,0 +1,59 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("modes mean little on Windows")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, test := range []struct {
+		profile   PermissionProfile
+		fileMode  os.FileMode
+		file, dir os.FileMode
+	}{
+		{PermissionsDefault, 0, 0644, 0755},
+		{PermissionsPrivate, 0, 0600, 0700},
+		{PermissionsGroupReadable, 0, 0640, 0750},
+		{PermissionsWorldReadable, 0, 0644, 0755},
+		{PermissionsWorldReadable, 0600, 0600, 0755},
+	} {
+		dir := makeTempDir("TestPermissions"+test.profile.String()+test.fileMode.String(), t)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "sub", "foobar.log")
+
+		l := &Logger{Filename: filename, Permissions: test.profile, FileMode: test.fileMode}
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+
+		info, err := os.Stat(filename)
+		isNil(err, t)
+		equals(test.file, info.Mode().Perm(), t)
+		info, err = os.Stat(filepath.Dir(filename))
+		isNil(err, t)
+		equals(test.dir, info.Mode().Perm(), t)
+	}
+}
+
+func TestPermissionProfileText(t *testing.T) {
+	for _, p := range []PermissionProfile{PermissionsDefault, PermissionsPrivate, PermissionsGroupReadable, PermissionsWorldReadable} {
+		text, err := p.MarshalText()
+		isNil(err, t)
+		var got PermissionProfile
+		isNil(got.UnmarshalText(text), t)
+		equals(p, got, t)
+	}
+	var p PermissionProfile
+	notNil(p.UnmarshalText([]byte("secret")), t)
+	_, err := PermissionProfile(7).MarshalText()
+	notNil(err, t)
+	equals("PermissionProfile(7)", PermissionProfile(7).String(), t)
+}
@@ -0,0 +1,134 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// pgzipBlockSize is the amount of input each worker compresses at once.
+	pgzipBlockSize = 1 << 20
+
+	// pgzipDictSize is how much of the preceding input each block is primed
+	// with, the size of the deflate window.
+	pgzipDictSize = 32 << 10
+)
+
+// gzipHeader is a minimal gzip member header, with no mtime and an unknown OS,
+// as written by compress/gzip.
+var gzipHeader = []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}
+
+// parallelGzip returns a function that gzips src into dst like gzipCopy, but
+// splits the input into blocks which are deflated concurrently by the given
+// number of workers, so that a single huge file can be compressed using several
+// cores.  Each block is primed with the tail of the block before it, so the
+// output is almost as small as that of a serial compressor.  Blocks end with a
+// sync flush, which lets their deflate streams simply be concatenated, and the
+// result is a single ordinary gzip member.
+func parallelGzip(workers int) func(dst io.Writer, src io.Reader) error {
+	return func(dst io.Writer, src io.Reader) error {
+		return pgzipCopy(dst, src, workers)
+	}
+}
+
+// pgzipResult is the compressed form of one block.
+type pgzipResult struct {
+	out []byte
+	err error
+}
+
+func pgzipCopy(dst io.Writer, src io.Reader, workers int) (err error) {
+	if _, err := dst.Write(gzipHeader); err != nil {
+		return err
+	}
+
+	// pending holds the results of the blocks being compressed, in input
+	// order, and never more than workers of them.
+	var pending []chan pgzipResult
+	defer func() {
+		// wait for the workers to finish if we bail out early.
+		for _, c := range pending {
+			<-c
+		}
+	}()
+	flush := func() error {
+		r := <-pending[0]
+		pending = pending[1:]
+		if r.err != nil {
+			return r.err
+		}
+		_, err := dst.Write(r.out)
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	var size uint32
+	var dict []byte
+	for {
+		block := make([]byte, pgzipBlockSize)
+		n, errRead := io.ReadFull(src, block)
+		block = block[:n]
+		if n > 0 {
+			crc.Write(block)
+			size += uint32(n)
+
+			if len(pending) == workers {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			c := make(chan pgzipResult, 1)
+			pending = append(pending, c)
+			go deflateBlock(block, dict, c)
+
+			dict = block
+			if len(dict) > pgzipDictSize {
+				dict = dict[len(dict)-pgzipDictSize:]
+			}
+		}
+		if errRead == io.EOF || errRead == io.ErrUnexpectedEOF {
+			break
+		}
+		if errRead != nil {
+			return errRead
+		}
+	}
+	for len(pending) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	// Finish the deflate stream with an empty final block, then add the
+	// trailer.
+	fw, err := flate.NewWriter(dst, gzip.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], crc.Sum32())
+	binary.LittleEndian.PutUint32(trailer[4:], size)
+	_, err = dst.Write(trailer[:])
+	return err
+}
+
+// deflateBlock compresses block, primed with dict, ending with a sync flush
+// rather than a final block, and sends the result to c.
+func deflateBlock(block, dict []byte, c chan<- pgzipResult) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriterDict(&buf, gzip.DefaultCompression, dict)
+	if err == nil {
+		_, err = fw.Write(block)
+	}
+	if err == nil {
+		err = fw.Flush()
+	}
+	c <- pgzipResult{out: buf.Bytes(), err: err}
+}
@@ -0,0 +1,68 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParallelGzip(t *testing.T) {
+	// enough lines for several blocks, with a partial one at the end.
+	var in bytes.Buffer
+	for i := 0; in.Len() < 3*pgzipBlockSize+12345; i++ {
+		fmt.Fprintf(&in, "%d: the quick brown fox jumps over the lazy dog\n", i)
+	}
+
+	for _, data := range [][]byte{in.Bytes(), []byte("boo!"), {}} {
+		var out bytes.Buffer
+		isNil(parallelGzip(4)(&out, bytes.NewReader(data)), t)
+
+		gz, err := gzip.NewReader(&out)
+		isNil(err, t)
+		got, err := ioutil.ReadAll(gz)
+		isNil(err, t)
+		equals(len(data), len(got), t)
+		assert(bytes.Equal(data, got), t, "decompressed data doesn't match the input")
+	}
+}
+
+func TestCompressConcurrency(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressConcurrency", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress:            true,
+		CompressConcurrency: 2,
+		Filename:            filename,
+		MaxSize:             10,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	notExist(backupFile(dir), t)
+	f, err := os.Open(backupFile(dir) + compressSuffix)
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	got, err := ioutil.ReadAll(gz)
+	isNil(err, t)
+	equals(b, got, t)
+}
@@ -0,0 +1,133 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// pinnedIn returns the pinned directory that the named file is in, opening
+// it if it is the directory of the log file and isn't open yet, or nil
+// without PinDir, where it isn't supported, for a file elsewhere, or if the
+// directory can't be opened, as before it is made, leaving the file to be
+// reached by its path.  This method assumes l.mu is held.
+func (l *Logger) pinnedIn(name string) *pinnedDir {
+	if !l.PinDir || !pinSupported {
+		return nil
+	}
+	dir := filepath.Clean(l.dir())
+	if filepath.Dir(name) != dir {
+		return nil
+	}
+	if l.pin != nil && l.pin.path != dir {
+		l.unpinDir()
+	}
+	if l.pin == nil {
+		d, err := pinDir(dir)
+		if err != nil {
+			return nil
+		}
+		l.pin = d
+	}
+	return l.pin
+}
+
+// unpinDir closes the pinned directory, if any, so that the directory of the
+// log file is resolved again the next time it is needed.  This method assumes
+// l.mu is held.
+func (l *Logger) unpinDir() {
+	if l.pin != nil {
+		l.pin.close()
+		l.pin = nil
+	}
+}
+
+// openAt opens the named file as osOpenFile does, but relative to the pinned
+// directory with PinDir, and in a guarded directory, without following a
+// symbolic link and only if the file is safe to take as the log file, which
+// is only truncated once it has been found to be.  This method assumes l.mu
+// is held.
+func (l *Logger) openAt(name string, flag int, mode os.FileMode) (*os.File, error) {
+	guarded := l.guarded(name)
+	truncate := guarded && flag&os.O_TRUNC != 0
+	if guarded {
+		// a hard link planted since the file was checked would be
+		// truncated on opening, before it could be refused.
+		flag = flag&^os.O_TRUNC | oNofollow
+	}
+	var f *os.File
+	var err error
+	if d := l.pinnedIn(name); d != nil {
+		f, err = d.openFile(filepath.Base(name), flag, mode)
+	} else {
+		f, err = osOpenFile(name, flag, mode)
+	}
+	if err != nil || !guarded {
+		return f, err
+	}
+	if f, err = openSafe(name, f); err != nil || !truncate {
+		return f, err
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// statAt stats the named file as osStat does, but relative to the pinned
+// directory with PinDir, and in a guarded directory, without following a
+// symbolic link, returning an *UnsafeFileError if the file isn't safe to take
+// as the log file.  This method assumes l.mu is held.
+func (l *Logger) statAt(name string) (os.FileInfo, error) {
+	guarded := l.guarded(name)
+	var info os.FileInfo
+	var err error
+	switch d := l.pinnedIn(name); {
+	case d != nil:
+		info, err = d.stat(filepath.Base(name), guarded)
+	case guarded:
+		info, err = os.Lstat(name)
+	default:
+		return osStat(name)
+	}
+	if err == nil && guarded {
+		err = checkSafe(name, info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// chownOnOpen reports whether the new log file at name is given its owner
+// once it is open, rather than by path before: relative to the pinned
+// directory with PinDir, or in a guarded directory, where the path could be
+// swapped for a symbolic link in between.  This method assumes l.mu is held.
+func (l *Logger) chownOnOpen(name string) bool {
+	return l.pinnedIn(name) != nil || l.guarded(name)
+}
+
+// chownOpen gives f, the log file just opened, the owner of the file
+// described by info, as chown does by path.
+func (l *Logger) chownOpen(f *os.File, info os.FileInfo) error {
+	if l.NoChown || l.SkipChownInSetgidDir && setgidDir(filepath.Dir(f.Name())) {
+		return nil
+	}
+	if err := l.fault(FaultChown, f.Name()); err != nil {
+		return l.chownFailed(f.Name(), err)
+	}
+	return l.chownFailed(f.Name(), fchown(f, info))
+}
+
+// renameAt renames from to to as rename does, but relative to the pinned
+// directory with PinDir, if both are in it.  This method assumes l.mu is held.
+func (l *Logger) renameAt(from, to string) error {
+	d := l.pinnedIn(from)
+	if d == nil || d != l.pinnedIn(to) {
+		return l.rename(from, to)
+	}
+	if err := l.fault(FaultRename, from); err != nil {
+		return err
+	}
+	return d.rename(filepath.Base(from), filepath.Base(to))
+}
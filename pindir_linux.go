@@ -0,0 +1,73 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	pinSupported = true
+
+	oPath = 0x200000 // O_PATH
+)
+
+// pinnedDir is a directory resolved once, with PinDir, that files are opened
+// and renamed relative to.
+type pinnedDir struct {
+	path string
+	fd   int
+}
+
+// pinDir resolves the directory at path.
+func pinDir(path string) (*pinnedDir, error) {
+	fd, err := syscall.Open(path, oPath|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return &pinnedDir{path: path, fd: fd}, nil
+}
+
+// openFile opens the file called base in d, as os.OpenFile does.
+func (d *pinnedDir) openFile(base string, flag int, mode os.FileMode) (*os.File, error) {
+	name := filepath.Join(d.path, base)
+	fd, err := syscall.Openat(d.fd, base, flag|syscall.O_CLOEXEC, uint32(mode.Perm()))
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: name, Err: err}
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// stat stats the file called base in d, as os.Stat does, or with nofollow, as
+// os.Lstat does.
+func (d *pinnedDir) stat(base string, nofollow bool) (os.FileInfo, error) {
+	name := filepath.Join(d.path, base)
+	flag := oPath | syscall.O_CLOEXEC
+	if nofollow {
+		flag |= syscall.O_NOFOLLOW
+	}
+	fd, err := syscall.Openat(d.fd, base, flag, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "statat", Path: name, Err: err}
+	}
+	f := os.NewFile(uintptr(fd), name)
+	defer f.Close()
+	return f.Stat()
+}
+
+// rename renames the file called from in d to to.
+func (d *pinnedDir) rename(from, to string) error {
+	if err := syscall.Renameat(d.fd, from, d.fd, to); err != nil {
+		return &os.LinkError{Op: "renameat", Old: filepath.Join(d.path, from), New: filepath.Join(d.path, to), Err: err}
+	}
+	return nil
+}
+
+// close closes the handle of d.
+func (d *pinnedDir) close() {
+	_ = syscall.Close(d.fd)
+}
@@ -0,0 +1,56 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestPinDir", t)
+	defer os.RemoveAll(dir)
+	moved := dir + "-moved"
+	defer os.RemoveAll(moved)
+	other := makeTempDir("TestPinDirOther", t)
+	defer os.RemoveAll(other)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		PinDir:   true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// the directory is moved and swapped for a link to another one under way:
+	// the rotation still happens in the directory first resolved.
+	isNil(os.Rename(dir, moved), t)
+	isNil(os.Symlink(other, dir), t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+
+	existsWithContent(filepath.Join(moved, filepath.Base(backupFile(dir))), []byte("boo!"), t)
+	existsWithContent(filepath.Join(moved, filepath.Base(filename)), []byte("foo!"), t)
+	files, err := ioutil.ReadDir(other)
+	isNil(err, t)
+	equals(0, len(files), t)
+
+	// closing lets go of it, and the next write resolves the directory again.
+	isNil(l.Close(), t)
+	l = &Logger{Filename: filename, PinDir: true}
+	defer l.Close()
+	_, err = l.Write([]byte("bar!"))
+	isNil(err, t)
+	existsWithContent(filepath.Join(other, filepath.Base(filename)), []byte("bar!"), t)
+}
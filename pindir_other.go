@@ -0,0 +1,35 @@
+//go:build !linux || !(386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build !linux !386,!amd64,!arm,!arm64,!riscv64,!s390x
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// pinSupported is false, as directories can only be pinned on Linux.
+const pinSupported = false
+
+// pinnedDir is never used on this platform.
+type pinnedDir struct {
+	path string
+}
+
+func pinDir(path string) (*pinnedDir, error) {
+	return nil, errors.New("directories can't be pinned on this platform")
+}
+
+func (d *pinnedDir) openFile(base string, flag int, mode os.FileMode) (*os.File, error) {
+	return nil, errors.New("directories can't be pinned on this platform")
+}
+
+func (d *pinnedDir) stat(base string, nofollow bool) (os.FileInfo, error) {
+	return nil, errors.New("directories can't be pinned on this platform")
+}
+
+func (d *pinnedDir) rename(from, to string) error {
+	return errors.New("directories can't be pinned on this platform")
+}
+
+func (d *pinnedDir) close() {}
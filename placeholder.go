@@ -0,0 +1,187 @@
+package lumberjack
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hostnamePlaceholder is replaced by the name of the host in Filename.
+const hostnamePlaceholder = "{hostname}"
+
+// hasPlaceholders reports whether name has any of the placeholders that are
+// expanded in Filename: %Y, %m, %d, %H, %M and %% or {hostname}.
+func hasPlaceholders(name string) bool {
+	if strings.Contains(name, hostnamePlaceholder) {
+		return true
+	}
+	for i := 0; i < len(name)-1; i++ {
+		if name[i] == '%' && strings.IndexByte("YmdHM%", name[i+1]) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPlaceholders returns name with its placeholders expanded for the time
+// t and the given hostname: %Y for the year, %m, %d, %H and %M for the month,
+// day, hour and minute, zero-padded to two digits, %% for a %, and
+// {hostname}.  Anything else is left as it is.
+func expandPlaceholders(name string, t time.Time, hostname string) string {
+	name = strings.Replace(name, hostnamePlaceholder, hostname, -1)
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '%' || i == len(name)-1 {
+			b.WriteByte(name[i])
+			continue
+		}
+		switch name[i+1] {
+		case 'Y':
+			b.WriteString(strconv.Itoa(t.Year()))
+		case 'm':
+			writeTwoDigits(&b, int(t.Month()))
+		case 'd':
+			writeTwoDigits(&b, t.Day())
+		case 'H':
+			writeTwoDigits(&b, t.Hour())
+		case 'M':
+			writeTwoDigits(&b, t.Minute())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// writeTwoDigits writes n, from 0 to 99, to b as two digits.
+func writeTwoDigits(b *strings.Builder, n int) {
+	b.WriteByte(byte('0' + n/10))
+	b.WriteByte(byte('0' + n%10))
+}
+
+// placeholderTime returns the time to expand the placeholders in Filename
+// for: now, in local time if LocalTime is set, as for backup names.
+func (l *Logger) placeholderTime() time.Time {
+	t := currentTime()
+	if !l.LocalTime {
+		t = t.UTC()
+	}
+	return t
+}
+
+// expanded returns what tmpl, Filename with placeholders, was expanded to for
+// the log file that is open, expanding it now if there is none yet.
+func (l *Logger) expanded(tmpl string) string {
+	l.targetMu.Lock()
+	defer l.targetMu.Unlock()
+	if l.expandedOf != tmpl {
+		hostname, _ := os.Hostname()
+		l.expandedOf, l.expandedName = tmpl, expandPlaceholders(tmpl, l.placeholderTime(), hostname)
+	}
+	return l.expandedName
+}
+
+// nextSwitch returns when the time placeholders in tmpl, expanded for t, next
+// expand to something else: the start of the next minute for %M, hour for %H,
+// day for %d, month for %m or year for %Y, whichever is the shortest, or the
+// zero time if tmpl has none of them.
+func nextSwitch(tmpl string, t time.Time) time.Time {
+	has := func(p string) bool {
+		return strings.Contains(strings.Replace(tmpl, "%%", "", -1), p)
+	}
+	switch {
+	case has("%M"):
+		return t.Truncate(time.Minute).Add(time.Minute)
+	case has("%H"):
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case has("%d"):
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	case has("%m"):
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+	case has("%Y"):
+		return time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// scheduleSwitch arranges for the log file to be switched when the
+// placeholders in Filename next expand to another name, even if nothing is
+// being written then, so that the file of each time bucket is closed as soon
+// as the bucket is over.  This method assumes l.mu is held.
+func (l *Logger) scheduleSwitch() {
+	l.stopSwitchTimer()
+	exp := l.expandedPath()
+	if l.Filename == "" || !exp.placeholders {
+		return
+	}
+	tmpl := exp.path
+	now := l.placeholderTime()
+	if at := nextSwitch(tmpl, now); !at.IsZero() {
+		l.switchTimer = time.AfterFunc(at.Sub(now), l.switchOnTime)
+	}
+}
+
+// switchOnTime switches the log file when its time bucket is over.
+func (l *Logger) switchOnTime() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() || l.file == nil {
+		return
+	}
+	// check now, rather than within the second of the last check.
+	l.expandedAt = 0
+	l.switchExpanded()
+	if l.file != nil {
+		// The clock was behind the timer.
+		l.scheduleSwitch()
+	}
+}
+
+// stopSwitchTimer cancels the switch arranged by scheduleSwitch, if any.  This
+// method assumes l.mu is held.
+func (l *Logger) stopSwitchTimer() {
+	if l.switchTimer != nil {
+		l.switchTimer.Stop()
+		l.switchTimer = nil
+	}
+}
+
+// switchExpanded checks, if Filename has placeholders, whether they expand to
+// another name by now, such as on a new day for %d.  If so, the log file is
+// closed, without being rotated, so that the next write opens the file of the
+// new name.  The check is done at most once a second.  This method assumes
+// l.mu is held.
+func (l *Logger) switchExpanded() {
+	exp := l.expandedPath()
+	if l.Filename == "" || !exp.placeholders {
+		return
+	}
+	tmpl := exp.path
+	t := l.placeholderTime()
+	if t.Unix() == l.expandedAt {
+		return
+	}
+	l.expandedAt = t.Unix()
+	hostname, _ := os.Hostname()
+	name := expandPlaceholders(tmpl, t, hostname)
+
+	l.targetMu.Lock()
+	changed := l.expandedOf == tmpl && l.expandedName != name
+	l.expandedOf, l.expandedName = tmpl, name
+	l.targetMu.Unlock()
+	if !changed || l.file == nil {
+		return
+	}
+
+	// The compressed backup being made of the old file won't be needed.
+	l.abortStream()
+	if err := l.close(); err != nil {
+		l.recordError(writeError, err)
+	}
+	l.emit(Event{Type: EventSwitched, Path: longPath(name)})
+}
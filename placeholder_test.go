@@ -0,0 +1,134 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	at := time.Date(2024, 3, 7, 9, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/var/log/app/%Y-%m-%d/app-%H.log", "/var/log/app/2024-03-07/app-09.log"},
+		{"/var/log/app-{hostname}-%H%M.log", "/var/log/app-box-0905.log"},
+		{"/var/log/100%%.log", "/var/log/100%.log"},
+		{"/var/log/%x%.log", "/var/log/%x%.log"},
+	}
+	for _, test := range tests {
+		equals(test.want, expandPlaceholders(test.name, at, "box"), t)
+	}
+	equals(false, hasPlaceholders("/var/log/%x.log"), t)
+	equals(true, hasPlaceholders("/var/log/{hostname}.log"), t)
+}
+
+func TestFilenamePlaceholders(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFilenamePlaceholders", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var events []Event
+	l := &Logger{
+		Filename: filepath.Join(dir, "%Y-%m-%d", "foo-%H.log"),
+		OnEvent: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	}
+	defer l.Close()
+
+	first := filepath.Join(dir, fakeCurrentTime.UTC().Format("2006-01-02"), fakeCurrentTime.UTC().Format("foo-15.log"))
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(first, []byte("boo!"), t)
+
+	// an hour later, the writes go to a new file, and the old one is left
+	// as it is.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Hour)
+	second := filepath.Join(dir, fakeCurrentTime.UTC().Format("2006-01-02"), fakeCurrentTime.UTC().Format("foo-15.log"))
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(first, []byte("boo!"), t)
+	existsWithContent(second, []byte("foo!"), t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals(1, len(events), t)
+	equals(EventSwitched, events[0].Type, t)
+	equals(second, events[0].Path, t)
+}
+
+func TestNextSwitch(t *testing.T) {
+	at := time.Date(2024, 3, 7, 9, 5, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		want time.Time
+	}{
+		{"/var/log/app-%Y%m%d%H%M.log", time.Date(2024, 3, 7, 9, 6, 0, 0, time.UTC)},
+		{"/var/log/app/%Y-%m-%d/app-%H.log", time.Date(2024, 3, 7, 10, 0, 0, 0, time.UTC)},
+		{"/var/log/app-%d.log", time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"/var/log/app-%Y-%m.log", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"/var/log/app-%Y.log", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"/var/log/app-%%H-%d.log", time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"/var/log/app-{hostname}.log", time.Time{}},
+	}
+	for _, test := range tests {
+		equals(test.want, nextSwitch(test.name, at), t)
+	}
+}
+
+func TestSwitchOnTime(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSwitchOnTime", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var events []Event
+	l := &Logger{
+		Filename: filepath.Join(dir, "foo-%Y%m%d%H.log"),
+		OnEvent: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	l.mu.Lock()
+	notNil(l.switchTimer, t)
+	l.mu.Unlock()
+
+	// within the hour, the file is kept open.
+	l.switchOnTime()
+	l.mu.Lock()
+	notNil(l.file, t)
+	l.mu.Unlock()
+
+	// once the hour is over, the file is closed without anything being
+	// written, and the next write opens the file of the new hour.
+	fakeCurrentTime = fakeCurrentTime.Add(time.Hour)
+	l.switchOnTime()
+	l.mu.Lock()
+	equals(true, l.file == nil, t)
+	l.mu.Unlock()
+	mu.Lock()
+	equals(1, len(events), t)
+	equals(EventSwitched, events[0].Type, t)
+	mu.Unlock()
+
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	second := filepath.Join(dir, fakeCurrentTime.UTC().Format("foo-2006010215.log"))
+	existsWithContent(second, []byte("foo!"), t)
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,35 @@
+package lumberjack
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+)
+
+// labeled runs fn with the profiler labels of the Logger's goroutines, so that
+// CPU and goroutine profiles of a process with many Loggers tell which one the
+// background work, such as compression, is done for: "lumberjack" is set to
+// the work, "lumberjack.file" to Filename, before its placeholders are
+// expanded, or to the log file if Filename isn't set, and the Labels to
+// themselves.  Goroutines that fn starts inherit the labels.
+func (l *Logger) labeled(work string, fn func()) {
+	names := make([]string, 0, len(l.Labels))
+	for name := range l.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	file := l.Filename
+	if file == "" {
+		file = l.configuredFilename()
+	}
+	args := []string{"lumberjack", work, "lumberjack.file", file}
+	for _, name := range names {
+		if name != "lumberjack" && name != "lumberjack.file" {
+			args = append(args, name, l.Labels[name])
+		}
+	}
+	pprof.Do(context.Background(), pprof.Labels(args...), func(context.Context) {
+		fn()
+	})
+}
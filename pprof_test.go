@@ -0,0 +1,48 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileLabels(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestProfileLabels", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		TriggerFile: filepath.Join(dir, "rotate"),
+		Labels:      map[string]string{"service": "web"},
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// the goroutine that looks for the TriggerFile is labeled with the work,
+	// the log file and the Labels.
+	want := []string{`"lumberjack":"trigger"`, fmt.Sprintf("%q:%q", "lumberjack.file", filename), `"service":"web"`}
+	var profile string
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var b bytes.Buffer
+		isNil(pprof.Lookup("goroutine").WriteTo(&b, 1), t)
+		profile = b.String()
+		if strings.Contains(profile, want[0]) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, label := range want {
+		assert(strings.Contains(profile, label), t, "expected label %s in the goroutine profile:\n%s", label, profile)
+	}
+}
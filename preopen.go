@@ -0,0 +1,24 @@
+package lumberjack
+
+// startPreopen opens the log file in a goroutine of its own, with Preopen, so
+// that the first write finds it open.
+func (l *Logger) startPreopen() {
+	if l.Preopen && !l.SingleWriter {
+		go l.labeled("preopen", l.preopen)
+	}
+}
+
+// preopen opens the log file, creating its directory if need be, unless a
+// write has got there first or the Logger has been closed.  Failing to is
+// recorded, and left for the first write to try again and report.
+func (l *Logger) preopen() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() || l.file != nil {
+		return
+	}
+	l.switchExpanded()
+	if err := l.openFirst(0); err != nil {
+		l.recordError(otherError, err)
+	}
+}
@@ -0,0 +1,61 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreopen(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestPreopen", t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "sub", "foobar.log")
+
+	l, err := New(Config{Filename: filename, Preopen: true})
+	isNil(err, t)
+	defer l.Close()
+	waitFor(func() bool { return l.File() != nil }, t)
+	existsWithContent(filename, []byte{}, t)
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(filename, b, t)
+	isNil(l.Close(), t)
+}
+
+func TestPreopenFactory(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestPreopenFactory", t)
+	defer os.RemoveAll(dir)
+
+	var setUp bool
+	f := NewFactory(Config{Preopen: true}, func(l *Logger) {
+		setUp = l.File() == nil
+	})
+	l, err := f.New(logFile(dir), nil)
+	isNil(err, t)
+	defer l.Close()
+	assert(setUp, t, "expected setup before the log file was opened")
+	waitFor(func() bool { return l.File() != nil }, t)
+	existsWithContent(logFile(dir), []byte{}, t)
+}
+
+func TestPreopenClosed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestPreopenClosed", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), Preopen: true}
+	isNil(l.Close(), t)
+	l.preopen()
+	notExist(logFile(dir), t)
+}
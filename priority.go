@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package lumberjack
+
+// lowerThreadPriority is a no-op anywhere but linux and windows, where
+// priorities can't be changed for a single thread.
+func lowerThreadPriority() error {
+	return nil
+}
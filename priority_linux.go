@@ -0,0 +1,26 @@
+package lumberjack
+
+import (
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// lowerThreadPriority moves the calling OS thread to the lowest CPU priority
+// and the idle IO scheduling class.  On Linux both apply per thread, so the
+// caller must have locked its goroutine to the thread.
+func lowerThreadPriority() error {
+	tid := syscall.Gettid()
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, 19); err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioClassIdle<<ioprioClassShift)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
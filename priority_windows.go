@@ -0,0 +1,25 @@
+package lumberjack
+
+import (
+	"syscall"
+)
+
+const threadModeBackgroundBegin = 0x00010000
+
+var (
+	modkernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetCurrentThread  = modkernel32.NewProc("GetCurrentThread")
+	procSetThreadPriority = modkernel32.NewProc("SetThreadPriority")
+)
+
+// lowerThreadPriority puts the calling OS thread into background processing
+// mode, which lowers both its CPU and IO priority.  The caller must have
+// locked its goroutine to the thread.
+func lowerThreadPriority() error {
+	h, _, _ := procGetCurrentThread.Call()
+	r, _, err := procSetThreadPriority.Call(h, threadModeBackgroundBegin)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
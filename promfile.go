@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// writePromFile rewrites the PromFile, if set, with the Logger's counters and
+// its backups as they are now.
+func (l *Logger) writePromFile() error {
+	if l.PromFile == "" {
+		return nil
+	}
+	files, err := l.oldLogFiles()
+	if err == nil {
+		err = writeFileAtomic(l.PromFile, l.promText(files), 0644)
+	}
+	if err != nil {
+		l.recordError(otherError, err)
+	}
+	return err
+}
+
+// promText returns the metrics of the PromFile, given the backups, in the
+// Prometheus text exposition format.
+func (l *Logger) promText(files []logInfo) []byte {
+	s := l.Stats()
+	var size int64
+	var last float64
+	for _, f := range files {
+		size += f.Size()
+	}
+	if len(files) > 0 {
+		last = float64(files[0].timestamp.UnixNano()) / 1e9
+	}
+	degraded := 0
+	if s.Degraded {
+		degraded = 1
+	}
+
+	labels := promLabels(l.filename(), l.Labels)
+	var b bytes.Buffer
+	for _, m := range []struct {
+		name, kind, help string
+		value            interface{}
+	}{
+		{"rotations_total", "counter", "Rotations of the log file.", s.Rotations},
+		{"write_errors_total", "counter", "Writes that failed.", s.WriteErrors},
+		{"compress_errors_total", "counter", "Backups that failed to be compressed.", s.CompressErrors},
+		{"remove_errors_total", "counter", "Old log files that failed to be removed.", s.RemoveErrors},
+		{"tee_errors_total", "counter", "Writes that failed to be copied to a tee.", s.TeeErrors},
+		{"compressions_total", "counter", "Backups compressed.", s.Compressions},
+		{"degraded", "gauge", "Whether cleanup has failed for long enough to count as degraded.", degraded},
+		{"backups", "gauge", "Backups of the log file.", len(files)},
+		{"backup_bytes", "gauge", "Total size of the backups of the log file.", size},
+		{"last_rotation_timestamp_seconds", "gauge", "When the newest backup was rotated out.", last},
+	} {
+		fmt.Fprintf(&b, "# HELP lumberjack_%s %s\n", m.name, m.help)
+		fmt.Fprintf(&b, "# TYPE lumberjack_%s %s\n", m.name, m.kind)
+		fmt.Fprintf(&b, "lumberjack_%s%s %v\n", m.name, labels, m.value)
+	}
+	return b.Bytes()
+}
+
+// promLabels returns the label set of the metrics of the PromFile: the log
+// file, and the Labels, with their names made valid for Prometheus.
+func promLabels(filename string, extra map[string]string) string {
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(`{file="`)
+	b.WriteString(promEscaper.Replace(filename))
+	b.WriteByte('"')
+	for _, name := range names {
+		b.WriteByte(',')
+		b.WriteString(promLabelName(name))
+		b.WriteString(`="`)
+		b.WriteString(promEscaper.Replace(extra[name]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// promEscaper escapes label values for the Prometheus text format.
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabelName returns name with the characters a Prometheus label name
+// can't have replaced by underscores.
+func promLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		letter := c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || name == "file" {
+		return "label_" + string(b)
+	}
+	return string(b)
+}
@@ -0,0 +1,77 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestPromFile", t)
+	defer os.RemoveAll(dir)
+
+	prom := filepath.Join(dir, "textfile", "app.prom")
+	isNil(os.Mkdir(filepath.Dir(prom), 0755), t)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    100,
+		MaxBackups: 1,
+		PromFile:   prom,
+		Labels:     map[string]string{"service": `my "app"`, "pod-name": "a"},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("foo!!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	labels := fmt.Sprintf(`{file=%q,pod_name="a",service="my \"app\""}`, filename)
+	want := []string{
+		"# TYPE lumberjack_rotations_total counter",
+		"lumberjack_rotations_total" + labels + " 2",
+		"lumberjack_write_errors_total" + labels + " 0",
+		"# TYPE lumberjack_backups gauge",
+		"lumberjack_backups" + labels + " 1",
+		"lumberjack_backup_bytes" + labels + " 5",
+		"lumberjack_degraded" + labels + " 0",
+	}
+	waitFor(func() bool {
+		b, err := ioutil.ReadFile(prom)
+		if err != nil {
+			return false
+		}
+		for _, line := range want {
+			if !strings.Contains(string(b), line+"\n") {
+				return false
+			}
+		}
+		return true
+	}, t)
+
+	// nothing but the file itself is left in the collector's directory.
+	fileCount(filepath.Dir(prom), 1, t)
+}
+
+func TestPromLabelName(t *testing.T) {
+	for name, want := range map[string]string{
+		"service":  "service",
+		"pod-name": "pod_name",
+		"9lives":   "_lives",
+		"v1.2":     "v1_2",
+		"file":     "label_file",
+		"":         "label_",
+	} {
+		equals(want, promLabelName(name), t)
+	}
+}
@@ -0,0 +1,40 @@
+package lumberjack
+
+import (
+	"fmt"
+)
+
+// dirQuota is replaceable in tests.
+var dirQuota = projectQuota
+
+// maxTotal returns the most the log file and its backups, which take ours
+// bytes now, may take in all: MaxTotalSize, or with ProjectQuota, the project
+// quota of the backup directory less what the other files of the project take,
+// if that is less, or 0 for no limit.
+func (l *Logger) maxTotal(ours int64) int64 {
+	max := int64(l.MaxTotalSize) * int64(megabyte)
+	if !l.ProjectQuota {
+		return max
+	}
+	limit, used, err := dirQuota(l.backupDir())
+	if err != nil {
+		l.recordError(otherError, fmt.Errorf("can't get project quota: %v", err))
+		return max
+	}
+	if limit <= 0 {
+		return max
+	}
+	others := used - ours
+	if others < 0 {
+		others = 0
+	}
+	quota := limit - others
+	if quota < 1 {
+		// the rest of the project leaves no room for backups at all.
+		quota = 1
+	}
+	if max <= 0 || quota < max {
+		return quota
+	}
+	return max
+}
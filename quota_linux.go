@@ -0,0 +1,171 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package lumberjack
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fsIocFsGetXattr = 0x801c581f // FS_IOC_FSGETXATTR
+
+	qGetQuota = 0x800007 // Q_GETQUOTA
+	prjQuota  = 2        // PRJQUOTA
+
+	dqBlockSize = 1024 // QIF_DQBLKSIZE, the unit of quota limits
+)
+
+// fsxattr is struct fsxattr of linux/fs.h.
+type fsxattr struct {
+	xflags     uint32
+	extsize    uint32
+	nextents   uint32
+	projid     uint32
+	cowextsize uint32
+	pad        [8]byte
+}
+
+// dqblk is struct if_dqblk of linux/quota.h.
+type dqblk struct {
+	bhardlimit uint64
+	bsoftlimit uint64
+	curspace   uint64
+	ihardlimit uint64
+	isoftlimit uint64
+	curinodes  uint64
+	btime      uint64
+	itime      uint64
+	valid      uint32
+	_          uint32
+}
+
+// projectQuota returns the space limit in bytes of the project quota of dir,
+// its hard limit or else its soft one, and the space the project uses now, or
+// a zero limit if dir isn't in a project, or its filesystem has no project
+// quotas on.
+func projectQuota(dir string) (limit, used int64, err error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	var attr fsxattr
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), fsIocFsGetXattr, uintptr(unsafe.Pointer(&attr))); e != 0 {
+		if e == syscall.ENOTTY || e == syscall.EOPNOTSUPP || e == syscall.EINVAL {
+			return 0, 0, nil
+		}
+		return 0, 0, e
+	}
+	if attr.projid == 0 {
+		return 0, 0, nil
+	}
+
+	device, err := mountSource(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	dev, err := syscall.BytePtrFromString(device)
+	if err != nil {
+		return 0, 0, err
+	}
+	var q dqblk
+	cmd := uint32(qGetQuota<<8 | prjQuota)
+	_, _, e := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(cmd), uintptr(unsafe.Pointer(dev)), uintptr(attr.projid), uintptr(unsafe.Pointer(&q)), 0, 0)
+	switch e {
+	case 0:
+	case syscall.ESRCH, syscall.ENOSYS, syscall.ENOTBLK, syscall.ENOENT:
+		// no quotas there.
+		return 0, 0, nil
+	default:
+		return 0, 0, e
+	}
+	blocks := q.bhardlimit
+	if blocks == 0 {
+		blocks = q.bsoftlimit
+	}
+	return int64(blocks * dqBlockSize), int64(q.curspace), nil
+}
+
+// mountSource returns the device of the filesystem holding dir.
+func mountSource(dir string) (string, error) {
+	path, err := filepath.Abs(dir)
+	if err == nil {
+		path, err = filepath.EvalSymlinks(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return mountSourceIn(f, path)
+}
+
+// mountSourceIn returns the source of the mount of mountinfo, in the format of
+// /proc/self/mountinfo, that path is on.
+func mountSourceIn(mountinfo io.Reader, path string) (string, error) {
+	var source, point string
+	s := bufio.NewScanner(mountinfo)
+	for s.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(s.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" && i >= 6 {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(fields) < sep+3 {
+			continue
+		}
+		mount := unescapeMount(fields[4])
+		if !onMount(path, mount) || len(mount) < len(point) {
+			continue
+		}
+		source, point = unescapeMount(fields[sep+2]), mount
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if point == "" {
+		return "", errors.New("can't find the filesystem of " + path)
+	}
+	return source, nil
+}
+
+// onMount reports whether path is on the mount point mount.
+func onMount(path, mount string) bool {
+	return mount == "/" || path == mount || strings.HasPrefix(path, mount+"/")
+}
+
+// unescapeMount undoes the octal escapes of spaces and such in mountinfo.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package lumberjack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMountSource(t *testing.T) {
+	mountinfo := `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+30 22 8:17 / /var/log rw,relatime shared:2 - xfs /dev/sdb1 rw,prjquota
+31 22 8:33 / /var/log\040old rw,relatime shared:3 master:1 - xfs /dev/sdc1 rw
+`
+	for path, want := range map[string]string{
+		"/var/log/app":     "/dev/sdb1",
+		"/var/log":         "/dev/sdb1",
+		"/var/logs":        "/dev/sda1",
+		"/var/log old/app": "/dev/sdc1",
+		"/home":            "/dev/sda1",
+	} {
+		got, err := mountSourceIn(strings.NewReader(mountinfo), path)
+		isNil(err, t)
+		equals(want, got, t)
+	}
+	_, err := mountSourceIn(strings.NewReader(""), "/var/log")
+	notNil(err, t)
+}
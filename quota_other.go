@@ -0,0 +1,9 @@
+//go:build !linux || !(386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build !linux !386,!amd64,!arm,!arm64,!riscv64,!s390x
+
+package lumberjack
+
+// projectQuota reports no quota where project quotas aren't supported.
+func projectQuota(dir string) (limit, used int64, err error) {
+	return 0, 0, nil
+}
@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectQuota(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestProjectQuota", t)
+	defer os.RemoveAll(dir)
+	defer func() { dirQuota = projectQuota }()
+
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte(strings.Repeat("x", 15)), 0644), t)
+	l := &Logger{Filename: filename, ProjectQuota: true}
+	defer l.Close()
+
+	// the quota of 50 bytes is down to 40 by what else is in the project,
+	// which leaves room for the current log file and two backups of 10 bytes.
+	dirQuota = func(string) (int64, int64, error) { return 50, 15 + 3*10 + 10, nil }
+	newest := logInfo{timestamp: fakeTime(), FileInfo: sizedFile{"foobar-3.log", 10}}
+	newer := logInfo{timestamp: fakeTime().Add(-time.Hour), FileInfo: sizedFile{"foobar-2.log", 10}}
+	older := logInfo{timestamp: fakeTime().Add(-2 * time.Hour), FileInfo: sizedFile{"foobar-1.log", 10}}
+	keep, remove := l.retain([]logInfo{newest, newer, older})
+	equals([]logInfo{newest, newer}, keep, t)
+	equals([]removal{{older, RemovedBySize}}, remove, t)
+
+	// MaxTotalSize applies when it is less.
+	l.MaxTotalSize = 30
+	keep, remove = l.retain([]logInfo{newest, newer, older})
+	equals([]logInfo{newest}, keep, t)
+	equals([]removal{{newer, RemovedBySize}, {older, RemovedBySize}}, remove, t)
+
+	// without a quota, or when it can't be read, it is MaxTotalSize alone.
+	l.MaxTotalSize = 0
+	dirQuota = func(string) (int64, int64, error) { return 0, 0, nil }
+	keep, remove = l.retain([]logInfo{newest, newer, older})
+	equals(3, len(keep), t)
+	equals(0, len(remove), t)
+	dirQuota = func(string) (int64, int64, error) { return 0, 0, errors.New("no quota for you") }
+	keep, _ = l.retain([]logInfo{newest, newer, older})
+	equals(3, len(keep), t)
+	notNil(l.Stats().LastError, t)
+}
@@ -0,0 +1,221 @@
+package lumberjack
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logSpan is a log file together with the time span it holds lines from.
+type logSpan struct {
+	name string
+
+	// start is the time of the rotation before the file, or zero if it is the
+	// oldest one.
+	start time.Time
+
+	// end is the time of the rotation that made the file a backup, or zero if
+	// it is the current log file.
+	end time.Time
+}
+
+// covers reports whether s can hold lines written between since and until,
+// either of which may be zero to leave that end of the range open.
+func (s logSpan) covers(since, until time.Time) bool {
+	return (since.IsZero() || s.end.IsZero() || !s.end.Before(since)) &&
+		(until.IsZero() || s.start.Before(until))
+}
+
+// logSpans returns the backups and the current log file, oldest first, with
+// the time spans they hold, judging by the rotation timestamps of the backups:
+// a backup holds the lines written after the previous rotation and before its
+// own.  If a backup is being compressed right now, the uncompressed file is
+// picked.
+func (l *Logger) logSpans() ([]logSpan, error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var spans []logSpan
+	var start time.Time
+	seen := make(map[string]bool)
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		base := uncompressedName(f.Name(), l.compressSuffixes())
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+		name := f.Name()
+		if archive, _, ok := splitPart(name, l.compressSuffixes()); ok {
+			name = archive + "." + formatPart(0)
+		}
+		if _, err := os.Stat(filepath.Join(f.dir, base)); err == nil {
+			name = base
+		}
+		spans = append(spans, logSpan{
+			name:  filepath.Join(f.dir, name),
+			start: start,
+			end:   f.timestamp,
+		})
+		start = f.timestamp
+	}
+	return append(spans, logSpan{name: l.filename(), start: start}), nil
+}
+
+// OpenBackupAt opens the log file holding the lines written at t for reading,
+// decompressing it if it is a compressed backup, for tools that fetch the log
+// from around a given time.  The file is picked by the rotation timestamps of
+// the backups, as with Grep: it is the oldest backup rotated at or after t, or
+// the current log file if there is none.  Times before the oldest backup still
+// around get the oldest backup.
+//
+// The returned reader holds the file open, so the file stays readable even if
+// cleanup removes it in the meantime.  It's the caller's job to close it.
+func (l *Logger) OpenBackupAt(t time.Time) (io.ReadCloser, error) {
+	spans, err := l.logSpans()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range spans {
+		if !s.covers(t, time.Time{}) {
+			continue
+		}
+		r, err := openLogReader(s.name, l.codec())
+		if os.IsNotExist(err) && !isCompressed(s.name, l.compressSuffixes()) && !s.end.IsZero() {
+			// compressed, or moved to BackupDir, since it was listed.
+			for _, name := range []string{
+				s.name + l.compressSuffix(),
+				s.name + l.compressSuffix() + "." + formatPart(0),
+				l.archivePath(s.name),
+				l.archivePath(s.name) + l.compressSuffix(),
+				l.archivePath(s.name) + l.compressSuffix() + "." + formatPart(0),
+			} {
+				if r, err = openLogReader(name, l.codec()); !os.IsNotExist(err) {
+					break
+				}
+			}
+			for _, name := range []string{s.name, l.archivePath(s.name)} {
+				if !os.IsNotExist(err) {
+					break
+				}
+				if hashed := l.hashedBackup(name); hashed != "" {
+					r, err = openLogReader(hashed, l.codec())
+				}
+			}
+		}
+		return r, err
+	}
+	// not reached: the current log file covers everything after the last
+	// rotation.
+	return nil, os.ErrNotExist
+}
+
+// OpenBackup opens the named backup for reading, decompressing it if it is
+// compressed, as told by its suffix, so that an admin endpoint can serve the
+// backups listed by Backups.  The name can be the Path of a backup, any of its
+// Files, or just the base name of one of those, including the name the backup
+// had before it was compressed or moved to BackupDir.  Any other name gets an
+// error satisfying os.IsNotExist, so it is safe to take the name from a
+// request.
+//
+// The returned reader holds the file open, so the file stays readable even if
+// cleanup removes it in the meantime.  It's the caller's job to close it.
+func (l *Logger) OpenBackup(name string) (io.ReadCloser, error) {
+	var err error
+	for try := 0; try < 2; try++ {
+		var path string
+		if path, err = l.backupPath(name); err != nil {
+			return nil, err
+		}
+		var r io.ReadCloser
+		if r, err = openLogReader(path, l.codec()); !os.IsNotExist(err) {
+			return r, err
+		}
+		// compressed, or moved to BackupDir, since it was listed.
+	}
+	return nil, err
+}
+
+// backupPath returns the Path of the backup that has a file by the given name,
+// as described for OpenBackup.
+func (l *Logger) backupPath(name string) (string, error) {
+	backups, err := l.Backups()
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(name)
+	for _, b := range backups {
+		for _, f := range b.Files {
+			fbase := filepath.Base(f)
+			if f == name || fbase == base || uncompressedName(fbase, l.compressSuffixes()) == base {
+				return b.Path, nil
+			}
+		}
+	}
+	return "", &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+// openCompressed opens the named log file for reading, decompressing it with d
+// if it is a compressed backup, as told by the given suffixes, or a part of
+// one.
+func openCompressed(name string, suffixes []string, d Decompressor) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	files := []*os.File{f}
+	r := io.Reader(f)
+	if archive, part, ok := splitPart(name, suffixes); ok {
+		readers := []io.Reader{f}
+		for i := part + 1; ; i++ {
+			f, err := os.Open(archive + "." + formatPart(i))
+			if os.IsNotExist(err) {
+				break
+			}
+			if err != nil {
+				closeFiles(files)
+				return nil, err
+			}
+			files = append(files, f)
+			readers = append(readers, f)
+		}
+		r = io.MultiReader(readers...)
+	} else if !hasAnySuffix(name, suffixes) {
+		return f, nil
+	}
+	dr, err := d.Decompress(r)
+	if err != nil {
+		closeFiles(files)
+		return nil, err
+	}
+	return &compressedFile{ReadCloser: dr, files: files}, nil
+}
+
+// compressedFile is a decompressing reader that closes its underlying files
+// when closed.
+type compressedFile struct {
+	io.ReadCloser
+	files []*os.File
+}
+
+func (c *compressedFile) Close() error {
+	err := c.ReadCloser.Close()
+	if errClose := closeFiles(c.files); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// closeFiles closes the given files, returning the first error.
+func closeFiles(files []*os.File) error {
+	var err error
+	for _, f := range files {
+		if errClose := f.Close(); err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
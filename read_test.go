@@ -0,0 +1,95 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenBackupAt(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestOpenBackupAt", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	// two rotations two days apart, the second one compressed.
+	t1 := fakeTime()
+	first := backupFile(dir)
+	isNil(ioutil.WriteFile(first, []byte("first"), 0644), t)
+
+	newFakeTime()
+	t2 := fakeTime()
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err := gz.Write([]byte("second"))
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	isNil(ioutil.WriteFile(backupFile(dir)+compressSuffix, bc.Bytes(), 0644), t)
+
+	isNil(ioutil.WriteFile(filename, []byte("current"), 0644), t)
+
+	for _, tt := range []struct {
+		at   time.Time
+		want string
+	}{
+		{t1.Add(-time.Hour), "first"},
+		{t1.Add(time.Hour), "second"},
+		{t2.Add(time.Hour), "current"},
+	} {
+		r, err := l.OpenBackupAt(tt.at)
+		isNil(err, t)
+		b, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		equals(tt.want, string(b), t)
+	}
+
+	// without a log file yet, there is nothing to open.
+	isNil(os.Remove(filename), t)
+	_, err = l.OpenBackupAt(t2.Add(time.Hour))
+	assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+}
+
+func TestOpenBackup(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOpenBackup", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, Compress: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("first"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	waitFor(func() bool {
+		_, err := os.Stat(backup)
+		return os.IsNotExist(err)
+	}, t)
+
+	// the backup can be opened by its name now or before it was compressed.
+	for _, name := range []string{backup + compressSuffix, backup, filepath.Base(backup)} {
+		r, err := l.OpenBackup(name)
+		isNil(err, t)
+		b, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		equals("first", string(b), t)
+	}
+
+	// but nothing else, the log file included.
+	for _, name := range []string{filename, filepath.Join(dir, "..", "passwd"), "foobar-x.log"} {
+		_, err := l.OpenBackup(name)
+		assert(os.IsNotExist(err), t, "expected a not exist error for %s, got %v", name, err)
+	}
+}
@@ -0,0 +1,143 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// copyBufSize is the size of the buffers in copyBufs.
+const copyBufSize = 32 * 1024
+
+// copyBufs holds the buffers that backups are copied into compressors through,
+// and that ReadFrom reads into, so that each copy doesn't allocate its own.
+var copyBufs = sync.Pool{New: func() interface{} {
+	b := make([]byte, copyBufSize)
+	return &b
+}}
+
+// copyBuffered copies src to dst like io.Copy, through a buffer from copyBufs.
+func copyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(buf)
+	// Hide the WriteTo of an *os.File, which falls back to io.Copy, and its
+	// own buffer, for anything but another file or a socket.
+	return io.CopyBuffer(dst, struct{ io.Reader }{src}, *buf)
+}
+
+// ReadFrom implements io.ReaderFrom, so that io.Copy into the Logger, as of a
+// large request dump, or of a long-lived stream such as the output of a child
+// process or a TCP connection from a log shipper, writes what it reads from r
+// to the log file as it goes, through a single pooled buffer, until r ends or
+// fails.  Each chunk is written as Write would write it, and they are sized to
+// fill what is left of the log file, so a payload that doesn't fit carries on
+// in the next one after a rotation.  Nothing is held locked while reading
+// from r, so an idle stream doesn't hold up other writes, Rotate or Close,
+// which makes ReadFrom return ErrClosed with what it read but didn't write
+// thrown away.  So that other writes only come in between whole lines of r,
+// a chunk ends at the last newline read, and a line is held until the rest
+// of it arrives, unless it fills the buffer, or the log file.  With
+// BufferSize or SingleWriter, the chunks are simply passed to Write.  Note
+// that io.Copy uses the WriteTo of a reader that has one, such as a
+// bytes.Reader, instead, which makes a single Write of all of it.
+func (l *Logger) ReadFrom(r io.Reader) (n int64, err error) {
+	buf := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(buf)
+	if l.openFiles != nil {
+		l.openFiles.touch(l)
+	}
+	if l.BufferSize > 0 || l.SingleWriter {
+		return io.CopyBuffer(struct{ io.Writer }{l}, struct{ io.Reader }{r}, *buf)
+	}
+
+	ctx := context.Background()
+	if l.Trace != nil {
+		var end func()
+		ctx, end = l.Trace(ctx, TraceWrite)
+		defer end()
+	}
+
+	var errRead error
+	end := 0 // of what has been read and not written yet
+	for errRead == nil || end > 0 {
+		if errRead == nil && end < len(*buf) {
+			var m int
+			m, errRead = r.Read((*buf)[end:])
+			end += m
+		}
+		var w int
+		var seq int64
+		w, seq, err = l.writeChunk(ctx, (*buf)[:end], errRead == nil)
+		n += int64(w)
+		if err != nil {
+			return n, err
+		}
+		if seq > 0 {
+			if err := l.waitSynced(seq); err != nil {
+				return n, err
+			}
+		}
+		end = copy(*buf, (*buf)[w:end])
+	}
+	if errRead == io.EOF {
+		errRead = nil
+	}
+	return n, errRead
+}
+
+// writeChunk writes the chunk of p that ReadFrom should write next, p being
+// all it has read and not written yet, at the start of its buffer, and more
+// being whether there may be more to come.  It returns the length of the
+// chunk, which may be 0, and the sequence number to wait for the group sync
+// of, if any.
+func (l *Logger) writeChunk(ctx context.Context, p []byte, more bool) (int, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return 0, 0, ErrClosed
+	}
+	if l.file == nil {
+		// Find out how much is in the log file already.  Failing to open it
+		// is left to the write to deal with.
+		_ = l.openFirst(0)
+	}
+	cut := l.chunkSize(len(p))
+	if cut == len(p) && more {
+		if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+			cut = i + 1
+		} else if len(p) < cap(p) {
+			// wait for the rest of the line.
+			cut = 0
+		}
+	}
+	if cut == 0 {
+		return 0, 0, nil
+	}
+	p = p[:cut]
+	l.traceCtx = ctx
+	defer func() { l.traceCtx = nil }()
+	if l.RecentSize > 0 {
+		l.recentRing().Write(p)
+	}
+	w, err := l.writeRecord(p)
+	seq := l.sequence(w, err)
+	if err == nil && w < len(p) {
+		err = io.ErrShortWrite
+	}
+	return w, seq, err
+}
+
+// chunkSize returns how much ReadFrom should read for its next write, at most
+// max: what is left of the log file, or of a new one if it's full, so that the
+// log file is filled before it's rotated.  This method assumes l.mu is held.
+func (l *Logger) chunkSize(max int) int {
+	room := l.max() - l.size
+	if room <= 0 {
+		room = l.max()
+	}
+	if room < int64(max) {
+		return int(room)
+	}
+	return max
+}
@@ -0,0 +1,142 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadFrom(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestReadFrom", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      10,
+		NamingScheme: Sequential,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// the payload fills up the log file before carrying on in the next ones.
+	payload := strings.Repeat("0123456789", 3)
+	n, err := io.Copy(l, io.LimitReader(strings.NewReader(payload), 100))
+	isNil(err, t)
+	equals(int64(30), n, t)
+
+	existsWithContent(filename+".3", []byte("boo!012345"), t)
+	existsWithContent(filename+".2", []byte("6789012345"), t)
+	existsWithContent(filename+".1", []byte("6789012345"), t)
+	existsWithContent(filename, []byte("6789"), t)
+}
+
+func TestReadFromBuffered(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestReadFromBuffered", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    1 << 20,
+		BufferSize: 1 << 10,
+	}
+	defer l.Close()
+
+	// with a buffer, the chunks are queued like writes.
+	payload := bytes.Repeat([]byte("x"), 3*copyBufSize+7)
+	n, err := l.ReadFrom(bytes.NewReader(payload))
+	isNil(err, t)
+	equals(int64(len(payload)), n, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, payload, t)
+}
+
+func TestCompressorReuse(t *testing.T) {
+	// a pooled gzip writer starts afresh for every backup.
+	for i := 0; i < 3; i++ {
+		for _, c := range []Compressor{Gzip{}, Gzip{Level: 1}} {
+			var compressed bytes.Buffer
+			want := strings.Repeat("compress me ", i+1)
+			isNil(c.Compress(&compressed, strings.NewReader(want)), t)
+			rc, err := c.(Decompressor).Decompress(&compressed)
+			isNil(err, t)
+			var got bytes.Buffer
+			_, err = got.ReadFrom(rc)
+			isNil(err, t)
+			equals(want, got.String(), t)
+		}
+	}
+}
+
+func TestReadFromStream(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestReadFromStream", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+	}
+	defer l.Close()
+
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := l.ReadFrom(pr)
+		done <- err
+	}()
+
+	// other writes go ahead while the stream is idle, and in between its
+	// lines.
+	_, err := pw.Write([]byte("streamed\nhalf a"))
+	isNil(err, t)
+	waitFor(func() bool {
+		b, _ := ioutil.ReadFile(filename)
+		return string(b) == "streamed\n"
+	}, t)
+	_, err = l.Write([]byte("written\n"))
+	isNil(err, t)
+	_, err = pw.Write([]byte(" line\n"))
+	isNil(err, t)
+	isNil(pw.Close(), t)
+	isNil(<-done, t)
+	existsWithContent(filename, []byte("streamed\nwritten\nhalf a line\n"), t)
+}
+
+func TestReadFromClosed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestReadFromClosed", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+	}
+
+	// closing the Logger doesn't wait for the stream.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.ReadFrom(pr)
+		done <- err
+	}()
+	_, err := pw.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	go pw.Write([]byte("too late\n"))
+	equals(ErrClosed, <-done, t)
+}
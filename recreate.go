@@ -0,0 +1,64 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// dirMode returns the mode of the directories the Logger creates.
+func (l *Logger) dirMode() os.FileMode {
+	if l.DirMode != 0 {
+		return l.DirMode
+	}
+	if mode := l.profileDirMode(); mode != 0 {
+		return mode
+	}
+	return 0755
+}
+
+// noteDir records the info of the directory of the log file, for RecreateDir
+// to create it again like it.  This method assumes l.mu is held.
+func (l *Logger) noteDir() {
+	if !l.RecreateDir {
+		return
+	}
+	dir := l.dir()
+	if info, err := osStat(dir); err == nil {
+		l.dirInfo = withSecurity(info, dir)
+	}
+}
+
+// recreateDirIfRemoved creates the directory of the log file again, and opens
+// a new log file in it, for a write of writeLen bytes, if the directory was
+// removed.  This method assumes l.mu is held.
+func (l *Logger) recreateDirIfRemoved(writeLen int) error {
+	dir := l.dir()
+	if _, err := osStat(dir); !os.IsNotExist(err) {
+		return nil
+	}
+
+	// The compressed backup being made of the file went with it.
+	l.abortStream()
+	if err := l.close(); err != nil {
+		l.recordError(writeError, err)
+	}
+	mode := l.dirMode()
+	if l.DirMode == 0 && l.profileDirMode() == 0 && l.dirInfo != nil {
+		mode = l.dirInfo.Mode().Perm()
+	}
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return fmt.Errorf("can't recreate log file directory: %w", err)
+	}
+	l.unpinDir()
+	if l.dirInfo != nil && !l.OwnerFromDir {
+		if err := l.chownDir(dir, l.dirInfo); err != nil {
+			return err
+		}
+	}
+	if err := l.openExistingOrNew(writeLen); err != nil {
+		return err
+	}
+	l.linkCurrent()
+	l.emit(Event{Type: EventDirRecreated, Path: dir})
+	return nil
+}
@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRecreateDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directories with open files can't be removed on windows")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	root := makeTempDir("TestRecreateDir", t)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "logs")
+	isNil(os.Mkdir(dir, 0750), t)
+	isNil(os.Chmod(dir, 0750), t)
+
+	var events []Event
+	l := &Logger{
+		Filename:    logFile(dir),
+		MaxSize:     100,
+		RecreateDir: true,
+		OnEvent:     func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	isNil(os.RemoveAll(dir), t)
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+
+	// like the directory that was removed.
+	existsWithContent(logFile(dir), []byte("foo!"), t)
+	info, err := os.Stat(dir)
+	isNil(err, t)
+	equals(os.FileMode(0750), info.Mode().Perm(), t)
+	equals(1, len(events), t)
+	equals(EventDirRecreated, events[0].Type, t)
+	equals(dir, events[0].Path, t)
+}
+
+func TestDirMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not fully supported on windows")
+	}
+	currentTime = fakeTime
+	megabyte = 1
+
+	root := makeTempDir("TestDirMode", t)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "logs")
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 100, DirMode: 0700}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	info, err := os.Stat(dir)
+	isNil(err, t)
+	equals(os.FileMode(0700), info.Mode().Perm(), t)
+}
@@ -0,0 +1,20 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64 s390x
+
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+)
+
+const ficlone = 0x40049409 // FICLONE
+
+// reflink makes dst share the blocks of src, or fails if the filesystem can't.
+func reflink(dst, src *os.File) error {
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd()); e != 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux || !(386 || amd64 || arm || arm64 || riscv64 || s390x)
+// +build !linux !386,!amd64,!arm,!arm64,!riscv64,!s390x
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is not supported on this platform.
+func reflink(dst, src *os.File) error {
+	return errors.New("reflinks are not supported on this platform")
+}
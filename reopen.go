@@ -0,0 +1,40 @@
+package lumberjack
+
+import "os"
+
+// reopenIfMoved checks, with ReopenOnMove, whether the open log file is still
+// the one Filename refers to, and at least as long as the Logger wrote it.  If
+// something else moved, removed or truncated it, the Logger lets go of it and
+// opens the log file afresh, ready for a write of writeLen bytes.  This method
+// assumes l.mu is held and that the file is open.
+func (l *Logger) reopenIfMoved(writeLen int) error {
+	open, err := l.file.Stat()
+	if err != nil {
+		return nil
+	}
+	info, err := osStat(l.filename())
+	switch {
+	case os.IsNotExist(err):
+		// removed, or moved away.
+	case err != nil:
+		return nil
+	case !os.SameFile(open, info):
+		// replaced, such as by logrotate's create.
+	case info.Size() < l.size:
+		// truncated, such as by logrotate's copytruncate.
+	default:
+		return nil
+	}
+
+	// The compressed backup being made of the file no longer matches it.
+	l.abortStream()
+	if err := l.close(); err != nil {
+		l.recordError(writeError, err)
+	}
+	if err := l.openExistingOrNew(writeLen); err != nil {
+		return err
+	}
+	l.linkCurrent()
+	l.emit(Event{Type: EventReopened, Path: l.filename()})
+	return nil
+}
@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReopenOnMove(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestReopenOnMove", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var events []Event
+	l := &Logger{
+		Filename:     filename,
+		MaxSize:      100,
+		ReopenOnMove: true,
+		OnEvent:      func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	write := func(s string) {
+		t.Helper()
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+	}
+	write("boo!")
+
+	// moved away, as by logrotate: the moved file keeps what was written to
+	// it, and the next write goes to a new log file.
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+	write("foo!")
+	existsWithContent(moved, []byte("boo!"), t)
+	existsWithContent(filename, []byte("foo!"), t)
+
+	// removed.
+	isNil(os.Remove(filename), t)
+	write("bar!")
+	existsWithContent(filename, []byte("bar!"), t)
+
+	// truncated, as by copytruncate: writes carry on at the start, rather than
+	// leaving a hole.
+	isNil(os.Truncate(filename, 0), t)
+	write("baz!")
+	existsWithContent(filename, []byte("baz!"), t)
+	equals(int64(4), l.CurrentSize(), t)
+
+	// nothing happened.
+	write("qux!")
+	existsWithContent(filename, []byte("baz!qux!"), t)
+
+	equals(3, len(events), t)
+	for _, e := range events {
+		equals(EventReopened, e.Type, t)
+		equals(filename, e.Path, t)
+	}
+}
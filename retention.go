@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// diskFree is a var so we can mock it out during tests.
+var diskFree = freeSpace
+
+// RemoveReason is the retention rule that had a backup removed.  See
+// EventRemoved.
+type RemoveReason int
+
+const (
+	// RemovedByAge means the backup was older than MaxAge.
+	RemovedByAge RemoveReason = iota + 1
+
+	// RemovedByHistory means the newer backups covered MaxHistory without it.
+	RemovedByHistory
+
+	// RemovedByCount means there were MaxBackups newer backups.
+	RemovedByCount
+
+	// RemovedBySize means the backup didn't fit in MaxTotalSize along with
+	// the newer backups.
+	RemovedBySize
+
+	// RemovedByDiskFree means the backup was removed to get MinDiskFree,
+	// MinFreeBytes or MinFreePercent free on the disk.
+	RemovedByDiskFree
+)
+
+// String returns a short lowercase name of the rule.
+func (r RemoveReason) String() string {
+	switch r {
+	case RemovedByAge:
+		return "age"
+	case RemovedByHistory:
+		return "history"
+	case RemovedByCount:
+		return "count"
+	case RemovedBySize:
+		return "size"
+	case RemovedByDiskFree:
+		return "disk free"
+	}
+	return fmt.Sprintf("RemoveReason(%d)", int(r))
+}
+
+// removal is a backup file to remove, and why.
+type removal struct {
+	logInfo
+	reason RemoveReason
+}
+
+// retain applies the retention rules to files, the backups sorted newest
+// first, in a single pass, returning the files to keep and the files to
+// remove.  The files of a backup, such as a backup and its compressed version
+// while it's being compressed, or the parts of a split one, are kept or
+// removed together.  A backup that several rules would remove is put down to
+// the first of MaxAge, MaxHistory, MaxBackups and MaxTotalSize, in that order,
+// and only the backups all of those keep are considered for the free space minimums,
+// oldest first.  Backups pinned with a .keep file are kept and not counted.
+func (l *Logger) retain(files []logInfo) (keep []logInfo, remove []removal) {
+	return l.retainWith(files, l.currentFileSize, l.pinned)
+}
+
+// retainWith is like retain, but takes the size of the current log file, for
+// MaxTotalSize, from current, and whether a backup is pinned from pinned,
+// rather than from the disk.
+func (l *Logger) retainWith(files []logInfo, current func() int64, pinned func(files []logInfo) bool) (keep []logInfo, remove []removal) {
+	var backups [][]logInfo
+	index := make(map[string]int)
+	for _, f := range files {
+		name := uncompressedName(f.Name(), l.compressSuffixes())
+		i, ok := index[name]
+		if !ok {
+			i = len(backups)
+			index[name] = i
+			backups = append(backups, nil)
+		}
+		backups[i] = append(backups[i], f)
+	}
+
+	var cutoff time.Time
+	if l.MaxAge > 0 {
+		diff := time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
+		cutoff = l.retentionNow().Add(-1 * diff)
+	}
+	var since time.Time
+	if l.MaxHistory > 0 && len(backups) > 0 {
+		since = backups[0][0].timestamp.Add(-l.MaxHistory)
+	}
+	var maxTotal, total int64
+	if l.MaxTotalSize > 0 || l.ProjectQuota {
+		// The current log file counts towards the budget too.
+		total = current()
+		ours := total
+		for _, b := range backups {
+			ours += backupSize(b)
+		}
+		maxTotal = l.maxTotal(ours)
+	}
+
+	reasons := make([]RemoveReason, len(backups))
+	isPinned := make([]bool, len(backups))
+	var kept int
+	var freed int64
+	full := false
+	for i, b := range backups {
+		size := backupSize(b)
+		switch {
+		case pinned(b):
+			isPinned[i] = true
+			continue
+		case l.MaxAge > 0 && b[0].timestamp.Before(cutoff):
+			reasons[i] = RemovedByAge
+		case l.MaxHistory > 0 && !b[0].timestamp.After(since):
+			// The backups rotated after it hold the whole of MaxHistory.
+			reasons[i] = RemovedByHistory
+		case l.MaxBackups > 0 && kept >= l.MaxBackups:
+			reasons[i] = RemovedByCount
+		case maxTotal > 0 && (full || total+size > maxTotal):
+			// Once a backup doesn't fit, older ones don't get to either.
+			full = true
+			reasons[i] = RemovedBySize
+		default:
+			kept++
+			total += size
+			continue
+		}
+		freed += size
+	}
+
+	if l.minFreeSet() {
+		free, size, err := cachedDiskFree(l.backupDir())
+		if err != nil {
+			l.recordError(otherError, fmt.Errorf("can't get free disk space: %v", err))
+		} else {
+			need := l.minFree(size) - int64(free) - freed
+			for i := len(backups) - 1; i >= 0 && need > 0; i-- {
+				if reasons[i] == 0 && !isPinned[i] {
+					reasons[i] = RemovedByDiskFree
+					need -= backupSize(backups[i])
+				}
+			}
+		}
+	}
+
+	for i, b := range backups {
+		for _, f := range b {
+			if reasons[i] == 0 {
+				keep = append(keep, f)
+			} else {
+				remove = append(remove, removal{f, reasons[i]})
+			}
+		}
+	}
+	return keep, remove
+}
+
+// currentFileSize returns the size of the current log file on disk, or 0 if
+// there isn't one.
+func (l *Logger) currentFileSize() int64 {
+	if info, err := osStat(l.filename()); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// backupSize returns the total size of the files of a backup.
+func backupSize(files []logInfo) int64 {
+	var size int64
+	for _, f := range files {
+		size += f.Size()
+	}
+	return size
+}
@@ -0,0 +1,136 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetentionRules(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRetentionRules", t)
+	defer os.RemoveAll(dir)
+
+	// six backups of 10 bytes, the newest first, rotated days ago; the third
+	// one is being compressed.
+	var names []string
+	for _, days := range []int{0, 1, 2, 3, 4, 6} {
+		age := time.Duration(days) * 24 * time.Hour
+		name := filepath.Join(dir, "foobar-"+fakeTime().Add(-age).UTC().Format(backupTimeFormat)+".log")
+		isNil(ioutil.WriteFile(name, []byte(strings.Repeat("x", 10)), 0644), t)
+		names = append(names, name)
+	}
+	isNil(ioutil.WriteFile(names[2]+compressSuffix, []byte("xx"), 0644), t)
+
+	free := uint64(1000)
+	diskFree = func(string) (uint64, uint64, error) { return free, 1 << 30, nil }
+	defer func() { diskFree = freeSpace }()
+
+	reasons := make(map[string]RemoveReason)
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxAge:       5,
+		MaxBackups:   4,
+		MaxTotalSize: 35,
+		MinDiskFree:  1041,
+		OnEvent: func(e Event) {
+			if e.Type == EventRemoved {
+				reasons[filepath.Base(e.Path)] = e.Reason
+			}
+		},
+	}
+	defer l.Close()
+	isNil(l.millRunOnce(), t)
+
+	// the oldest is past MaxAge; the fourth and fifth don't fit in
+	// MaxTotalSize, which the third, with both its files, just does.  That
+	// frees 30 bytes, which leaves 11 to go for MinDiskFree, so the third
+	// backup, as the oldest left, goes as well.
+	equals(map[string]RemoveReason{
+		filepath.Base(names[5]):                  RemovedByAge,
+		filepath.Base(names[4]):                  RemovedBySize,
+		filepath.Base(names[3]):                  RemovedBySize,
+		filepath.Base(names[2]):                  RemovedByDiskFree,
+		filepath.Base(names[2] + compressSuffix): RemovedByDiskFree,
+	}, reasons, t)
+	exists(names[0], t)
+	exists(names[1], t)
+	fileCount(dir, 2, t)
+}
+
+func TestRetentionTotalSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRetentionTotalSize", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte(strings.Repeat("x", 15)), 0644), t)
+	l := &Logger{Filename: filename, MaxTotalSize: 30}
+	defer l.Close()
+
+	// the current log file leaves room for one backup of 10 bytes.
+	newer := logInfo{timestamp: fakeTime(), FileInfo: sizedFile{"foobar-2.log", 10}}
+	older := logInfo{timestamp: fakeTime().Add(-time.Hour), FileInfo: sizedFile{"foobar-1.log", 10}}
+	keep, remove := l.retain([]logInfo{newer, older})
+	equals([]logInfo{newer}, keep, t)
+	equals([]removal{{older, RemovedBySize}}, remove, t)
+}
+
+func TestRetentionCount(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRetentionCount", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxBackups: 1}
+	defer l.Close()
+	newer := logInfo{timestamp: fakeTime(), FileInfo: sizedFile{"foobar-2.log", 1}}
+	older := logInfo{timestamp: fakeTime().Add(-time.Hour), FileInfo: sizedFile{"foobar-1.log", 1}}
+	keep, remove := l.retain([]logInfo{newer, older})
+	equals([]logInfo{newer}, keep, t)
+	equals([]removal{{older, RemovedByCount}}, remove, t)
+	equals("count", RemovedByCount.String(), t)
+}
+
+func TestRetentionHistory(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRetentionHistory", t)
+	defer os.RemoveAll(dir)
+
+	// the newest backup is long past, as if the application had been stopped
+	// for a week, which doesn't count against MaxHistory.
+	l := &Logger{Filename: logFile(dir), MaxHistory: 72 * time.Hour}
+	defer l.Close()
+	newest := fakeTime().Add(-7 * 24 * time.Hour)
+	var files []logInfo
+	for i, hours := range []int{0, 30, 60, 72, 100} {
+		files = append(files, logInfo{
+			timestamp: newest.Add(-time.Duration(hours) * time.Hour),
+			FileInfo:  sizedFile{fmt.Sprintf("foobar-%d.log", 5-i), 1},
+		})
+	}
+
+	// the first three hold the 72 hours before the newest rotation.
+	keep, remove := l.retain(files)
+	equals(files[:3], keep, t)
+	equals([]removal{{files[3], RemovedByHistory}, {files[4], RemovedByHistory}}, remove, t)
+	equals("history", RemovedByHistory.String(), t)
+}
+
+// sizedFile is an os.FileInfo with just a name and a size.
+type sizedFile struct {
+	name string
+	size int64
+}
+
+func (f sizedFile) Name() string       { return f.name }
+func (f sizedFile) Size() int64        { return f.size }
+func (f sizedFile) Mode() os.FileMode  { return 0644 }
+func (f sizedFile) ModTime() time.Time { return time.Time{} }
+func (f sizedFile) IsDir() bool        { return false }
+func (f sizedFile) Sys() interface{}   { return nil }
@@ -0,0 +1,124 @@
+package lumberjack
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Ring is an io.Writer that keeps the last so many bytes written to it in
+// memory, dropping the oldest.  It backs Logger.RecentLines, and also makes a
+// Fallback that keeps the most recent output around for a debug endpoint while
+// the disk can't be written to.  It is safe for concurrent use.
+type Ring struct {
+	mu    sync.Mutex
+	buf   []byte
+	next  int   // where the next byte goes
+	total int64 // bytes written in all
+
+	// lastDropped is the newest byte dropped to make room, which tells whether
+	// the oldest byte held starts a line.
+	lastDropped byte
+}
+
+// NewRing returns a Ring holding up to size bytes.
+func NewRing(size int) *Ring {
+	return &Ring{buf: make([]byte, size)}
+}
+
+// Write implements io.Writer.  It never fails.
+func (r *Ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	size := len(r.buf)
+	held := r.held()
+	switch over := held + n - size; {
+	case n > size:
+		r.lastDropped = p[n-size-1]
+		p = p[n-size:]
+	case over > 0:
+		oldest := (r.next - held + size) % size
+		r.lastDropped = r.buf[(oldest+over-1)%size]
+	}
+	for len(p) > 0 {
+		c := copy(r.buf[r.next:], p)
+		p = p[c:]
+		r.next = (r.next + c) % size
+	}
+	r.total += int64(n)
+	return n, nil
+}
+
+// held returns the number of bytes held.  It assumes that r.mu is held.
+func (r *Ring) held() int {
+	if r.total < int64(len(r.buf)) {
+		return int(r.total)
+	}
+	return len(r.buf)
+}
+
+// Bytes returns a copy of the bytes held, oldest first.
+func (r *Ring) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bytes()
+}
+
+// bytes is Bytes, assuming that r.mu is held.
+func (r *Ring) bytes() []byte {
+	if r.total < int64(len(r.buf)) {
+		return append([]byte(nil), r.buf[:r.next]...)
+	}
+	b := make([]byte, 0, len(r.buf))
+	b = append(b, r.buf[r.next:]...)
+	return append(b, r.buf[:r.next]...)
+}
+
+// Lines returns the lines held, oldest first, without their newlines.  A line
+// whose start was dropped to make room is left out, while the last line is
+// included even if it isn't finished yet.
+func (r *Ring) Lines() []string {
+	r.mu.Lock()
+	b := r.bytes()
+	partial := r.total > int64(len(r.buf)) && r.lastDropped != '\n'
+	r.mu.Unlock()
+
+	if partial {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil
+		}
+		b = b[i+1:]
+	}
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	if len(b) == 0 {
+		return nil
+	}
+	lines := bytes.Split(b, []byte("\n"))
+	s := make([]string, len(lines))
+	for i, line := range lines {
+		s[i] = string(line)
+	}
+	return s
+}
+
+// recentRing returns the Ring holding the Logger's recent writes, creating it
+// if necessary.
+func (l *Logger) recentRing() *Ring {
+	l.recentOnce.Do(func() {
+		l.recent = NewRing(l.RecentSize)
+	})
+	return l.recent
+}
+
+// RecentLines returns the lines most recently written to the Logger, up to
+// RecentSize bytes of them, oldest first and without their newlines.  They
+// include writes that failed, so that crash handlers and debug endpoints can
+// show the latest output even when the disk couldn't be written to.  It returns
+// nil if RecentSize is 0.
+func (l *Logger) RecentLines() []string {
+	if l.RecentSize <= 0 {
+		return nil
+	}
+	return l.recentRing().Lines()
+}
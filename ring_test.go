@@ -0,0 +1,66 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing(16)
+	equals(0, len(r.Lines()), t)
+
+	r.Write([]byte("one\ntwo\n"))
+	equals("one\ntwo\n", string(r.Bytes()), t)
+	equals([]string{"one", "two"}, r.Lines(), t)
+
+	// the oldest bytes are dropped, and so is what's left of their line.
+	r.Write([]byte("three\nfour"))
+	equals("e\ntwo\nthree\nfour", string(r.Bytes()), t)
+	equals([]string{"two", "three", "four"}, r.Lines(), t)
+
+	// a write larger than the ring keeps its end.
+	r.Write([]byte(strings.Repeat("x", 20) + "\nfive\n"))
+	equals(strings.Repeat("x", 10)+"\nfive\n", string(r.Bytes()), t)
+	equals([]string{"five"}, r.Lines(), t)
+
+	// a line that starts exactly at the oldest byte is kept whole.
+	r = NewRing(3)
+	r.Write([]byte("a\n"))
+	r.Write([]byte("bc\n"))
+	equals("bc\n", string(r.Bytes()), t)
+	equals([]string{"bc"}, r.Lines(), t)
+
+	r = NewRing(0)
+	n, err := r.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	equals(0, len(r.Lines()), t)
+}
+
+func TestRecentLines(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRecentLines", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), RecentSize: 1024}
+	defer l.Close()
+	equals(0, len(l.RecentLines()), t)
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+
+	// writes that fail are kept too.  A file where the log directory should
+	// be makes them fail.
+	notdir := filepath.Join(dir, "notdir")
+	isNil(ioutil.WriteFile(notdir, nil, 0644), t)
+	isNil(l.closeFile(), t)
+	l.Filename = logFile(notdir)
+	_, err = l.Write([]byte("foo!\n"))
+	notNil(err, t)
+	equals([]string{"boo!", "foo!"}, l.RecentLines(), t)
+
+	equals(0, len((&Logger{}).RecentLines()), t)
+}
@@ -23,3 +23,10 @@ func ExampleLogger_Rotate() {
 		}
 	}()
 }
+
+// Example of how to have the package rotate in response to SIGHUP.
+func ExampleLogger_RotateOnSignal() {
+	l := &Logger{}
+	log.SetOutput(l)
+	l.RotateOnSignal(syscall.SIGHUP)
+}
@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// keyPlaceholder is replaced by the key in Router.Filename.
+const keyPlaceholder = "{key}"
+
+// Router hands out a writer per key, such as a tenant or a component, each
+// backed by its own Logger that is created the first time the key is written
+// to.  This suits multi-tenant daemons, where the set of log files isn't known
+// up front.
+//
+// The zero value isn't usable; Filename must be set.
+type Router struct {
+	// Filename is the template for the log file of each key, in which
+	// "{key}" is replaced by the key, e.g. /var/log/myapp/{key}/app.log or
+	// /var/log/myapp/tenant-{key}.log.  Characters other than letters,
+	// digits, '.', '-' and '_' are replaced by '_' in the key first, so a key
+	// can't point outside the directory it's meant for.  Keys that come out
+	// the same share a file.
+	Filename string
+
+	// NewLogger returns a new Logger for the given key and file name, which
+	// it can use to give all Loggers the same MaxSize, MaxBackups, MaxAge and
+	// so on.  It must set the Logger's Filename to filename.  The default is
+	// a Logger with default settings.
+	NewLogger func(key, filename string) *Logger
+
+	// MaxOpenFiles is the maximum number of log files to keep open at once.
+	// When a write to another key, or a rotation, would exceed it, the file
+	// written to least recently is closed; it's reopened the next time its key is written to.
+	// With many concurrent writers the limit can be exceeded briefly.  The
+	// default is no limit.
+	MaxOpenFiles int
+
+	once    sync.Once
+	loggers MultiLogger
+}
+
+// WriterFor returns the writer for key.  Every writer for the same key writes
+// to the same Logger.
+func (r *Router) WriterFor(key string) io.Writer {
+	return r.logger(key)
+}
+
+// Rotate rotates the log files of every key written to so far.
+func (r *Router) Rotate() error {
+	r.init()
+	return r.loggers.Rotate()
+}
+
+// Close closes the log files of every key written to so far.  The Router can't
+// be used after Close; its writers return ErrClosed.
+func (r *Router) Close() error {
+	r.init()
+	return r.loggers.Close()
+}
+
+// init sets up the Router the first time it's used.
+func (r *Router) init() {
+	r.once.Do(func() {
+		r.loggers.MaxOpenFiles = r.MaxOpenFiles
+	})
+}
+
+// logger returns the Logger for key, creating it if needed.  Loggers are kept
+// by file name, so that keys that come out the same share one.
+func (r *Router) logger(key string) *Logger {
+	r.init()
+	filename := strings.Replace(r.Filename, keyPlaceholder, sanitizeKey(key), -1)
+	return r.loggers.loggerOr(filename, func() *Logger {
+		if r.NewLogger != nil {
+			return r.NewLogger(key, filename)
+		}
+		return &Logger{Filename: filename}
+	})
+}
+
+// sanitizeKey replaces the characters of key that aren't safe in a file name.
+func sanitizeKey(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		case c == '.' && key != "." && key != "..":
+		default:
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
@@ -0,0 +1,66 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRouter", t)
+	defer os.RemoveAll(dir)
+
+	var keys []string
+	r := &Router{
+		Filename: filepath.Join(dir, "{key}", "app.log"),
+		NewLogger: func(key, filename string) *Logger {
+			keys = append(keys, key)
+			return &Logger{Filename: filename, MaxSize: 10, MaxBackups: 1}
+		},
+		MaxOpenFiles: 2,
+	}
+	defer r.Close()
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, err := r.WriterFor(key).Write([]byte(key + "!"))
+		isNil(err, t)
+	}
+	equals([]string{"a", "b", "c"}, keys, t)
+	existsWithContent(filepath.Join(dir, "a", "app.log"), []byte("a!a!"), t)
+	existsWithContent(filepath.Join(dir, "b", "app.log"), []byte("b!"), t)
+	existsWithContent(filepath.Join(dir, "c", "app.log"), []byte("c!"), t)
+
+	// b was written to least recently, so its file was closed to stay within
+	// MaxOpenFiles, and is reopened when needed.
+	b := r.logger("b")
+	assert(b.File() == nil, t, "expected the file of b to be closed")
+	assert(r.logger("a").File() != nil, t, "expected the file of a to be open")
+	_, err := r.WriterFor("b").Write([]byte("b!"))
+	isNil(err, t)
+	existsWithContent(filepath.Join(dir, "b", "app.log"), []byte("b!b!"), t)
+
+	// every key shares the retention settings.
+	newFakeTime()
+	isNil(r.Rotate(), t)
+	existsWithContent(filepath.Join(dir, "a", "app-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("a!a!"), t)
+
+	isNil(r.Close(), t)
+	assert(b.File() == nil, t, "expected Close to close every file")
+}
+
+func TestRouterKeys(t *testing.T) {
+	dir := makeTempDir("TestRouterKeys", t)
+	defer os.RemoveAll(dir)
+
+	r := &Router{Filename: filepath.Join(dir, "tenant-{key}.log")}
+	defer r.Close()
+
+	// keys can't escape the directory, and keys that come out the same share
+	// a Logger.
+	equals(filepath.Join(dir, "tenant-.._.._etc.log"), r.logger("../../etc").Filename, t)
+	equals(filepath.Join(dir, "tenant-__.log"), r.logger("..").Filename, t)
+	assert(r.logger("a/b") == r.logger("a b"), t, "expected keys that come out the same to share a Logger")
+	equals(filepath.Join(dir, "tenant-_.log"), r.logger("").Filename, t)
+}
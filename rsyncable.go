@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// rsyncWindow is the size of the input the rolling sum of rsyncableWriter is
+// taken over, which is also about how far apart its flushes are, as with gzip
+// --rsyncable.
+const rsyncWindow = 4096
+
+// rsyncableWriter passes what is written to it on to a gzip writer, flushing
+// the compressor wherever the sum of the last rsyncWindow bytes of input is a
+// multiple of rsyncWindow.  Those points depend only on the input just before
+// them, so a change to the input changes the compressed output only up to the
+// next one or two of them, and rsync finds the blocks after that unchanged.
+// See Logger.CompressRsyncable.
+type rsyncableWriter struct {
+	gz     *gzip.Writer
+	window [rsyncWindow]byte
+	n      int // bytes in window, up to rsyncWindow
+	pos    int // where the next byte goes in window
+	sum    uint32
+}
+
+// Write implements io.Writer.
+func (w *rsyncableWriter) Write(p []byte) (int, error) {
+	written := 0
+	for i, c := range p {
+		if w.n == rsyncWindow {
+			w.sum -= uint32(w.window[w.pos])
+		} else {
+			w.n++
+		}
+		w.window[w.pos] = c
+		w.pos = (w.pos + 1) % rsyncWindow
+		w.sum += uint32(c)
+		if w.n < rsyncWindow || w.sum%rsyncWindow != 0 {
+			continue
+		}
+		m, err := w.gz.Write(p[written : i+1])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		if err := w.gz.Flush(); err != nil {
+			return written, err
+		}
+	}
+	m, err := w.gz.Write(p[written:])
+	return written + m, err
+}
+
+// rsyncableGzip returns a function that gzips everything from src into dst at
+// the given level, like gzipCopy, flushing the compressor as rsyncableWriter
+// does.
+func rsyncableGzip(level int) func(dst io.Writer, src io.Reader) error {
+	return func(dst io.Writer, src io.Reader) error {
+		gz, err := getGzipWriter(dst, level)
+		if err != nil {
+			return err
+		}
+		defer putGzipWriter(gz, level)
+		if _, err := copyBuffered(&rsyncableWriter{gz: gz}, src); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// rsyncLog returns n bytes of made up log lines.
+func rsyncLog(n int) []byte {
+	r := rand.New(rand.NewSource(1))
+	var b bytes.Buffer
+	for i := 0; b.Len() < n; i++ {
+		fmt.Fprintf(&b, "%d: request %x took %dms\n", i, r.Int63(), r.Intn(1000))
+	}
+	return b.Bytes()[:n]
+}
+
+// commonSuffix returns how many bytes at the end of a and b are the same.
+func commonSuffix(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// compressedWith returns a function that returns data compressed with compress.
+func compressedWith(compress func(dst io.Writer, src io.Reader) error) func(data []byte, t testing.TB) []byte {
+	return func(data []byte, t testing.TB) []byte {
+		var out bytes.Buffer
+		isNil(compress(&out, bytes.NewReader(data)), t)
+		return out.Bytes()
+	}
+}
+
+func TestRsyncableGzip(t *testing.T) {
+	in := rsyncLog(256 * 1024)
+	for _, data := range [][]byte{in, []byte("boo!"), {}} {
+		out := compressedWith(rsyncableGzip(gzip.DefaultCompression))(data, t)
+		gz, err := gzip.NewReader(bytes.NewReader(out))
+		isNil(err, t)
+		got, err := ioutil.ReadAll(gz)
+		isNil(err, t)
+		assert(bytes.Equal(data, got), t, "decompressed data doesn't match the input")
+	}
+}
+
+func TestRsyncableGzipResyncs(t *testing.T) {
+	in := rsyncLog(256 * 1024)
+	changed := append([]byte(nil), in...)
+	copy(changed[100:], "CHANGED")
+
+	// leaving out the trailer, with the CRC and size of all of the input.
+	rsyncable := compressedWith(rsyncableGzip(gzip.DefaultCompression))
+	a, b := rsyncable(in, t), rsyncable(changed, t)
+	a, b = a[:len(a)-8], b[:len(b)-8]
+
+	// with a change near the start, all but the start of the output should
+	// be the same.
+	n := commonSuffix(a, b)
+	assert(n > len(a)*3/4, t, "only %d bytes of %d at the end match", n, len(a))
+
+	// unlike without.
+	plain := compressedWith(gzipCopy)
+	a, b = plain(in, t), plain(changed, t)
+	a, b = a[:len(a)-8], b[:len(b)-8]
+	n = commonSuffix(a, b)
+	assert(n < len(a)/4, t, "%d bytes of %d at the end match", n, len(a))
+}
+
+func TestCompressRsyncable(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, onWrite := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprintf("TestCompressRsyncable%v", onWrite), t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Compress:          true,
+			CompressRsyncable: true,
+			CompressOnWrite:   onWrite,
+			Filename:          logFile(dir),
+			MaxSize:           1000000,
+		}
+		defer l.Close()
+		b := rsyncLog(64 * 1024)
+		_, err := l.Write(b)
+		isNil(err, t)
+
+		newFakeTime()
+		isNil(l.Rotate(), t)
+
+		waitFor(func() bool {
+			_, err := os.Stat(backupFile(dir))
+			return os.IsNotExist(err)
+		}, t)
+		compressed, err := ioutil.ReadFile(backupFile(dir) + compressSuffix)
+		isNil(err, t)
+		equals(compressedWith(rsyncableGzip(gzip.DefaultCompression))(b, t), compressed, t)
+	}
+}
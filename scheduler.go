@@ -0,0 +1,125 @@
+package lumberjack
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Scheduler runs the post-rotation work of many Loggers, compression and
+// removal of old log files, on a bounded number of goroutines.  Without one,
+// every Logger does that work on a goroutine of its own, so an application with
+// hundreds of Loggers can end up compressing hundreds of backups at once.  Set
+// the same Scheduler as the Scheduler of every Logger that should share it.
+//
+// Workers are started as work arrives and exit when there is none left, so an
+// idle Scheduler holds no goroutines.  The zero value is ready to use.
+type Scheduler struct {
+	// Workers is the maximum number of Loggers whose post-rotation work runs
+	// at the same time.  The default is 1.
+	Workers int
+
+	// LowPriority lowers the CPU and I/O priority of the workers, like
+	// Logger.LowPriority does for a Logger's own goroutine.
+	LowPriority bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []*Logger
+	state   map[*Logger]schedState
+	running int
+}
+
+// schedState is where a Logger is in a Scheduler.
+type schedState int
+
+const (
+	// schedQueued means the Logger is waiting for a worker.
+	schedQueued schedState = iota + 1
+
+	// schedRunning means a worker is running the Logger's work.
+	schedRunning
+
+	// schedRerun means the Logger's work is running, and was asked for
+	// again since it started, so it must run once more.
+	schedRerun
+)
+
+// schedule queues the post-rotation work of l, unless it's queued already.  A
+// Logger's work never runs on two workers at once.
+func (s *Scheduler) schedule(l *Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		s.state = make(map[*Logger]schedState)
+		s.cond = sync.NewCond(&s.mu)
+	}
+	switch s.state[l] {
+	case schedQueued, schedRerun:
+		return
+	case schedRunning:
+		s.state[l] = schedRerun
+		return
+	}
+	s.state[l] = schedQueued
+	s.queue = append(s.queue, l)
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if s.running < workers {
+		s.running++
+		go s.work()
+	}
+}
+
+// work runs queued work until there is none left.
+func (s *Scheduler) work() {
+	if s.LowPriority {
+		// The thread is never unlocked, so it exits along with this goroutine
+		// rather than going back to the scheduler with a lowered priority.
+		runtime.LockOSThread()
+		_ = lowerThreadPriority()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) > 0 {
+		l := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.state[l] = schedRunning
+		s.mu.Unlock()
+
+		l.labeled("mill", l.millRunBackoff)
+
+		s.mu.Lock()
+		if s.state[l] == schedRerun {
+			s.state[l] = schedQueued
+			s.queue = append(s.queue, l)
+		} else {
+			delete(s.state, l)
+			s.cond.Broadcast()
+		}
+	}
+	s.running--
+	s.cond.Broadcast()
+}
+
+// Wait blocks until all the work scheduled so far has been done.
+func (s *Scheduler) Wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.running > 0 {
+		s.cond.Wait()
+	}
+}
+
+// waitFor blocks until the work scheduled for l so far has been done.
+func (s *Scheduler) waitFor(l *Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.state[l] != 0 {
+		s.cond.Wait()
+	}
+}
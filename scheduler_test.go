@@ -0,0 +1,69 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestScheduler(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestScheduler", t)
+	defer os.RemoveAll(dir)
+
+	before := runtime.NumGoroutine()
+	s := &Scheduler{Workers: 2}
+	var loggers []*Logger
+	for i := 0; i < 10; i++ {
+		l := &Logger{
+			Filename:  filepath.Join(dir, fmt.Sprintf("log%d.log", i)),
+			MaxSize:   10,
+			Compress:  true,
+			Scheduler: s,
+		}
+		defer l.Close()
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		loggers = append(loggers, l)
+	}
+
+	newFakeTime()
+	for _, l := range loggers {
+		isNil(l.Rotate(), t)
+	}
+	s.mu.Lock()
+	assert(s.running <= 2, t, "expected at most 2 workers, got %d", s.running)
+	s.mu.Unlock()
+
+	s.Wait()
+	for i := range loggers {
+		backup := filepath.Join(dir, fmt.Sprintf("log%d-%s.log", i, fakeTime().UTC().Format(backupTimeFormat)))
+		notExist(backup, t)
+		exists(backup+compressSuffix, t)
+	}
+
+	// the workers exit once the work is done, and no Logger started its own
+	// goroutine.
+	goroutinesExit(before, t)
+	for _, l := range loggers {
+		assert(l.millCh == nil, t, "expected no mill goroutine")
+	}
+}
+
+func TestSchedulerRerun(t *testing.T) {
+	s := &Scheduler{}
+	l := &Logger{}
+
+	// work asked for while it's running runs once more afterwards, but work
+	// asked for while it's queued doesn't.
+	s.mu.Lock()
+	s.state = map[*Logger]schedState{l: schedRunning}
+	s.mu.Unlock()
+	s.schedule(l)
+	s.schedule(l)
+	equals(schedRerun, s.state[l], t)
+	equals(0, len(s.queue), t)
+}
@@ -0,0 +1,85 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// SequencePosition is where in each write its sequence number goes.  See
+// Logger.Sequence.
+type SequencePosition int
+
+const (
+	// SequenceNone doesn't number writes.
+	SequenceNone SequencePosition = iota
+
+	// SequencePrefix puts the number at the start of the write, followed by
+	// a space.
+	SequencePrefix
+
+	// SequenceSuffix puts the number at the end of the write, after a space
+	// and before its trailing newline.
+	SequenceSuffix
+)
+
+// sequenceNames holds the text form of each SequencePosition.
+var sequenceNames = []string{
+	SequenceNone:   "none",
+	SequencePrefix: "prefix",
+	SequenceSuffix: "suffix",
+}
+
+// String returns the name of s: "none", "prefix" or "suffix".
+func (s SequencePosition) String() string {
+	if s < 0 || int(s) >= len(sequenceNames) {
+		return fmt.Sprintf("SequencePosition(%d)", int(s))
+	}
+	return sequenceNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SequencePosition) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(sequenceNames) {
+		return nil, fmt.Errorf("invalid sequence position %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "none",
+// "prefix" and "suffix", so that the position can be set from config files.
+func (s *SequencePosition) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = SequenceNone
+		return nil
+	}
+	for i, name := range sequenceNames {
+		if string(text) == name {
+			*s = SequencePosition(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sequence position %q, expected none, prefix or suffix", text)
+}
+
+// number returns p with the sequence number of the next write added where
+// Sequence says.  Like wrap, it only uses up the number if the write isn't too
+// long for the log file, and it assumes that writes are serialized.
+func (l *Logger) number(p []byte) ([]byte, error) {
+	seq := strconv.AppendInt(nil, l.recordSeq+1, 10)
+	rec := make([]byte, 0, len(p)+len(seq)+1)
+	if l.Sequence == SequencePrefix {
+		rec = append(append(append(rec, seq...), ' '), p...)
+	} else {
+		body := bytes.TrimRight(p, "\r\n")
+		rec = append(append(append(rec, body...), ' '), seq...)
+		rec = append(rec, p[len(body):]...)
+	}
+	if l.tooLong(int64(len(rec))) {
+		return nil, fmt.Errorf(
+			"write length %d with its sequence number exceeds maximum file size %d", len(rec), l.max(),
+		)
+	}
+	l.recordSeq++
+	return rec, nil
+}
@@ -0,0 +1,47 @@
+package lumberjack
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSequence", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 100, Sequence: SequencePrefix}
+	defer l.Close()
+
+	n, err := l.Write([]byte("GET / 200\n"))
+	isNil(err, t)
+	equals(10, n, t)
+
+	// a write too long with its number doesn't use it up.
+	_, err = l.Write([]byte(strings.Repeat("x", 99)))
+	notNil(err, t)
+
+	l.Sequence = SequenceSuffix
+	_, err = l.Write([]byte("GET /x 404\r\n"))
+	isNil(err, t)
+	_, err = l.Write([]byte("no newline"))
+	isNil(err, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("1 GET / 200\nGET /x 404 2\r\nno newline 3"), t)
+}
+
+func TestSequencePositionText(t *testing.T) {
+	for _, s := range []SequencePosition{SequenceNone, SequencePrefix, SequenceSuffix} {
+		b, err := s.MarshalText()
+		isNil(err, t)
+		var got SequencePosition
+		isNil(got.UnmarshalText(b), t)
+		equals(s, got, t)
+	}
+	var s SequencePosition
+	notNil(s.UnmarshalText([]byte("middle")), t)
+}
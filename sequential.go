@@ -0,0 +1,118 @@
+package lumberjack
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NamingScheme is how backups are named.  See Logger.NamingScheme.
+type NamingScheme int
+
+const (
+	// Timestamped names backups after the time of their rotation, as in
+	// foo-2016-11-04T18-30-00.000.log.
+	Timestamped NamingScheme = iota
+
+	// Sequential numbers backups the way logrotate does, as in foo.log.1 for
+	// the newest one and foo.log.2.gz for the one before it, renumbering them
+	// all on each rotation.
+	Sequential
+)
+
+// namingSchemeNames holds the text form of each NamingScheme.
+var namingSchemeNames = []string{
+	Timestamped: "timestamped",
+	Sequential:  "sequential",
+}
+
+// String returns the name of s: "timestamped" or "sequential".
+func (s NamingScheme) String() string {
+	if s < 0 || int(s) >= len(namingSchemeNames) {
+		return fmt.Sprintf("NamingScheme(%d)", int(s))
+	}
+	return namingSchemeNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s NamingScheme) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(namingSchemeNames) {
+		return nil, fmt.Errorf("invalid naming scheme %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "timestamped"
+// and "sequential", so that the scheme can be set from config files.
+func (s *NamingScheme) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = Timestamped
+		return nil
+	}
+	for i, name := range namingSchemeNames {
+		if string(text) == name {
+			*s = NamingScheme(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid naming scheme %q, expected timestamped or sequential", text)
+}
+
+// sequential reports whether backups are named by number.
+func (l *Logger) sequential() bool {
+	return l.NamingScheme == Sequential
+}
+
+// sequentialName returns the name of backup number n of the named log file.
+func sequentialName(name string, n int) string {
+	return name + "." + strconv.Itoa(n)
+}
+
+// sequenceOf returns the number of the backup with the given base name, and
+// whether it is a backup at all, with Sequential naming.
+func (l *Logger) sequenceOf(name string) (int, bool) {
+	prefix := filepath.Base(l.filename()) + "."
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+	rest := name[len(prefix):]
+	for _, suffix := range append([]string{""}, l.compressSuffixes()...) {
+		if !strings.HasSuffix(rest, suffix) {
+			continue
+		}
+		digits := rest[:len(rest)-len(suffix)]
+		if n, err := strconv.Atoi(digits); err == nil && n > 0 && strconv.Itoa(n) == digits {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// shiftBackups adds one to the number of every backup, along with its keep
+// file, starting with the oldest, to make way for a new backup number 1.
+// Cleanup is paused first, so that no backup is renamed out from under it,
+// and it is up to the caller to start it again.  This method assumes l.mu is
+// held.
+func (l *Logger) shiftBackups() error {
+	l.pauseMill()
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(l.filename())
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		suffix := f.Name()[len(sequentialName(base, f.seq)):]
+		to := sequentialName(base, f.seq+1) + suffix
+		if err := l.rename(f.path(), filepath.Join(f.dir, to)); err != nil {
+			return fmt.Errorf("can't renumber backup: %s", err)
+		}
+		suffixes := l.compressSuffixes()
+		l.renameSegment(uncompressedName(f.path(), suffixes), uncompressedName(filepath.Join(f.dir, to), suffixes))
+		if err := l.renameKeep(f.dir, f.Name(), to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
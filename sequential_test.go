@@ -0,0 +1,119 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSequentialNaming(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSequentialNaming", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxBackups:   2,
+		NamingScheme: Sequential,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"one", "two"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	existsWithContent(filename+".1", []byte("two"), t)
+	existsWithContent(filename+".2", []byte("one"), t)
+	equals(filename+".1", l.NextBackupName(), t)
+
+	// a pinned backup keeps its keep file as it is renumbered, and doesn't
+	// count towards MaxBackups.
+	isNil(ioutil.WriteFile(filename+".2"+keepSuffix, nil, 0644), t)
+	for _, s := range []string{"three", "four"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+	}
+	waitFor(func() bool { _, err := os.Stat(filename + ".3"); return os.IsNotExist(err) }, t)
+	existsWithContent(filename+".1", []byte("four"), t)
+	existsWithContent(filename+".2", []byte("three"), t)
+	existsWithContent(filename+".4", []byte("one"), t)
+	exists(filename+".4"+keepSuffix, t)
+	fileCount(dir, 5, t)
+}
+
+func TestSequentialNamingCompressed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSequentialNamingCompressed", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Compress:     true,
+		NamingScheme: Sequential,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"one", "two", "three"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		waitFor(func() bool { _, err := os.Stat(filename + ".1" + compressSuffix); return err == nil }, t)
+	}
+	notExist(filename+".1", t)
+	for i, want := range []string{"three", "two", "one"} {
+		name := sequentialName(filename, i+1) + compressSuffix
+		rc, err := l.OpenBackup(name)
+		isNil(err, t)
+		b, err := ioutil.ReadAll(rc)
+		isNil(err, t)
+		rc.Close()
+		equals(want, string(b), t)
+	}
+
+	// a dump is the newest backup.
+	name, err := l.WriteDump([]byte("dump"))
+	isNil(err, t)
+	equals(filename+".1", name, t)
+	waitFor(func() bool { _, err := os.Stat(filename + ".4" + compressSuffix); return err == nil }, t)
+}
+
+func TestSequenceOf(t *testing.T) {
+	l := &Logger{Filename: "/var/log/foo.log", NamingScheme: Sequential}
+	tests := []struct {
+		name string
+		seq  int
+		ok   bool
+	}{
+		{"foo.log.1", 1, true},
+		{"foo.log.12.gz", 12, true},
+		{"foo.log", 0, false},
+		{"foo.log.0", 0, false},
+		{"foo.log.01", 0, false},
+		{"foo.log.1.txt", 0, false},
+		{"foo-2016-11-04T18-30-00.000.log", 0, false},
+	}
+	for _, test := range tests {
+		seq, ok := l.sequenceOf(test.name)
+		equals(test.ok, ok, t)
+		equals(test.seq, seq, t)
+	}
+}
+
+func TestNamingSchemeText(t *testing.T) {
+	for _, s := range []NamingScheme{Timestamped, Sequential} {
+		b, err := json.Marshal(s)
+		isNil(err, t)
+		var got NamingScheme
+		isNil(json.Unmarshal(b, &got), t)
+		equals(s, got, t)
+	}
+	var s NamingScheme
+	notNil(s.UnmarshalText([]byte("alphabetical")), t)
+}
@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Service control codes for the Logger of a Windows service, which has no
+// SIGHUP to be told to rotate with.  Windows leaves codes 128 to 255 to the
+// service, and sends them with, say, "sc control MyService 128".  See
+// HandleServiceControl.
+const (
+	ServiceControlRotate = 128
+	ServiceControlReopen = 129
+)
+
+// HandleServiceControl rotates the log file for ServiceControlRotate and
+// reopens it for ServiceControlReopen, reporting whether code was one of them,
+// so that a service's control handler can pass on every code it gets:
+//
+//	case c := <-requests:
+//		if logger.HandleServiceControl(uint32(c.Cmd)) {
+//			continue
+//		}
+//
+// As with RotateOnSignal, a failure is recorded as an error rather than
+// returned, since the one asking for it is the service control manager.
+func (l *Logger) HandleServiceControl(code uint32) bool {
+	var fn func() error
+	switch code {
+	case ServiceControlRotate:
+		fn = l.Rotate
+	case ServiceControlReopen:
+		fn = l.Reopen
+	default:
+		return false
+	}
+	if err := fn(); err != nil && err != ErrClosed {
+		l.recordError(writeError, err)
+	}
+	return true
+}
+
+// ServiceLogDir returns the directory the logs of the named service go in:
+// %ProgramData%\service\Logs on Windows, and /var/log/service elsewhere.
+func ServiceLogDir(service string) string {
+	return serviceLogDir(service)
+}
+
+// NewServiceLogger returns a Logger for the named service, writing service.log
+// in ServiceLogDir(service), which it has to itself (see Logger.Dir).  The
+// directory is created if need be, letting in only the system, administrators
+// and the owner of each file on Windows, where ProgramData lets every user
+// read by default, and only the service's own user and group elsewhere.  A
+// directory that already exists is left as it is, in case its access has been
+// set up on purpose.  Other options can be set on the Logger before it is
+// first written to.
+func NewServiceLogger(service string) (*Logger, error) {
+	if service == "" || service == "." || service == ".." || filepath.Base(service) != service {
+		return nil, fmt.Errorf("invalid service name %q", service)
+	}
+	dir := ServiceLogDir(service)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := makeServiceDir(dir); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return &Logger{Dir: dir, BaseName: service}, nil
+}
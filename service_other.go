@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// serviceLogDir returns /var/log/service.
+func serviceLogDir(service string) string {
+	return filepath.Join("/var/log", service)
+}
+
+// makeServiceDir creates dir for the service's user and group alone.
+func makeServiceDir(dir string) error {
+	return os.MkdirAll(dir, 0750)
+}
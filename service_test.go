@@ -0,0 +1,49 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHandleServiceControl(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestHandleServiceControl", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 100}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// codes that aren't the Logger's are left to the service.
+	equals(false, l.HandleServiceControl(127), t)
+	fileCount(dir, 1, t)
+
+	newFakeTime()
+	equals(true, l.HandleServiceControl(ServiceControlRotate), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	fileCount(dir, 2, t)
+
+	_, err = l.Write([]byte("foo"))
+	isNil(err, t)
+	isNil(os.Rename(logFile(dir), logFile(dir)+".moved"), t)
+	equals(true, l.HandleServiceControl(ServiceControlReopen), t)
+	_, err = l.Write([]byte("bar"))
+	isNil(err, t)
+	existsWithContent(logFile(dir)+".moved", []byte("foo"), t)
+	existsWithContent(logFile(dir), []byte("bar"), t)
+
+	// once closed, the codes are still the Logger's, and do nothing.
+	isNil(l.Close(), t)
+	equals(true, l.HandleServiceControl(ServiceControlRotate), t)
+	equals(int64(0), l.Stats().WriteErrors, t)
+}
+
+func TestNewServiceLoggerInvalidName(t *testing.T) {
+	for _, name := range []string{"", "a/b", ".."} {
+		_, err := NewServiceLogger(name)
+		notNil(err, t)
+	}
+}
@@ -0,0 +1,32 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// serviceDirSDDL grants full access to the system, administrators and the
+// owner of each file, and nobody else, to the directory and everything
+// created in it, without inheriting from ProgramData.
+const serviceDirSDDL = "D:P(A;OICI;FA;;;SY)(A;OICI;FA;;;BA)(A;OICI;FA;;;OW)"
+
+// serviceLogDir returns %ProgramData%\service\Logs.
+func serviceLogDir(service string) string {
+	root := os.Getenv("ProgramData")
+	if root == "" {
+		root = `C:\ProgramData`
+	}
+	return filepath.Join(root, service, "Logs")
+}
+
+// makeServiceDir creates dir, giving it serviceDirSDDL.
+func makeServiceDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	sd, err := sddlSecurity(serviceDirSDDL)
+	if err != nil {
+		return err
+	}
+	return setFileSecurity(dir, sd)
+}
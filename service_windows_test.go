@@ -0,0 +1,25 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewServiceLogger(t *testing.T) {
+	dir := makeTempDir("TestNewServiceLogger", t)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("ProgramData", os.Getenv("ProgramData"))
+	os.Setenv("ProgramData", dir)
+
+	l, err := NewServiceLogger("MyService")
+	isNil(err, t)
+	defer l.Close()
+	logs := filepath.Join(dir, "MyService", "Logs")
+	equals(logs, ServiceLogDir("MyService"), t)
+	equals(true, daclProtected(logs, t), t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(filepath.Join(logs, "MyService.log"), []byte("boo!"), t)
+}
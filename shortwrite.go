@@ -0,0 +1,133 @@
+package lumberjack
+
+import (
+	"fmt"
+)
+
+// ShortWritePolicy is what to do with a record that the log file took only
+// part of, as when the disk fills up in the middle of it.  See
+// Logger.ShortWrites.
+type ShortWritePolicy int
+
+const (
+	// ShortLeave leaves the record cut short, so that the next write carries
+	// on from where it was cut.
+	ShortLeave ShortWritePolicy = iota
+
+	// ShortComplete writes the rest of the record before the next write, so
+	// that it ends up whole in the log file, once there is room for it.
+	ShortComplete
+
+	// ShortMark ends the record with partialMarker and a newline before the
+	// next write, so that the next record starts on a line of its own.
+	ShortMark
+)
+
+// shortWriteNames holds the text form of each ShortWritePolicy.
+var shortWriteNames = []string{
+	ShortLeave:    "leave",
+	ShortComplete: "complete",
+	ShortMark:     "mark",
+}
+
+// String returns the name of s: "leave", "complete" or "mark".
+func (s ShortWritePolicy) String() string {
+	if s < 0 || int(s) >= len(shortWriteNames) {
+		return fmt.Sprintf("ShortWritePolicy(%d)", int(s))
+	}
+	return shortWriteNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ShortWritePolicy) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(shortWriteNames) {
+		return nil, fmt.Errorf("invalid short write policy %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "leave",
+// "complete" and "mark", so that the policy can be set from config files.
+func (s *ShortWritePolicy) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = ShortLeave
+		return nil
+	}
+	for i, name := range shortWriteNames {
+		if string(text) == name {
+			*s = ShortWritePolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid short write policy %q, expected leave, complete or mark", text)
+}
+
+// ShortWriteError is returned by writes that the log file took only part of.
+type ShortWriteError struct {
+	// Written is the number of bytes of the record that are in the log
+	// file, and Size the size of the whole record.
+	Written int
+	Size    int
+
+	// Err is the error the write to the log file failed with.
+	Err error
+}
+
+// Error implements error.
+func (e *ShortWriteError) Error() string {
+	return fmt.Sprintf("lumberjack: write cut short after %d of %d bytes: %v", e.Written, e.Size, e.Err)
+}
+
+// Unwrap returns the error the write failed with, for errors.Is and errors.As.
+func (e *ShortWriteError) Unwrap() error {
+	return e.Err
+}
+
+// cutShort records that the log file took only the first n bytes of the
+// record p, failing with err, and returns the *ShortWriteError for it.  This
+// method assumes l.mu is held.
+func (l *Logger) cutShort(p []byte, n int, err error) error {
+	l.countShortWrite(n)
+	switch l.ShortWrites {
+	case ShortComplete:
+		l.short = append(l.short[:0], p[n:]...)
+		l.shortFile = l.file
+	case ShortMark:
+		l.short = append(l.short[:0], partialMarker+"\n"...)
+		l.shortFile = l.file
+	}
+	return &ShortWriteError{Written: n, Size: len(p), Err: err}
+}
+
+// resumeShort writes what ShortWrites calls for after a record that was cut
+// short to the log file it was cut short in, if that is still open.  What it
+// can't write is left for the next try.  This method assumes l.mu is held.
+func (l *Logger) resumeShort() error {
+	if l.shortFile == nil {
+		return nil
+	}
+	if l.shortFile != l.file {
+		l.short, l.shortFile = l.short[:0], nil
+		return nil
+	}
+	n, err := l.file.Write(l.short)
+	l.size += int64(n)
+	l.streamWrite(l.short[:n])
+	if err != nil {
+		l.short = l.short[:copy(l.short, l.short[n:])]
+		return fmt.Errorf("can't finish write cut short: %w", err)
+	}
+	l.short, l.shortFile = l.short[:0], nil
+	return nil
+}
+
+// finishShort writes what ShortWrites calls for after a record that was cut
+// short before the log file is rotated or closed, so that it doesn't run into
+// the footer of the file, or is lost with it.  If that fails too, the record
+// is left as it is.  This method assumes l.mu is held.
+func (l *Logger) finishShort() {
+	if err := l.resumeShort(); err != nil {
+		l.recordError(writeError, err)
+	}
+	l.short, l.shortFile = l.short[:0], nil
+}
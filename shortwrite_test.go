@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestShortWrites(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for policy, want := range map[ShortWritePolicy]string{
+		ShortLeave:    "one\ntwthree\n",
+		ShortComplete: "one\ntwo\nthree\n",
+		ShortMark:     "one\ntw" + partialMarker + "\nthree\n",
+	} {
+		dir := makeTempDir("TestShortWrites"+policy.String(), t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{Filename: logFile(dir), MaxSize: 100, ShortWrites: policy}
+		defer l.Close()
+		_, err := l.Write([]byte("one\n"))
+		isNil(err, t)
+
+		// the disk fills up after "tw".
+		l.mu.Lock()
+		n, err := l.file.Write([]byte("tw"))
+		isNil(err, t)
+		l.size += int64(n)
+		full := errors.New("no space left on device")
+		err = l.cutShort([]byte("two\n"), n, full)
+		l.mu.Unlock()
+		var short *ShortWriteError
+		assert(errors.As(err, &short), t, "expected a *ShortWriteError, got %v", err)
+		equals(2, short.Written, t)
+		equals(4, short.Size, t)
+		assert(errors.Is(err, full), t, "expected the error to wrap the write error")
+
+		_, err = l.Write([]byte("three\n"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+		existsWithContent(logFile(dir), []byte(want), t)
+		s := l.Stats()
+		equals(int64(1), s.ShortWrites, t)
+		equals(int64(2), s.ShortWriteBytes, t)
+	}
+}
+
+func TestShortWritesOnClose(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestShortWritesOnClose", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxSize: 100, ShortWrites: ShortComplete}
+	defer l.Close()
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	l.mu.Lock()
+	n, err := l.file.Write([]byte("tw"))
+	isNil(err, t)
+	l.size += int64(n)
+	_ = l.cutShort([]byte("two\n"), n, errors.New("no space left on device"))
+	l.mu.Unlock()
+
+	// the rest goes into the log file it belongs to before it is rotated.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("three\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir), []byte("one\ntwo\n"), t)
+	existsWithContent(logFile(dir), []byte("three\n"), t)
+}
+
+func TestShortWritePolicyText(t *testing.T) {
+	for _, s := range []ShortWritePolicy{ShortLeave, ShortComplete, ShortMark} {
+		text, err := s.MarshalText()
+		isNil(err, t)
+		var got ShortWritePolicy
+		isNil(got.UnmarshalText(text), t)
+		equals(s, got, t)
+	}
+	var s ShortWritePolicy
+	notNil(s.UnmarshalText([]byte("fix")), t)
+	_, err := ShortWritePolicy(7).MarshalText()
+	notNil(err, t)
+	equals("ShortWritePolicy(7)", ShortWritePolicy(7).String(), t)
+}
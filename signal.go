@@ -0,0 +1,93 @@
+package lumberjack
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+)
+
+// RotateOnSignal rotates the log file every time the process receives one of
+// the given signals, such as syscall.SIGHUP or syscall.SIGUSR1, until Close or
+// the returned function is called.  Rotations go through Rotate, so they are
+// serialized with writes, and with the compression and removal of old log
+// files that follow.
+func (l *Logger) RotateOnSignal(sigs ...os.Signal) (stop func()) {
+	return l.onSignal(sigs, l.Rotate)
+}
+
+// ReopenOnSignal reopens the log file every time the process receives one of
+// the given signals, until Close or the returned function is called.  This is
+// what logrotate's postrotate script expects of an application when it has
+// renamed the log file out of the way itself.  See Reopen.
+func (l *Logger) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	return l.onSignal(sigs, l.Reopen)
+}
+
+// Reopen closes the current log file, after writing out everything queued in
+// the write buffer, so that the next write opens Filename again.  Unlike
+// Rotate, it leaves the old file alone, for when something else has moved the
+// log file aside, and so doesn't compress or remove old log files either.
+func (l *Logger) Reopen() error {
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return ErrClosed
+	}
+	// Whatever the stream compressed belongs to the old file.
+	l.abortStream()
+	return l.close()
+}
+
+// onSignal calls fn every time the process receives one of sigs, until Close
+// or the returned function is called.
+func (l *Logger) onSignal(sigs []os.Signal, fn func() error) (stop func()) {
+	l.mu.Lock()
+	if l.signalStop == nil {
+		l.signalStop = make(chan struct{})
+		if l.isClosed() {
+			close(l.signalStop)
+		}
+	}
+	closed := l.signalStop
+	l.mu.Unlock()
+	// a SingleWriter can't write without l.mu alongside the handler.
+	atomic.StoreInt32(&l.signalled, 1)
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				if err := fn(); err != nil && err != ErrClosed {
+					l.recordError(writeError, err)
+				}
+			case <-done:
+				return
+			case <-closed:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// stopSignals stops handling the signals given to RotateOnSignal and
+// ReopenOnSignal.  This method assumes l.mu is held.
+func (l *Logger) stopSignals() {
+	if l.signalStop == nil {
+		// Handlers started later see they're too late.
+		l.signalStop = make(chan struct{})
+	}
+	select {
+	case <-l.signalStop:
+	default:
+		close(l.signalStop)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build linux
+// +build linux
+
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestRotateOnSignal(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRotateOnSignal", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+	stop := l.RotateOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(syscall.Kill(os.Getpid(), syscall.SIGUSR1), t)
+	waitFor(func() bool { return fileExists(backupFile(dir)) }, t)
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(filename, []byte{}, t)
+	isNil(l.Close(), t)
+}
+
+func TestReopenOnSignal(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestReopenOnSignal", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+	l.ReopenOnSignal(syscall.SIGUSR2)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// as logrotate would do it.
+	moved := filename + ".1"
+	isNil(os.Rename(filename, moved), t)
+	isNil(syscall.Kill(os.Getpid(), syscall.SIGUSR2), t)
+	waitFor(func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.file == nil
+	}, t)
+
+	_, err = l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(moved, []byte("boo!"), t)
+	existsWithContent(filename, []byte("foo!"), t)
+	fileCount(dir, 2, t)
+
+	// Close stops the handler too.
+	isNil(l.Close(), t)
+	l.mu.Lock()
+	stopped := l.signalStop
+	l.mu.Unlock()
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("expected Close to stop the signal handler")
+	}
+}
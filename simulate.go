@@ -0,0 +1,131 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ActionType is what a mill run does to a backup.  See Action.
+type ActionType int
+
+const (
+	// ActionKeep means the backup is left as it is.
+	ActionKeep ActionType = iota
+
+	// ActionCompress means the backup is kept and compressed.
+	ActionCompress
+
+	// ActionRemove means the backup is removed.
+	ActionRemove
+)
+
+// String returns a short lowercase name of the action.
+func (a ActionType) String() string {
+	switch a {
+	case ActionKeep:
+		return "keep"
+	case ActionCompress:
+		return "compress"
+	case ActionRemove:
+		return "remove"
+	}
+	return fmt.Sprintf("ActionType(%d)", int(a))
+}
+
+// Action is what a mill run of a configuration does to one backup, as
+// SimulateRetention works it out.
+type Action struct {
+	Type   ActionType
+	Backup BackupInfo
+
+	// Reason is the retention rule that removes the backup, for ActionRemove.
+	Reason RemoveReason
+}
+
+// SimulateRetention returns what a mill run with cfg, at the current time,
+// would do to a directory holding the backups in history, such as those that
+// Logger.Backups returns, or made-up ones: an Action for each of them, newest
+// first.  It works it out as the mill does, but without looking at the disk,
+// so tooling and tests can try out a policy before applying it.  The current
+// log file is taken to be empty, only the Pinned backups to be pinned, and the
+// limits that depend on the disk, MinDiskFree, MinFreeBytes, MinFreePercent
+// and ProjectQuota, are left out.  Backups are told apart, and compressed
+// ones recognized, by the base of their Path, and nothing of them needs to
+// exist.
+func SimulateRetention(history []BackupInfo, cfg Config) []Action {
+	l := &Logger{}
+	cfg.applyFixed(l)
+	cfg.apply(l)
+	l.MinDiskFree, l.MinFreeBytes, l.MinFreePercent = 0, 0, 0
+	l.ProjectQuota = false
+	l.MaxClockSkew = 0
+
+	backups := append([]BackupInfo(nil), history...)
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Timestamp.After(backups[j].Timestamp)
+	})
+	files := make([]logInfo, len(backups))
+	index := make(map[string]int, len(backups))
+	for i, b := range backups {
+		files[i] = logInfo{
+			timestamp: b.Timestamp,
+			dir:       filepath.Dir(b.Path),
+			FileInfo:  simulatedFile{name: filepath.Base(b.Path), size: b.Size, modTime: b.Timestamp},
+		}
+		index[files[i].path()] = i
+	}
+	pinned := func(files []logInfo) bool {
+		for _, f := range files {
+			if backups[index[f.path()]].Pinned {
+				return true
+			}
+		}
+		return false
+	}
+
+	actions := make([]Action, len(backups))
+	for i, b := range backups {
+		actions[i] = Action{Type: ActionKeep, Backup: b}
+	}
+	keep, remove := l.retainWith(files, func() int64 { return 0 }, pinned)
+	for _, f := range remove {
+		a := &actions[index[f.path()]]
+		a.Type, a.Reason = ActionRemove, f.reason
+	}
+	if !l.Compress {
+		return actions
+	}
+	now := currentTime()
+	allowed := l.CompressWindow.untilOpen(now) == 0
+	for _, f := range keep {
+		// as millRunOnce decides.
+		b := backups[index[f.path()]]
+		switch {
+		case b.Compressed || isCompressed(f.Name(), l.compressSuffixes()):
+		case l.tooSmallToCompress(f.Size()):
+		case !allowed:
+		case l.CompressAfter > 0 && now.Sub(f.timestamp) < l.CompressAfter:
+		default:
+			actions[index[f.path()]].Type = ActionCompress
+		}
+	}
+	return actions
+}
+
+// simulatedFile is the os.FileInfo of a backup that SimulateRetention is
+// given.
+type simulatedFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (f simulatedFile) Name() string       { return f.name }
+func (f simulatedFile) Size() int64        { return f.size }
+func (f simulatedFile) Mode() os.FileMode  { return 0644 }
+func (f simulatedFile) ModTime() time.Time { return f.modTime }
+func (f simulatedFile) IsDir() bool        { return false }
+func (f simulatedFile) Sys() interface{}   { return nil }
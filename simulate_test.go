@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSimulateRetention(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	now := currentTime()
+	dir := "logs"
+	backup := func(days int, name string, compressed, pinned bool) BackupInfo {
+		return BackupInfo{
+			Path:       filepath.Join(dir, name),
+			Timestamp:  now.Add(-time.Duration(days) * 24 * time.Hour),
+			Size:       100,
+			Compressed: compressed,
+			Pinned:     pinned,
+		}
+	}
+	history := []BackupInfo{
+		backup(5, "foo-5.log", false, true),
+		backup(1, "foo-1.log", false, false),
+		backup(2, "foo-2.log.gz", true, false),
+		backup(3, "foo-3.log", false, false),
+		backup(4, "foo-4.log", false, false),
+	}
+
+	type want struct {
+		name   string
+		action ActionType
+		reason RemoveReason
+	}
+	check := func(actions []Action, wants []want) {
+		t.Helper()
+		equals(len(wants), len(actions), t)
+		for i, w := range wants {
+			equals(w.name, filepath.Base(actions[i].Backup.Path), t)
+			equals(w.action, actions[i].Type, t)
+			equals(w.reason, actions[i].Reason, t)
+		}
+	}
+
+	// newest first; the pinned backup doesn't count towards MaxBackups, but is
+	// compressed like the others.
+	check(SimulateRetention(history, Config{Filename: "foo.log", MaxBackups: 2, Compress: true}), []want{
+		{"foo-1.log", ActionCompress, 0},
+		{"foo-2.log.gz", ActionKeep, 0},
+		{"foo-3.log", ActionRemove, RemovedByCount},
+		{"foo-4.log", ActionRemove, RemovedByCount},
+		{"foo-5.log", ActionCompress, 0},
+	})
+
+	check(SimulateRetention(history, Config{
+		Filename:     "foo.log",
+		MaxAge:       Duration(3 * 24 * time.Hour),
+		MaxTotalSize: 250,
+	}), []want{
+		{"foo-1.log", ActionKeep, 0},
+		{"foo-2.log.gz", ActionKeep, 0},
+		{"foo-3.log", ActionRemove, RemovedBySize},
+		{"foo-4.log", ActionRemove, RemovedByAge},
+		{"foo-5.log", ActionKeep, 0},
+	})
+
+	// the history given is left as it is.
+	equals("foo-5.log", filepath.Base(history[0].Path), t)
+	equals(ActionRemove.String(), "remove", t)
+}
@@ -0,0 +1,45 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+)
+
+// SourceWriter returns a writer for one of several components sharing the
+// Logger, whose writes go to the Logger under the given key, as with
+// WithWriteKey: with EnvelopeSource, each is marked as the key's in its
+// envelope, so that the components' writes can be told apart later, and with
+// KeyQuota, they count against the key's quota.
+func (l *Logger) SourceWriter(key string) io.Writer {
+	return sourceWriter{l: l, ctx: WithWriteKey(context.Background(), key)}
+}
+
+// sourceWriter is the writer of SourceWriter.
+type sourceWriter struct {
+	l   *Logger
+	ctx context.Context
+}
+
+// Write implements io.Writer.
+func (w sourceWriter) Write(p []byte) (int, error) {
+	return w.l.WriteContext(w.ctx, p)
+}
+
+// source returns the key of the write under way, as its envelope gives it
+// with EnvelopeSource, or "" without a key.  It assumes that l.mu is held, or
+// that there is a SingleWriter.
+func (l *Logger) source() string {
+	if !l.EnvelopeSource || l.traceCtx == nil {
+		return ""
+	}
+	return writeKey(l.traceCtx)
+}
+
+// source returns the given key of a buffered write as its envelope gives it
+// with EnvelopeSource.
+func (b *asyncBuffer) source(key string) string {
+	if !b.l.EnvelopeSource {
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,70 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEnvelopeSource(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, buffered := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprintf("TestEnvelopeSource%v", buffered), t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		l := &Logger{Filename: filename, MaxSize: 10000, Envelope: true, EnvelopeSource: true}
+		if buffered {
+			l.BufferSize = 1000
+		}
+		defer l.Close()
+
+		api, db := l.SourceWriter("api"), l.SourceWriter(`db "primary"`)
+		for _, w := range []struct {
+			write func([]byte) (int, error)
+			s     string
+		}{
+			{api.Write, "GET / 200\n"},
+			{db.Write, "query took 3ms\n"},
+			{l.Write, "no source\n"},
+			{api.Write, "GET /x 404\n"},
+		} {
+			_, err := w.write([]byte(w.s))
+			isNil(err, t)
+		}
+		isNil(l.Close(), t)
+
+		b, err := ioutil.ReadFile(filename)
+		isNil(err, t)
+		lines := bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+		equals(4, len(lines), t)
+		for i, want := range []string{"api", `db "primary"`, "", "api"} {
+			var e envelope
+			isNil(json.Unmarshal(lines[i], &e), t)
+			equals(want, e.Source, t)
+		}
+	}
+}
+
+func TestEnvelopeWithoutSource(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestEnvelopeWithoutSource", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 10000, Envelope: true}
+	defer l.Close()
+	_, err := l.SourceWriter("api").Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	b, err := ioutil.ReadFile(filename)
+	isNil(err, t)
+	assert(!bytes.Contains(b, []byte(`"source"`)), t, "expected no source without EnvelopeSource, got %s", b)
+}
@@ -0,0 +1,158 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is what the StateFile holds: where the Logger is writing, and which
+// backups there are, as of the end of the work that follows each rotation.
+type State struct {
+	// File is the path of the log file.
+	File string `json:"file"`
+
+	// LastRotation is the time of the newest backup, or the zero time if
+	// there isn't one.
+	LastRotation time.Time `json:"lastrotation"`
+
+	// Backups holds the paths of the backups, newest first, once they have
+	// been compressed, moved or removed as configured.
+	Backups []string `json:"backups"`
+
+	// Updated is when the file was written.
+	Updated time.Time `json:"updated"`
+
+	// Rotations are the rotations that made the backups, newest first, as
+	// far as the process that wrote the file did them, with the readings of
+	// both clocks, and Started is when the monotonic clock of that process
+	// started, on the wall clock.  The Monotonic readings of files with
+	// different Started times come from different processes, and can't be
+	// compared.
+	Rotations []RotationMark `json:"rotations,omitempty"`
+	Started   time.Time      `json:"started"`
+}
+
+// RotationMark is a rotation as the Logger saw it on the wall clock and on the
+// monotonic clock.  See State.
+type RotationMark struct {
+	// Backup is the path of the backup the log file was rotated to, as it
+	// is now.
+	Backup string `json:"backup"`
+
+	// Time is when the rotation happened, and Monotonic the reading of the
+	// monotonic clock then, the time since the process started.
+	Time      time.Time     `json:"time"`
+	Monotonic time.Duration `json:"monotonic"`
+}
+
+// markRotation records the rotation of the log file to backup for the
+// StateFile, if it is set.
+func (l *Logger) markRotation(backup string, t time.Time, mono time.Duration) {
+	if l.StateFile == "" {
+		return
+	}
+	l.marksMu.Lock()
+	defer l.marksMu.Unlock()
+	l.marks = append(l.marks, RotationMark{Backup: backup, Time: l.stateTime(t), Monotonic: mono})
+}
+
+// rotationMarks returns the RotationMarks of the backups among files, newest
+// first, with their paths as they are now, and forgets those of the backups
+// that are gone.
+func (l *Logger) rotationMarks(files []logInfo) []RotationMark {
+	suffixes := l.compressSuffixes()
+	paths := make(map[string]string)
+	for _, f := range files {
+		name := uncompressedName(f.Name(), suffixes)
+		if _, ok := paths[name]; !ok || !isCompressed(f.Name(), suffixes) {
+			paths[name] = f.path()
+		}
+	}
+	l.marksMu.Lock()
+	defer l.marksMu.Unlock()
+	var kept, marks []RotationMark
+	for _, m := range l.marks {
+		path, ok := paths[filepath.Base(m.Backup)]
+		if !ok {
+			continue
+		}
+		kept = append(kept, m)
+		m.Backup = path
+		marks = append([]RotationMark{m}, marks...)
+	}
+	l.marks = kept
+	return marks
+}
+
+// writeState writes the StateFile, if it is set, with the backups there are
+// now.  Failing to is recorded as an error.
+func (l *Logger) writeState() error {
+	if l.StateFile == "" {
+		return nil
+	}
+	files, err := l.oldLogFiles()
+	if err == nil {
+		s := State{File: l.filename(), Backups: []string{}, Updated: l.stateTime(currentTime())}
+		for _, f := range files {
+			s.Backups = append(s.Backups, f.path())
+		}
+		if len(files) > 0 {
+			s.LastRotation = l.stateTime(files[0].timestamp)
+		}
+		s.Rotations = l.rotationMarks(files)
+		s.Started = l.stateTime(monotonicStart.Round(0))
+		b, _ := json.Marshal(s)
+		err = writeFileAtomic(l.StateFile, append(b, '\n'), l.markerMode())
+	}
+	if err != nil {
+		l.recordError(otherError, err)
+	}
+	return err
+}
+
+// stateTime returns t as the StateFile gives it: in UTC, unless LocalTime is
+// set.
+func (l *Logger) stateTime(t time.Time) time.Time {
+	if l.LocalTime {
+		return t
+	}
+	return t.UTC()
+}
+
+// ReadState returns the State in the named StateFile.
+func ReadState(name string) (State, error) {
+	var s State
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+// writeFileAtomic writes data to the named file with the given mode, under a
+// temporary name that is then renamed into place, so that a reader sees the
+// old file or the new one, and never a partial one.
+func writeFileAtomic(name string, data []byte, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
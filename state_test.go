@@ -0,0 +1,117 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStateFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestStateFile", t)
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+
+	l := &Logger{
+		Filename:  logFile(dir),
+		MaxSize:   100,
+		Compress:  true,
+		StateFile: state,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// written once the backup has been compressed.
+	var s State
+	waitFor(func() bool {
+		s, err = ReadState(state)
+		return err == nil && len(s.Backups) == 1 && s.Backups[0] == backupFile(dir)+compressSuffix
+	}, t)
+	equals(logFile(dir), s.File, t)
+	// to the precision of the backup's name.
+	equals(fakeTime().UTC().Truncate(time.Millisecond), s.LastRotation, t)
+	equals(fakeTime().UTC(), s.Updated, t)
+
+	// with nothing else left lying around.
+	fileCount(dir, 3, t)
+}
+
+func TestStateFileNoBackups(t *testing.T) {
+	dir := makeTempDir("TestStateFileNoBackups", t)
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+
+	l := &Logger{Filename: logFile(dir), StateFile: state}
+	isNil(l.writeState(), t)
+	isNil(l.Close(), t)
+
+	s, err := ReadState(state)
+	isNil(err, t)
+	equals(0, len(s.Backups), t)
+	equals(true, s.LastRotation.IsZero(), t)
+
+	_, err = ReadState(filepath.Join(dir, "missing.json"))
+	equals(true, os.IsNotExist(err), t)
+}
+
+func TestStateFileRotations(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	mono := int64(time.Hour)
+	monotonicNow = func() time.Duration { return time.Duration(atomic.LoadInt64(&mono)) }
+	defer func() {
+		monotonicNow = func() time.Duration { return time.Since(monotonicStart) }
+	}()
+
+	dir := makeTempDir("TestStateFileRotations", t)
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+
+	var rotated []Event
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    100,
+		MaxBackups: 1,
+		Compress:   true,
+		StateFile:  state,
+		OnEvent: func(e Event) {
+			if e.Type == EventRotated {
+				rotated = append(rotated, e)
+			}
+		},
+	}
+	defer l.Close()
+	for i := 0; i < 2; i++ {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		atomic.AddInt64(&mono, int64(time.Minute))
+		isNil(l.Rotate(), t)
+	}
+
+	// the rotation of the backup that is left, in both clocks.
+	var s State
+	var err error
+	waitFor(func() bool {
+		s, err = ReadState(state)
+		return err == nil && len(s.Backups) == 1 && s.Backups[0] == backupFile(dir)+compressSuffix
+	}, t)
+	equals([]RotationMark{{
+		Backup:    backupFile(dir) + compressSuffix,
+		Time:      fakeTime().UTC(),
+		Monotonic: time.Hour + 2*time.Minute,
+	}}, s.Rotations, t)
+	equals(monotonicStart.UTC().Round(0), s.Started, t)
+	isNil(l.Close(), t)
+	equals(2, len(rotated), t)
+	equals(time.Hour+time.Minute, rotated[0].Monotonic, t)
+	equals(time.Hour+2*time.Minute, rotated[1].Monotonic, t)
+}
@@ -0,0 +1,207 @@
+package lumberjack
+
+import (
+	"os"
+	"time"
+)
+
+// Stats holds counters of the failures a Logger has run into, so that
+// monitoring can detect a degraded Logger even when nothing checks the errors
+// returned by Write or the errors from post-rotation work, which happens in the
+// background.  It also sums up the compression of backups, which helps judge
+// whether another compressor or level would pay off.
+type Stats struct {
+	// WriteErrors is the number of writes that failed, including failures to
+	// open or rotate the log file.
+	WriteErrors int64
+
+	// CompressErrors is the number of backups that failed to be compressed.
+	CompressErrors int64
+
+	// RemoveErrors is the number of old log files that failed to be removed.
+	RemoveErrors int64
+
+	// TeeErrors is the number of writes that made it to the log file but
+	// failed to be copied to a Tee writer.
+	TeeErrors int64
+
+	// MillFailures is the number of runs of compression and removal of old
+	// log files in a row that failed, and Degraded whether that has gone on
+	// long enough for the Logger to count as degraded.  See
+	// Logger.MillRetryInterval.
+	MillFailures int64
+	Degraded     bool
+
+	// Rotations is the number of times the log file was rotated.
+	Rotations int64
+
+	// ShortWrites is the number of writes that the log file took only part
+	// of, and ShortWriteBytes the number of bytes of them that it took.  See
+	// Logger.ShortWrites.
+	ShortWrites     int64
+	ShortWriteBytes int64
+
+	// Syncs is the number of times the log file was synced to disk for
+	// SyncInterval or SyncWrites, which, compared to the number of writes, shows how well
+	// syncs are shared.
+	Syncs int64
+
+	// LastError is the most recent error of any kind, and LastErrorTime the
+	// time at which it happened.
+	LastError     error
+	LastErrorTime time.Time
+
+	// Compressions is the number of backups compressed successfully.
+	Compressions int64
+
+	// CompressInput and CompressOutput are the total sizes in bytes of the
+	// backups compressed, before and after compression, and CompressTime is
+	// the total time spent compressing them.
+	CompressInput  int64
+	CompressOutput int64
+	CompressTime   time.Duration
+
+	// LastCompression describes the most recent successful compression.
+	LastCompression Compression
+
+	// BufferWaits is the number of buffered writes that waited for room in
+	// the buffer, and BufferWaitTime the total time they waited, which show
+	// how often and how long writers are held up by a saturated Logger.
+	BufferWaits    int64
+	BufferWaitTime time.Duration
+
+	// QuotaWaits is the number of buffered writes that waited for their key
+	// to get back under KeyQuota, QuotaWaitTime the total time they waited,
+	// and QuotaWaitsByKey the number of them for each key.  See
+	// Logger.KeyQuota.
+	QuotaWaits      int64
+	QuotaWaitTime   time.Duration
+	QuotaWaitsByKey map[string]int64
+}
+
+// Compression describes the compression of one backup.
+type Compression struct {
+	// InputSize and OutputSize are the sizes in bytes of the backup before
+	// and after compression.
+	InputSize  int64
+	OutputSize int64
+
+	// Duration is how long compressing took.
+	Duration time.Duration
+}
+
+// Ratio returns the output size as a fraction of the input size, or 0 for an
+// empty input.
+func (c Compression) Ratio() float64 {
+	if c.InputSize == 0 {
+		return 0
+	}
+	return float64(c.OutputSize) / float64(c.InputSize)
+}
+
+// Stats returns a snapshot of the Logger's counters.
+func (l *Logger) Stats() Stats {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	s := l.stats
+	if s.QuotaWaitsByKey != nil {
+		s.QuotaWaitsByKey = make(map[string]int64, len(l.stats.QuotaWaitsByKey))
+		for key, n := range l.stats.QuotaWaitsByKey {
+			s.QuotaWaitsByKey[key] = n
+		}
+	}
+	return s
+}
+
+// LastError returns the most recent error the Logger has run into, whether
+// returned from Write or hit during post-rotation work in the background, or
+// nil if there was none.
+func (l *Logger) LastError() error {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	return l.stats.LastError
+}
+
+// errorKind classifies errors for counting in Stats.
+type errorKind int
+
+const (
+	otherError errorKind = iota
+	writeError
+	compressError
+	removeError
+	teeError
+)
+
+// recordError notes err as the last error, counts it according to kind, and
+// passes it to the ErrorHandler.
+func (l *Logger) recordError(kind errorKind, err error) {
+	if l.ErrorHandler != nil {
+		defer l.ErrorHandler(err)
+	}
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	switch kind {
+	case writeError:
+		l.stats.WriteErrors++
+	case compressError:
+		l.stats.CompressErrors++
+	case removeError:
+		l.stats.RemoveErrors++
+	case teeError:
+		l.stats.TeeErrors++
+	}
+	l.stats.LastError = err
+	l.stats.LastErrorTime = currentTime()
+}
+
+// recordCompression counts a successful compression.
+func (l *Logger) recordCompression(c Compression) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.Compressions++
+	l.stats.CompressInput += c.InputSize
+	l.stats.CompressOutput += c.OutputSize
+	l.stats.CompressTime += c.Duration
+	l.stats.LastCompression = c
+}
+
+// countRotation counts a rotation of the log file.
+func (l *Logger) countRotation() {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.Rotations++
+}
+
+// countShortWrite counts a write that the log file took only n bytes of.
+func (l *Logger) countShortWrite(n int) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.ShortWrites++
+	l.stats.ShortWriteBytes += int64(n)
+}
+
+// recordSync counts a sync for SyncInterval or SyncWrites.
+func (l *Logger) recordSync() {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.Syncs++
+}
+
+// compressionDone records the compression of input bytes into the named
+// files, which took dur, and sends EventCompressed.
+func (l *Logger) compressionDone(input int64, dur time.Duration, names []string) {
+	l.listAdd(names...)
+	c := Compression{InputSize: input, Duration: dur}
+	for _, name := range names {
+		if info, err := os.Stat(name); err == nil {
+			c.OutputSize += info.Size()
+		}
+	}
+	l.recordCompression(c)
+	if m := l.metrics(); m != nil {
+		m.CompressDuration(dur)
+	}
+	l.emit(Event{Type: EventCompressed, Path: names[0], Compression: c})
+	l.writeMarker(uncompressedName(names[0], l.compressSuffixes()), names)
+}
@@ -0,0 +1,158 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsWriteErrors(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestStatsWriteErrors", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  5,
+	}
+	defer l.Close()
+	isNil(l.LastError(), t)
+
+	_, err := l.Write([]byte("booooooooooooooo!"))
+	notNil(err, t)
+
+	stats := l.Stats()
+	equals(int64(1), stats.WriteErrors, t)
+	equals(int64(0), stats.CompressErrors, t)
+	equals(int64(0), stats.RemoveErrors, t)
+	equals(err, stats.LastError, t)
+	equals(fakeTime(), stats.LastErrorTime, t)
+	equals(err, l.LastError(), t)
+
+	// a successful write doesn't clear the last error.
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	notNil(l.LastError(), t)
+	equals(int64(1), l.Stats().WriteErrors, t)
+}
+
+func TestStatsCompressErrors(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestStatsCompressErrors", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress: true,
+		Filename: filename,
+		MaxSize:  10,
+	}
+	defer l.Close()
+
+	// A directory in the way of the temporary compressed file makes
+	// compression fail.
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+	isNil(os.Mkdir(backup+compressSuffix+tmpSuffix, 0700), t)
+	isNil(ioutil.WriteFile(filepath.Join(backup+compressSuffix+tmpSuffix, "x"), nil, 0644), t)
+
+	_, err := l.Write([]byte("foo!"))
+	isNil(err, t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	stats := l.Stats()
+	equals(int64(0), stats.WriteErrors, t)
+	equals(int64(1), stats.CompressErrors, t)
+	notNil(stats.LastError, t)
+}
+
+func TestErrorHandler(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestErrorHandler", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var errs []error
+	filename := logFile(dir)
+	l := &Logger{
+		Compress: true,
+		Filename: filename,
+		MaxSize:  10,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}
+	defer l.Close()
+
+	// A directory in the way of the temporary compressed file makes
+	// compression fail, in the background.
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+	isNil(os.Mkdir(backup+compressSuffix+tmpSuffix, 0700), t)
+	isNil(ioutil.WriteFile(filepath.Join(backup+compressSuffix+tmpSuffix, "x"), nil, 0644), t)
+
+	_, err := l.Write([]byte("foo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals(1, len(errs), t)
+	equals(l.LastError(), errs[0], t)
+}
+
+func TestStatsCompression(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestStatsCompression", t)
+	defer os.RemoveAll(dir)
+
+	var events []Event
+	l := &Logger{
+		Compress: true,
+		Filename: logFile(dir),
+		MaxSize:  1000,
+		OnEvent: func(e Event) {
+			if e.Type != EventRotated {
+				events = append(events, e)
+			}
+		},
+	}
+	defer l.Close()
+
+	b := bytes.Repeat([]byte("boo!"), 200)
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// Close waits for the compression to finish.
+	isNil(l.Close(), t)
+	info, err := os.Stat(backupFile(dir) + compressSuffix)
+	isNil(err, t)
+
+	stats := l.Stats()
+	equals(int64(1), stats.Compressions, t)
+	equals(int64(len(b)), stats.CompressInput, t)
+	equals(info.Size(), stats.CompressOutput, t)
+	equals(stats.CompressTime, stats.LastCompression.Duration, t)
+	equals(int64(len(b)), stats.LastCompression.InputSize, t)
+	assert(stats.LastCompression.Ratio() < 0.5, t, "expected repetitive data to compress well, got %v", stats.LastCompression.Ratio())
+
+	equals(1, len(events), t)
+	equals(EventCompressed, events[0].Type, t)
+	equals(backupFile(dir)+compressSuffix, events[0].Path, t)
+	equals(stats.LastCompression, events[0].Compression, t)
+}
@@ -0,0 +1,166 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+)
+
+// compressStream compresses what is written to the current log file as it is
+// written, so that the file doesn't have to be read back in to be compressed
+// once it has been rotated.  See Logger.CompressOnWrite.
+type compressStream struct {
+	w   *partWriter
+	gz  *gzip.Writer
+	zw  io.Writer     // gz, or an rsyncableWriter over it
+	sum hash.Hash     // of what was written, for VerifyCompression
+	in  int64         // bytes written to the log file and the stream
+	dur time.Duration // time spent compressing
+}
+
+// startStream starts compressing what is written to the log file that was
+// just created with the given info, if CompressOnWrite is set.  This method
+// assumes l.mu is held.
+func (l *Logger) startStream(info os.FileInfo) {
+	l.abortStream()
+	g, ok := l.gzipCompressor()
+	if !l.Compress || !l.CompressOnWrite || l.Adopt || !ok {
+		return
+	}
+	gz, err := g.newWriter(nil)
+	if err != nil {
+		l.recordError(compressError, fmt.Errorf("failed to compress log file: %v", err))
+		return
+	}
+	w := &partWriter{
+		tmp:   l.filename() + compressSuffix,
+		size:  int64(l.CompressPartSize) * int64(megabyte),
+		mode:  l.BackupMode,
+		info:  info,
+		chown: l.chown,
+	}
+	if l.ContentHash {
+		w.hash, w.hashBefore = sha256.New(), l.hashBefore()
+	}
+	gz.Reset(w)
+	l.stream = &compressStream{w: w, gz: gz, zw: gz}
+	if l.CompressRsyncable {
+		l.stream.zw = &rsyncableWriter{gz: gz}
+	}
+	if l.VerifyCompression {
+		l.stream.sum = sha256.New()
+	}
+}
+
+// streamWrite compresses p, which was just written to the log file, dropping
+// the stream if that fails.  This method assumes l.mu is held.
+func (l *Logger) streamWrite(p []byte) {
+	s := l.stream
+	if s == nil || len(p) == 0 {
+		return
+	}
+	start := time.Now()
+	_, err := s.zw.Write(p)
+	if s.w.hash != nil {
+		s.w.hash.Write(p)
+	}
+	if s.sum != nil {
+		s.sum.Write(p)
+	}
+	s.dur += time.Since(start)
+	s.in += int64(len(p))
+	if err != nil {
+		l.abortStream()
+		l.recordError(compressError, s.error(err))
+	}
+}
+
+// finishStream makes the stream the compressed version of the backup that the
+// log file, which held size bytes, was just renamed to, and removes the
+// backup, unless it is too small to compress.  If the stream doesn't hold all of the backup, or can't be
+// finished, it is dropped, and the backup is compressed after rotation as
+// usual.  This method assumes l.mu is held.
+func (l *Logger) finishStream(backup string, size int64) {
+	s := l.stream
+	l.stream = nil
+	if s == nil {
+		return
+	}
+	if s.in != size {
+		// something else wrote to the file.
+		s.w.abort()
+		return
+	}
+	if l.tooSmallToCompress(size) {
+		s.w.abort()
+		return
+	}
+
+	start := time.Now()
+	err := s.gz.Close()
+	if pad := l.padSize(); err == nil && pad > 0 {
+		_, err = s.w.Write(gzipPadding(s.w.written(), pad))
+	}
+	if err == nil && s.sum != nil {
+		if err = s.w.verify(l.verifier(), s.sum.Sum(nil)); err != nil {
+			s.w.abort()
+			l.recordError(compressError, fmt.Errorf("failed to verify compressed log file: %v", err))
+			return
+		}
+	}
+	var names []string
+	if err == nil {
+		s.w.dst = backup + l.compressSuffix()
+		names, err = s.w.commit()
+	}
+	if err != nil {
+		s.w.abort()
+		l.recordError(compressError, s.error(err))
+		return
+	}
+	if err := removeFile(backup); err != nil {
+		l.recordError(compressError, fmt.Errorf("failed to compress log file: %v", err))
+	} else {
+		l.listRemove(backup)
+	}
+
+	l.compressionDone(size, s.dur+time.Since(start), names)
+}
+
+// setFinishing records that the stream is being finished for the named
+// backup, so that the mill leaves it alone, or that it no longer is, if name
+// is "".
+func (l *Logger) setFinishing(name string) {
+	l.streamMu.Lock()
+	defer l.streamMu.Unlock()
+	l.finishing = name
+}
+
+// isFinishing reports whether the stream is being finished for the named
+// backup.
+func (l *Logger) isFinishing(name string) bool {
+	l.streamMu.Lock()
+	defer l.streamMu.Unlock()
+	return name != "" && name == l.finishing
+}
+
+// abortStream drops the stream, if any.  This method assumes l.mu is held.
+func (l *Logger) abortStream() {
+	if l.stream != nil {
+		l.stream.w.abort()
+		l.stream = nil
+	}
+}
+
+// error returns the error to report for err, which writing to or finishing
+// the stream returned.
+func (s *compressStream) error(err error) error {
+	if s.w.err != nil {
+		return s.w.err
+	}
+	return fmt.Errorf("failed to compress log file: %v", err)
+}
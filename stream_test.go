@@ -0,0 +1,107 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressOnWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressOnWrite", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var events []Event
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         100,
+		Compress:        true,
+		CompressOnWrite: true,
+		OnEvent: func(e Event) {
+			if e.Type != EventRotated {
+				events = append(events, e)
+			}
+		},
+	}
+	defer l.Close()
+
+	// the file the Logger started is compressed by the time it is rotated,
+	// without an uncompressed backup.
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	notNil(l.stream, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	notExist(backup, t)
+	existsWithContent(backup+compressSuffix, gzipped([]byte("boo!\n"), t), t)
+	equals(1, len(events), t)
+	equals(EventCompressed, events[0].Type, t)
+	equals(backup+compressSuffix, events[0].Path, t)
+	equals(int64(5), events[0].Compression.InputSize, t)
+
+	// no temporary files are left behind on close.
+	isNil(l.Close(), t)
+	files, err := filepath.Glob(filepath.Join(dir, "*"+tmpSuffix))
+	isNil(err, t)
+	equals(0, len(files), t)
+
+	// a log file left from before is compressed after rotation as usual.
+	l = &Logger{
+		Filename:        filename,
+		Compress:        true,
+		CompressOnWrite: true,
+	}
+	defer l.Close()
+	_, err = l.Write([]byte("again\n"))
+	isNil(err, t)
+	equals((*compressStream)(nil), l.stream, t)
+}
+
+func TestCompressOnWriteForeignWrite(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressOnWriteForeignWrite", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         100,
+		Compress:        true,
+		CompressOnWrite: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	notNil(l.stream, t)
+
+	// something else appends to the file, so the stream is dropped at
+	// rotation, and the backup is compressed by the mill instead.
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	isNil(err, t)
+	_, err = f.Write([]byte("foreign\n"))
+	isNil(err, t)
+	isNil(f.Close(), t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	notExist(backupFile(dir), t)
+	existsWithContent(backupFile(dir)+compressSuffix, gzipped([]byte("boo!\nforeign\n"), t), t)
+}
+
+// gzipped returns b compressed the way lumberjack compresses backups.
+func gzipped(b []byte, t testing.TB) []byte {
+	bc := new(bytes.Buffer)
+	gz := gzip.NewWriter(bc)
+	_, err := gz.Write(b)
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	return bc.Bytes()
+}
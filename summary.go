@@ -0,0 +1,159 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SummaryPlacement is where the summary of a log file goes when it is
+// rotated.  See Logger.Summary.
+type SummaryPlacement int
+
+const (
+	// SummaryNone doesn't write summaries.
+	SummaryNone SummaryPlacement = iota
+
+	// SummaryEnd writes the summary as the last line of the file rotated.
+	SummaryEnd
+
+	// SummaryStart writes the summary as the first line of the new file,
+	// after the Header, if there is one.
+	SummaryStart
+
+	// SummaryBoth writes the summary in both places.
+	SummaryBoth
+)
+
+// summaryNames holds the text form of each SummaryPlacement.
+var summaryNames = []string{
+	SummaryNone:  "none",
+	SummaryEnd:   "end",
+	SummaryStart: "start",
+	SummaryBoth:  "both",
+}
+
+// String returns the name of s: "none", "end", "start" or "both".
+func (s SummaryPlacement) String() string {
+	if s < 0 || int(s) >= len(summaryNames) {
+		return fmt.Sprintf("SummaryPlacement(%d)", int(s))
+	}
+	return summaryNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SummaryPlacement) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(summaryNames) {
+		return nil, fmt.Errorf("invalid summary placement %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "none", "end",
+// "start" and "both", so that the placement can be set from config files.
+func (s *SummaryPlacement) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = SummaryNone
+		return nil
+	}
+	for i, name := range summaryNames {
+		if string(text) == name {
+			*s = SummaryPlacement(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid summary placement %q, expected none, end, start or both", text)
+}
+
+// fileSummary tallies the writes to the current log file for its summary.
+type fileSummary struct {
+	lines int64
+	bytes int64
+	first time.Time
+	last  time.Time
+}
+
+// summaryRecord is the JSON form of a fileSummary.
+type summaryRecord struct {
+	Summary string            `json:"lumberjack"`
+	Lines   int64             `json:"lines"`
+	Bytes   int64             `json:"bytes"`
+	First   string            `json:"first,omitempty"`
+	Last    string            `json:"last,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// countWrite adds p, just written to the log file, to the summary of the file,
+// if summaries are written.  This method assumes l.mu is held.
+func (l *Logger) countWrite(p []byte) {
+	if l.Summary == SummaryNone || len(p) == 0 {
+		return
+	}
+	now := currentTime()
+	if l.summary.first.IsZero() {
+		l.summary.first = now
+	}
+	l.summary.last = now
+	l.summary.lines += int64(bytes.Count(p, []byte{'\n'}))
+	l.summary.bytes += int64(len(p))
+}
+
+// summaryLine returns the summary of the current log file as a line of JSON.
+func (l *Logger) summaryLine() []byte {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		if !l.LocalTime {
+			t = t.UTC()
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	b, _ := json.Marshal(summaryRecord{
+		Summary: "rotation summary",
+		Lines:   l.summary.lines,
+		Bytes:   l.summary.bytes,
+		First:   format(l.summary.first),
+		Last:    format(l.summary.last),
+		Labels:  l.Labels,
+	})
+	return append(b, '\n')
+}
+
+// endSummary writes the summary of the current log file at its end, if
+// SummaryEnd is set, and returns the summary for startSummary, or nil if the
+// file isn't open, so there is nothing to summarize.  This method assumes
+// l.mu is held.
+func (l *Logger) endSummary() []byte {
+	if l.Summary == SummaryNone || l.file == nil {
+		return nil
+	}
+	b := l.summaryLine()
+	if l.Summary == SummaryEnd || l.Summary == SummaryBoth {
+		l.writeSummary(b)
+	}
+	return b
+}
+
+// startSummary writes the summary b that endSummary returned for the file
+// rotated at the start of the new one, if SummaryStart is set.  This method
+// assumes l.mu is held.
+func (l *Logger) startSummary(b []byte) {
+	if b != nil && (l.Summary == SummaryStart || l.Summary == SummaryBoth) {
+		l.writeSummary(b)
+	}
+}
+
+// writeSummary writes the summary line b to the current log file.  It counts
+// towards the size of the file, but not towards the next summary.  A summary
+// that can't be written is recorded as an error, but doesn't fail the
+// rotation.  This method assumes l.mu is held.
+func (l *Logger) writeSummary(b []byte) {
+	n, err := l.file.Write(b)
+	l.size += int64(n)
+	l.streamWrite(b[:n])
+	if err != nil {
+		l.recordError(writeError, fmt.Errorf("can't write rotation summary: %v", err))
+	}
+}
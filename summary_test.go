@@ -0,0 +1,71 @@
+package lumberjack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSummary", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxSize: 1000, Summary: SummaryBoth}
+	defer l.Close()
+
+	first := fakeTime()
+	_, err := l.Write([]byte("one\ntwo\n"))
+	isNil(err, t)
+	newFakeTime()
+	last := fakeTime()
+	_, err = l.Write([]byte("three\n"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("four\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	want := summaryRecord{
+		Summary: "rotation summary",
+		Lines:   3,
+		Bytes:   14,
+		First:   first.UTC().Format(time.RFC3339Nano),
+		Last:    last.UTC().Format(time.RFC3339Nano),
+	}
+
+	// the backup ends with it, and the new file starts with it.
+	b, err := ioutil.ReadFile(backupFile(dir))
+	isNil(err, t)
+	lines := bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+	equals(4, len(lines), t)
+	var got summaryRecord
+	isNil(json.Unmarshal(lines[3], &got), t)
+	equals(want, got, t)
+
+	b, err = ioutil.ReadFile(filename)
+	isNil(err, t)
+	lines = bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n"))
+	equals(2, len(lines), t)
+	got = summaryRecord{}
+	isNil(json.Unmarshal(lines[0], &got), t)
+	equals(want, got, t)
+	equals("four", string(lines[1]), t)
+}
+
+func TestSummaryPlacementText(t *testing.T) {
+	for _, s := range []SummaryPlacement{SummaryNone, SummaryEnd, SummaryStart, SummaryBoth} {
+		b, err := s.MarshalText()
+		isNil(err, t)
+		var got SummaryPlacement
+		isNil(got.UnmarshalText(b), t)
+		equals(s, got, t)
+	}
+	var s SummaryPlacement
+	notNil(s.UnmarshalText([]byte("middle")), t)
+}
@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxLinks is the number of symbolic links resolveLink follows before giving
+// up, as the Linux kernel does.
+const maxLinks = 40
+
+// resolveLink returns the file that name points at, following symbolic links
+// in its last element, or name itself if it isn't a link.  Links in the
+// directories leading up to it are left alone, so backups go next to the file
+// written to, whatever path it is reached by.  A link that is dangling still
+// resolves, to the file that would be created by writing through it.
+func resolveLink(name string) string {
+	for i := 0; i < maxLinks; i++ {
+		info, err := os.Lstat(name)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return name
+		}
+		dest, err := os.Readlink(name)
+		if err != nil {
+			return name
+		}
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(filepath.Dir(name), dest)
+		}
+		name = longPath(dest)
+	}
+	return name
+}
+
+// resolveAgain forgets where Filename pointed, so that the next call to
+// filename looks it up again.
+func (l *Logger) resolveAgain() {
+	l.targetMu.Lock()
+	defer l.targetMu.Unlock()
+	l.targetOf = ""
+}
+
+// symlinkName returns the name of the Symlink, resolved against the directory
+// of the log file.
+func (l *Logger) symlinkName() string {
+	name := expandPath(l.Symlink)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(l.dir(), name)
+	}
+	return name
+}
+
+// linkCurrent points the Symlink, if one is set, at the log file, unless it
+// does already.  The link is replaced atomically, so that it never goes
+// missing for those following it.  A link in the directory of the log file is
+// relative, so that the directory can be moved or mounted elsewhere.  This
+// method assumes l.mu is held.
+func (l *Logger) linkCurrent() {
+	if l.Symlink == "" {
+		return
+	}
+	name := l.symlinkName()
+	target := l.filename()
+	if filepath.Dir(name) == filepath.Dir(target) {
+		target = filepath.Base(target)
+	}
+	if dest, err := os.Readlink(name); err == nil && dest == target {
+		return
+	}
+
+	tmp := name + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		l.recordError(otherError, fmt.Errorf("can't create symlink: %s", err))
+		return
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		l.recordError(otherError, fmt.Errorf("can't replace symlink: %s", err))
+	}
+}
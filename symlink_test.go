@@ -0,0 +1,108 @@
+//go:build !windows
+// +build !windows
+
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymlink(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSymlink", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	link := filepath.Join(dir, "foobar.log.current")
+	l := &Logger{Filename: filename, MaxSize: 10, Symlink: "foobar.log.current"}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	dest, err := os.Readlink(link)
+	isNil(err, t)
+	equals("foobar.log", dest, t)
+
+	// a link that went missing comes back on rotation.
+	isNil(os.Remove(link), t)
+	newFakeTime()
+	_, err = l.Write([]byte("foooooo!"))
+	isNil(err, t)
+	b, err := ioutil.ReadFile(link)
+	isNil(err, t)
+	equals("foooooo!", string(b), t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	fileCount(dir, 3, t)
+
+	// one elsewhere points at the full path.
+	other := makeTempDir("TestSymlinkOther", t)
+	defer os.RemoveAll(other)
+	l.Symlink = filepath.Join(other, "current")
+	isNil(l.Rotate(), t)
+	dest, err = os.Readlink(l.Symlink)
+	isNil(err, t)
+	equals(filename, dest, t)
+}
+
+func TestFilenameSymlink(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFilenameSymlink", t)
+	defer os.RemoveAll(dir)
+
+	release1 := filepath.Join(dir, "release1")
+	release2 := filepath.Join(dir, "release2")
+	isNil(os.Mkdir(release1, 0755), t)
+	link := logFile(dir)
+	isNil(os.Symlink(logFile(release1), link), t)
+
+	l := &Logger{Filename: link, MaxSize: 10, ResolveOnRotate: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(logFile(release1), []byte("boo!"), t)
+
+	// rotation moves the file the link points at, next to itself, and leaves
+	// the link alone.
+	newFakeTime()
+	_, err = l.Write([]byte("foooooo!"))
+	isNil(err, t)
+	existsWithContent(backupFile(release1), []byte("boo!"), t)
+	existsWithContent(logFile(release1), []byte("foooooo!"), t)
+	dest, err := os.Readlink(link)
+	isNil(err, t)
+	equals(logFile(release1), dest, t)
+	fileCount(dir, 2, t)
+
+	// with ResolveOnRotate, the log file follows the link to where it points
+	// now at the next rotation.
+	isNil(os.Remove(link), t)
+	isNil(os.Symlink(filepath.Join("release2", "foobar.log"), link), t)
+	newFakeTime()
+	_, err = l.Write([]byte("baaaaar!"))
+	isNil(err, t)
+	existsWithContent(backupFile(release1), []byte("foooooo!"), t)
+	notExist(logFile(release1), t)
+	existsWithContent(logFile(release2), []byte("baaaaar!"), t)
+}
+
+func TestSymlinkDupFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestSymlinkDupFile", t)
+	defer os.RemoveAll(dir)
+
+	// the file DupFile opens is set up like the one a write opens.
+	l := &Logger{Filename: logFile(dir), Symlink: "foobar.log.current"}
+	defer l.Close()
+	f, err := l.DupFile()
+	isNil(err, t)
+	isNil(f.Close(), t)
+	dest, err := os.Readlink(filepath.Join(dir, "foobar.log.current"))
+	isNil(err, t)
+	equals("foobar.log", dest, t)
+}
@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTee", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var a, b bytes.Buffer
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  10,
+		Tee:      []io.Writer{&a, &b},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals("boo!", a.String(), t)
+	equals("boo!", b.String(), t)
+
+	// a write that fails doesn't get copied.
+	_, err = l.Write([]byte("booooooooooooooo!"))
+	notNil(err, t)
+	equals("boo!", a.String(), t)
+
+	existsWithContent(filename, []byte("boo!"), t)
+}
+
+func TestTeeErrors(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTeeErrors", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var out bytes.Buffer
+	l := &Logger{
+		Filename: filename,
+		MaxSize:  100,
+		Tee:      []io.Writer{failingWriter{}, &out},
+	}
+	defer l.Close()
+
+	// by default, a failing tee is only counted.
+	n, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	equals(4, n, t)
+	equals(int64(1), l.Stats().TeeErrors, t)
+	equals(int64(0), l.Stats().WriteErrors, t)
+	equals("boo!", out.String(), t)
+
+	// with TeeErrors, Write returns it, after writing to the log file and to
+	// the other writers.
+	l.TeeErrors = true
+	n, err = l.Write([]byte("foo!"))
+	notNil(err, t)
+	equals(4, n, t)
+	assert(errors.Is(err, errFailingWriter), t, "expected the tee's error, got %v", err)
+	equals("boo!foo!", out.String(), t)
+	existsWithContent(filename, []byte("boo!foo!"), t)
+	equals(int64(2), l.Stats().TeeErrors, t)
+	equals(int64(0), l.Stats().WriteErrors, t)
+}
+
+var errFailingWriter = errors.New("failing writer")
+
+// failingWriter is an io.Writer whose writes always fail.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errFailingWriter
+}
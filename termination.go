@@ -0,0 +1,137 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// terminationLimit is how much of a termination message Kubernetes reads.
+const terminationLimit = 4096
+
+// defaultTerminationLines is the default TerminationLines.
+const defaultTerminationLines = 10
+
+// maxTerminationLine is how much of each error line goes in the termination
+// message, so that one long line can't crowd out the others.
+const maxTerminationLine = 512
+
+// termination holds what goes in the TerminationFile.
+type termination struct {
+	errors       [][]byte // the last error lines, oldest first
+	rotations    int64
+	lastRotation time.Time
+	written      bool // whether WriteTermination has been called
+}
+
+// noteErrorLines keeps the lines of p, just written to the log file, that
+// are at error level or above, for the TerminationFile.  This method assumes
+// l.mu is held.
+func (l *Logger) noteErrorLines(p []byte) {
+	if l.TerminationFile == "" {
+		return
+	}
+	max := l.TerminationLines
+	if max <= 0 {
+		max = defaultTerminationLines
+	}
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line, p = p[:i], p[i+1:]
+		} else {
+			p = nil
+		}
+		if level := DetectLevel(line); level != LevelError && level != LevelFatal {
+			continue
+		}
+		if len(line) > maxTerminationLine {
+			line = line[:maxTerminationLine]
+		}
+		l.term.errors = append(l.term.errors, append([]byte(nil), line...))
+	}
+	if len(l.term.errors) > max {
+		l.term.errors = append(l.term.errors[:0:0], l.term.errors[len(l.term.errors)-max:]...)
+	}
+}
+
+// WriteTermination writes the TerminationFile, if it is set, giving reason
+// as why the Logger ended, after writing out everything queued in the write
+// buffer.  It is for the paths a program exits by without closing the
+// Logger, such as a fatal error, and is called by FlushOnPanic.  Once it has
+// been called, Close leaves the file alone.
+func (l *Logger) WriteTermination(reason string) error {
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.term.written = true
+	return l.writeTermination(reason)
+}
+
+// closeTermination writes the TerminationFile on Close, unless
+// WriteTermination has already given the reason.
+func (l *Logger) closeTermination() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.term.written {
+		_ = l.writeTermination("log closed")
+	}
+}
+
+// writeTermination writes the termination message to TerminationFile, if it
+// is set.  Failing to is recorded as an error.  This method assumes l.mu is
+// held.
+func (l *Logger) writeTermination(reason string) error {
+	if l.TerminationFile == "" {
+		return nil
+	}
+	err := ioutil.WriteFile(l.TerminationFile, l.terminationMessage(reason), 0644)
+	if err != nil {
+		err = fmt.Errorf("can't write termination file: %v", err)
+		l.recordError(otherError, err)
+	}
+	return err
+}
+
+// terminationMessage returns the termination message giving reason, cut
+// down to terminationLimit by dropping the oldest error lines.  This method
+// assumes l.mu is held.
+func (l *Logger) terminationMessage(reason string) []byte {
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "lumberjack: %s\n", reason)
+	fmt.Fprintf(&head, "log file %s: %d bytes, %d rotations", l.filename(), l.size, l.term.rotations)
+	if !l.term.lastRotation.IsZero() {
+		t := l.term.lastRotation
+		if !l.LocalTime {
+			t = t.UTC()
+		}
+		fmt.Fprintf(&head, ", last at %s", t.Format(time.RFC3339))
+	}
+	head.WriteByte('\n')
+
+	lines := l.term.errors
+	size := head.Len()
+	if len(lines) > 0 {
+		size += len("last error lines:\n")
+	}
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	for len(lines) > 0 && size > terminationLimit {
+		size -= len(lines[0]) + 1
+		lines = lines[1:]
+	}
+	b := head.Bytes()
+	if len(lines) > 0 {
+		b = append(b, "last error lines:\n"...)
+	}
+	for _, line := range lines {
+		b = append(append(b, line...), '\n')
+	}
+	if len(b) > terminationLimit {
+		b = b[:terminationLimit]
+	}
+	return b
+}
@@ -0,0 +1,116 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTerminationFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestTerminationFile", t)
+	defer os.RemoveAll(dir)
+	term := filepath.Join(dir, "termination-log")
+
+	l := &Logger{
+		Filename:         logFile(dir),
+		MaxSize:          100,
+		TerminationFile:  term,
+		TerminationLines: 2,
+	}
+	defer l.Close()
+	for _, line := range []string{
+		"ERROR first\n",
+		"INFO fine\n",
+		`{"level":"error","msg":"second"}` + "\n" + "FATAL third\n",
+	} {
+		_, err := l.Write([]byte(line))
+		isNil(err, t)
+	}
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+
+	got, err := ioutil.ReadFile(term)
+	isNil(err, t)
+	want := fmt.Sprintf("lumberjack: log closed\n"+
+		"log file %s: 0 bytes, 1 rotations, last at %s\n"+
+		"last error lines:\n"+
+		`{"level":"error","msg":"second"}`+"\n"+
+		"FATAL third\n", logFile(dir), fakeTime().UTC().Format("2006-01-02T15:04:05Z07:00"))
+	equals(want, string(got), t)
+}
+
+func TestTerminationFileLimit(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestTerminationFileLimit", t)
+	defer os.RemoveAll(dir)
+	term := filepath.Join(dir, "termination-log")
+
+	l := &Logger{
+		Filename:         logFile(dir),
+		MaxSize:          100000,
+		TerminationFile:  term,
+		TerminationLines: 100,
+	}
+	defer l.Close()
+	for i := 0; i < 20; i++ {
+		_, err := l.Write([]byte(fmt.Sprintf("ERROR %d %s\n", i, strings.Repeat("x", 1000))))
+		isNil(err, t)
+	}
+	isNil(l.Close(), t)
+
+	// the newest lines are kept, cut short, and the oldest dropped to fit.
+	got, err := ioutil.ReadFile(term)
+	isNil(err, t)
+	assert(len(got) <= terminationLimit, t, "termination message is %d bytes", len(got))
+	assert(bytes.HasSuffix(got, []byte("\n")), t, "termination message ends mid-line")
+	assert(bytes.Contains(got, []byte("ERROR 19 ")), t, "newest line is missing")
+	assert(!bytes.Contains(got, []byte("ERROR 0 ")), t, "oldest line is kept")
+}
+
+func TestWriteTermination(t *testing.T) {
+	dir := makeTempDir("TestWriteTermination", t)
+	defer os.RemoveAll(dir)
+	term := filepath.Join(dir, "termination-log")
+
+	l := &Logger{Filename: logFile(dir), TerminationFile: term}
+	defer l.Close()
+	_, err := l.Write([]byte("ERROR boom\n"))
+	isNil(err, t)
+
+	func() {
+		defer func() {
+			equals("boom", recover(), t)
+		}()
+		defer FlushOnPanic(l)
+		panic("boom")
+	}()
+
+	// Close leaves the reason given alone.
+	isNil(l.Close(), t)
+	got, err := ioutil.ReadFile(term)
+	isNil(err, t)
+	assert(bytes.HasPrefix(got, []byte("lumberjack: panic: boom\n")), t, "unexpected termination message %q", got)
+	assert(bytes.HasSuffix(got, []byte("last error lines:\nERROR boom\n")), t, "unexpected termination message %q", got)
+}
+
+func TestNoTerminationFile(t *testing.T) {
+	dir := makeTempDir("TestNoTerminationFile", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	_, err := l.Write([]byte("ERROR boom\n"))
+	isNil(err, t)
+	isNil(l.WriteTermination("fatal"), t)
+	isNil(l.Close(), t)
+	fileCount(dir, 1, t)
+}
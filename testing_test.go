@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+	"time"
 )
 
 // assert will log the given message if condition is false.
@@ -89,3 +90,23 @@ func _isNil(obtained interface{}) bool {
 
 	return false
 }
+
+// waitMill waits for the compression and removal of old log files that l has
+// under way to finish, so that the fake clock can be moved without racing the
+// mill goroutine.
+func waitMill(l *Logger) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pauseMill()
+}
+
+// waitFor waits up to five seconds for cond to become true.
+func waitFor(cond func() bool, t testing.TB) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,52 @@
+package lumberjack
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// The operations passed to Logger.Trace.
+const (
+	// TraceWrite is a call to Write or WriteContext, from when it is called,
+	// including the wait for other writes, until it returns.
+	TraceWrite = "write"
+
+	// TraceRotate is a rotation, up to where cleanup is handed the backup.
+	TraceRotate = "rotate"
+
+	// TraceSync is an fsync of the log file, by Sync, for SyncInterval or
+	// SyncWrites, or on closing the log file with either.
+	TraceSync = "sync"
+)
+
+// RuntimeTrace is a Logger.Trace that marks the operations as regions of the
+// runtime/trace execution trace, named lumberjack.write, lumberjack.rotate and
+// lumberjack.sync, so that they show up in go tool trace, within the task of
+// the context passed to WriteContext, if any.
+func RuntimeTrace(ctx context.Context, op string) (context.Context, func()) {
+	return ctx, trace.StartRegion(ctx, "lumberjack."+op).End
+}
+
+// trace starts tracing op with Trace, if set, under the write it is part of,
+// if any, returning the function that ends it.  It assumes that l.mu is held.
+func (l *Logger) trace(op string) func() {
+	if l.Trace == nil {
+		return func() {}
+	}
+	ctx := l.traceCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, end := l.Trace(ctx, op)
+	return end
+}
+
+// syncFile syncs the current log file, tracing it as TraceSync.  It assumes
+// that l.mu is held and that the file is open.
+func (l *Logger) syncFile() error {
+	if l.skipSync() {
+		return nil
+	}
+	defer l.trace(TraceSync)()
+	return l.file.Sync()
+}
@@ -0,0 +1,68 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"runtime/trace"
+	"strings"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestTrace(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTrace", t)
+	defer os.RemoveAll(dir)
+
+	// each operation is logged with the operations it is nested in.
+	var ops []string
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+		Trace: func(ctx context.Context, op string) (context.Context, func()) {
+			path, _ := ctx.Value(traceKey{}).(string)
+			path += "/" + op
+			ops = append(ops, path)
+			return context.WithValue(ctx, traceKey{}, path), func() { ops = append(ops, path+" done") }
+		},
+	}
+	defer l.Close()
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "req")
+	_, err := l.WriteContext(ctx, []byte("boo!boo!"))
+	isNil(err, t)
+	newFakeTime()
+	_, err = l.WriteContext(ctx, []byte("boo!"))
+	isNil(err, t)
+	isNil(l.Sync(), t)
+
+	equals([]string{
+		"req/write", "req/write done",
+		"req/write", "req/write/rotate", "req/write/rotate done", "req/write done",
+		"/sync", "/sync done",
+	}, ops, t)
+}
+
+func TestRuntimeTrace(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRuntimeTrace", t)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("can't start tracing: %v", err)
+	}
+	l := &Logger{Filename: logFile(dir), Trace: RuntimeTrace}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	trace.Stop()
+
+	assert(strings.Contains(buf.String(), "lumberjack.write"), t, "expected a lumberjack.write region in the trace")
+	assert(strings.Contains(buf.String(), "lumberjack.rotate"), t, "expected a lumberjack.rotate region in the trace")
+}
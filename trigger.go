@@ -0,0 +1,80 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultTriggerInterval is how often the TriggerFile is looked for, if
+// TriggerInterval isn't set.
+const defaultTriggerInterval = time.Second
+
+// triggerName returns the name of the TriggerFile, resolved against the
+// directory of the log file.
+func (l *Logger) triggerName() string {
+	name := expandPath(l.TriggerFile)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(l.dir(), name)
+	}
+	return name
+}
+
+// watchTrigger starts looking for the TriggerFile, if one is set and it isn't
+// being looked for already.  This method assumes l.mu is held.
+func (l *Logger) watchTrigger() {
+	if l.TriggerFile == "" || l.triggerStop != nil || l.isClosed() {
+		return
+	}
+	l.triggerStop = make(chan struct{})
+	l.triggerDone = make(chan struct{})
+	stop, done := l.triggerStop, l.triggerDone
+	go l.labeled("trigger", func() { l.runTrigger(stop, done) })
+}
+
+// runTrigger rotates the log file whenever the TriggerFile appears, until stop
+// is closed.
+func (l *Logger) runTrigger(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	interval := l.TriggerInterval
+	if interval <= 0 {
+		interval = defaultTriggerInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		name := l.triggerName()
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		// Remove the trigger first, so that failing to remove it can't rotate
+		// the log file over and over.
+		if err := os.Remove(name); err != nil {
+			l.recordError(otherError, err)
+			continue
+		}
+		if err := l.Rotate(); err != nil && err != ErrClosed {
+			l.recordError(writeError, err)
+		}
+	}
+}
+
+// stopTrigger stops looking for the TriggerFile, returning a channel that is
+// closed once the goroutine doing so has exited, or nil if there is none.
+// This method assumes l.mu is held.
+func (l *Logger) stopTrigger() <-chan struct{} {
+	if l.triggerStop == nil {
+		return nil
+	}
+	close(l.triggerStop)
+	done := l.triggerDone
+	l.triggerStop, l.triggerDone = nil, nil
+	return done
+}
@@ -0,0 +1,54 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTriggerFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTriggerFile", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxSize:         100,
+		TriggerFile:     "foobar.log.rotate",
+		TriggerInterval: 10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	trigger := filepath.Join(dir, "foobar.log.rotate")
+	isNil(ioutil.WriteFile(trigger, nil, 0644), t)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !fileExists(backupFile(dir)) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// wait for the rotation to finish.
+	l.mu.Lock()
+	l.mu.Unlock()
+	existsWithContent(backupFile(dir), b, t)
+	notExist(trigger, t)
+	existsWithContent(filename, []byte{}, t)
+
+	// the watcher stops with Close.
+	isNil(l.Close(), t)
+	isNil(ioutil.WriteFile(trigger, nil, 0644), t)
+	time.Sleep(50 * time.Millisecond)
+	exists(trigger, t)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,118 @@
+package lumberjack
+
+import "time"
+
+// UpdateConfig changes the configuration of the Logger to cfg while it is in
+// use, such as on a reload after SIGHUP, so that the writers don't have to be
+// handed a new Logger.  The fields of the Logger that cfg covers are changed
+// all at once, between writes, while the others, such as OnEvent, are left as
+// they are.  If the log file is already bigger than the new MaxSize, it is
+// rotated right away, and cleanup runs with the new rules.  Options that take
+// effect when the log file is opened, such as FileMode or Header, apply from
+// the next log file on.
+//
+// The options that are set up when the Logger is first used can't change:
+// Filename, BufferSize and the other buffer options, SingleWriter,
+// SyncInterval, SyncWrites, LockFile, TriggerFile, TriggerInterval and
+// RecentSize.  A cfg that changes any of them, or that Validate finds wrong, is
+// refused with a *ConfigError, and the Logger is left alone.  Like Rotate, UpdateConfig must
+// not be called concurrently with Write with SingleWriter.
+func (l *Logger) UpdateConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	// Get anything written before the call out under the old configuration.
+	l.flushBuffer()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isClosed() {
+		return ErrClosed
+	}
+	if err := l.checkFixed(cfg); err != nil {
+		return err
+	}
+
+	// Cleanup reads the configuration without holding l.mu.
+	l.pauseMill()
+
+	stream := l.streamSettings()
+	interval := l.RotationInterval
+	cfg.apply(l)
+	l.reportDeprecations()
+	// The backups may be named, or kept, differently now.
+	l.forgetBackups()
+	if l.streamSettings() != stream {
+		// The backup gets compressed after rotation instead, as configured.
+		l.abortStream()
+	}
+	if l.RotationInterval != interval && l.file != nil {
+		l.stopRotationTimer()
+		l.scheduleRotation(currentTime())
+	}
+
+	if l.file != nil && l.size > l.max() && !l.rotationLimited() {
+		return l.rotate()
+	}
+	l.mill()
+	return nil
+}
+
+// streamSettings are the options that the compressed backup CompressOnWrite
+// is making for the log file depends on.
+type streamSettings struct {
+	compress, onWrite, contentHash bool
+	partSize                       int
+	backupDir                      string
+}
+
+// streamSettings returns the current streamSettings.
+func (l *Logger) streamSettings() streamSettings {
+	return streamSettings{l.Compress, l.CompressOnWrite, l.ContentHash, l.CompressPartSize, l.BackupDir}
+}
+
+// checkFixed returns a *ConfigError if cfg changes any of the options that
+// UpdateConfig can't change.  This method assumes l.mu is held.
+func (l *Logger) checkFixed(cfg Config) error {
+	var problems []string
+	for _, o := range []struct {
+		name    string
+		changed bool
+	}{
+		{"filename", cfg.Filename != l.Filename},
+		{"dir", cfg.Dir != l.Dir},
+		{"basename", cfg.BaseName != l.BaseName},
+		{"buffersize", int(cfg.BufferSize) != l.BufferSize},
+		{"bufferspill", cfg.BufferSpill != l.BufferSpill},
+		{"flushinterval", time.Duration(cfg.FlushInterval) != l.FlushInterval},
+		{"strictorder", cfg.StrictOrder != l.StrictOrder},
+		{"keyquota", int(cfg.KeyQuota) != l.KeyQuota},
+		{"singlewriter", cfg.SingleWriter != l.SingleWriter},
+		{"syncinterval", time.Duration(cfg.SyncInterval) != l.SyncInterval},
+		{"syncwrites", cfg.SyncWrites != l.SyncWrites},
+		{"lockfile", cfg.LockFile != l.LockFile},
+		{"triggerfile", cfg.TriggerFile != l.TriggerFile},
+		{"triggerinterval", time.Duration(cfg.TriggerInterval) != l.TriggerInterval},
+		{"recentsize", int(cfg.RecentSize) != l.RecentSize},
+	} {
+		if o.changed {
+			problems = append(problems, o.name+" can't change while the Logger is in use")
+		}
+	}
+	if problems != nil {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// pauseMill waits for the compression and removal of old log files under way,
+// if any, to finish, stopping the mill goroutine, which the next call to mill
+// starts again.  This method assumes l.mu is held.
+func (l *Logger) pauseMill() {
+	if done := l.stopMill(); done != nil {
+		<-done
+	}
+	if l.Scheduler != nil {
+		l.Scheduler.waitFor(l)
+	}
+}
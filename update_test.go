@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestUpdateConfig", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l, err := New(Config{Filename: filename, MaxSize: 100})
+	isNil(err, t)
+	defer l.Close()
+
+	b := []byte("boo!")
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+
+	// a smaller MaxSize than the log file rotates it right away.
+	isNil(l.UpdateConfig(Config{Filename: filename, MaxSize: 10, MaxBackups: 1}), t)
+	existsWithContent(backupFile(dir), []byte("boo!boo!boo!"), t)
+	fileCount(dir, 2, t)
+	equals(int64(10), l.max(), t)
+
+	// and the new limits apply to the writes that follow.
+	first := backupFile(dir)
+	newFakeTime()
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+	waitFor(func() bool {
+		_, err := os.Stat(first)
+		return os.IsNotExist(err)
+	}, t)
+	existsWithContent(backupFile(dir), []byte("boo!boo!"), t)
+
+	// options fixed once the Logger is in use can't change, and neither can
+	// an invalid config be applied; the Logger is left alone either way.
+	err = l.UpdateConfig(Config{Filename: filename + ".new", BufferSize: 10})
+	cerr, ok := err.(*ConfigError)
+	assert(ok, t, "expected a *ConfigError, got %v", err)
+	equals([]string{
+		"filename can't change while the Logger is in use",
+		"buffersize can't change while the Logger is in use",
+	}, cerr.Problems, t)
+	notNil(l.UpdateConfig(Config{Filename: filename, MaxSize: -5}), t)
+	equals(int64(10), l.max(), t)
+
+	isNil(l.Close(), t)
+	equals(ErrClosed, l.UpdateConfig(Config{Filename: filename}), t)
+}
+
+func TestUpdateConfigConcurrent(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestUpdateConfigConcurrent", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l, err := New(Config{Filename: filename, MaxSize: 50, Compress: true})
+	isNil(err, t)
+	defer l.Close()
+
+	// writes carry on while the config changes under them, with the mill
+	// running, without any being lost or racing with the update.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := l.Write([]byte("boo!\n"))
+				isNil(err, t)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		cfg := Config{Filename: filename, MaxSize: ByteSize(20 + i), Compress: i%2 == 0, MaxBackups: 100}
+		isNil(l.UpdateConfig(cfg), t)
+	}
+	wg.Wait()
+	isNil(l.Close(), t)
+}
@@ -0,0 +1,95 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// UTF8Policy is what to do with writes that aren't valid UTF-8.  See
+// Logger.InvalidUTF8.
+type UTF8Policy int
+
+const (
+	// UTF8Keep writes invalid UTF-8 as it is.
+	UTF8Keep UTF8Policy = iota
+
+	// UTF8Replace replaces each run of invalid bytes with the Unicode
+	// replacement character, U+FFFD.
+	UTF8Replace
+
+	// UTF8Reject fails the write with an *InvalidUTF8Error, writing none of
+	// it.
+	UTF8Reject
+)
+
+// utf8PolicyNames holds the text form of each UTF8Policy.
+var utf8PolicyNames = []string{
+	UTF8Keep:    "keep",
+	UTF8Replace: "replace",
+	UTF8Reject:  "reject",
+}
+
+// String returns the name of u: "keep", "replace" or "reject".
+func (u UTF8Policy) String() string {
+	if u < 0 || int(u) >= len(utf8PolicyNames) {
+		return fmt.Sprintf("UTF8Policy(%d)", int(u))
+	}
+	return utf8PolicyNames[u]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u UTF8Policy) MarshalText() ([]byte, error) {
+	if u < 0 || int(u) >= len(utf8PolicyNames) {
+		return nil, fmt.Errorf("invalid UTF-8 policy %d", int(u))
+	}
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "keep",
+// "replace" and "reject", so that the policy can be set from config files.
+func (u *UTF8Policy) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*u = UTF8Keep
+		return nil
+	}
+	for i, name := range utf8PolicyNames {
+		if string(text) == name {
+			*u = UTF8Policy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid UTF-8 policy %q, expected keep, replace or reject", text)
+}
+
+// InvalidUTF8Error is returned by writes with UTF8Reject that aren't valid
+// UTF-8.
+type InvalidUTF8Error struct {
+	// Offset is where in the write the first invalid byte is.
+	Offset int
+}
+
+// Error implements error.
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("lumberjack: write is not valid UTF-8 at offset %d", e.Offset)
+}
+
+// checkUTF8 applies the InvalidUTF8 policy to p, returning what to write
+// instead.
+func (l *Logger) checkUTF8(p []byte) ([]byte, error) {
+	if l.InvalidUTF8 == UTF8Keep || utf8.Valid(p) {
+		return p, nil
+	}
+	if l.InvalidUTF8 == UTF8Replace {
+		return bytes.ToValidUTF8(p, []byte(string(utf8.RuneError))), nil
+	}
+	offset := 0
+	for offset < len(p) {
+		r, size := utf8.DecodeRune(p[offset:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		offset += size
+	}
+	return nil, &InvalidUTF8Error{Offset: offset}
+}
@@ -0,0 +1,42 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestInvalidUTF8", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, InvalidUTF8: UTF8Replace}
+	defer l.Close()
+
+	n, err := l.Write([]byte("bad \xff\xfe byte\n"))
+	isNil(err, t)
+	equals(12, n, t)
+	_, err = l.Write([]byte("fine ✓\n"))
+	isNil(err, t)
+
+	l.InvalidUTF8 = UTF8Reject
+	n, err = l.Write([]byte("ok\x80\n"))
+	equals(0, n, t)
+	equals(&InvalidUTF8Error{Offset: 2}, err, t)
+
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("bad � byte\nfine ✓\n"), t)
+}
+
+func TestUTF8PolicyText(t *testing.T) {
+	for _, u := range []UTF8Policy{UTF8Keep, UTF8Replace, UTF8Reject} {
+		b, err := u.MarshalText()
+		isNil(err, t)
+		var got UTF8Policy
+		isNil(got.UnmarshalText(b), t)
+		equals(u, got, t)
+	}
+	var u UTF8Policy
+	notNil(u.UnmarshalText([]byte("drop")), t)
+}
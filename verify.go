@@ -0,0 +1,73 @@
+package lumberjack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+)
+
+// errVerify is returned by partWriter.verify for compressed output that
+// doesn't decompress to what was compressed.
+var errVerify = errors.New("compressed log file doesn't match the log file")
+
+// verifier returns the Decompressor that compressed backups are read back with
+// to check them, if VerifyCompression is set, or else nil.
+func (l *Logger) verifier() Decompressor {
+	if !l.VerifyCompression {
+		return nil
+	}
+	c := l.codec()
+	if c == nil {
+		return Gzip{}
+	}
+	if d, ok := c.(Decompressor); ok {
+		return d
+	}
+	return noDecompressor{}
+}
+
+// noDecompressor is the Decompressor of a Compressor without one, which fails
+// to verify anything.
+type noDecompressor struct{}
+
+// Decompress implements Decompressor.
+func (noDecompressor) Decompress(io.Reader) (io.ReadCloser, error) {
+	return nil, errors.New("can't decompress backup: the Compressor has no Decompress method")
+}
+
+// verify reads back what has been written, decompressing it with d, and checks
+// that it has the given SHA-256 sum, that of what was compressed, before the
+// files are committed.
+func (w *partWriter) verify(d Decompressor, sum []byte) error {
+	readers := make([]io.Reader, len(w.files))
+	for i := range w.files {
+		f, err := os.Open(w.tmpName(i))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers[i] = f
+	}
+	if len(readers) == 0 {
+		// nothing was written, which is only right for nothing compressed.
+		if empty := sha256.Sum256(nil); !bytes.Equal(sum, empty[:]) {
+			return errVerify
+		}
+		return nil
+	}
+	r, err := d.Decompress(io.MultiReader(readers...))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := copyBuffered(h, r); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return errVerify
+	}
+	return nil
+}
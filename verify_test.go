@@ -0,0 +1,103 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// lossyCompressor is a gzip Compressor that loses the last byte of what it
+// compresses.
+type lossyCompressor struct {
+	Gzip
+}
+
+func (c lossyCompressor) Compress(dst io.Writer, src io.Reader) error {
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(src); err != nil {
+		return err
+	}
+	if b.Len() > 0 {
+		b.Truncate(b.Len() - 1)
+	}
+	return c.Gzip.Compress(dst, &b)
+}
+
+func TestVerifyCompression(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	for _, onWrite := range []bool{false, true} {
+		dir := makeTempDir(fmt.Sprintf("TestVerifyCompression%v", onWrite), t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Compress:          true,
+			CompressOnWrite:   onWrite,
+			VerifyCompression: true,
+			Filename:          logFile(dir),
+			MaxSize:           100,
+		}
+		defer l.Close()
+		b := []byte("boo!")
+		_, err := l.Write(b)
+		isNil(err, t)
+
+		newFakeTime()
+		isNil(l.Rotate(), t)
+
+		waitFor(func() bool {
+			_, err := os.Stat(backupFile(dir))
+			return os.IsNotExist(err)
+		}, t)
+		existsWithContent(backupFile(dir)+compressSuffix, gzipped(b, t), t)
+		equals(int64(0), l.Stats().CompressErrors, t)
+	}
+}
+
+func TestVerifyCompressionFails(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestVerifyCompressionFails", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:          true,
+		Compressor:        lossyCompressor{},
+		VerifyCompression: true,
+		Filename:          logFile(dir),
+		MaxSize:           100,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the backup is kept as it is, with nothing left over from compressing it.
+	waitFor(func() bool { return l.Stats().CompressErrors > 0 }, t)
+	<-time.After(10 * time.Millisecond)
+	existsWithContent(backupFile(dir), b, t)
+	notExist(backupFile(dir)+compressSuffix, t)
+	fileCount(dir, 2, t)
+}
+
+func TestVerifyCompressionNoDecompressor(t *testing.T) {
+	dir := makeTempDir("TestVerifyCompressionNoDecompressor", t)
+	defer os.RemoveAll(dir)
+
+	src := logFile(dir)
+	isNil(ioutil.WriteFile(src, []byte("boo!"), 0644), t)
+	l := &Logger{Compressor: blockingCompressor{}, VerifyCompression: true}
+	_, err := compressFile(src, src+".gz", 0, "", 0, gzipCopy, l.verifier(), chown)
+	notNil(err, t)
+	existsWithContent(src, []byte("boo!"), t)
+	fileCount(dir, 1, t)
+}
@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time window, given by its start and end as offsets from
+// midnight in local time.  A window whose end comes before its start spans
+// midnight.  The zero Window, like any window that starts where it ends,
+// covers the whole day.  See Logger.CompressWindow.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// day is the length of a day on the wall clock.
+const day = 24 * time.Hour
+
+// offset returns the time of day of t on the wall clock.
+func offset(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// contains reports whether t falls within w.
+func (w Window) contains(t time.Time) bool {
+	start, end, off := w.Start%day, w.End%day, offset(t)
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return start <= off && off < end
+	default:
+		return off >= start || off < end
+	}
+}
+
+// untilOpen returns how long after t w opens next, or 0 if t falls within w.
+func (w Window) untilOpen(t time.Time) time.Duration {
+	if w.contains(t) {
+		return 0
+	}
+	d := w.Start%day - offset(t)
+	if d < 0 {
+		d += day
+	}
+	return d
+}
+
+// String returns w in the form 02:00-05:00.
+func (w Window) String() string {
+	return formatClock(w.Start) + "-" + formatClock(w.End)
+}
+
+// formatClock formats an offset from midnight as hours and minutes, with the
+// seconds if there are any.
+func formatClock(d time.Duration) string {
+	d %= day
+	h, m, s := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+	if s != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", h, m)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (w Window) MarshalText() ([]byte, error) {
+	if w == (Window{}) {
+		return []byte{}, nil
+	}
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting windows in the
+// form 02:00-05:00, with optional seconds, so that windows can be set from
+// config files.  An empty string gives the zero Window.
+func (w *Window) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*w = Window{}
+		return nil
+	}
+	i := strings.IndexByte(string(text), '-')
+	if i < 0 {
+		return fmt.Errorf("invalid window %q, expected a form like 02:00-05:00", text)
+	}
+	var err error
+	var parsed Window
+	if parsed.Start, err = parseClock(string(text[:i])); err == nil {
+		parsed.End, err = parseClock(string(text[i+1:]))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid window %q: %v", text, err)
+	}
+	*w = parsed
+	return nil
+}
+
+// parseClock parses a time of day, 15:04 or 15:04:05, into an offset from
+// midnight.
+func parseClock(s string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return offset(t), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", s)
+}
+
+// compressAllowed reports whether backups may be compressed now, according to
+// CompressWindow.  If not, it arranges for the mill to run again once the
+// window opens.
+func (l *Logger) compressAllowed() bool {
+	wait := l.CompressWindow.untilOpen(currentTime())
+	if wait == 0 {
+		return true
+	}
+
+	l.millAfter(wait)
+	return false
+}
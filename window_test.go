@@ -0,0 +1,97 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestWindowContains(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2024, 3, 1, h, m, 0, 0, time.Local)
+	}
+	night := Window{Start: 2 * time.Hour, End: 5 * time.Hour}
+	overMidnight := Window{Start: 22 * time.Hour, End: 2 * time.Hour}
+	for _, tt := range []struct {
+		w    Window
+		at   time.Time
+		in   bool
+		wait time.Duration
+	}{
+		{Window{}, at(12, 0), true, 0},
+		{night, at(2, 0), true, 0},
+		{night, at(4, 59), true, 0},
+		{night, at(5, 0), false, 21 * time.Hour},
+		{night, at(1, 30), false, 30 * time.Minute},
+		{overMidnight, at(23, 0), true, 0},
+		{overMidnight, at(1, 0), true, 0},
+		{overMidnight, at(12, 0), false, 10 * time.Hour},
+	} {
+		equals(tt.in, tt.w.contains(tt.at), t)
+		equals(tt.wait, tt.w.untilOpen(tt.at), t)
+	}
+}
+
+func TestWindowText(t *testing.T) {
+	var w Window
+	isNil(w.UnmarshalText([]byte("02:00-05:30")), t)
+	equals(Window{Start: 2 * time.Hour, End: 5*time.Hour + 30*time.Minute}, w, t)
+	text, err := w.MarshalText()
+	isNil(err, t)
+	equals("02:00-05:30", string(text), t)
+
+	isNil(w.UnmarshalText([]byte("23:00:30-01:00")), t)
+	equals("23:00:30-01:00", w.String(), t)
+
+	for _, bad := range []string{"02:00", "2am-5am", "02:00-25:00"} {
+		notNil(w.UnmarshalText([]byte(bad)), t)
+	}
+
+	var l Logger
+	isNil(json.Unmarshal([]byte(`{"compresswindow": "02:00-05:00"}`), &l), t)
+	equals(Window{Start: 2 * time.Hour, End: 5 * time.Hour}, l.CompressWindow, t)
+	l = Logger{}
+	isNil(yaml.Unmarshal([]byte(`compresswindow: "22:00-02:00"`), &l), t)
+	equals(Window{Start: 22 * time.Hour, End: 2 * time.Hour}, l.CompressWindow, t)
+}
+
+func TestCompressWindow(t *testing.T) {
+	if inlineMill {
+		t.Skip("compression waits for the next rotation in TinyGo builds")
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+	dir := makeTempDir("TestCompressWindow", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		Compress:       true,
+		CompressWindow: Window{Start: 2 * time.Hour, End: 5 * time.Hour},
+	}
+	defer l.Close()
+
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(backup, []byte("boo!"), 0644), t)
+
+	// outside the window, compression waits for it to open.
+	isNil(l.millRunOnce(), t)
+	exists(backup, t)
+	notExist(backup+compressSuffix, t)
+	notNil(l.millTimer, t)
+
+	now = now.Add(15 * time.Hour)
+	isNil(l.millRunOnce(), t)
+	notExist(backup, t)
+	existsWithContent(backup+compressSuffix, gzipped([]byte("boo!"), t), t)
+
+	// closing cancels the timer.
+	isNil(l.Close(), t)
+	equals((*time.Timer)(nil), l.millTimer, t)
+}
@@ -0,0 +1,51 @@
+package lumberjack
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// tagged reports whether backups get extended attributes.
+func (l *Logger) tagged() bool {
+	return len(l.BackupXattrs) > 0 || l.BackupXattrID != ""
+}
+
+// backupXattrs returns the extended attributes to set on the files of the
+// named backup: BackupXattrs, and with BackupXattrID, its rotation ID.
+func (l *Logger) backupXattrs(name string) map[string]string {
+	attrs := make(map[string]string, len(l.BackupXattrs)+1)
+	for k, v := range l.BackupXattrs {
+		attrs[k] = v
+	}
+	if l.BackupXattrID != "" {
+		attrs[l.BackupXattrID] = l.rotationID(name)
+	}
+	return attrs
+}
+
+// rotationID returns the UUID identifying the rotation that made the named
+// backup.  It is derived from the host name, the log file and the name of the
+// uncompressed backup, in the manner of a version 5 UUID, so that every file of
+// a backup gets the same one, however often the mill gets to it.
+func (l *Logger) rotationID(name string) string {
+	hostname, _ := os.Hostname()
+	base := filepath.Base(uncompressedName(name, l.compressSuffixes()))
+	sum := sha1.Sum([]byte(hostname + "\x00" + l.configuredFilename() + "\x00" + base))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// setXattrs sets the extended attributes of the named backup file, returning
+// the first error.
+func (l *Logger) setXattrs(name string) error {
+	var err error
+	for k, v := range l.backupXattrs(name) {
+		if errSet := setXattr(name, k, v); errSet != nil && err == nil {
+			err = fmt.Errorf("can't set extended attribute %s: %v", k, errSet)
+		}
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package lumberjack
+
+import (
+	"bytes"
+	"syscall"
+)
+
+// setXattr sets the extended attribute attr of the named file to value,
+// unless it has that value already, since an immutable file can't have its
+// attributes changed.
+func setXattr(name, attr, value string) error {
+	buf := make([]byte, len(value)+1)
+	if n, err := syscall.Getxattr(name, attr, buf); err == nil && bytes.Equal(buf[:n], []byte(value)) {
+		return nil
+	}
+	return syscall.Setxattr(name, attr, []byte(value), 0)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package lumberjack
+
+// setXattr is a no-op anywhere but linux.
+func setXattr(_, _, _ string) error {
+	return nil
+}